@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.11
 // 	protoc        v5.29.3
 // source: api/transaction-event.proto
 
@@ -25,8 +25,9 @@ const (
 type TransactionType int32
 
 const (
-	TransactionType_TRANSACTION_TYPE_BET TransactionType = 0
-	TransactionType_TRANSACTION_TYPE_WIN TransactionType = 1
+	TransactionType_TRANSACTION_TYPE_BET     TransactionType = 0
+	TransactionType_TRANSACTION_TYPE_WIN     TransactionType = 1
+	TransactionType_TRANSACTION_TYPE_DEPOSIT TransactionType = 2
 )
 
 // Enum value maps for TransactionType.
@@ -34,10 +35,12 @@ var (
 	TransactionType_name = map[int32]string{
 		0: "TRANSACTION_TYPE_BET",
 		1: "TRANSACTION_TYPE_WIN",
+		2: "TRANSACTION_TYPE_DEPOSIT",
 	}
 	TransactionType_value = map[string]int32{
-		"TRANSACTION_TYPE_BET": 0,
-		"TRANSACTION_TYPE_WIN": 1,
+		"TRANSACTION_TYPE_BET":     0,
+		"TRANSACTION_TYPE_WIN":     1,
+		"TRANSACTION_TYPE_DEPOSIT": 2,
 	}
 )
 
@@ -74,6 +77,8 @@ type TransactionEvent struct {
 	TransactionType TransactionType        `protobuf:"varint,2,opt,name=transaction_type,json=transactionType,proto3,enum=api.TransactionType" json:"transaction_type,omitempty"`
 	Amount          float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
 	Timestamp       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	EventId         string                 `protobuf:"bytes,5,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	TenantId        string                 `protobuf:"bytes,6,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -136,19 +141,36 @@ func (x *TransactionEvent) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *TransactionEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *TransactionEvent) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
 var File_api_transaction_event_proto protoreflect.FileDescriptor
 
 const file_api_transaction_event_proto_rawDesc = "" +
 	"\n" +
-	"\x1bapi/transaction-event.proto\x12\x03api\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbe\x01\n" +
+	"\x1bapi/transaction-event.proto\x12\x03api\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf6\x01\n" +
 	"\x10TransactionEvent\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12?\n" +
 	"\x10transaction_type\x18\x02 \x01(\x0e2\x14.api.TransactionTypeR\x0ftransactionType\x12\x16\n" +
 	"\x06amount\x18\x03 \x01(\x01R\x06amount\x128\n" +
-	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp*E\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x19\n" +
+	"\bevent_id\x18\x05 \x01(\tR\aeventId\x12\x1b\n" +
+	"\ttenant_id\x18\x06 \x01(\tR\btenantId*c\n" +
 	"\x0fTransactionType\x12\x18\n" +
 	"\x14TRANSACTION_TYPE_BET\x10\x00\x12\x18\n" +
-	"\x14TRANSACTION_TYPE_WIN\x10\x01B/Z-github.com/bsko/casino-transaction-system/apib\x06proto3"
+	"\x14TRANSACTION_TYPE_WIN\x10\x01\x12\x1c\n" +
+	"\x18TRANSACTION_TYPE_DEPOSIT\x10\x02B/Z-github.com/bsko/casino-transaction-system/apib\x06proto3"
 
 var (
 	file_api_transaction_event_proto_rawDescOnce sync.Once
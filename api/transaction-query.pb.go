@@ -0,0 +1,581 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: api/transaction-query.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	TransactionType *TransactionType       `protobuf:"varint,2,opt,name=transaction_type,json=transactionType,proto3,enum=api.TransactionType,oneof" json:"transaction_type,omitempty"`
+	AmountFrom      *float64               `protobuf:"fixed64,3,opt,name=amount_from,json=amountFrom,proto3,oneof" json:"amount_from,omitempty"`
+	AmountTo        *float64               `protobuf:"fixed64,4,opt,name=amount_to,json=amountTo,proto3,oneof" json:"amount_to,omitempty"`
+	CreatedFrom     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	Limit           int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset          int32                  `protobuf:"varint,8,opt,name=offset,proto3" json:"offset,omitempty"`
+	TenantId        *string                `protobuf:"bytes,9,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_api_transaction_query_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchRequest) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetTransactionType() TransactionType {
+	if x != nil && x.TransactionType != nil {
+		return *x.TransactionType
+	}
+	return TransactionType_TRANSACTION_TYPE_BET
+}
+
+func (x *SearchRequest) GetAmountFrom() float64 {
+	if x != nil && x.AmountFrom != nil {
+		return *x.AmountFrom
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetAmountTo() float64 {
+	if x != nil && x.AmountTo != nil {
+		return *x.AmountTo
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Transactions  []*TransactionEvent    `protobuf:"bytes,4,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_api_transaction_query_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetTransactions() []*TransactionEvent {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type GetBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalanceRequest) Reset() {
+	*x = GetBalanceRequest{}
+	mi := &file_api_transaction_query_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalanceRequest) ProtoMessage() {}
+
+func (x *GetBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetBalanceRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetBalanceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TotalBet      float64                `protobuf:"fixed64,2,opt,name=total_bet,json=totalBet,proto3" json:"total_bet,omitempty"`
+	TotalWin      float64                `protobuf:"fixed64,3,opt,name=total_win,json=totalWin,proto3" json:"total_win,omitempty"`
+	Balance       float64                `protobuf:"fixed64,4,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalanceResponse) Reset() {
+	*x = GetBalanceResponse{}
+	mi := &file_api_transaction_query_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalanceResponse) ProtoMessage() {}
+
+func (x *GetBalanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalanceResponse.ProtoReflect.Descriptor instead.
+func (*GetBalanceResponse) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBalanceResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetBalanceResponse) GetTotalBet() float64 {
+	if x != nil {
+		return x.TotalBet
+	}
+	return 0
+}
+
+func (x *GetBalanceResponse) GetTotalWin() float64 {
+	if x != nil {
+		return x.TotalWin
+	}
+	return 0
+}
+
+func (x *GetBalanceResponse) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	TenantId      *string                `protobuf:"bytes,4,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_api_transaction_query_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStatsRequest) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *GetStatsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BetCount      int64                  `protobuf:"varint,1,opt,name=bet_count,json=betCount,proto3" json:"bet_count,omitempty"`
+	WinCount      int64                  `protobuf:"varint,2,opt,name=win_count,json=winCount,proto3" json:"win_count,omitempty"`
+	BetAmount     float64                `protobuf:"fixed64,3,opt,name=bet_amount,json=betAmount,proto3" json:"bet_amount,omitempty"`
+	WinAmount     float64                `protobuf:"fixed64,4,opt,name=win_amount,json=winAmount,proto3" json:"win_amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_api_transaction_query_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_transaction_query_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_api_transaction_query_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStatsResponse) GetBetCount() int64 {
+	if x != nil {
+		return x.BetCount
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetWinCount() int64 {
+	if x != nil {
+		return x.WinCount
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetBetAmount() float64 {
+	if x != nil {
+		return x.BetAmount
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetWinAmount() float64 {
+	if x != nil {
+		return x.WinAmount
+	}
+	return 0
+}
+
+var File_api_transaction_query_proto protoreflect.FileDescriptor
+
+const file_api_transaction_query_proto_rawDesc = "" +
+	"\n" +
+	"\x1bapi/transaction-query.proto\x12\x03api\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/transaction-event.proto\"\xd2\x03\n" +
+	"\rSearchRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12D\n" +
+	"\x10transaction_type\x18\x02 \x01(\x0e2\x14.api.TransactionTypeH\x01R\x0ftransactionType\x88\x01\x01\x12$\n" +
+	"\vamount_from\x18\x03 \x01(\x01H\x02R\n" +
+	"amountFrom\x88\x01\x01\x12 \n" +
+	"\tamount_to\x18\x04 \x01(\x01H\x03R\bamountTo\x88\x01\x01\x12=\n" +
+	"\fcreated_from\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\b \x01(\x05R\x06offset\x12 \n" +
+	"\ttenant_id\x18\t \x01(\tH\x04R\btenantId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_idB\x13\n" +
+	"\x11_transaction_typeB\x0e\n" +
+	"\f_amount_fromB\f\n" +
+	"\n" +
+	"_amount_toB\f\n" +
+	"\n" +
+	"_tenant_id\"\x8f\x01\n" +
+	"\x0eSearchResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x129\n" +
+	"\ftransactions\x18\x04 \x03(\v2\x15.api.TransactionEventR\ftransactions\",\n" +
+	"\x11GetBalanceRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\x81\x01\n" +
+	"\x12GetBalanceResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttotal_bet\x18\x02 \x01(\x01R\btotalBet\x12\x1b\n" +
+	"\ttotal_win\x18\x03 \x01(\x01R\btotalWin\x12\x18\n" +
+	"\abalance\x18\x04 \x01(\x01R\abalance\"\xe5\x01\n" +
+	"\x0fGetStatsRequest\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12=\n" +
+	"\fcreated_from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12 \n" +
+	"\ttenant_id\x18\x04 \x01(\tH\x01R\btenantId\x88\x01\x01B\n" +
+	"\n" +
+	"\b_user_idB\f\n" +
+	"\n" +
+	"_tenant_id\"\x8a\x01\n" +
+	"\x10GetStatsResponse\x12\x1b\n" +
+	"\tbet_count\x18\x01 \x01(\x03R\bbetCount\x12\x1b\n" +
+	"\twin_count\x18\x02 \x01(\x03R\bwinCount\x12\x1d\n" +
+	"\n" +
+	"bet_amount\x18\x03 \x01(\x01R\tbetAmount\x12\x1d\n" +
+	"\n" +
+	"win_amount\x18\x04 \x01(\x01R\twinAmount2\x81\x02\n" +
+	"\x17TransactionQueryService\x121\n" +
+	"\x06Search\x12\x12.api.SearchRequest\x1a\x13.api.SearchResponse\x12;\n" +
+	"\fStreamSearch\x12\x12.api.SearchRequest\x1a\x15.api.TransactionEvent0\x01\x12=\n" +
+	"\n" +
+	"GetBalance\x12\x16.api.GetBalanceRequest\x1a\x17.api.GetBalanceResponse\x127\n" +
+	"\bGetStats\x12\x14.api.GetStatsRequest\x1a\x15.api.GetStatsResponseB/Z-github.com/bsko/casino-transaction-system/apib\x06proto3"
+
+var (
+	file_api_transaction_query_proto_rawDescOnce sync.Once
+	file_api_transaction_query_proto_rawDescData []byte
+)
+
+func file_api_transaction_query_proto_rawDescGZIP() []byte {
+	file_api_transaction_query_proto_rawDescOnce.Do(func() {
+		file_api_transaction_query_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_transaction_query_proto_rawDesc), len(file_api_transaction_query_proto_rawDesc)))
+	})
+	return file_api_transaction_query_proto_rawDescData
+}
+
+var file_api_transaction_query_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_api_transaction_query_proto_goTypes = []any{
+	(*SearchRequest)(nil),         // 0: api.SearchRequest
+	(*SearchResponse)(nil),        // 1: api.SearchResponse
+	(*GetBalanceRequest)(nil),     // 2: api.GetBalanceRequest
+	(*GetBalanceResponse)(nil),    // 3: api.GetBalanceResponse
+	(*GetStatsRequest)(nil),       // 4: api.GetStatsRequest
+	(*GetStatsResponse)(nil),      // 5: api.GetStatsResponse
+	(TransactionType)(0),          // 6: api.TransactionType
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+	(*TransactionEvent)(nil),      // 8: api.TransactionEvent
+}
+var file_api_transaction_query_proto_depIdxs = []int32{
+	6,  // 0: api.SearchRequest.transaction_type:type_name -> api.TransactionType
+	7,  // 1: api.SearchRequest.created_from:type_name -> google.protobuf.Timestamp
+	7,  // 2: api.SearchRequest.created_to:type_name -> google.protobuf.Timestamp
+	8,  // 3: api.SearchResponse.transactions:type_name -> api.TransactionEvent
+	7,  // 4: api.GetStatsRequest.created_from:type_name -> google.protobuf.Timestamp
+	7,  // 5: api.GetStatsRequest.created_to:type_name -> google.protobuf.Timestamp
+	0,  // 6: api.TransactionQueryService.Search:input_type -> api.SearchRequest
+	0,  // 7: api.TransactionQueryService.StreamSearch:input_type -> api.SearchRequest
+	2,  // 8: api.TransactionQueryService.GetBalance:input_type -> api.GetBalanceRequest
+	4,  // 9: api.TransactionQueryService.GetStats:input_type -> api.GetStatsRequest
+	1,  // 10: api.TransactionQueryService.Search:output_type -> api.SearchResponse
+	8,  // 11: api.TransactionQueryService.StreamSearch:output_type -> api.TransactionEvent
+	3,  // 12: api.TransactionQueryService.GetBalance:output_type -> api.GetBalanceResponse
+	5,  // 13: api.TransactionQueryService.GetStats:output_type -> api.GetStatsResponse
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_api_transaction_query_proto_init() }
+func file_api_transaction_query_proto_init() {
+	if File_api_transaction_query_proto != nil {
+		return
+	}
+	file_api_transaction_event_proto_init()
+	file_api_transaction_query_proto_msgTypes[0].OneofWrappers = []any{}
+	file_api_transaction_query_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_transaction_query_proto_rawDesc), len(file_api_transaction_query_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_transaction_query_proto_goTypes,
+		DependencyIndexes: file_api_transaction_query_proto_depIdxs,
+		MessageInfos:      file_api_transaction_query_proto_msgTypes,
+	}.Build()
+	File_api_transaction_query_proto = out.File
+	file_api_transaction_query_proto_goTypes = nil
+	file_api_transaction_query_proto_depIdxs = nil
+}
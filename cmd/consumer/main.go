@@ -11,6 +11,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "offsets" {
+		runOffsets(os.Args[2:])
+		return
+	}
+
 	ctx := context.Background()
 
 	app := &consumer.ConsumerApp{}
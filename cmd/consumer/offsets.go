@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bsko/casino-transaction-system/internal/app/offsets"
+)
+
+const offsetsUsage = `usage: consumer offsets <lag|reset|replay> [flags]
+
+  lag     show the committed offset and lag of every partition
+  reset   move the committed offsets of the consumer group, the consumers must be stopped
+  replay  store the events of a time range without touching the committed offsets
+`
+
+// runOffsets runs the offsets subcommand: consumer offsets <action> [flags].
+func runOffsets(args []string) {
+	flags := flag.NewFlagSet("offsets", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), offsetsUsage)
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	options := offsets.Options{Action: args[0]}
+	flags.StringVar(&options.ConfigFile, "config", "", "path to the config file (default configs/consumer/config.yaml)")
+	flags.StringVar(&options.Topic, "topic", "", "topic, the main topic of the config when empty")
+	flags.StringVar(&options.To, "to", "", "reset: earliest, latest, timestamp or offset")
+	flags.StringVar(&options.At, "at", "", "reset: time in RFC 3339 of the timestamp target")
+	flags.Int64Var(&options.Offset, "offset", -1, "reset: offset of the offset target")
+	flags.IntVar(&options.Partition, "partition", -1, "reset: only this partition, -1 for all")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "reset: print the new offsets without committing them")
+	flags.StringVar(&options.From, "from", "", "replay: range start in RFC 3339, inclusive")
+	flags.StringVar(&options.Until, "until", "", "replay: range end in RFC 3339, exclusive")
+	_ = flags.Parse(args[1:])
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := &offsets.OffsetsApp{Options: options}
+
+	if err := app.Initialize(ctx); err != nil {
+		if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+			log.Printf("Shutdown error after init failure: %v", shutdownErr)
+		}
+		log.Fatalf("Failed to initialize offsets command: %v", err)
+	}
+
+	err := app.Exec(ctx)
+	if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+		log.Printf("Shutdown error: %v", shutdownErr)
+	}
+	if err != nil {
+		log.Fatalf("Offsets command failed: %v", err)
+	}
+}
@@ -33,17 +33,25 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	errChan := make(chan error, 1)
 	go func() {
-		if err := app.Exec(ctx); err != nil {
-			errChan <- err
-		}
+		errChan <- app.Exec(execCtx)
 	}()
 
 	select {
 	case sig := <-sigChan:
 		log.Printf("Received signal: %v. Shutting down gracefully...", sig)
+		// let the producer stop its workers and log the run report
+		cancel()
+		<-errChan
 	case err := <-errChan:
-		log.Printf("Application error: %v. Shutting down...", err)
+		if err != nil {
+			log.Printf("Application error: %v. Shutting down...", err)
+		} else {
+			log.Println("Producer run completed")
+		}
 	}
 }
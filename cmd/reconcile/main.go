@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bsko/casino-transaction-system/internal/app/reconcile"
+)
+
+func main() {
+	var options reconcile.Options
+	flag.StringVar(&options.ConfigFile, "config", "", "path to the config file (default configs/reconcile/config.yaml)")
+	flag.StringVar(&options.Provider, "provider", "", "provider name, selects the settlement format")
+	flag.StringVar(&options.File, "file", "", "path to the provider settlement CSV file")
+	flag.StringVar(&options.From, "from", "", "period start in RFC 3339, derived from the file when empty")
+	flag.StringVar(&options.To, "to", "", "period end in RFC 3339, derived from the file when empty")
+	flag.StringVar(&options.JSONReport, "json", "-", "path of the JSON report, - for stdout, empty to skip")
+	flag.StringVar(&options.CSVReport, "csv", "", "path of the CSV report, - for stdout, empty to skip")
+	flag.BoolVar(&options.SkipExtra, "skip-extra", false, "do not report stored transactions missing from the file")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := &reconcile.ReconcileApp{Options: options}
+
+	if err := app.Initialize(ctx); err != nil {
+		if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+			log.Printf("Shutdown error after init failure: %v", shutdownErr)
+		}
+		log.Fatalf("Failed to initialize application: %v", err)
+	}
+
+	err := app.Exec(ctx)
+	if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+		log.Printf("Shutdown error: %v", shutdownErr)
+	}
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bsko/casino-transaction-system/internal/app/consumer"
+	"github.com/bsko/casino-transaction-system/internal/app/producer"
+)
+
+// standalone runs the producer and the consumer in one process. With the
+// memory transport and the memory database driver of its default configs
+// it needs no external service.
+func main() {
+	var producerOptions producer.Options
+	var consumerOptions consumer.Options
+	flag.StringVar(&producerOptions.ConfigFile, "producer-config", "configs/standalone/producer.yaml", "path to the producer config file")
+	flag.StringVar(&consumerOptions.ConfigFile, "consumer-config", "configs/standalone/consumer.yaml", "path to the consumer config file")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	consumerApp := &consumer.ConsumerApp{Options: consumerOptions}
+	producerApp := &producer.ProducerApp{Options: producerOptions}
+
+	shutdown := func() {
+		if err := producerApp.Shutdown(context.Background()); err != nil {
+			log.Printf("Producer shutdown error: %v", err)
+		}
+		if err := consumerApp.Shutdown(context.Background()); err != nil {
+			log.Printf("Consumer shutdown error: %v", err)
+		}
+	}
+
+	// the consumer connects first, so it reads the first published events
+	if err := consumerApp.Initialize(ctx); err != nil {
+		shutdown()
+		log.Fatalf("Failed to initialize consumer: %v", err)
+	}
+	if err := producerApp.Initialize(ctx); err != nil {
+		shutdown()
+		log.Fatalf("Failed to initialize producer: %v", err)
+	}
+	defer func() {
+		shutdown()
+		log.Println("Application shutdown completed")
+	}()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- consumerApp.Exec(ctx)
+	}()
+	go func() {
+		// the consumer keeps serving the API once the producer run is over
+		if err := producerApp.Exec(ctx); err != nil {
+			log.Printf("Producer error: %v", err)
+		} else if ctx.Err() == nil {
+			log.Println("Producer run completed")
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Println("Received signal. Shutting down gracefully...")
+	case err := <-errChan:
+		log.Printf("Consumer error: %v. Shutting down...", err)
+	}
+}
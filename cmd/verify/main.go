@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bsko/casino-transaction-system/internal/app/verify"
+)
+
+func main() {
+	var options verify.Options
+	flag.StringVar(&options.ConfigFile, "config", "", "path to the config file (default configs/consumer/config.yaml)")
+	flag.StringVar(&options.UserID, "user", "", "verify only the chain of this user")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := &verify.VerifyApp{Options: options}
+
+	if err := app.Initialize(ctx); err != nil {
+		if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+			log.Printf("Shutdown error after init failure: %v", shutdownErr)
+		}
+		log.Fatalf("Failed to initialize application: %v", err)
+	}
+
+	err := app.Exec(ctx)
+	if shutdownErr := app.Shutdown(ctx); shutdownErr != nil {
+		log.Printf("Shutdown error: %v", shutdownErr)
+	}
+	if err != nil {
+		log.Fatalf("Verification failed: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/fraud"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraudAlerts(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	alertRepo := repositories.NewAlertRepository(dbInstance, dbInstance)
+
+	rules, err := fraud.ParseRules([]byte(`
+rules:
+  - name: bet-burst
+    severity: high
+    transaction_type: bet
+    window: 1m
+    aggregate: count
+    threshold: 2
+`))
+	require.NoError(t, err)
+	engine := fraud.NewEngine(rules, alertRepo)
+	processor := fraud.NewAlertProcessor(alertRepo)
+
+	suspicious := entity.UserID{UUID: uuid.New()}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_, err = engine.Inspect(ctx, entity.TransactionEvent{
+			UserID: suspicious, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+		require.NoError(t, err)
+	}
+	_, err = engine.Inspect(ctx, entity.TransactionEvent{
+		UserID: entity.UserID{UUID: uuid.New()}, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now,
+	})
+	require.NoError(t, err)
+
+	t.Run("Alert is stored for the suspicious user only", func(t *testing.T) {
+		alerts, err := processor.ListAlerts(ctx, entity.AlertFilter{})
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+		require.Equal(t, suspicious, alerts[0].UserID)
+		require.Equal(t, "bet-burst", alerts[0].RuleName)
+		require.Equal(t, 2.0, alerts[0].Value)
+		require.Nil(t, alerts[0].AcknowledgedAt)
+	})
+
+	t.Run("Acknowledged alerts are filtered out", func(t *testing.T) {
+		alerts, err := processor.ListAlerts(ctx, entity.AlertFilter{UserID: &suspicious})
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+
+		acknowledged, err := processor.AcknowledgeAlert(ctx, alerts[0].ID, "analyst")
+		require.NoError(t, err)
+		require.NotNil(t, acknowledged.AcknowledgedAt)
+		require.Equal(t, "analyst", acknowledged.AcknowledgedBy)
+
+		again, err := processor.AcknowledgeAlert(ctx, alerts[0].ID, "someone-else")
+		require.NoError(t, err)
+		require.Equal(t, "analyst", again.AcknowledgedBy)
+
+		unacknowledged := false
+		alerts, err = processor.ListAlerts(ctx, entity.AlertFilter{Acknowledged: &unacknowledged})
+		require.NoError(t, err)
+		require.Empty(t, alerts)
+	})
+
+	t.Run("Unknown alert returns not found", func(t *testing.T) {
+		_, err := processor.AcknowledgeAlert(ctx, 999999, "analyst")
+		require.ErrorIs(t, err, entity.ErrNotFound)
+	})
+}
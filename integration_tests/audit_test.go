@@ -0,0 +1,84 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/audit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTrail(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionsRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	auditRepo := repositories.NewAuditRepository(dbInstance)
+	conf := config.Audit{SigningKey: "test-key"}
+	verifier := audit.NewVerifier(auditRepo, conf)
+
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, transactionsRepo.BatchStore(ctx, []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now.Add(time.Duration(i) * time.Nanosecond)},
+			{UserID: entity.UserID{UUID: uuid.New()}, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(300), CreatedAt: now},
+			{UserID: userID, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(250), CreatedAt: now.Add(time.Second)},
+		}))
+	}
+
+	t.Run("Stored chains are intact", func(t *testing.T) {
+		verification, err := verifier.Verify(ctx, nil)
+		require.NoError(t, err)
+		require.True(t, verification.Valid())
+		require.Equal(t, 3, verification.CheckedUsers)
+		require.Equal(t, int64(6), verification.CheckedRows)
+	})
+
+	t.Run("Digests of finished days are sealed and verified", func(t *testing.T) {
+		_, err := GetTestDB().Exec("UPDATE transaction_events SET ingested_at = ingested_at - INTERVAL '2 days'")
+		require.NoError(t, err)
+
+		sealed, err := audit.NewSealer(auditRepo, conf).SealPending(ctx, time.Now())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, sealed, 1)
+
+		verification, err := verifier.Verify(ctx, nil)
+		require.NoError(t, err)
+		require.True(t, verification.Valid())
+		require.Equal(t, sealed, verification.CheckedDigests)
+	})
+
+	t.Run("Altered row is reported", func(t *testing.T) {
+		var id int64
+		require.NoError(t, GetTestDB().Get(&id, "SELECT id FROM transaction_events WHERE user_id = $1 ORDER BY id OFFSET 2 LIMIT 1", userID.UUID.String()))
+		_, err := GetTestDB().Exec("UPDATE transaction_events SET amount = 1 WHERE id = $1", id)
+		require.NoError(t, err)
+
+		verification, err := verifier.Verify(ctx, &userID)
+		require.NoError(t, err)
+		require.NotNil(t, verification.TamperedRow)
+		require.Equal(t, id, verification.TamperedRow.RowID)
+
+		verification, err = verifier.Verify(ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, id, verification.TamperedRow.RowID)
+		require.Nil(t, verification.TamperedDigest)
+	})
+
+	t.Run("Rewritten hash is caught by the digest", func(t *testing.T) {
+		_, err := GetTestDB().Exec("UPDATE transaction_events SET row_hash = md5(row_hash) || md5(row_hash) WHERE user_id = $1", userID.UUID.String())
+		require.NoError(t, err)
+
+		verification, err := verifier.Verify(ctx, nil)
+		require.NoError(t, err)
+		require.NotNil(t, verification.TamperedDigest)
+		require.Equal(t, "rows of the day do not match the digest", verification.TamperedDigest.Reason)
+	})
+}
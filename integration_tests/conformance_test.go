@@ -0,0 +1,14 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repotest"
+)
+
+func TestTransactionEventRepositoryConformance(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, dbInstance *repositories.DB) {
+		repotest.RunTransactionEventRepository(t, repositories.NewTransactionEventRepository(dbInstance, dbInstance))
+	})
+}
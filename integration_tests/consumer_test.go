@@ -13,74 +13,72 @@ import (
 )
 
 func TestConsumer(t *testing.T) {
-	CleanupDB(t)
-
-	ctx := context.Background()
-	db := GetTestDB()
-	dbInstance := repositories.NewDB(db)
-	repo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
-	kafkaReaderMock := newKafkaReader()
-	consumer := consumer.NewConsumer(kafkaReaderMock, repo)
-	consumer.SetBatchSize(2)
-
-	t.Run("Successful saving", func(t *testing.T) {
-		cctx, cancel := context.WithCancel(ctx)
-		defer cancel()
-
-		errCh := make(chan error, 1)
-		go func() {
-			errCh <- consumer.Start(cctx)
-		}()
-
-		events := []entity.TransactionEvent{
-			{
-				UserID:          entity.UserID{UUID: uuid.New()},
-				TransactionType: entity.TransactionTypeBet,
-				Amount:          100,
-				CreatedAt:       time.Now(),
-			},
-			{
-				UserID:          entity.UserID{UUID: uuid.New()},
-				TransactionType: entity.TransactionTypeBet,
-				Amount:          150,
-				CreatedAt:       time.Now(),
-			},
-			{
-				UserID:          entity.UserID{UUID: uuid.New()},
-				TransactionType: entity.TransactionTypeBet,
-				Amount:          200,
-				CreatedAt:       time.Now(),
-			},
-			{
-				UserID:          entity.UserID{UUID: uuid.New()},
-				TransactionType: entity.TransactionTypeWin,
-				Amount:          1000,
-				CreatedAt:       time.Now(),
-			},
-			{
-				UserID:          entity.UserID{UUID: uuid.New()},
-				TransactionType: entity.TransactionTypeWin,
-				Amount:          2000,
-				CreatedAt:       time.Now(),
-			},
-		}
-
-		for _, event := range events {
-			kafkaReaderMock.Send(event)
-		}
-
-		time.Sleep(100 * time.Millisecond)
-
-		cancel()
-
-		err := <-errCh
-		require.NoError(t, err)
-
-		time.Sleep(100 * time.Millisecond)
-
-		allEvents, err := repo.GetListByFilter(entity.TransactionEventFilter{Limit: 100})
-		require.NoError(t, err)
-		require.Equal(t, 5, len(allEvents), "Expected 5 events in database")
+	ForEachDatabase(t, func(t *testing.T, dbInstance *repositories.DB) {
+		ctx := context.Background()
+		repo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+		kafkaReaderMock := newKafkaReader()
+		consumer := consumer.NewConsumer(kafkaReaderMock, repo)
+		consumer.SetBatchSize(2)
+
+		t.Run("Successful saving", func(t *testing.T) {
+			cctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- consumer.Start(cctx)
+			}()
+
+			events := []entity.TransactionEvent{
+				{
+					UserID:          entity.UserID{UUID: uuid.New()},
+					TransactionType: entity.TransactionTypeBet,
+					Amount:          100,
+					CreatedAt:       time.Now(),
+				},
+				{
+					UserID:          entity.UserID{UUID: uuid.New()},
+					TransactionType: entity.TransactionTypeBet,
+					Amount:          150,
+					CreatedAt:       time.Now(),
+				},
+				{
+					UserID:          entity.UserID{UUID: uuid.New()},
+					TransactionType: entity.TransactionTypeBet,
+					Amount:          200,
+					CreatedAt:       time.Now(),
+				},
+				{
+					UserID:          entity.UserID{UUID: uuid.New()},
+					TransactionType: entity.TransactionTypeWin,
+					Amount:          1000,
+					CreatedAt:       time.Now(),
+				},
+				{
+					UserID:          entity.UserID{UUID: uuid.New()},
+					TransactionType: entity.TransactionTypeWin,
+					Amount:          2000,
+					CreatedAt:       time.Now(),
+				},
+			}
+
+			for _, event := range events {
+				kafkaReaderMock.Send(event)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+
+			cancel()
+
+			err := <-errCh
+			require.NoError(t, err)
+
+			time.Sleep(100 * time.Millisecond)
+
+			allEvents, err := repo.GetListByFilter(ctx, entity.TransactionEventFilter{Limit: 100})
+			require.NoError(t, err)
+			require.Equal(t, 5, len(allEvents), "Expected 5 events in database")
+		})
 	})
 }
 
@@ -94,10 +92,10 @@ func newKafkaReader() *kafkaReader {
 	}
 }
 
-func (k *kafkaReader) Read(ctx context.Context) (*entity.TransactionEvent, error) {
+func (k *kafkaReader) Read(ctx context.Context) (*entity.ConsumedEvent, error) {
 	select {
 	case event := <-k.events:
-		return &event, nil
+		return &entity.ConsumedEvent{Topic: "transactions", Event: event}, nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
@@ -0,0 +1,120 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/audit"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer"
+	"github.com/bsko/casino-transaction-system/internal/services/erasure"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserErasure(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionsRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	erasureRepo := repositories.NewErasureRepository(dbInstance)
+	conf := config.Erasure{LookupKey: "test-lookup-key"}
+	processor := erasure.NewProcessor(erasureRepo, conf)
+
+	queries := consumer.NewGetListProcessor(transactionsRepo)
+	queries.SetErasureGuard(erasure.NewGuard(erasureRepo, conf))
+
+	userID := entity.UserID{UUID: uuid.New()}
+	otherID := entity.UserID{UUID: uuid.New()}
+	now := time.Now()
+	require.NoError(t, transactionsRepo.BatchStore(ctx, []entity.TransactionEvent{
+		{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now},
+		{UserID: otherID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(300), CreatedAt: now},
+		{UserID: userID, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(250), CreatedAt: now.Add(time.Second)},
+	}))
+
+	var erased *entity.UserErasure
+	t.Run("Erase replaces the user id with the pseudonym", func(t *testing.T) {
+		var err error
+		erased, err = processor.Erase(ctx, userID, "dpo@example.com")
+		require.NoError(t, err)
+		require.NotNil(t, erased.Pseudonym)
+		require.Nil(t, erased.VaultDestroyedAt)
+
+		var count int
+		require.NoError(t, GetTestDB().Get(&count, "SELECT COUNT(*) FROM transaction_events WHERE user_id = $1", userID.UUID.String()))
+		require.Zero(t, count)
+		require.NoError(t, GetTestDB().Get(&count, "SELECT COUNT(*) FROM transaction_events WHERE user_id = $1", erased.Pseudonym.UUID.String()))
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("Erased user is not resolved", func(t *testing.T) {
+		_, err := queries.GetBalance(ctx, userID)
+		require.ErrorIs(t, err, entity.ErrUserErased)
+
+		_, err = queries.GetListByFilter(ctx, entity.TransactionEventFilter{UserID: &userID, Limit: 10})
+		require.ErrorIs(t, err, entity.ErrUserErased)
+
+		balance, err := queries.GetBalance(ctx, otherID)
+		require.NoError(t, err)
+		require.Equal(t, entity.Money(300), balance.TotalBet)
+	})
+
+	t.Run("Second erasure is rejected", func(t *testing.T) {
+		_, err := processor.Erase(ctx, userID, "dpo@example.com")
+		require.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("Events replayed after the erasure are not stored", func(t *testing.T) {
+		guard := erasure.NewGuard(erasureRepo, conf)
+		replayed := []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now},
+		}
+
+		users, err := guard.ErasedUsers(ctx, []entity.UserID{userID, otherID})
+		require.NoError(t, err)
+		require.Equal(t, map[entity.UserID]struct{}{userID: {}}, users)
+
+		// a batch checked before the erasure committed is refused in its transaction
+		err = transactionsRepo.BatchStoreWithDerived(ctx, replayed, entity.DerivedRecords{
+			UserHashes: []string{guard.UserHash(userID)},
+		})
+		require.ErrorIs(t, err, entity.ErrUserErased)
+
+		var count int
+		require.NoError(t, GetTestDB().Get(&count, "SELECT COUNT(*) FROM transaction_events WHERE user_id = $1", userID.UUID.String()))
+		require.Zero(t, count)
+
+		_, err = processor.Erase(ctx, userID, "dpo@example.com")
+		require.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("Audit chain stays verifiable", func(t *testing.T) {
+		verification, err := audit.NewVerifier(repositories.NewAuditRepository(dbInstance), config.Audit{SigningKey: "test-key"}).Verify(ctx, nil)
+		require.NoError(t, err)
+		require.True(t, verification.Valid())
+		require.Equal(t, 2, verification.CheckedUsers)
+	})
+
+	t.Run("Destroying the vault unlinks the pseudonym", func(t *testing.T) {
+		require.NoError(t, processor.DestroyVault(ctx, userID))
+
+		erasure, err := processor.GetErasure(ctx, userID)
+		require.NoError(t, err)
+		require.NotNil(t, erasure.VaultDestroyedAt)
+		require.Nil(t, erasure.Pseudonym)
+
+		var count int
+		require.NoError(t, GetTestDB().Get(&count, "SELECT COUNT(*) FROM pseudonym_vault"))
+		require.Zero(t, count)
+	})
+
+	t.Run("Vault of an unknown user is not found", func(t *testing.T) {
+		err := processor.DestroyVault(ctx, entity.UserID{UUID: uuid.New()})
+		require.ErrorIs(t, err, entity.ErrNotFound)
+	})
+}
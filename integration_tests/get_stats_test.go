@@ -0,0 +1,68 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceAndStats(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, dbInstance *repositories.DB) {
+		ctx := context.Background()
+		repo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+		service := consumer.NewGetListProcessor(repo)
+
+		user1 := uuid.New()
+		user2 := uuid.New()
+		now := time.Now()
+
+		err := repo.BatchStore(ctx, []entity.TransactionEvent{
+			{UserID: entity.UserID{UUID: user1}, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(10000), CreatedAt: now.Add(-2 * time.Hour)},
+			{UserID: entity.UserID{UUID: user1}, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(5000), CreatedAt: now.Add(-1 * time.Hour)},
+			{UserID: entity.UserID{UUID: user1}, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(30000), CreatedAt: now},
+			{UserID: entity.UserID{UUID: user2}, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(20000), CreatedAt: now},
+		})
+		require.NoError(t, err)
+
+		t.Run("Balance for user with bets and wins", func(t *testing.T) {
+			balance, err := service.GetBalance(ctx, entity.UserID{UUID: user1})
+			require.NoError(t, err)
+			require.Equal(t, entity.Money(15000), balance.TotalBet)
+			require.Equal(t, entity.Money(30000), balance.TotalWin)
+			require.Equal(t, entity.Money(15000), balance.Balance())
+		})
+
+		t.Run("Balance for unknown user is zero", func(t *testing.T) {
+			balance, err := service.GetBalance(ctx, entity.UserID{UUID: uuid.New()})
+			require.NoError(t, err)
+			require.Equal(t, entity.Money(0), balance.Balance())
+		})
+
+		t.Run("Stats across all users", func(t *testing.T) {
+			stats, err := service.GetStats(ctx, entity.TransactionEventFilter{})
+			require.NoError(t, err)
+			require.Equal(t, int64(3), stats.BetCount)
+			require.Equal(t, int64(1), stats.WinCount)
+			require.Equal(t, entity.Money(35000), stats.BetAmount)
+			require.Equal(t, entity.Money(30000), stats.WinAmount)
+		})
+
+		t.Run("Stats filtered by user and time range", func(t *testing.T) {
+			createdFrom := now.Add(-90 * time.Minute)
+			stats, err := service.GetStats(ctx, entity.TransactionEventFilter{
+				UserID:      entity.NewUserID(user1),
+				CreatedFrom: &createdFrom,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), stats.BetCount)
+			require.Equal(t, int64(1), stats.WinCount)
+			require.Equal(t, entity.Money(5000), stats.BetAmount)
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package integration_tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/limits"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLimits(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	limitRepo := repositories.NewLimitRepository(dbInstance, dbInstance)
+	processor := limits.NewProcessor(limitRepo)
+	evaluator := limits.NewEvaluator(limitRepo)
+
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Now()
+
+	lossLimit, err := processor.CreateLimit(ctx, entity.UserLimit{
+		UserID: userID, Type: entity.LimitTypeLoss, Period: entity.LimitPeriodDaily, Amount: entity.Money(10000),
+	})
+	require.NoError(t, err)
+
+	t.Run("Duplicate limit is rejected", func(t *testing.T) {
+		_, err := processor.CreateLimit(ctx, entity.UserLimit{
+			UserID: userID, Type: entity.LimitTypeLoss, Period: entity.LimitPeriodDaily, Amount: entity.Money(500),
+		})
+		require.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("Transactions outside the window are not counted", func(t *testing.T) {
+		events := []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(50000), CreatedAt: now.Add(-48 * time.Hour)},
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(6000), CreatedAt: now.Add(-time.Hour)},
+		}
+		check, err := evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		stored, err := processor.ListBreaches(ctx, userID, 10, 0)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+
+		usage, err := processor.GetUsage(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, usage, 1)
+		require.Equal(t, entity.Money(6000), usage[0].Consumed)
+		require.Equal(t, entity.Money(4000), usage[0].Remaining())
+	})
+
+	t.Run("Breach of a failed batch is not recorded", func(t *testing.T) {
+		events := []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(5000), CreatedAt: now},
+			// transaction_type is VARCHAR(10), the insert of the batch fails
+			{UserID: userID, TransactionType: "chargeback_reversal", Amount: entity.Money(1), CreatedAt: now},
+		}
+		check, err := evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.Error(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		stored, err := processor.ListBreaches(ctx, userID, 10, 0)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+	})
+
+	t.Run("Breach is recorded once per window", func(t *testing.T) {
+		events := []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(5000), CreatedAt: now},
+		}
+		check, err := evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		check, err = evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		stored, err := processor.ListBreaches(ctx, userID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, lossLimit.ID, stored[0].LimitID)
+		require.Equal(t, entity.Money(11000), stored[0].Consumed)
+	})
+
+	t.Run("Concurrent batches of a user are checked one after another", func(t *testing.T) {
+		wagerUserID := entity.UserID{UUID: uuid.New()}
+		wagerLimit, err := processor.CreateLimit(ctx, entity.UserLimit{
+			UserID: wagerUserID, Type: entity.LimitTypeWager, Period: entity.LimitPeriodDaily, Amount: entity.Money(10000),
+		})
+		require.NoError(t, err)
+
+		// each batch stays under the limit, the two of them exceed it
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			events := []entity.TransactionEvent{
+				{UserID: wagerUserID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(6000), CreatedAt: now},
+			}
+			check, err := evaluator.Evaluate(ctx, events)
+			require.NoError(t, err)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check})
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, errors.Join(errs...))
+
+		stored, err := processor.ListBreaches(ctx, wagerUserID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, wagerLimit.ID, stored[0].LimitID)
+		require.Equal(t, entity.Money(12000), stored[0].Consumed)
+	})
+
+	t.Run("Deposits count against deposit limits", func(t *testing.T) {
+		depositUserID := entity.UserID{UUID: uuid.New()}
+		depositLimit, err := processor.CreateLimit(ctx, entity.UserLimit{
+			UserID: depositUserID, Type: entity.LimitTypeDeposit, Period: entity.LimitPeriodWeekly, Amount: entity.Money(10000),
+		})
+		require.NoError(t, err)
+
+		events := []entity.TransactionEvent{
+			{UserID: depositUserID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.Money(7000), CreatedAt: now},
+			{UserID: depositUserID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(50000), CreatedAt: now},
+		}
+		check, err := evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		stored, err := processor.ListBreaches(ctx, depositUserID, 10, 0)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+
+		events = []entity.TransactionEvent{
+			{UserID: depositUserID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.Money(4000), CreatedAt: now},
+		}
+		check, err = evaluator.Evaluate(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{Limits: check}))
+
+		stored, err = processor.ListBreaches(ctx, depositUserID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, depositLimit.ID, stored[0].LimitID)
+		require.Equal(t, entity.Money(11000), stored[0].Consumed)
+	})
+
+	t.Run("Limits are scoped to the user", func(t *testing.T) {
+		_, err := processor.GetLimit(ctx, entity.UserID{UUID: uuid.New()}, lossLimit.ID)
+		require.ErrorIs(t, err, entity.ErrNotFound)
+
+		updated, err := processor.UpdateLimit(ctx, entity.UserLimit{ID: lossLimit.ID, UserID: userID, Amount: entity.Money(20000)})
+		require.NoError(t, err)
+		require.Equal(t, entity.Money(20000), updated.Amount)
+		require.Equal(t, entity.LimitTypeLoss, updated.Type)
+
+		require.NoError(t, processor.DeleteLimit(ctx, userID, lossLimit.ID))
+		require.ErrorIs(t, processor.DeleteLimit(ctx, userID, lossLimit.ID), entity.ErrNotFound)
+	})
+}
@@ -6,8 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
@@ -19,6 +24,8 @@ import (
 var (
 	testDB     *sqlx.DB
 	testDBConn string
+	// testMySQL runs the tests of the transaction repository against mysql
+	testMySQL *sqlx.DB
 )
 
 func TestMain(m *testing.M) {
@@ -79,26 +86,94 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	mysqlContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "mysql:8.0",
+			ExposedPorts: []string{"3306/tcp"},
+			Env: map[string]string{
+				"MYSQL_DATABASE":      "casino_transactions",
+				"MYSQL_ROOT_PASSWORD": "123456",
+			},
+			WaitingFor: wait.ForAll(
+				wait.ForLog("port: 3306  MySQL Community Server"),
+				wait.ForListeningPort("3306/tcp"),
+			),
+		},
+		Started: true,
+	})
+	if err != nil {
+		fmt.Printf("Failed to start mysql container: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mysqlContainer.Terminate(ctx); err != nil {
+			fmt.Printf("Failed to terminate mysql container: %v\n", err)
+		}
+	}()
+
+	mysqlHost, err := mysqlContainer.Host(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get container host: %v\n", err)
+		os.Exit(1)
+	}
+	mysqlPort, err := mysqlContainer.MappedPort(ctx, "3306")
+	if err != nil {
+		fmt.Printf("Failed to get container port: %v\n", err)
+		os.Exit(1)
+	}
+
+	mysqlConf := mysql.NewConfig()
+	mysqlConf.User = "root"
+	mysqlConf.Passwd = "123456"
+	mysqlConf.Net = "tcp"
+	mysqlConf.Addr = fmt.Sprintf("%s:%s", mysqlHost, mysqlPort.Port())
+	mysqlConf.DBName = "casino_transactions"
+	mysqlConf.ParseTime = true
+	mysqlConf.Loc = time.UTC
+	mysqlConf.MultiStatements = true
+
+	testMySQL, err = sqlx.Connect(repositories.DriverMySQL, mysqlConf.FormatDSN())
+	if err != nil {
+		fmt.Printf("Failed to connect to test mysql database: %v\n", err)
+		os.Exit(1)
+	}
+	defer testMySQL.Close()
+
+	if err := runMySQLMigrations(testMySQL); err != nil {
+		fmt.Printf("Failed to run mysql migrations: %v\n", err)
+		os.Exit(1)
+	}
+
 	code := m.Run()
 	os.Exit(code)
 }
 
 func runMigrations(db *sqlx.DB) error {
-	migrationsPath, err := getMigrationsPath()
+	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create postgres driver: %w", err)
 	}
+	return migrateUp(driver, "postgresql", "postgres")
+}
 
-	sqlDB := db.DB
+func runMySQLMigrations(db *sqlx.DB) error {
+	driver, err := migratemysql.WithInstance(db.DB, &migratemysql.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create mysql driver: %w", err)
+	}
+	return migrateUp(driver, "mysql", "mysql")
+}
 
-	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+// migrateUp applies the migrations of the directory under migrations.
+func migrateUp(driver database.Driver, dir, databaseName string) error {
+	migrationsPath, err := getMigrationsPath(dir)
 	if err != nil {
-		return fmt.Errorf("failed to create postgres driver: %w", err)
+		return err
 	}
 
 	migrator, err := migrate.NewWithDatabaseInstance(
 		fmt.Sprintf("file://%s", migrationsPath),
-		"postgres",
+		databaseName,
 		driver,
 	)
 	if err != nil {
@@ -112,7 +187,7 @@ func runMigrations(db *sqlx.DB) error {
 	return nil
 }
 
-func getMigrationsPath() (string, error) {
+func getMigrationsPath(dir string) (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		return "", fmt.Errorf("failed to get working directory: %w", err)
@@ -120,9 +195,9 @@ func getMigrationsPath() (string, error) {
 
 	var migrationsDir string
 	if filepath.Base(wd) == "integration_tests" {
-		migrationsDir = filepath.Join(wd, "..", "migrations", "postgresql")
+		migrationsDir = filepath.Join(wd, "..", "migrations", dir)
 	} else {
-		migrationsDir = filepath.Join(wd, "migrations", "postgresql")
+		migrationsDir = filepath.Join(wd, "migrations", dir)
 	}
 
 	migrationsPath, err := filepath.Abs(migrationsDir)
@@ -141,8 +216,32 @@ func CleanupDB(t *testing.T) {
 	if testDB == nil {
 		t.Fatal("testDB is not initialized")
 	}
-	_, err := testDB.Exec("TRUNCATE TABLE transaction_events")
+	_, err := testDB.Exec("TRUNCATE TABLE transaction_events, webhook_subscriptions, webhook_deliveries, webhook_delivery_attempts, outbox_messages, user_limits, limit_breaches, alerts, reconciliation_runs, reconciliation_items, audit_chain_heads, audit_digests, erased_users, pseudonym_vault")
 	if err != nil {
 		t.Fatalf("Failed to cleanup database: %v", err)
 	}
 }
+
+func CleanupMySQL(t *testing.T) {
+	if testMySQL == nil {
+		t.Fatal("testMySQL is not initialized")
+	}
+	for _, table := range []string{"transaction_events", "audit_chain_heads"} {
+		if _, err := testMySQL.Exec("TRUNCATE TABLE " + table); err != nil {
+			t.Fatalf("Failed to cleanup mysql database: %v", err)
+		}
+	}
+}
+
+// ForEachDatabase runs fn against every database backing the transaction
+// repository, each one cleaned up first.
+func ForEachDatabase(t *testing.T, fn func(t *testing.T, db *repositories.DB)) {
+	t.Run(repositories.DriverPostgres, func(t *testing.T) {
+		CleanupDB(t)
+		fn(t, repositories.NewDB(GetTestDB()))
+	})
+	t.Run(repositories.DriverMySQL, func(t *testing.T) {
+		CleanupMySQL(t)
+		fn(t, repositories.NewDB(testMySQL))
+	})
+}
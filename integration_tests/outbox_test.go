@@ -0,0 +1,78 @@
+package integration_tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	outboxRepo := repositories.NewOutboxRepository(dbInstance)
+
+	userID := uuid.New()
+	events := []entity.TransactionEvent{
+		{UserID: entity.UserID{UUID: userID}, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(10000), CreatedAt: time.Now()},
+		{UserID: entity.UserID{UUID: userID}, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(500000), CreatedAt: time.Now()},
+	}
+	messages, err := outbox.NewDeriver(1000).Derive(events)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	t.Run("Batch and messages are stored together", func(t *testing.T) {
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{OutboxMessages: messages}))
+
+		var stored int
+		require.NoError(t, GetTestDB().Get(&stored, "SELECT COUNT(*) FROM transaction_events"))
+		require.Equal(t, 2, stored)
+	})
+
+	t.Run("Failed batch leaves no messages", func(t *testing.T) {
+		// transaction_type is VARCHAR(10), the insert of the batch fails
+		invalid := []entity.TransactionEvent{{UserID: entity.UserID{UUID: userID}, TransactionType: "chargeback_reversal", Amount: entity.Money(1), CreatedAt: time.Now()}}
+		require.Error(t, transactionRepo.BatchStoreWithDerived(ctx, invalid, entity.DerivedRecords{OutboxMessages: messages}))
+
+		var pending int
+		require.NoError(t, GetTestDB().Get(&pending, "SELECT COUNT(*) FROM outbox_messages"))
+		require.Equal(t, 2, pending)
+	})
+
+	t.Run("Publish error keeps messages unsent", func(t *testing.T) {
+		_, err := outboxRepo.ProcessUnsent(ctx, 10, func([]entity.OutboxMessage) error {
+			return errors.New("kafka unavailable")
+		})
+		require.Error(t, err)
+
+		var unsent int
+		require.NoError(t, GetTestDB().Get(&unsent, "SELECT COUNT(*) FROM outbox_messages WHERE sent_at IS NULL"))
+		require.Equal(t, 2, unsent)
+	})
+
+	t.Run("Published messages are marked sent in order", func(t *testing.T) {
+		var published []entity.OutboxMessage
+		processed, err := outboxRepo.ProcessUnsent(ctx, 10, func(batch []entity.OutboxMessage) error {
+			published = batch
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, processed)
+		require.Equal(t, entity.OutboxEventLargeWin, published[0].EventType)
+		require.Equal(t, entity.OutboxEventBalanceChanged, published[1].EventType)
+		require.Equal(t, userID.String(), published[1].Key)
+
+		processed, err = outboxRepo.ProcessUnsent(ctx, 10, func([]entity.OutboxMessage) error { return nil })
+		require.NoError(t, err)
+		require.Equal(t, 0, processed)
+	})
+}
@@ -0,0 +1,165 @@
+package integration_tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/rabbitmq"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// startRabbitMQ runs a broker container for the test and returns its URL.
+func startRabbitMQ(t *testing.T) string {
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "rabbitmq:3.13-alpine",
+			ExposedPorts: []string{"5672/tcp"},
+			WaitingFor: wait.ForAll(
+				wait.ForLog("Server startup complete"),
+				wait.ForListeningPort("5672/tcp"),
+			),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Logf("Failed to terminate rabbitmq container: %v", err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5672")
+	require.NoError(t, err)
+	return fmt.Sprintf("amqp://guest:guest@%s:%s/", host, port.Port())
+}
+
+func rabbitMQEvent(eventID string) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		EventID:         eventID,
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.ToMoney(5),
+		CreatedAt:       time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func readRabbitMQ(t *testing.T, reader *rabbitmq.RabbitMQReader) (*entity.ConsumedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return reader.Read(ctx)
+}
+
+// queueLength returns the number of ready messages of the queue.
+func queueLength(t *testing.T, url, queue string) int {
+	conn, err := amqp.Dial(url)
+	require.NoError(t, err)
+	defer conn.Close()
+	ch, err := conn.Channel()
+	require.NoError(t, err)
+	q, err := ch.QueueDeclarePassive(queue, true, false, false, false, nil)
+	require.NoError(t, err)
+	return q.Messages
+}
+
+func TestRabbitMQ(t *testing.T) {
+	ctx := context.Background()
+	conf := config.RabbitMQ{URL: startRabbitMQ(t), Prefetch: 10}
+
+	writer := rabbitmq.NewRabbitMQWriter(conf)
+	require.NoError(t, writer.Connect(ctx))
+	defer writer.Close()
+
+	t.Run("Published events are read with their tenant", func(t *testing.T) {
+		reader := rabbitmq.NewRabbitMQReader(conf)
+		require.NoError(t, reader.Connect(ctx))
+		defer reader.Close()
+
+		event := rabbitMQEvent("e1")
+		event.TenantID = "brand-a"
+		require.NoError(t, writer.Publish(ctx, event))
+
+		consumed, err := readRabbitMQ(t, reader)
+		require.NoError(t, err)
+		assert.Equal(t, event.EventID, consumed.Event.EventID)
+		assert.Equal(t, event.UserID, consumed.Event.UserID)
+		assert.Equal(t, "brand-a", consumed.Event.TenantID)
+		require.NoError(t, reader.Commit(ctx))
+	})
+
+	t.Run("Uncommitted events are redelivered", func(t *testing.T) {
+		reader := rabbitmq.NewRabbitMQReader(conf)
+		require.NoError(t, reader.Connect(ctx))
+
+		require.NoError(t, writer.Publish(ctx, rabbitMQEvent("e2")))
+		consumed, err := readRabbitMQ(t, reader)
+		require.NoError(t, err)
+		assert.Equal(t, "e2", consumed.Event.EventID)
+		// closed before the commit, like a consumer crashing mid-batch
+		require.NoError(t, reader.Close())
+
+		reader = rabbitmq.NewRabbitMQReader(conf)
+		require.NoError(t, reader.Connect(ctx))
+		defer reader.Close()
+		consumed, err = readRabbitMQ(t, reader)
+		require.NoError(t, err)
+		assert.Equal(t, "e2", consumed.Event.EventID)
+		require.NoError(t, reader.Commit(ctx))
+	})
+
+	t.Run("Invalid events are dead-lettered when read", func(t *testing.T) {
+		reader := rabbitmq.NewRabbitMQReader(conf)
+		require.NoError(t, reader.Connect(ctx))
+		defer reader.Close()
+
+		require.NoError(t, writer.PublishFault(ctx, rabbitMQEvent("e3"), entity.Fault{Kind: entity.FaultMalformed}))
+		require.NoError(t, writer.Publish(ctx, rabbitMQEvent("e4")))
+
+		_, err := readRabbitMQ(t, reader)
+		var invalid *entity.InvalidMessageError
+		require.True(t, errors.As(err, &invalid))
+		assert.Equal(t, entity.FaultMalformed, invalid.Fault)
+		require.Eventually(t, func() bool {
+			return queueLength(t, conf.URL, "transactions.dead") == 1
+		}, 5*time.Second, 100*time.Millisecond)
+
+		consumed, err := readRabbitMQ(t, reader)
+		require.NoError(t, err)
+		assert.Equal(t, "e4", consumed.Event.EventID)
+		require.NoError(t, reader.Commit(ctx))
+		require.Eventually(t, func() bool {
+			return queueLength(t, conf.URL, "transactions") == 0
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Async publishes report their confirms", func(t *testing.T) {
+		asyncConf := conf
+		asyncConf.Async = true
+		asyncWriter := rabbitmq.NewRabbitMQWriter(asyncConf)
+		require.NoError(t, asyncWriter.Connect(ctx))
+
+		delivered := make(chan error, 3)
+		for i := range 3 {
+			event := rabbitMQEvent(fmt.Sprintf("async-%d", i))
+			require.NoError(t, asyncWriter.PublishAsync(ctx, event, entity.Fault{}, func(err error) { delivered <- err }))
+		}
+		require.NoError(t, asyncWriter.Close())
+		close(delivered)
+		for err := range delivered {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 3, queueLength(t, conf.URL, "transactions"))
+	})
+}
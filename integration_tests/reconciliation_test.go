@@ -0,0 +1,76 @@
+package integration_tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/reconciliation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciliation(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionsRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	reconciliationRepo := repositories.NewReconciliationRepository(dbInstance)
+
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, transactionsRepo.BatchStore(ctx, []entity.TransactionEvent{
+		{EventID: "r-1", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(1000), CreatedAt: now},
+		{EventID: "r-2", UserID: userID, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(2500), CreatedAt: now.Add(time.Second)},
+		{EventID: "r-4", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(700), CreatedAt: now.Add(2 * time.Second)},
+	}))
+
+	reader, err := reconciliation.NewSettlementReader(config.SettlementFormat{
+		Columns: config.SettlementColumns{EventID: "round_id"},
+	})
+	require.NoError(t, err)
+	records, err := reader.Read(strings.NewReader(fmt.Sprintf(
+		"round_id,user_id,transaction_type,amount,timestamp\n"+
+			"r-1,%[1]s,bet,10.00,%[2]s\n"+
+			"r-2,%[1]s,win,30.00,%[2]s\n"+
+			"r-3,%[1]s,bet,5.00,%[2]s\n",
+		userID.UUID, now.Format(time.RFC3339))))
+	require.NoError(t, err)
+
+	run, err := reconciliation.NewReconciler(reconciliationRepo).Reconcile(ctx, reconciliation.Request{
+		Provider:   "acme",
+		FileName:   "acme.csv",
+		Records:    records,
+		TimeWindow: reader.TimeWindow(),
+	})
+	require.NoError(t, err)
+
+	t.Run("Discrepancies are found", func(t *testing.T) {
+		require.NotZero(t, run.ID)
+		require.Equal(t, 3, run.StoredRecords)
+		require.Equal(t, 1, run.Matched)
+		require.Equal(t, 1, run.Mismatched)
+		require.Equal(t, 1, run.Missing)
+		require.Equal(t, 1, run.Extra)
+	})
+
+	t.Run("Run and items are stored", func(t *testing.T) {
+		var stored struct {
+			Provider string `db:"provider"`
+			Missing  int    `db:"missing"`
+		}
+		require.NoError(t, GetTestDB().Get(&stored, "SELECT provider, missing FROM reconciliation_runs WHERE id = $1", run.ID))
+		require.Equal(t, "acme", stored.Provider)
+		require.Equal(t, 1, stored.Missing)
+
+		var statuses []string
+		require.NoError(t, GetTestDB().Select(&statuses, "SELECT status FROM reconciliation_items WHERE run_id = $1 ORDER BY id", run.ID))
+		require.Equal(t, []string{"mismatched", "missing", "extra"}, statuses)
+	})
+}
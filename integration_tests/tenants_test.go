@@ -0,0 +1,52 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantScoping(t *testing.T) {
+	ForEachDatabase(t, func(t *testing.T, dbInstance *repositories.DB) {
+		ctx := context.Background()
+		repo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+
+		userID := entity.UserID{UUID: uuid.New()}
+		now := time.Now()
+		require.NoError(t, repo.BatchStore(ctx, []entity.TransactionEvent{
+			{TenantID: "brand-a", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(100), CreatedAt: now},
+			{TenantID: "brand-b", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.Money(200), CreatedAt: now},
+			{UserID: userID, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(300), CreatedAt: now},
+		}))
+
+		t.Run("Filter by tenant", func(t *testing.T) {
+			tenantID := "brand-a"
+			events, err := repo.GetListByFilter(ctx, entity.TransactionEventFilter{TenantID: &tenantID})
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			require.Equal(t, "brand-a", events[0].TenantID)
+			require.Equal(t, entity.Money(100), events[0].Amount)
+		})
+
+		t.Run("Events without tenant belong to the default tenant", func(t *testing.T) {
+			tenantID := entity.DefaultTenantID
+			events, err := repo.GetListByFilter(ctx, entity.TransactionEventFilter{TenantID: &tenantID})
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			require.Equal(t, entity.TransactionTypeWin, events[0].TransactionType)
+		})
+
+		t.Run("Stats are scoped by tenant", func(t *testing.T) {
+			tenantID := "brand-b"
+			stats, err := repo.GetStats(ctx, entity.TransactionEventFilter{TenantID: &tenantID})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), stats.BetCount)
+			require.Equal(t, entity.Money(200), stats.BetAmount)
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package integration_tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/webhooks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	webhookRepo := repositories.NewWebhookRepository(dbInstance, dbInstance)
+
+	const secret = "integration-secret"
+	received := make(chan []byte, 10)
+	failFirst := true
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failFirst {
+			failFirst = false
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		if r.Header.Get(webhooks.SignatureHeader) != "sha256="+webhooks.Sign(secret, timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	admin := webhooks.NewAdminProcessor(webhookRepo, nil)
+	subscription, err := admin.CreateSubscription(ctx, entity.WebhookSubscription{
+		Name:             "big-wins",
+		FilterExpression: `type == "win" && amount >= 1000`,
+		TargetURL:        receiver.URL,
+		Secret:           secret,
+		Active:           true,
+	})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	events := []entity.TransactionEvent{
+		{UserID: entity.UserID{UUID: userID}, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(150000), CreatedAt: time.Now()},
+		{UserID: entity.UserID{UUID: userID}, TransactionType: entity.TransactionTypeWin, Amount: entity.Money(500), CreatedAt: time.Now()},
+	}
+	dispatcher := webhooks.NewDispatcher(webhookRepo, transactionRepo, time.Minute)
+	derived, err := dispatcher.Derive(ctx, events)
+	require.NoError(t, err)
+	require.Len(t, derived, 1)
+
+	// deliveries of a batch that fails to store are not enqueued
+	invalid := append(events, entity.TransactionEvent{UserID: entity.UserID{UUID: userID}, TransactionType: "chargeback_reversal", Amount: entity.Money(1), CreatedAt: time.Now()})
+	require.Error(t, transactionRepo.BatchStoreWithDerived(ctx, invalid, entity.DerivedRecords{WebhookDeliveries: derived}))
+	require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{WebhookDeliveries: derived}))
+
+	sender := webhooks.NewSender(webhookRepo, receiver.Client(), config.Webhooks{InitialBackoffMs: 1})
+
+	processed, err := sender.ProcessDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+
+	require.Eventually(t, func() bool {
+		_, err := sender.ProcessDue(ctx)
+		return err == nil && len(received) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	deliveries, err := admin.ListDeliveries(ctx, subscription.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, entity.WebhookDeliveryStatusDelivered, deliveries[0].Status)
+	require.Equal(t, 2, deliveries[0].Attempts)
+
+	attempts, err := admin.ListAttempts(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+}
+
+func TestWebhookFirstDeposit(t *testing.T) {
+	CleanupDB(t)
+
+	ctx := context.Background()
+	dbInstance := repositories.NewDB(GetTestDB())
+	transactionRepo := repositories.NewTransactionEventRepository(dbInstance, dbInstance)
+	webhookRepo := repositories.NewWebhookRepository(dbInstance, dbInstance)
+
+	admin := webhooks.NewAdminProcessor(webhookRepo, nil)
+	subscription, err := admin.CreateSubscription(ctx, entity.WebhookSubscription{
+		Name:             "first-deposits",
+		FilterExpression: `type == "deposit" && first == true`,
+		TargetURL:        "https://crm.example.com/hooks",
+		Active:           true,
+	})
+	require.NoError(t, err)
+
+	dispatcher := webhooks.NewDispatcher(webhookRepo, transactionRepo, time.Minute)
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Now()
+
+	// both deposits of the first batch share a timestamp, only one is first
+	for _, events := range [][]entity.TransactionEvent{
+		{
+			{UserID: userID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.Money(1000), CreatedAt: now},
+			{UserID: userID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.Money(2000), CreatedAt: now},
+		},
+		{
+			{UserID: userID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.Money(3000), CreatedAt: now.Add(-time.Hour)},
+		},
+	} {
+		deliveries, err := dispatcher.Derive(ctx, events)
+		require.NoError(t, err)
+		require.NoError(t, transactionRepo.BatchStoreWithDerived(ctx, events, entity.DerivedRecords{WebhookDeliveries: deliveries}))
+	}
+
+	deliveries, err := admin.ListDeliveries(ctx, subscription.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Contains(t, string(deliveries[0].Payload), `"amount":10`)
+}
@@ -3,79 +3,236 @@ package consumer
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
+	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/grpc"
 	"github.com/bsko/casino-transaction-system/internal/infrastructure/http"
 	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/memory"
 	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/transport"
+	"github.com/bsko/casino-transaction-system/internal/services/audit"
 	"github.com/bsko/casino-transaction-system/internal/services/consumer"
+	"github.com/bsko/casino-transaction-system/internal/services/erasure"
+	"github.com/bsko/casino-transaction-system/internal/services/feed"
+	"github.com/bsko/casino-transaction-system/internal/services/fraud"
+	"github.com/bsko/casino-transaction-system/internal/services/limits"
+	"github.com/bsko/casino-transaction-system/internal/services/outbox"
+	"github.com/bsko/casino-transaction-system/internal/services/webhooks"
 )
 
 const (
 	consumerConfigFilename = "configs/consumer/config.yaml"
 )
 
+// Options are the settings of the consumer that are not read from the
+// config file.
+type Options struct {
+	// ConfigFile replaces configs/consumer/config.yaml.
+	ConfigFile string
+}
+
 type ConsumerApp struct {
+	Options Options
+
 	conf             *config.App
 	kafka            kafkaReaderInterface
 	transactionsRepo transactionEventRepositoryInterface
 	dbMaster         *repositories.DB
 	dbSlave          *repositories.DB
 	consumer         consumerInterface
+	feedHub          feedHubInterface
 	httpServer       httpServer
+	grpcServer       grpcServer
+	webhookSender    backgroundWorker
+	outboxRelay      backgroundWorker
+	outboxWriter     outboxWriterInterface
+	auditSealer      backgroundWorker
+	captureFile      eventFileInterface
 }
 
 func (p *ConsumerApp) Initialize(ctx context.Context) error {
 	configReader := config.NewReader()
-	conf, err := configReader.Read(consumerConfigFilename)
+	configFile := p.Options.ConfigFile
+	if configFile == "" {
+		configFile = consumerConfigFilename
+	}
+	conf, err := configReader.Read(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to init config: %w", err)
 	}
-	if conf.Kafka == nil {
-		return fmt.Errorf("no kafka config provided")
+	reader, err := transport.NewReader(conf)
+	if err != nil {
+		return fmt.Errorf("failed to init transport: %w", err)
 	}
-
-	kafkaAdapter := kafka.NewKafkaReader(*conf.Kafka)
-	err = kafkaAdapter.Connect(ctx)
+	err = reader.Connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to kafka: %w", err)
+		return fmt.Errorf("failed to connect reader: %w", err)
 	}
+	p.kafka = reader
 
 	if conf.PostgresMaster == nil {
 		return fmt.Errorf("no postgres master config provided")
 	}
-	if conf.PostgresSlave == nil {
-		return fmt.Errorf("no postgres slave config provided")
+	if driver := conf.PostgresMaster.Driver; driver == repositories.DriverMySQL || driver == repositories.DriverMemory {
+		if err = checkPostgresFeatures(conf); err != nil {
+			return err
+		}
 	}
 
-	dbMaster := repositories.NewDB(nil)
-	err = dbMaster.Connect(conf.PostgresMaster)
-	if err != nil {
-		return fmt.Errorf("failed to connect to postgres: %w", err)
-	}
+	var (
+		dbMaster         *repositories.DB
+		dbSlave          *repositories.DB
+		transactionsRepo transactionEventRepositoryInterface
+	)
+	if conf.PostgresMaster.Driver == repositories.DriverMemory {
+		transactionsRepo = memory.NewTransactionEventRepository()
+	} else {
+		if conf.PostgresSlave == nil {
+			return fmt.Errorf("no postgres slave config provided")
+		}
 
-	dbSlave := repositories.NewDB(nil)
-	err = dbSlave.Connect(conf.PostgresSlave)
-	if err != nil {
-		return fmt.Errorf("failed to connect to postgres: %w", err)
+		dbMaster = repositories.NewDB(nil)
+		err = dbMaster.Connect(conf.PostgresMaster)
+		if err != nil {
+			return fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+
+		dbSlave = repositories.NewDB(nil)
+		err = dbSlave.Connect(conf.PostgresSlave)
+		if err != nil {
+			return fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+
+		transactionsRepo = repositories.NewTransactionEventRepository(dbMaster, dbSlave)
 	}
 
-	transactionsRepo := repositories.NewTransactionEventRepository(dbMaster, dbSlave)
+	var feedBufferSize int
+	if conf.Feed != nil {
+		feedBufferSize = conf.Feed.SubscriberBufferSize
+	}
+	feedHub := feed.NewHub(feedBufferSize)
 
-	consumerService := consumer.NewConsumer(kafkaAdapter, transactionsRepo)
+	consumerService := consumer.NewConsumer(reader, transactionsRepo)
+	if conf.Kafka != nil {
+		if err = consumerService.SetTopics(conf.Kafka.Topics); err != nil {
+			return fmt.Errorf("invalid kafka topics config: %w", err)
+		}
+	}
+	consumerService.SetEventPublisher(feedHub)
 	transactionsHandler := consumer.NewGetListProcessor(transactionsRepo)
-	httpServerInstance := http.NewHttpServer(transactionsHandler, conf.Http)
+	httpServerInstance := http.NewHttpServer(transactionsHandler, feedHub, conf.Http)
+	httpServerInstance.SetConsumerStatsHandler(consumerService)
+
+	var erasureGuard *erasure.Guard
+	if conf.Erasure != nil {
+		if conf.Erasure.LookupKey == "" {
+			return fmt.Errorf("no erasure lookup key provided")
+		}
+		erasureRepo := repositories.NewErasureRepository(dbMaster)
+		erasureGuard = erasure.NewGuard(erasureRepo, *conf.Erasure)
+		transactionsHandler.SetErasureGuard(erasureGuard)
+		consumerService.SetErasureGuard(erasureGuard)
+		httpServerInstance.SetErasureHandler(erasure.NewProcessor(erasureRepo, *conf.Erasure))
+	}
+	if conf.Webhooks != nil {
+		webhookRepo := repositories.NewWebhookRepository(dbMaster, dbSlave)
+		dispatcher := webhooks.NewDispatcher(webhookRepo, transactionsRepo,
+			time.Duration(conf.Webhooks.SubscriptionsCacheTTLMs)*time.Millisecond)
+		consumerService.SetWebhooks(dispatcher)
+		httpServerInstance.SetWebhookAdminHandler(webhooks.NewAdminProcessor(webhookRepo, dispatcher))
+		p.webhookSender = webhooks.NewSender(webhookRepo, &nethttp.Client{}, *conf.Webhooks)
+	}
+	if conf.Limits != nil && conf.Limits.Enabled {
+		limitRepo := repositories.NewLimitRepository(dbMaster, dbSlave)
+		consumerService.SetLimits(limits.NewEvaluator(limitRepo))
+		limitsProcessor := limits.NewProcessor(limitRepo)
+		if erasureGuard != nil {
+			limitsProcessor.SetErasureGuard(erasureGuard)
+		}
+		httpServerInstance.SetLimitsHandler(limitsProcessor)
+	}
+	if conf.Fraud != nil {
+		rules, err := fraud.LoadRules(conf.Fraud.RulesFile)
+		if err != nil {
+			return fmt.Errorf("failed to load fraud rules: %w", err)
+		}
+		alertRepo := repositories.NewAlertRepository(dbMaster, dbSlave)
+		consumerService.SetEventInspector(fraud.NewEngine(rules, alertRepo))
+		alertProcessor := fraud.NewAlertProcessor(alertRepo)
+		if erasureGuard != nil {
+			alertProcessor.SetErasureGuard(erasureGuard)
+		}
+		httpServerInstance.SetAlertsHandler(alertProcessor)
+	}
+	if conf.Outbox != nil {
+		if conf.Outbox.Kafka == nil {
+			return fmt.Errorf("no outbox kafka config provided")
+		}
+		// per-user ordering relies on all messages of a user landing in one partition
+		outboxKafkaConf := *conf.Outbox.Kafka
+		outboxKafkaConf.Balancer = kafka.BalancerHash
+		outboxWriter := kafka.NewKafkaWriter(outboxKafkaConf)
+		if err = outboxWriter.Connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect outbox kafka writer: %w", err)
+		}
+		consumerService.SetOutbox(outbox.NewDeriver(conf.Outbox.LargeWinThreshold))
+		p.outboxRelay = outbox.NewRelay(repositories.NewOutboxRepository(dbMaster), outboxWriter, *conf.Outbox)
+		p.outboxWriter = outboxWriter
+	}
+	if conf.Audit != nil {
+		if conf.Audit.SigningKey == "" {
+			return fmt.Errorf("no audit signing key provided")
+		}
+		auditRepo := repositories.NewAuditRepository(dbMaster)
+		httpServerInstance.SetAuditHandler(audit.NewVerifier(auditRepo, *conf.Audit))
+		p.auditSealer = audit.NewSealer(auditRepo, *conf.Audit)
+	}
+	if conf.Capture != nil {
+		captureFile, err := kafka.CreateEventFile(conf.Capture.File, conf.Capture.Format)
+		if err != nil {
+			return fmt.Errorf("failed to create capture file: %w", err)
+		}
+		consumerService.SetCapture(captureFile)
+		p.captureFile = captureFile
+	}
+	if conf.Grpc != nil {
+		grpcServer := grpc.NewGrpcServer(transactionsHandler, conf.Grpc)
+		grpcServer.SetAPIKeys(conf.Http.APIKeys)
+		p.grpcServer = grpcServer
+	}
 
 	p.conf = conf
-	p.kafka = kafkaAdapter
 	p.dbMaster = dbMaster
 	p.dbSlave = dbSlave
 	p.transactionsRepo = transactionsRepo
 	p.consumer = consumerService
+	p.feedHub = feedHub
 	p.httpServer = httpServerInstance
 	return nil
 }
 
+// checkPostgresFeatures rejects the features whose repositories need
+// postgres, mysql and memory back the transaction repository only.
+func checkPostgresFeatures(conf *config.App) error {
+	features := map[string]bool{
+		"erasure":  conf.Erasure != nil,
+		"webhooks": conf.Webhooks != nil,
+		"limits":   conf.Limits != nil && conf.Limits.Enabled,
+		"fraud":    conf.Fraud != nil,
+		"outbox":   conf.Outbox != nil,
+		"audit":    conf.Audit != nil,
+	}
+	for _, name := range []string{"erasure", "webhooks", "limits", "fraud", "outbox", "audit"} {
+		if features[name] {
+			return fmt.Errorf("%s requires the postgres driver", name)
+		}
+	}
+	return nil
+}
+
 func (p *ConsumerApp) Exec(ctx context.Context) error {
 	if p.consumer == nil {
 		return fmt.Errorf("consumer is not initialized")
@@ -84,7 +241,7 @@ func (p *ConsumerApp) Exec(ctx context.Context) error {
 		return fmt.Errorf("http server is not initialized")
 	}
 
-	errChan := make(chan error, 1)
+	errChan := make(chan error, 6)
 
 	go func() {
 		if err := p.httpServer.Start(ctx); err != nil {
@@ -92,6 +249,38 @@ func (p *ConsumerApp) Exec(ctx context.Context) error {
 		}
 	}()
 
+	if p.grpcServer != nil {
+		go func() {
+			if err := p.grpcServer.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("grpc server error: %w", err)
+			}
+		}()
+	}
+
+	if p.webhookSender != nil {
+		go func() {
+			if err := p.webhookSender.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("webhook sender error: %w", err)
+			}
+		}()
+	}
+
+	if p.outboxRelay != nil {
+		go func() {
+			if err := p.outboxRelay.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("outbox relay error: %w", err)
+			}
+		}()
+	}
+
+	if p.auditSealer != nil {
+		go func() {
+			if err := p.auditSealer.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("audit sealer error: %w", err)
+			}
+		}()
+	}
+
 	go func() {
 		if err := p.consumer.Start(ctx); err != nil {
 			errChan <- fmt.Errorf("consumer error: %w", err)
@@ -109,15 +298,37 @@ func (p *ConsumerApp) Exec(ctx context.Context) error {
 func (p *ConsumerApp) Shutdown(ctx context.Context) error {
 	var errs []error
 
+	if p.feedHub != nil {
+		p.feedHub.Close()
+	}
+
 	if p.httpServer != nil {
 		if err := p.httpServer.Shutdown(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("htpp server close error: %w", err))
 		}
 	}
 
+	if p.grpcServer != nil {
+		if err := p.grpcServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("grpc server close error: %w", err))
+		}
+	}
+
 	if p.kafka != nil {
 		if err := p.kafka.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("kafka close error: %w", err))
+			errs = append(errs, fmt.Errorf("reader close error: %w", err))
+		}
+	}
+
+	if p.outboxWriter != nil {
+		if err := p.outboxWriter.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("outbox kafka writer close error: %w", err))
+		}
+	}
+
+	if p.captureFile != nil {
+		if err := p.captureFile.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("capture file close error: %w", err))
 		}
 	}
 
@@ -13,16 +13,42 @@ type consumerInterface interface {
 
 type kafkaReaderInterface interface {
 	Connect(ctx context.Context) error
-	Read(ctx context.Context) (*entity.TransactionEvent, error)
+	Read(ctx context.Context) (*entity.ConsumedEvent, error)
 	Commit(ctx context.Context) error
 	Close() error
 }
 
 type transactionEventRepositoryInterface interface {
+	GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error)
+	GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error)
+	HasTransaction(ctx context.Context, userID entity.UserID, transactionType entity.TransactionType) (bool, error)
 	BatchStore(ctx context.Context, batch []entity.TransactionEvent) error
+	BatchStoreWithDerived(ctx context.Context, batch []entity.TransactionEvent, derived entity.DerivedRecords) error
 }
 
 type httpServer interface {
 	Start(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 }
+
+type grpcServer interface {
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+type feedHubInterface interface {
+	Close()
+}
+
+type backgroundWorker interface {
+	Start(ctx context.Context) error
+}
+
+type outboxWriterInterface interface {
+	Close() error
+}
+
+type eventFileInterface interface {
+	Close() error
+}
@@ -0,0 +1,288 @@
+package offsets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer"
+	"github.com/bsko/casino-transaction-system/internal/services/erasure"
+	"github.com/bsko/casino-transaction-system/internal/services/limits"
+	"github.com/bsko/casino-transaction-system/internal/services/outbox"
+	"github.com/bsko/casino-transaction-system/internal/services/webhooks"
+)
+
+const (
+	offsetsConfigFilename = "configs/consumer/config.yaml"
+
+	ActionLag    = "lag"
+	ActionReset  = "reset"
+	ActionReplay = "replay"
+)
+
+// Options are the command line arguments of the offsets command. Topic
+// defaults to the main topic of the config. To, At, Offset, Partition and
+// DryRun belong to the reset action, From and Until to the replay action;
+// times are RFC 3339 and Partition -1 means every partition.
+type Options struct {
+	ConfigFile string
+	Action     string
+	Topic      string
+	To         string
+	At         string
+	Offset     int64
+	Partition  int
+	DryRun     bool
+	From       string
+	Until      string
+}
+
+type OffsetsApp struct {
+	Options Options
+
+	dbMaster     *repositories.DB
+	kafkaOffsets kafkaOffsetsInterface
+	manager      offsetManagerInterface
+	replayer     replayerInterface
+	topic        string
+	reset        entity.OffsetReset
+	replayRange  entity.ReplayRange
+}
+
+func (p *OffsetsApp) Initialize(ctx context.Context) error {
+	configFile := p.Options.ConfigFile
+	if configFile == "" {
+		configFile = offsetsConfigFilename
+	}
+	configReader := config.NewReader()
+	conf, err := configReader.Read(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to init config: %w", err)
+	}
+	if conf.Kafka == nil {
+		return fmt.Errorf("no kafka config provided")
+	}
+
+	topic := p.Options.Topic
+	if topic == "" {
+		topic = conf.Kafka.Topic
+	}
+	if topic == "" {
+		return fmt.Errorf("no topic provided")
+	}
+	p.topic = topic
+
+	switch p.Options.Action {
+	case ActionLag, ActionReset:
+		if conf.Kafka.GroupID == "" {
+			return fmt.Errorf("no kafka consumer group configured")
+		}
+		if p.Options.Action == ActionReset {
+			if p.reset, err = parseReset(p.Options); err != nil {
+				return err
+			}
+			p.reset.Topic = topic
+		}
+	case ActionReplay:
+		if p.replayRange, err = parseReplayRange(p.Options); err != nil {
+			return err
+		}
+		p.replayRange.Topic = topic
+	default:
+		return fmt.Errorf("unknown action %q, must be one of lag, reset, replay", p.Options.Action)
+	}
+
+	kafkaOffsets := kafka.NewKafkaOffsets(*conf.Kafka)
+	if err = kafkaOffsets.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	p.kafkaOffsets = kafkaOffsets
+	p.manager = consumer.NewOffsetManager(kafkaOffsets)
+
+	if p.Options.Action != ActionReplay {
+		return nil
+	}
+
+	if conf.PostgresMaster == nil {
+		return fmt.Errorf("no postgres master config provided")
+	}
+	if conf.PostgresMaster.Driver == repositories.DriverMemory {
+		return fmt.Errorf("replay requires a database, the memory driver keeps nothing between processes")
+	}
+	if conf.PostgresMaster.Driver == repositories.DriverMySQL {
+		if conf.Outbox != nil {
+			return fmt.Errorf("outbox requires the postgres driver")
+		}
+		if conf.Limits != nil && conf.Limits.Enabled {
+			return fmt.Errorf("limits require the postgres driver")
+		}
+		if conf.Webhooks != nil {
+			return fmt.Errorf("webhooks require the postgres driver")
+		}
+		if conf.Erasure != nil {
+			return fmt.Errorf("erasure requires the postgres driver")
+		}
+	}
+	if conf.Erasure != nil && conf.Erasure.LookupKey == "" {
+		return fmt.Errorf("no erasure lookup key provided")
+	}
+	dbMaster := repositories.NewDB(nil)
+	if err = dbMaster.Connect(conf.PostgresMaster); err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	p.dbMaster = dbMaster
+
+	transactionsRepo := repositories.NewTransactionEventRepository(dbMaster, nil)
+	replayer := consumer.NewReplayer(kafkaOffsets, transactionsRepo)
+	if err = replayer.SetTopics(conf.Kafka.Topics); err != nil {
+		return fmt.Errorf("invalid kafka topics config: %w", err)
+	}
+	if conf.Outbox != nil {
+		replayer.SetOutbox(outbox.NewDeriver(conf.Outbox.LargeWinThreshold))
+	}
+	if conf.Limits != nil && conf.Limits.Enabled {
+		replayer.SetLimits(limits.NewEvaluator(repositories.NewLimitRepository(dbMaster, dbMaster)))
+	}
+	if conf.Webhooks != nil {
+		replayer.SetWebhooks(webhooks.NewDispatcher(repositories.NewWebhookRepository(dbMaster, dbMaster), transactionsRepo,
+			time.Duration(conf.Webhooks.SubscriptionsCacheTTLMs)*time.Millisecond))
+	}
+	if conf.Erasure != nil {
+		replayer.SetErasureGuard(erasure.NewGuard(repositories.NewErasureRepository(dbMaster), *conf.Erasure))
+	}
+	p.replayer = replayer
+	return nil
+}
+
+// Exec runs the action and prints its result to stdout.
+func (p *OffsetsApp) Exec(ctx context.Context) error {
+	if p.manager == nil {
+		return fmt.Errorf("offset manager is not initialized")
+	}
+
+	switch p.Options.Action {
+	case ActionLag:
+		offsets, err := p.manager.Lag(ctx, p.topic)
+		if err != nil {
+			return err
+		}
+		return printLag(offsets)
+	case ActionReset:
+		changes, err := p.manager.Reset(ctx, p.reset)
+		if err != nil {
+			return err
+		}
+		if p.reset.DryRun {
+			log.Println("Dry run, no offsets committed")
+		}
+		return printChanges(changes)
+	case ActionReplay:
+		if p.replayer == nil {
+			return fmt.Errorf("replayer is not initialized")
+		}
+		result, err := p.replayer.Replay(ctx, p.replayRange)
+		if err != nil {
+			return err
+		}
+		log.Printf("Replay of %s from %s until %s: %d read, %d rejected, %d already stored, %d stored",
+			p.replayRange.Topic, p.replayRange.From.Format(time.RFC3339), p.replayRange.To.Format(time.RFC3339),
+			result.Read, result.Rejected, result.Skipped, result.Stored)
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", p.Options.Action)
+}
+
+func (p *OffsetsApp) Shutdown(_ context.Context) error {
+	if p.kafkaOffsets != nil {
+		if err := p.kafkaOffsets.Close(); err != nil {
+			return fmt.Errorf("kafka close error: %w", err)
+		}
+	}
+	if p.dbMaster != nil {
+		if err := p.dbMaster.Close(); err != nil {
+			return fmt.Errorf("db master close error: %w", err)
+		}
+	}
+	return nil
+}
+
+func parseReset(options Options) (entity.OffsetReset, error) {
+	reset := entity.OffsetReset{
+		Target: entity.OffsetResetTarget(options.To),
+		DryRun: options.DryRun,
+	}
+	if options.Partition >= 0 {
+		partition := options.Partition
+		reset.Partition = &partition
+	}
+
+	switch reset.Target {
+	case entity.OffsetResetEarliest, entity.OffsetResetLatest:
+	case entity.OffsetResetTimestamp:
+		at, err := time.Parse(time.RFC3339, options.At)
+		if err != nil {
+			return reset, fmt.Errorf("invalid reset time: %w", err)
+		}
+		reset.Time = at
+	case entity.OffsetResetOffset:
+		if options.Offset < 0 {
+			return reset, fmt.Errorf("no reset offset provided")
+		}
+		reset.Offset = options.Offset
+	default:
+		return reset, fmt.Errorf("unknown reset target %q, must be one of earliest, latest, timestamp, offset", options.To)
+	}
+	return reset, nil
+}
+
+func parseReplayRange(options Options) (entity.ReplayRange, error) {
+	var replayRange entity.ReplayRange
+	var err error
+	if replayRange.From, err = time.Parse(time.RFC3339, options.From); err != nil {
+		return replayRange, fmt.Errorf("invalid replay start: %w", err)
+	}
+	if replayRange.To, err = time.Parse(time.RFC3339, options.Until); err != nil {
+		return replayRange, fmt.Errorf("invalid replay end: %w", err)
+	}
+	if !replayRange.From.Before(replayRange.To) {
+		return replayRange, fmt.Errorf("replay start must be before its end")
+	}
+	return replayRange, nil
+}
+
+func printLag(offsets []entity.PartitionOffsets) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tFIRST\tLAST\tCOMMITTED\tLAG")
+	var total int64
+	for _, partition := range offsets {
+		total += partition.Lag()
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%d\n",
+			partition.Partition, partition.First, partition.Last, formatOffset(partition.Committed), partition.Lag())
+	}
+	fmt.Fprintf(w, "total\t\t\t\t%d\n", total)
+	return w.Flush()
+}
+
+func printChanges(changes []entity.OffsetChange) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tPREVIOUS\tOFFSET")
+	for _, change := range changes {
+		fmt.Fprintf(w, "%d\t%s\t%d\n", change.Partition, formatOffset(change.Previous), change.Offset)
+	}
+	return w.Flush()
+}
+
+func formatOffset(offset int64) string {
+	if offset == entity.NoCommittedOffset {
+		return "-"
+	}
+	return strconv.FormatInt(offset, 10)
+}
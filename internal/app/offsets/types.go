@@ -0,0 +1,21 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package offsets
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type offsetManagerInterface interface {
+	Lag(ctx context.Context, topic string) ([]entity.PartitionOffsets, error)
+	Reset(ctx context.Context, reset entity.OffsetReset) ([]entity.OffsetChange, error)
+}
+
+type replayerInterface interface {
+	Replay(ctx context.Context, replayRange entity.ReplayRange) (*entity.ReplayResult, error)
+}
+
+type kafkaOffsetsInterface interface {
+	Close() error
+}
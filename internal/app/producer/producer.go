@@ -3,9 +3,15 @@ package producer
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http"
 	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/spool"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/transport"
+	"github.com/bsko/casino-transaction-system/internal/services/ingest"
 	"github.com/bsko/casino-transaction-system/internal/services/producer"
 )
 
@@ -13,49 +19,203 @@ const (
 	producerConfigFilename = "configs/producer/config.yaml"
 )
 
+// Options are the settings of the producer that are not read from the
+// config file.
+type Options struct {
+	// ConfigFile replaces configs/producer/config.yaml.
+	ConfigFile string
+}
+
 type ProducerApp struct {
-	conf     *config.App
-	kafka    kafkaAdapterInterface
-	producer producerInterface
+	Options Options
+
+	conf        *config.App
+	kafka       kafkaAdapterInterface
+	producer    producerInterface
+	eventFile   eventFileInterface
+	httpServer  httpServer
+	spool       spoolInterface
+	spoolWriter spoolWriterInterface
 }
 
 func (p *ProducerApp) Initialize(ctx context.Context) error {
 	configReader := config.NewReader()
-	conf, err := configReader.Read(producerConfigFilename)
+	configFile := p.Options.ConfigFile
+	if configFile == "" {
+		configFile = producerConfigFilename
+	}
+	conf, err := configReader.Read(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to init config: %w", err)
 	}
-	if conf.Kafka == nil {
-		return fmt.Errorf("no kafka config provided")
+	transportWriter, err := transport.NewWriter(conf)
+	if err != nil {
+		return fmt.Errorf("failed to init transport: %w", err)
 	}
-
-	kafkaAdapter := kafka.NewKafkaWriter(*conf.Kafka)
-	err = kafkaAdapter.Connect(ctx)
+	err = transportWriter.Connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to kafka: %w", err)
+		return fmt.Errorf("failed to connect writer: %w", err)
 	}
+	p.kafka = transportWriter
 
 	if conf.Producer == nil {
 		return fmt.Errorf("no producer config provided")
 	}
-	producer := producer.NewProducer(*conf.Producer, kafkaAdapter)
+
+	var writer eventWriter = transportWriter
+	if conf.Producer.Spool != nil {
+		eventSpool, err := spool.Open(*conf.Producer.Spool)
+		if err != nil {
+			return fmt.Errorf("failed to open spool: %w", err)
+		}
+		log.Printf("Spooling unpublished events in %s, %d bytes pending", conf.Producer.Spool.Dir, eventSpool.Bytes())
+		spoolWriter := spool.NewWriter(transportWriter, eventSpool,
+			time.Duration(conf.Producer.Spool.RetryIntervalMs)*time.Millisecond)
+		p.spool = eventSpool
+		p.spoolWriter = spoolWriter
+		writer = spoolWriter
+	}
+	if conf.Producer.Replay != nil {
+		if conf.Http != nil {
+			return fmt.Errorf("the control API is not available when replaying a file")
+		}
+		if conf.Gateway != nil {
+			return fmt.Errorf("the ingestion gateway is not available when replaying a file")
+		}
+		if conf.Producer.Faults != nil {
+			return fmt.Errorf("faults are not injected when replaying a file")
+		}
+		eventFile, err := kafka.OpenEventFile(conf.Producer.Replay.File, conf.Producer.Replay.Format)
+		if err != nil {
+			return fmt.Errorf("failed to open replay file: %w", err)
+		}
+		log.Printf("Replaying events of %s", conf.Producer.Replay.File)
+
+		p.conf = conf
+		p.eventFile = eventFile
+		p.producer = producer.NewFileReplayer(eventFile, writer, *conf.Producer.Replay)
+		return nil
+	}
+	producerService := producer.NewProducer(*conf.Producer, writer)
+	if transport.Async(conf) {
+		producerService.SetAsyncWriter(writer)
+	}
+	if p.spoolWriter != nil {
+		producerService.ContinueOnError()
+	}
+	if conf.Producer.Profile != nil {
+		if err = producerService.SetLoadProfile(*conf.Producer.Profile); err != nil {
+			return fmt.Errorf("invalid load profile: %w", err)
+		}
+	}
+	if conf.Producer.Faults != nil {
+		if err = producerService.SetFaults(*conf.Producer.Faults); err != nil {
+			return fmt.Errorf("invalid fault injection: %w", err)
+		}
+		log.Println("Injecting faults into the published events")
+	}
+	if conf.Producer.ScenarioFile != "" {
+		scenario, err := producer.LoadScenario(conf.Producer.ScenarioFile)
+		if err != nil {
+			return fmt.Errorf("failed to load scenario: %w", err)
+		}
+		seed := scenario.Seed()
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		log.Printf("Playing scenario %s with seed %d", conf.Producer.ScenarioFile, seed)
+		producerService.SetScenario(producer.NewEngine(scenario, seed, conf.Producer.TenantID))
+	}
+
+	if conf.Gateway != nil && conf.Http == nil {
+		return fmt.Errorf("the ingestion gateway needs an http config")
+	}
+	if conf.Http != nil {
+		httpServerInstance := http.NewHttpServer(nil, nil, conf.Http)
+		httpServerInstance.SetProducerControlHandler(producerService)
+		if conf.Gateway != nil {
+			httpServerInstance.SetIngestHandler(ingest.NewGateway(*conf.Gateway, writer))
+			log.Println("Serving the ingestion gateway on POST /events")
+		}
+		p.httpServer = httpServerInstance
+	}
 
 	p.conf = conf
-	p.kafka = kafkaAdapter
-	p.producer = producer
+	p.producer = producerService
 	return nil
 }
 
+// Exec runs the producer and, when configured, the control API and the
+// spool replay next to it. The run ends when the producer stops; events
+// still spooled then are replayed by the next run.
 func (p *ProducerApp) Exec(ctx context.Context) error {
-	return p.producer.Start(ctx)
+	if p.httpServer == nil && p.spoolWriter == nil {
+		return p.producer.Start(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errChan := make(chan error, 2)
+	if p.httpServer != nil {
+		go func() {
+			if err := p.httpServer.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("http server error: %w", err)
+				cancel()
+			}
+		}()
+	}
+	spoolDone := make(chan struct{})
+	if p.spoolWriter != nil {
+		go func() {
+			defer close(spoolDone)
+			if err := p.spoolWriter.Start(ctx); err != nil {
+				errChan <- fmt.Errorf("spool error: %w", err)
+				cancel()
+			}
+		}()
+	} else {
+		close(spoolDone)
+	}
+
+	err := p.producer.Start(ctx)
+	cancel()
+	<-spoolDone
+	if err != nil {
+		return err
+	}
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
 }
 
-func (p *ProducerApp) Shutdown(_ context.Context) error {
+func (p *ProducerApp) Shutdown(ctx context.Context) error {
 	var errs []error
 
+	if p.httpServer != nil {
+		if err := p.httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http server shutdown error: %w", err))
+		}
+	}
+
+	if p.eventFile != nil {
+		if err := p.eventFile.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("replay file close error: %w", err))
+		}
+	}
+
+	if p.spool != nil {
+		if err := p.spool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("spool close error: %w", err))
+		}
+	}
+
 	if p.kafka != nil {
 		if err := p.kafka.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("kafka close error: %w", err))
+			errs = append(errs, fmt.Errorf("writer close error: %w", err))
 		}
 	}
 
@@ -16,3 +16,28 @@ type kafkaAdapterInterface interface {
 	Publish(ctx context.Context, event entity.TransactionEvent) error
 	Close() error
 }
+
+type eventFileInterface interface {
+	Close() error
+}
+
+type httpServer interface {
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// eventWriter is the writer the producer publishes through, Kafka itself or
+// the spool in front of it.
+type eventWriter interface {
+	Publish(ctx context.Context, event entity.TransactionEvent) error
+	PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error
+	PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error
+}
+
+type spoolInterface interface {
+	Close() error
+}
+
+type spoolWriterInterface interface {
+	Start(ctx context.Context) error
+}
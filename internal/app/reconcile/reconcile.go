@@ -0,0 +1,192 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/reconciliation"
+)
+
+const (
+	reconcileConfigFilename = "configs/reconcile/config.yaml"
+	stdoutPath              = "-"
+)
+
+// Options are the command line arguments of a reconciliation run. From and
+// To are optional RFC 3339 timestamps; JSONReport and CSVReport are output
+// paths where "-" means standard output and an empty path skips the report.
+type Options struct {
+	ConfigFile string
+	Provider   string
+	File       string
+	From       string
+	To         string
+	JSONReport string
+	CSVReport  string
+	SkipExtra  bool
+}
+
+type ReconcileApp struct {
+	Options Options
+
+	dbMaster   *repositories.DB
+	reader     settlementReaderInterface
+	reconciler reconcilerInterface
+	from       time.Time
+	to         time.Time
+}
+
+func (p *ReconcileApp) Initialize(_ context.Context) error {
+	if p.Options.File == "" {
+		return fmt.Errorf("no settlement file provided")
+	}
+	if p.Options.Provider == "" {
+		return fmt.Errorf("no provider provided")
+	}
+
+	var err error
+	if p.from, err = parseOptionalTime(p.Options.From); err != nil {
+		return fmt.Errorf("invalid period start: %w", err)
+	}
+	if p.to, err = parseOptionalTime(p.Options.To); err != nil {
+		return fmt.Errorf("invalid period end: %w", err)
+	}
+	if p.from.IsZero() != p.to.IsZero() {
+		return fmt.Errorf("period start and end must be provided together")
+	}
+
+	configFile := p.Options.ConfigFile
+	if configFile == "" {
+		configFile = reconcileConfigFilename
+	}
+	configReader := config.NewReader()
+	conf, err := configReader.Read(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to init config: %w", err)
+	}
+	if conf.Reconciliation == nil {
+		return fmt.Errorf("no reconciliation config provided")
+	}
+	if conf.PostgresMaster == nil {
+		return fmt.Errorf("no postgres master config provided")
+	}
+	if driver := conf.PostgresMaster.Driver; driver != "" && driver != repositories.DriverPostgres {
+		return fmt.Errorf("reconciliation requires the postgres driver")
+	}
+
+	format, err := findFormat(conf.Reconciliation.Providers, p.Options.Provider)
+	if err != nil {
+		return err
+	}
+	reader, err := reconciliation.NewSettlementReader(*format)
+	if err != nil {
+		return fmt.Errorf("invalid settlement format of provider %s: %w", p.Options.Provider, err)
+	}
+
+	dbMaster := repositories.NewDB(nil)
+	if err = dbMaster.Connect(conf.PostgresMaster); err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	p.dbMaster = dbMaster
+	p.reader = reader
+	p.reconciler = reconciliation.NewReconciler(repositories.NewReconciliationRepository(dbMaster))
+	return nil
+}
+
+func (p *ReconcileApp) Exec(ctx context.Context) error {
+	if p.reconciler == nil || p.reader == nil {
+		return fmt.Errorf("reconciler is not initialized")
+	}
+
+	file, err := os.Open(p.Options.File)
+	if err != nil {
+		return fmt.Errorf("failed to open settlement file: %w", err)
+	}
+	defer file.Close()
+
+	records, err := p.reader.Read(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse settlement file: %w", err)
+	}
+
+	run, err := p.reconciler.Reconcile(ctx, reconciliation.Request{
+		Provider:   p.Options.Provider,
+		FileName:   filepath.Base(p.Options.File),
+		Records:    records,
+		TimeWindow: p.reader.TimeWindow(),
+		From:       p.from,
+		To:         p.to,
+		SkipExtra:  p.Options.SkipExtra,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile: %w", err)
+	}
+
+	if err = writeReport(p.Options.JSONReport, run, reconciliation.WriteJSONReport); err != nil {
+		return err
+	}
+	if err = writeReport(p.Options.CSVReport, run, reconciliation.WriteCSVReport); err != nil {
+		return err
+	}
+
+	log.Printf("Reconciliation run %d: %d provider records, %d stored, %d matched, %d missing, %d extra, %d mismatched",
+		run.ID, run.ProviderRecords, run.StoredRecords, run.Matched, run.Missing, run.Extra, run.Mismatched)
+	return nil
+}
+
+func (p *ReconcileApp) Shutdown(_ context.Context) error {
+	if p.dbMaster != nil {
+		if err := p.dbMaster.Close(); err != nil {
+			return fmt.Errorf("db master close error: %w", err)
+		}
+	}
+	return nil
+}
+
+func findFormat(formats []config.SettlementFormat, provider string) (*config.SettlementFormat, error) {
+	for i := range formats {
+		if strings.EqualFold(formats[i].Name, provider) {
+			return &formats[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no settlement format configured for provider %s", provider)
+}
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writeReport(path string, run *entity.ReconciliationRun, write func(w io.Writer, run *entity.ReconciliationRun) error) error {
+	if path == "" {
+		return nil
+	}
+	if path == stdoutPath {
+		return write(os.Stdout, run)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	if err = write(file, run); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close report file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package reconcile
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/reconciliation"
+)
+
+type reconcilerInterface interface {
+	Reconcile(ctx context.Context, request reconciliation.Request) (*entity.ReconciliationRun, error)
+}
+
+type settlementReaderInterface interface {
+	Read(r io.Reader) ([]entity.SettlementRecord, error)
+	TimeWindow() time.Duration
+}
@@ -0,0 +1,12 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package verify
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type verifierInterface interface {
+	Verify(ctx context.Context, userID *entity.UserID) (*entity.AuditVerification, error)
+}
@@ -0,0 +1,108 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repositories"
+	"github.com/bsko/casino-transaction-system/internal/services/audit"
+	"github.com/google/uuid"
+)
+
+const (
+	verifyConfigFilename = "configs/consumer/config.yaml"
+)
+
+var ErrTampered = errors.New("audit trail has been tampered with")
+
+// Options are the command line arguments of the verify command. UserID
+// limits the verification to the chain of one user.
+type Options struct {
+	ConfigFile string
+	UserID     string
+}
+
+type VerifyApp struct {
+	Options Options
+
+	dbMaster *repositories.DB
+	verifier verifierInterface
+	userID   *entity.UserID
+}
+
+func (p *VerifyApp) Initialize(_ context.Context) error {
+	if p.Options.UserID != "" {
+		parsedUUID, err := uuid.Parse(p.Options.UserID)
+		if err != nil {
+			return fmt.Errorf("invalid user id: %w", err)
+		}
+		p.userID = entity.NewUserID(parsedUUID)
+	}
+
+	configFile := p.Options.ConfigFile
+	if configFile == "" {
+		configFile = verifyConfigFilename
+	}
+	configReader := config.NewReader()
+	conf, err := configReader.Read(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to init config: %w", err)
+	}
+	if conf.Audit == nil || conf.Audit.SigningKey == "" {
+		return fmt.Errorf("no audit signing key provided")
+	}
+	if conf.PostgresMaster == nil {
+		return fmt.Errorf("no postgres master config provided")
+	}
+	if driver := conf.PostgresMaster.Driver; driver != "" && driver != repositories.DriverPostgres {
+		return fmt.Errorf("audit verification requires the postgres driver")
+	}
+
+	dbMaster := repositories.NewDB(nil)
+	if err = dbMaster.Connect(conf.PostgresMaster); err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	p.dbMaster = dbMaster
+	p.verifier = audit.NewVerifier(repositories.NewAuditRepository(dbMaster), *conf.Audit)
+	return nil
+}
+
+// Exec prints the verification report to stdout and returns ErrTampered when
+// a tampered row or digest was found.
+func (p *VerifyApp) Exec(ctx context.Context) error {
+	if p.verifier == nil {
+		return fmt.Errorf("verifier is not initialized")
+	}
+
+	verification, err := p.verifier.Verify(ctx, p.userID)
+	if err != nil {
+		return fmt.Errorf("failed to verify audit trail: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(http.TransformAuditVerificationToDTO(*verification)); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if !verification.Valid() {
+		return ErrTampered
+	}
+	return nil
+}
+
+func (p *VerifyApp) Shutdown(_ context.Context) error {
+	if p.dbMaster != nil {
+		if err := p.dbMaster.Close(); err != nil {
+			return fmt.Errorf("db master close error: %w", err)
+		}
+	}
+	return nil
+}
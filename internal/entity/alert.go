@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+const (
+	AlertSeverityLow    AlertSeverity = "low"
+	AlertSeverityMedium AlertSeverity = "medium"
+	AlertSeverityHigh   AlertSeverity = "high"
+)
+
+type AlertSeverity string
+
+func (s AlertSeverity) IsValid() bool {
+	return s == AlertSeverityLow || s == AlertSeverityMedium || s == AlertSeverityHigh
+}
+
+// Alert is raised by a fraud or AML rule for a user. Value is what the rule
+// measured over the window, in the unit of the rule: a count, an amount in
+// dollars or a ratio.
+type Alert struct {
+	ID             int64
+	RuleName       string
+	Severity       AlertSeverity
+	UserID         UserID
+	Description    string
+	Value          float64
+	Threshold      float64
+	EventCount     int
+	WindowStart    time.Time
+	WindowEnd      time.Time
+	CreatedAt      time.Time
+	AcknowledgedAt *time.Time
+	AcknowledgedBy string
+}
+
+type AlertFilter struct {
+	UserID       *UserID
+	RuleName     *string
+	Severity     *AlertSeverity
+	Acknowledged *bool
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Limit        int
+	Offset       int
+}
@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ChainHash returns the audit hash of an event chained to the hash of the
+// previous event of the same user. The first event of a chain has an empty
+// previous hash. Timestamps are hashed with microsecond precision, which is
+// what postgres stores. The tenant is hashed unless it is the default one, so
+// events chained before tenants existed keep their hashes.
+func ChainHash(prevHash string, event TransactionEvent) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s|%s|%s|%s|%d|%d",
+		prevHash,
+		event.EventID,
+		event.UserID.UUID,
+		event.TransactionType,
+		int64(event.Amount),
+		event.CreatedAt.UnixMicro(),
+	)
+	if event.TenantID != "" && event.TenantID != DefaultTenantID {
+		_, _ = fmt.Fprintf(h, "|%s", event.TenantID)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditedEvent is a stored event together with its position in the audit
+// chain of its user. Events stored before the audit trail existed have empty
+// hashes.
+type AuditedEvent struct {
+	ID         int64
+	Event      TransactionEvent
+	PrevHash   string
+	RowHash    string
+	IngestedAt time.Time
+}
+
+// AuditChainHead is the hash of the last event of a user's chain, kept apart
+// from the events so that deleting events from the end of a chain is
+// detectable.
+//
+// Erased chains belong to pseudonymized users: their rows no longer carry the
+// user id they were hashed with, so only the links between rows can be
+// verified.
+type AuditChainHead struct {
+	UserID   UserID
+	LastHash string
+	Erased   bool
+}
+
+// AuditDigest seals the hashes of all events ingested on a UTC day. Digests
+// are chained through PrevDigest and signed with the audit signing key.
+type AuditDigest struct {
+	Day        time.Time
+	RowCount   int64
+	PrevDigest string
+	Digest     string
+	Signature  string
+	CreatedAt  time.Time
+}
+
+type AuditFinding struct {
+	RowID  int64
+	UserID UserID
+	Reason string
+}
+
+type AuditDigestFinding struct {
+	Day    time.Time
+	Reason string
+}
+
+// AuditVerification is the outcome of walking the audit chains. TamperedRow
+// is the tampered row with the lowest ID, TamperedDigest the earliest
+// digest that does not match the stored events.
+type AuditVerification struct {
+	CheckedUsers   int
+	CheckedRows    int64
+	CheckedDigests int
+	TamperedRow    *AuditFinding
+	TamperedDigest *AuditDigestFinding
+}
+
+func (v AuditVerification) Valid() bool {
+	return v.TamperedRow == nil && v.TamperedDigest == nil
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "fmt"
+
+// ConsumedEvent is an event decoded from a message of a consumed topic.
+// Fault is the kind of fault the producer tagged the message with, if any.
+type ConsumedEvent struct {
+	Topic string
+	Event TransactionEvent
+	Fault string
+}
+
+// InvalidMessageError reports a message of a topic that could not be decoded.
+type InvalidMessageError struct {
+	Topic string
+	Fault string
+	Err   error
+}
+
+func (e *InvalidMessageError) Error() string {
+	return fmt.Sprintf("invalid message on topic %s: %v", e.Topic, e.Err)
+}
+
+func (e *InvalidMessageError) Unwrap() error {
+	return e.Err
+}
+
+// TopicStats counts the messages of a consumed topic since the consumer
+// started. Rejected messages could not be decoded, failed validation or
+// belong to an erased user.
+// Faults counts the messages tagged with an injected fault by kind.
+type TopicStats struct {
+	Topic     string
+	Received  int64
+	Rejected  int64
+	Stored    int64
+	Inspected int64
+	Faults    map[string]FaultStats
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// UserErasure records that a user invoked the right to be forgotten. The
+// user is identified only by UserHash, a keyed hash of the user id, so the
+// record cannot be linked back to the user without knowing the id. Pseudonym
+// is set only while the vault entry of the user exists.
+type UserErasure struct {
+	UserHash         string
+	RequestedBy      string
+	ErasedAt         time.Time
+	VaultDestroyedAt *time.Time
+	Pseudonym        *UserID
+}
+
+// PseudonymVaultEntry links an erased user to the pseudonym that replaced the
+// user id in stored records. Destroying the entry makes the pseudonymization
+// irreversible.
+type PseudonymVaultEntry struct {
+	UserHash     string
+	UserID       UserID
+	Pseudonym    UserID
+	PseudonymKey string
+	CreatedAt    time.Time
+}
@@ -1 +1,10 @@
 package entity
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrUserErased      = errors.New("user has been erased")
+	ErrConflict        = errors.New("conflict")
+)
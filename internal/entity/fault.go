@@ -0,0 +1,48 @@
+package entity
+
+// Kinds of faults the producer injects to test how the consumer copes with
+// bad input.
+const (
+	// FaultMalformed is a payload that is not valid protobuf.
+	FaultMalformed = "malformed"
+	// FaultUnknownEnum is a transaction type outside the enum.
+	FaultUnknownEnum = "unknown_enum"
+	// FaultInvalidUUID is a user_id that is not a UUID.
+	FaultInvalidUUID = "invalid_uuid"
+	// FaultDuplicate is a second copy of an event already published.
+	FaultDuplicate = "duplicate"
+	// FaultOutOfOrder is an event timestamped before the events published
+	// before it.
+	FaultOutOfOrder = "out_of_order"
+	// FaultFarFuture is an event timestamped a year ahead.
+	FaultFarFuture = "far_future"
+	// FaultOversized is a valid event padded with an unknown field.
+	FaultOversized = "oversized"
+)
+
+// IsFaultKind reports whether the kind is one of the faults the producer
+// injects.
+func IsFaultKind(kind string) bool {
+	switch kind {
+	case FaultMalformed, FaultUnknownEnum, FaultInvalidUUID, FaultDuplicate, FaultOutOfOrder, FaultFarFuture, FaultOversized:
+		return true
+	default:
+		return false
+	}
+}
+
+// Fault is a fault injected into a published message. Size is the payload
+// size of an oversized message.
+type Fault struct {
+	Kind string
+	Size int
+}
+
+// FaultStats counts the consumed messages tagged with an injected fault.
+// Received messages were decoded, rejected ones could not be decoded or
+// failed validation.
+type FaultStats struct {
+	Received int64
+	Rejected int64
+	Stored   int64
+}
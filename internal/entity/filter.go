@@ -3,12 +3,61 @@ package entity
 import "time"
 
 type TransactionEventFilter struct {
+	TenantID        *string
 	UserID          *UserID
 	TransactionType *TransactionType
 	AmountFrom      *Money
 	AmountTo        *Money
 	CreatedFrom     *time.Time
 	CreatedTo       *time.Time
-	Limit           int
-	Offset          int
+	// After continues a listing after the event of the cursor: only the
+	// events listed after it, newest first, match.
+	After  *EventCursor
+	Limit  int
+	Offset int
+}
+
+// EventCursor is the position of a stored event in a listing, which is
+// ordered by creation time and id.
+type EventCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// CursorOf returns the position of a stored event.
+func CursorOf(event TransactionEvent) *EventCursor {
+	return &EventCursor{CreatedAt: event.CreatedAt, ID: event.ID}
+}
+
+// Precedes reports whether the event of the cursor is listed before the
+// event.
+func (c EventCursor) Precedes(event TransactionEvent) bool {
+	return event.CreatedAt.Before(c.CreatedAt) || event.CreatedAt.Equal(c.CreatedAt) && event.ID < c.ID
+}
+
+// Matches reports whether the event satisfies every criterion of the filter.
+// After, Limit and Offset are ignored.
+func (f TransactionEventFilter) Matches(event TransactionEvent) bool {
+	if f.TenantID != nil && *f.TenantID != event.TenantID {
+		return false
+	}
+	if f.UserID != nil && f.UserID.UUID != event.UserID.UUID {
+		return false
+	}
+	if f.TransactionType != nil && *f.TransactionType != event.TransactionType {
+		return false
+	}
+	if f.AmountFrom != nil && event.Amount < *f.AmountFrom {
+		return false
+	}
+	if f.AmountTo != nil && event.Amount > *f.AmountTo {
+		return false
+	}
+	if f.CreatedFrom != nil && event.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && event.CreatedAt.After(*f.CreatedTo) {
+		return false
+	}
+	return true
 }
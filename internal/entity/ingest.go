@@ -0,0 +1,42 @@
+package entity
+
+// Ingestion statuses of an event posted to the gateway.
+const (
+	IngestAccepted = "accepted"
+	IngestRejected = "rejected"
+	IngestFailed   = "failed"
+)
+
+// IngestItem is an event posted to the gateway, or the reason it is
+// invalid.
+type IngestItem struct {
+	Event *TransactionEvent
+	Err   error
+}
+
+// IngestRequest is a batch of events posted to the gateway. Requests with
+// the same IdempotencyKey and tenant are published once; Fingerprint tells
+// a retry from a different request reusing the key.
+type IngestRequest struct {
+	TenantID       string
+	IdempotencyKey string
+	Fingerprint    string
+	Items          []IngestItem
+}
+
+// IngestItemResult is the outcome of an item: accepted with the ID of the
+// published event, rejected as invalid or failed to publish, which a retry
+// may fix.
+type IngestItemResult struct {
+	EventID string
+	Status  string
+	Error   string
+}
+
+// IngestResult holds a result per item of the request, in request order.
+// Replayed is set when the results are those of an earlier request with the
+// same idempotency key.
+type IngestResult struct {
+	Items    []IngestItemResult
+	Replayed bool
+}
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	LimitTypeLoss    LimitType = "loss"
+	LimitTypeWager   LimitType = "wager"
+	LimitTypeDeposit LimitType = "deposit"
+
+	LimitPeriodDaily   LimitPeriod = "daily"
+	LimitPeriodWeekly  LimitPeriod = "weekly"
+	LimitPeriodMonthly LimitPeriod = "monthly"
+)
+
+// LimitType tells what a responsible-gambling limit caps: the total amount
+// wagered, the net loss (bets minus wins) or the total amount deposited.
+type LimitType string
+
+func (t LimitType) IsValid() bool {
+	return t == LimitTypeLoss || t == LimitTypeWager || t == LimitTypeDeposit
+}
+
+// Consumption is how much of a limit of this type the given totals use up.
+func (t LimitType) Consumption(totals UserBalance) Money {
+	switch t {
+	case LimitTypeWager:
+		return totals.TotalBet
+	case LimitTypeDeposit:
+		return totals.TotalDeposit
+	}
+	loss := totals.TotalBet - totals.TotalWin
+	if loss < 0 {
+		return 0
+	}
+	return loss
+}
+
+// LimitPeriod is the length of the rolling window a limit applies to.
+type LimitPeriod string
+
+func (p LimitPeriod) IsValid() bool {
+	return p.Window() > 0
+}
+
+func (p LimitPeriod) Window() time.Duration {
+	switch p {
+	case LimitPeriodDaily:
+		return 24 * time.Hour
+	case LimitPeriodWeekly:
+		return 7 * 24 * time.Hour
+	case LimitPeriodMonthly:
+		return 30 * 24 * time.Hour
+	}
+	return 0
+}
+
+type UserLimit struct {
+	ID        int64
+	UserID    UserID
+	Type      LimitType
+	Period    LimitPeriod
+	Amount    Money
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LimitUsage is the consumption of a limit over the window ending at the
+// moment it was computed.
+type LimitUsage struct {
+	Limit       UserLimit
+	Consumed    Money
+	WindowStart time.Time
+}
+
+func (u LimitUsage) Remaining() Money {
+	if u.Consumed >= u.Limit.Amount {
+		return 0
+	}
+	return u.Limit.Amount - u.Consumed
+}
+
+func (u LimitUsage) Exceeded() bool {
+	return u.Consumed > u.Limit.Amount
+}
+
+type LimitBreach struct {
+	ID          int64
+	LimitID     int64
+	UserID      UserID
+	Type        LimitType
+	Period      LimitPeriod
+	Amount      Money
+	Consumed    Money
+	WindowStart time.Time
+	DetectedAt  time.Time
+}
+
+// UserTotalsReader reads the totals of a user created at or after since.
+type UserTotalsReader interface {
+	GetTotalsSince(ctx context.Context, userID UserID, since time.Time) (*UserBalance, error)
+}
+
+// LimitCheck finds the limits breached by a batch. It runs in the transaction
+// that stores the batch, after the batch is written and the rows of its users
+// are locked, so the totals it reads include the batch and concurrent batches
+// of the same user are checked one after another.
+type LimitCheck interface {
+	Breaches(ctx context.Context, totals UserTotalsReader) ([]LimitBreach, error)
+}
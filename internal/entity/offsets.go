@@ -0,0 +1,73 @@
+package entity
+
+import "time"
+
+// NoCommittedOffset is the committed offset of a partition the consumer
+// group has not committed yet.
+const NoCommittedOffset int64 = -1
+
+type OffsetResetTarget string
+
+const (
+	OffsetResetEarliest  OffsetResetTarget = "earliest"
+	OffsetResetLatest    OffsetResetTarget = "latest"
+	OffsetResetTimestamp OffsetResetTarget = "timestamp"
+	OffsetResetOffset    OffsetResetTarget = "offset"
+)
+
+// PartitionOffsets are the offsets of a topic partition: First is the oldest
+// retained message, Last the offset the next message will get and Committed
+// the offset the consumer group resumes from.
+type PartitionOffsets struct {
+	Partition int
+	First     int64
+	Last      int64
+	Committed int64
+}
+
+// Lag is the number of messages the consumer group has not consumed yet, a
+// group without a committed offset starts from the oldest retained message.
+func (p PartitionOffsets) Lag() int64 {
+	if p.Committed == NoCommittedOffset || p.Committed < p.First {
+		return p.Last - p.First
+	}
+	return p.Last - p.Committed
+}
+
+// OffsetReset moves the committed offsets of a topic. Time is used by the
+// timestamp target, Offset by the offset target. Partition limits the reset
+// to one partition.
+type OffsetReset struct {
+	Topic     string
+	Target    OffsetResetTarget
+	Time      time.Time
+	Offset    int64
+	Partition *int
+	DryRun    bool
+}
+
+// OffsetChange is the committed offset of a partition before and after a
+// reset.
+type OffsetChange struct {
+	Partition int
+	Previous  int64
+	Offset    int64
+}
+
+// ReplayRange is a time range of a topic read into the repository. From is
+// inclusive and To exclusive, both compared to the message timestamps.
+type ReplayRange struct {
+	Topic string
+	From  time.Time
+	To    time.Time
+}
+
+// ReplayResult counts the messages of a replay. Rejected messages could not
+// be decoded, failed validation or belong to an erased user, skipped ones
+// were already stored.
+type ReplayResult struct {
+	Read     int64
+	Rejected int64
+	Skipped  int64
+	Stored   int64
+}
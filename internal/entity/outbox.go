@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	OutboxEventBalanceChanged OutboxEventType = "balance_changed"
+	OutboxEventLargeWin       OutboxEventType = "large_win"
+)
+
+type OutboxEventType string
+
+// OutboxMessage is a derived event stored together with the batch it was
+// derived from. Key is the Kafka message key, messages sharing a key are
+// published in ID order.
+type OutboxMessage struct {
+	ID        int64
+	Key       string
+	EventType OutboxEventType
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	SentAt    *time.Time
+}
+
+// DerivedRecords are derived from a batch before it is stored and written in
+// the transaction that stores it, so they are never lost for a stored batch
+// nor written for a batch that failed.
+type DerivedRecords struct {
+	OutboxMessages []OutboxMessage
+	// Limits finds the limit breaches of the batch in the transaction, they
+	// are stored with it.
+	Limits            LimitCheck
+	WebhookDeliveries []WebhookDelivery
+	// UserHashes are the erasure hashes of the batch users, the batch is
+	// refused with ErrUserErased when one of them was erased meanwhile.
+	UserHashes []string
+}
+
+func (r DerivedRecords) IsEmpty() bool {
+	return len(r.OutboxMessages) == 0 && r.Limits == nil && len(r.WebhookDeliveries) == 0 &&
+		len(r.UserHashes) == 0
+}
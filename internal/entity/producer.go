@@ -0,0 +1,35 @@
+package entity
+
+// ProducerSettings are the generation settings the producer applies while
+// it runs.
+type ProducerSettings struct {
+	CreationRPS   int
+	DistinctUsers int
+	AmountFrom    int
+	AmountTo      int
+}
+
+// ProducerSettingsUpdate changes the settings that are not nil.
+type ProducerSettingsUpdate struct {
+	CreationRPS   *int
+	DistinctUsers *int
+	AmountFrom    *int
+	AmountTo      *int
+}
+
+// ProducerStatus is the state of a running producer. TargetRate is the job
+// rate the producer currently aims for, CurrentRate the events published per
+// second over the last seconds. Published and Failed count events since the
+// start of the run.
+type ProducerStatus struct {
+	Running     bool
+	Paused      bool
+	Settings    ProducerSettings
+	Workers     int
+	TargetRate  float64
+	CurrentRate float64
+	Scheduled   int64
+	Dropped     int64
+	Published   int64
+	Failed      int64
+}
@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+const (
+	ReconciliationStatusMissing    ReconciliationStatus = "missing"
+	ReconciliationStatusExtra      ReconciliationStatus = "extra"
+	ReconciliationStatusMismatched ReconciliationStatus = "mismatched"
+)
+
+// ReconciliationStatus describes a discrepancy between a provider settlement
+// file and the stored transactions: missing means the provider reported a
+// transaction we do not have, extra means we have one the provider did not
+// report, mismatched means both sides have it but disagree on its details.
+type ReconciliationStatus string
+
+// SettlementRecord is a transaction as reported by a game provider. Line is
+// the line of the settlement file it was read from.
+type SettlementRecord struct {
+	Line            int
+	EventID         string
+	UserID          UserID
+	TransactionType TransactionType
+	Amount          Money
+	CreatedAt       time.Time
+}
+
+// ReconciliationItem is one discrepancy found by a reconciliation run. The
+// provider fields are empty for extra items and the stored fields are empty
+// for missing ones.
+type ReconciliationItem struct {
+	Status          ReconciliationStatus
+	Line            int
+	EventID         string
+	UserID          UserID
+	TransactionType TransactionType
+	ProviderAmount  *Money
+	StoredAmount    *Money
+	ProviderTime    *time.Time
+	StoredTime      *time.Time
+	Reason          string
+}
+
+type ReconciliationRun struct {
+	ID              int64
+	Provider        string
+	FileName        string
+	PeriodFrom      time.Time
+	PeriodTo        time.Time
+	ProviderRecords int
+	StoredRecords   int
+	Matched         int
+	Missing         int
+	Extra           int
+	Mismatched      int
+	CreatedAt       time.Time
+	Items           []ReconciliationItem
+}
@@ -8,8 +8,12 @@ import (
 )
 
 const (
-	TransactionTypeBet TransactionType = "bet"
-	TransactionTypeWin TransactionType = "win"
+	TransactionTypeBet     TransactionType = "bet"
+	TransactionTypeWin     TransactionType = "win"
+	TransactionTypeDeposit TransactionType = "deposit"
+
+	// DefaultTenantID is the tenant of events that do not name one.
+	DefaultTenantID = "default"
 )
 
 type UserID struct {
@@ -22,8 +26,14 @@ func NewUserID(uuid uuid.UUID) *UserID {
 	}
 }
 
+// TransactionType is a bet or a win of a game, or a deposit to the account of
+// the user. Deposits do not count in the bet and win totals.
 type TransactionType string
 
+func (t TransactionType) IsValid() bool {
+	return t == TransactionTypeBet || t == TransactionTypeWin || t == TransactionTypeDeposit
+}
+
 type Money int64
 
 func (m Money) String() string {
@@ -40,6 +50,13 @@ func ToMoney(m float64) Money {
 }
 
 type TransactionEvent struct {
+	// ID is the id the event is stored under, set on the events read from a
+	// repository. Events created at the same time are listed by it.
+	ID int64
+	// EventID is the provider's identifier of the event, empty when unknown.
+	EventID string
+	// TenantID is the operator brand the event belongs to.
+	TenantID        string
 	UserID          UserID
 	TransactionType TransactionType
 	Amount          Money
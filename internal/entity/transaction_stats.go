@@ -0,0 +1,21 @@
+package entity
+
+type UserBalance struct {
+	UserID   UserID
+	TotalBet Money
+	TotalWin Money
+	// TotalDeposit is only computed for limits, deposits do not change the
+	// balance of bets and wins.
+	TotalDeposit Money
+}
+
+func (b UserBalance) Balance() Money {
+	return b.TotalWin - b.TotalBet
+}
+
+type TransactionStats struct {
+	BetCount  int64
+	WinCount  int64
+	BetAmount Money
+	WinAmount Money
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyMetadata = "x-api-key"
+)
+
+type callerContextKey struct{}
+
+// caller is the authenticated client of a call. An empty tenant means an
+// operator with access to every tenant.
+type caller struct {
+	tenantID string
+}
+
+// SetAPIKeys enables authentication of every call with the keys of the HTTP
+// API, passed in the x-api-key metadata or as a bearer token in the
+// authorization metadata. Tenant keys are bound to their tenant like over
+// HTTP.
+func (s *GrpcServer) SetAPIKeys(keys []config.APIKey) {
+	if len(keys) == 0 {
+		s.apiKeys = nil
+		return
+	}
+	s.apiKeys = make(map[string]string, len(keys))
+	for _, apiKey := range keys {
+		s.apiKeys[apiKey.Key] = apiKey.Tenant
+	}
+}
+
+// authenticate resolves the caller from the metadata of the call and rejects
+// calls without a known key.
+func (s *GrpcServer) authenticate(ctx context.Context) (context.Context, error) {
+	if s.apiKeys == nil {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := firstValue(md, apiKeyMetadata)
+	if key == "" {
+		key = strings.TrimPrefix(firstValue(md, "authorization"), "Bearer ")
+	}
+
+	tenantID, ok := s.apiKeys[key]
+	if key == "" || !ok {
+		return nil, status.Error(codes.Unauthenticated, "a valid API key is required")
+	}
+	return context.WithValue(ctx, callerContextKey{}, caller{tenantID: tenantID}), nil
+}
+
+func (s *GrpcServer) authenticateUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *GrpcServer) authenticateStream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticatedStream carries the caller in the context of a stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// callerTenant returns the tenant the caller of the call is scoped to.
+// Without authentication every caller is an operator.
+func callerTenant(ctx context.Context) (string, bool) {
+	c, ok := ctx.Value(callerContextKey{}).(caller)
+	if !ok || c.tenantID == "" {
+		return "", false
+	}
+	return c.tenantID, true
+}
+
+// scopeFilter restricts the filter to the tenant of the caller. Asking for
+// another tenant is denied.
+func scopeFilter(ctx context.Context, filter *entity.TransactionEventFilter) error {
+	tenantID, scoped := callerTenant(ctx)
+	if !scoped {
+		return nil
+	}
+	if filter.TenantID != nil && *filter.TenantID != tenantID {
+		return status.Error(codes.PermissionDenied, "tenant_id does not match the tenant of the API key")
+	}
+	filter.TenantID = &tenantID
+	return nil
+}
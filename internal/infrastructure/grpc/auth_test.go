@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/grpc/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcServer_TenantScoping(t *testing.T) {
+	newClient := func(t *testing.T) (api.TransactionQueryServiceClient, *mocks.MocktransactionQueryHandler) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMocktransactionQueryHandler(ctrl)
+		server := NewGrpcServer(handler, &config.Grpc{})
+		server.SetAPIKeys([]config.APIKey{
+			{Key: "operator-key"},
+			{Key: "brand-a-key", Tenant: "brand-a"},
+		})
+		return serveTestServer(t, server), handler
+	}
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, key)
+	}
+	tenant := func(tenantID string) *string { return &tenantID }
+
+	t.Run("missing or unknown key is rejected", func(t *testing.T) {
+		client, _ := newClient(t)
+
+		_, err := client.Search(context.Background(), &api.SearchRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		_, err = client.GetStats(withKey("unknown"), &api.GetStatsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		stream, err := client.StreamSearch(context.Background(), &api.SearchRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("tenant key is scoped to its tenant", func(t *testing.T) {
+		client, handler := newClient(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, "brand-a", *filter.TenantID)
+				return nil, nil
+			}).
+			Times(2)
+		handler.EXPECT().
+			GetStats(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+				assert.Equal(t, "brand-a", *filter.TenantID)
+				return &entity.TransactionStats{}, nil
+			})
+
+		_, err := client.Search(withKey("brand-a-key"), &api.SearchRequest{})
+		require.NoError(t, err)
+		_, err = client.GetStats(withKey("brand-a-key"), &api.GetStatsRequest{})
+		require.NoError(t, err)
+
+		stream, err := client.StreamSearch(withKey("brand-a-key"), &api.SearchRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("tenant key cannot query another tenant", func(t *testing.T) {
+		client, _ := newClient(t)
+
+		_, err := client.Search(withKey("brand-a-key"), &api.SearchRequest{TenantId: tenant("brand-b")})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = client.GetStats(withKey("brand-a-key"), &api.GetStatsRequest{TenantId: tenant("brand-b")})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = client.GetBalance(withKey("brand-a-key"), &api.GetBalanceRequest{UserId: uuid.NewString()})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("operator key queries any tenant", func(t *testing.T) {
+		client, handler := newClient(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, "brand-b", *filter.TenantID)
+				return nil, nil
+			})
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer operator-key")
+		_, err := client.Search(ctx, &api.SearchRequest{TenantId: tenant("brand-b")})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,212 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	streamPageSize = 1000
+)
+
+type GrpcServer struct {
+	api.UnimplementedTransactionQueryServiceServer
+
+	transactionQueryHandler transactionQueryHandler
+	server                  *grpc.Server
+	apiKeys                 map[string]string
+	port                    int
+}
+
+func NewGrpcServer(transactionQueryHandler transactionQueryHandler, conf *config.Grpc) *GrpcServer {
+	s := &GrpcServer{
+		transactionQueryHandler: transactionQueryHandler,
+		port:                    conf.Port,
+	}
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.authenticateUnary),
+		grpc.ChainStreamInterceptor(s.authenticateStream),
+	)
+	api.RegisterTransactionQueryServiceServer(s.server, s)
+	return s
+}
+
+func (s *GrpcServer) Start(_ context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+
+	log.Printf("Starting gRPC server on port %d", s.port)
+	return s.Serve(listener)
+}
+
+func (s *GrpcServer) Serve(listener net.Listener) error {
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("failed to start gRPC server: %w", err)
+	}
+	return nil
+}
+
+func (s *GrpcServer) Shutdown(ctx context.Context) error {
+	log.Println("Shutting down gRPC server...")
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		log.Println("gRPC server stopped successfully")
+		return nil
+	case <-shutdownCtx.Done():
+		s.server.Stop()
+		return fmt.Errorf("failed to shutdown gRPC server gracefully: %w", shutdownCtx.Err())
+	}
+}
+
+func (s *GrpcServer) Search(ctx context.Context, req *api.SearchRequest) (*api.SearchResponse, error) {
+	filter, err := TransformSearchRequestToFilter(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter parameters: %v", err)
+	}
+	if err = scopeFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionQueryHandler.GetListByFilter(ctx, filter)
+	if err != nil {
+		return nil, queryError(err, "retrieve transactions")
+	}
+
+	response, err := TransformTransactionsToResponse(transactions, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to transform transactions: %v", err)
+	}
+	return response, nil
+}
+
+// StreamSearch sends every transaction matching the request, paging through
+// the repository after the last transaction sent, so transactions stored
+// meanwhile neither shift nor repeat the pages. A zero limit streams the
+// whole result set.
+func (s *GrpcServer) StreamSearch(req *api.SearchRequest, stream grpc.ServerStreamingServer[api.TransactionEvent]) error {
+	filter, err := TransformSearchRequestToFilter(req)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid filter parameters: %v", err)
+	}
+	if err = scopeFilter(stream.Context(), &filter); err != nil {
+		return err
+	}
+
+	remaining := filter.Limit
+	page := filter
+	for {
+		if err = stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		pageSize := streamPageSize
+		if remaining > 0 && remaining < pageSize {
+			pageSize = remaining
+		}
+
+		page.Limit = pageSize
+
+		transactions, err := s.transactionQueryHandler.GetListByFilter(stream.Context(), page)
+		if err != nil {
+			return queryError(err, "retrieve transactions")
+		}
+
+		for _, transaction := range transactions {
+			dto, err := TransformEventToDTO(transaction)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to transform transaction: %v", err)
+			}
+			if err = stream.Send(dto); err != nil {
+				return err
+			}
+		}
+
+		if len(transactions) < pageSize {
+			return nil
+		}
+
+		page.After = entity.CursorOf(transactions[len(transactions)-1])
+		page.Offset = 0
+		if remaining > 0 {
+			remaining -= len(transactions)
+			if remaining == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// GetBalance sums the transactions of a user over every tenant, so it is
+// not available to tenant keys.
+func (s *GrpcServer) GetBalance(ctx context.Context, req *api.GetBalanceRequest) (*api.GetBalanceResponse, error) {
+	if tenantID, scoped := callerTenant(ctx); scoped {
+		return nil, status.Error(codes.PermissionDenied, "balance is not available to tenant "+tenantID)
+	}
+	parsedUUID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id format: %v", err)
+	}
+
+	balance, err := s.transactionQueryHandler.GetBalance(ctx, *entity.NewUserID(parsedUUID))
+	if err != nil {
+		return nil, queryError(err, "retrieve balance")
+	}
+
+	return TransformBalanceToResponse(balance), nil
+}
+
+func (s *GrpcServer) GetStats(ctx context.Context, req *api.GetStatsRequest) (*api.GetStatsResponse, error) {
+	filter, err := TransformStatsRequestToFilter(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter parameters: %v", err)
+	}
+	if err = scopeFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.transactionQueryHandler.GetStats(ctx, filter)
+	if err != nil {
+		return nil, queryError(err, "retrieve stats")
+	}
+
+	return TransformStatsToResponse(stats), nil
+}
+
+// queryError maps query errors to gRPC statuses. Erased users are reported
+// as not found, queries cancelled with the call as canceled or deadline
+// exceeded.
+func queryError(err error, action string) error {
+	switch {
+	case errors.Is(err, entity.ErrUserErased):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "failed to "+action+": request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "failed to "+action+": query timed out")
+	}
+	log.Printf("Failed to %s: %v", action, err)
+	return status.Error(codes.Internal, "failed to "+action)
+}
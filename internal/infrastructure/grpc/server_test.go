@@ -0,0 +1,260 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/grpc/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func startTestServer(t *testing.T, handler transactionQueryHandler) api.TransactionQueryServiceClient {
+	t.Helper()
+	return serveTestServer(t, NewGrpcServer(handler, &config.Grpc{}))
+}
+
+func serveTestServer(t *testing.T, server *GrpcServer) api.TransactionQueryServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return api.NewTransactionQueryServiceClient(conn)
+}
+
+func TestGrpcServer_Search(t *testing.T) {
+	t.Run("successful search", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+		client := startTestServer(t, mockHandler)
+
+		userID := uuid.New()
+		userIDStr := userID.String()
+		now := time.Now().UTC()
+
+		mockHandler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, userID, filter.UserID.UUID)
+				assert.Equal(t, entity.TransactionTypeWin, *filter.TransactionType)
+				assert.Equal(t, 10, filter.Limit)
+				return []entity.TransactionEvent{
+					{
+						UserID:          *entity.NewUserID(userID),
+						TransactionType: entity.TransactionTypeWin,
+						Amount:          entity.ToMoney(25.5),
+						CreatedAt:       now,
+					},
+				}, nil
+			}).
+			Times(1)
+
+		transactionType := api.TransactionType_TRANSACTION_TYPE_WIN
+		response, err := client.Search(context.Background(), &api.SearchRequest{
+			UserId:          &userIDStr,
+			TransactionType: &transactionType,
+			Limit:           10,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), response.Total)
+		require.Len(t, response.Transactions, 1)
+		assert.Equal(t, userIDStr, response.Transactions[0].UserId)
+		assert.Equal(t, api.TransactionType_TRANSACTION_TYPE_WIN, response.Transactions[0].TransactionType)
+		assert.Equal(t, 25.5, response.Transactions[0].Amount)
+		assert.True(t, now.Equal(response.Transactions[0].Timestamp.AsTime()))
+	})
+
+	t.Run("invalid user id returns invalid argument", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+		client := startTestServer(t, mockHandler)
+
+		userID := "not-a-uuid"
+		_, err := client.Search(context.Background(), &api.SearchRequest{UserId: &userID})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("repository error returns internal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+		client := startTestServer(t, mockHandler)
+
+		mockHandler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("db error")).
+			Times(1)
+
+		_, err := client.Search(context.Background(), &api.SearchRequest{})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestGrpcServer_StreamSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+	client := startTestServer(t, mockHandler)
+
+	createdAt := time.Now()
+	page := make([]entity.TransactionEvent, streamPageSize)
+	for i := range page {
+		page[i] = entity.TransactionEvent{
+			ID:              int64(streamPageSize + 10 - i),
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(1.0),
+			CreatedAt:       createdAt,
+		}
+	}
+
+	gomock.InOrder(
+		mockHandler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, streamPageSize, filter.Limit)
+				assert.Nil(t, filter.After)
+				return page, nil
+			}),
+		mockHandler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				// pages continue after the last transaction sent
+				assert.Equal(t, &entity.EventCursor{CreatedAt: createdAt, ID: 11}, filter.After)
+				assert.Equal(t, 0, filter.Offset)
+				return page[:3], nil
+			}),
+	)
+
+	stream, err := client.StreamSearch(context.Background(), &api.SearchRequest{})
+	require.NoError(t, err)
+
+	received := 0
+	for {
+		_, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		received++
+	}
+
+	assert.Equal(t, streamPageSize+3, received)
+}
+
+func TestGrpcServer_GetBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+	client := startTestServer(t, mockHandler)
+
+	userID := uuid.New()
+	mockHandler.EXPECT().
+		GetBalance(gomock.Any(), *entity.NewUserID(userID)).
+		Return(&entity.UserBalance{
+			UserID:   *entity.NewUserID(userID),
+			TotalBet: entity.ToMoney(100.0),
+			TotalWin: entity.ToMoney(250.0),
+		}, nil).
+		Times(1)
+
+	response, err := client.GetBalance(context.Background(), &api.GetBalanceRequest{UserId: userID.String()})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), response.UserId)
+	assert.Equal(t, 100.0, response.TotalBet)
+	assert.Equal(t, 250.0, response.TotalWin)
+	assert.Equal(t, 150.0, response.Balance)
+}
+
+func TestGrpcServer_GetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+	client := startTestServer(t, mockHandler)
+
+	createdFrom := time.Now().Add(-time.Hour).UTC()
+	mockHandler.EXPECT().
+		GetStats(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+			assert.Nil(t, filter.UserID)
+			assert.True(t, createdFrom.Equal(*filter.CreatedFrom))
+			return &entity.TransactionStats{
+				BetCount:  4,
+				WinCount:  2,
+				BetAmount: entity.ToMoney(40.0),
+				WinAmount: entity.ToMoney(30.0),
+			}, nil
+		}).
+		Times(1)
+
+	response, err := client.GetStats(context.Background(), &api.GetStatsRequest{
+		CreatedFrom: timestamppb.New(createdFrom),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), response.BetCount)
+	assert.Equal(t, int64(2), response.WinCount)
+	assert.Equal(t, 40.0, response.BetAmount)
+	assert.Equal(t, 30.0, response.WinAmount)
+}
+
+func TestGrpcServer_QueryDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHandler := mocks.NewMocktransactionQueryHandler(ctrl)
+	client := startTestServer(t, mockHandler)
+
+	mockHandler.EXPECT().
+		GetStats(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+			<-ctx.Done()
+			return nil, fmt.Errorf("failed to get stats: %w", ctx.Err())
+		}).
+		Times(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.GetStats(ctx, &api.GetStatsRequest{})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
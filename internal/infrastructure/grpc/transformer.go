@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	dtoToEntityTypeMap = map[api.TransactionType]entity.TransactionType{
+		api.TransactionType_TRANSACTION_TYPE_BET:     entity.TransactionTypeBet,
+		api.TransactionType_TRANSACTION_TYPE_WIN:     entity.TransactionTypeWin,
+		api.TransactionType_TRANSACTION_TYPE_DEPOSIT: entity.TransactionTypeDeposit,
+	}
+
+	entityToDTOTypeMap = map[entity.TransactionType]api.TransactionType{
+		entity.TransactionTypeBet:     api.TransactionType_TRANSACTION_TYPE_BET,
+		entity.TransactionTypeWin:     api.TransactionType_TRANSACTION_TYPE_WIN,
+		entity.TransactionTypeDeposit: api.TransactionType_TRANSACTION_TYPE_DEPOSIT,
+	}
+)
+
+func TransformSearchRequestToFilter(req *api.SearchRequest) (entity.TransactionEventFilter, error) {
+	filter := entity.TransactionEventFilter{
+		Limit:  int(req.GetLimit()),
+		Offset: int(req.GetOffset()),
+	}
+
+	userID, err := parseUserID(req.UserId)
+	if err != nil {
+		return filter, err
+	}
+	filter.UserID = userID
+	filter.TenantID = parseTenantID(req.TenantId)
+
+	if req.TransactionType != nil {
+		transactionType, ok := dtoToEntityTypeMap[req.GetTransactionType()]
+		if !ok {
+			return filter, fmt.Errorf("invalid transaction type: %s", req.GetTransactionType())
+		}
+		filter.TransactionType = &transactionType
+	}
+
+	if req.AmountFrom != nil {
+		amount := entity.ToMoney(req.GetAmountFrom())
+		filter.AmountFrom = &amount
+	}
+
+	if req.AmountTo != nil {
+		amount := entity.ToMoney(req.GetAmountTo())
+		filter.AmountTo = &amount
+	}
+
+	filter.CreatedFrom = parseTimestamp(req.GetCreatedFrom())
+	filter.CreatedTo = parseTimestamp(req.GetCreatedTo())
+
+	return filter, nil
+}
+
+func TransformStatsRequestToFilter(req *api.GetStatsRequest) (entity.TransactionEventFilter, error) {
+	var filter entity.TransactionEventFilter
+
+	userID, err := parseUserID(req.UserId)
+	if err != nil {
+		return filter, err
+	}
+	filter.UserID = userID
+	filter.TenantID = parseTenantID(req.TenantId)
+	filter.CreatedFrom = parseTimestamp(req.GetCreatedFrom())
+	filter.CreatedTo = parseTimestamp(req.GetCreatedTo())
+
+	return filter, nil
+}
+
+func TransformEventToDTO(event entity.TransactionEvent) (*api.TransactionEvent, error) {
+	transactionType, ok := entityToDTOTypeMap[event.TransactionType]
+	if !ok {
+		return nil, fmt.Errorf("invalid transaction type: %s", event.TransactionType)
+	}
+
+	return &api.TransactionEvent{
+		UserId:          event.UserID.UUID.String(),
+		TransactionType: transactionType,
+		Amount:          event.Amount.ToFloat(),
+		Timestamp:       timestamppb.New(event.CreatedAt),
+		EventId:         event.EventID,
+		TenantId:        event.TenantID,
+	}, nil
+}
+
+func TransformTransactionsToResponse(transactions []entity.TransactionEvent, limit, offset int) (*api.SearchResponse, error) {
+	resultList := make([]*api.TransactionEvent, 0, len(transactions))
+
+	for _, transaction := range transactions {
+		dto, err := TransformEventToDTO(transaction)
+		if err != nil {
+			return nil, err
+		}
+		resultList = append(resultList, dto)
+	}
+
+	return &api.SearchResponse{
+		Total:        int32(len(resultList)),
+		Limit:        int32(limit),
+		Offset:       int32(offset),
+		Transactions: resultList,
+	}, nil
+}
+
+func TransformBalanceToResponse(balance *entity.UserBalance) *api.GetBalanceResponse {
+	return &api.GetBalanceResponse{
+		UserId:   balance.UserID.UUID.String(),
+		TotalBet: balance.TotalBet.ToFloat(),
+		TotalWin: balance.TotalWin.ToFloat(),
+		Balance:  balance.Balance().ToFloat(),
+	}
+}
+
+func TransformStatsToResponse(stats *entity.TransactionStats) *api.GetStatsResponse {
+	return &api.GetStatsResponse{
+		BetCount:  stats.BetCount,
+		WinCount:  stats.WinCount,
+		BetAmount: stats.BetAmount.ToFloat(),
+		WinAmount: stats.WinAmount.ToFloat(),
+	}
+}
+
+func parseUserID(userID *string) (*entity.UserID, error) {
+	if userID == nil || *userID == "" {
+		return nil, nil
+	}
+
+	parsedUUID, err := uuid.Parse(*userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user_id format: %w", err)
+	}
+	return entity.NewUserID(parsedUUID), nil
+}
+
+func parseTenantID(tenantID *string) *string {
+	if tenantID == nil || *tenantID == "" {
+		return nil
+	}
+	return tenantID
+}
+
+func parseTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -0,0 +1,14 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package grpc
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type transactionQueryHandler interface {
+	GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error)
+	GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error)
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+// TransformQueryToAlertFilter reads the GET /alerts query parameters.
+func TransformQueryToAlertFilter(query url.Values) (entity.AlertFilter, error) {
+	var filter entity.AlertFilter
+
+	if value := query.Get("user_id"); value != "" {
+		parsedUUID, err := uuid.Parse(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user_id: %w", err)
+		}
+		filter.UserID = entity.NewUserID(parsedUUID)
+	}
+
+	if value := query.Get("rule"); value != "" {
+		filter.RuleName = &value
+	}
+
+	if value := query.Get("severity"); value != "" {
+		severity := entity.AlertSeverity(value)
+		filter.Severity = &severity
+	}
+
+	if value := query.Get("acknowledged"); value != "" {
+		acknowledged, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid acknowledged: %w", err)
+		}
+		filter.Acknowledged = &acknowledged
+	}
+
+	if value := query.Get("created_from"); value != "" {
+		createdFrom, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("created_from must be in ISO 8601 format: %w", err)
+		}
+		filter.CreatedFrom = &createdFrom
+	}
+
+	if value := query.Get("created_to"); value != "" {
+		createdTo, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("created_to must be in ISO 8601 format: %w", err)
+		}
+		filter.CreatedTo = &createdTo
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %w", err)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid offset: %w", err)
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+func TransformAlertToDTO(alert entity.Alert) AlertDTO {
+	return AlertDTO{
+		ID:             alert.ID,
+		RuleName:       alert.RuleName,
+		Severity:       string(alert.Severity),
+		UserID:         alert.UserID.UUID.String(),
+		Description:    alert.Description,
+		Value:          alert.Value,
+		Threshold:      alert.Threshold,
+		EventCount:     alert.EventCount,
+		WindowStart:    alert.WindowStart,
+		WindowEnd:      alert.WindowEnd,
+		CreatedAt:      alert.CreatedAt,
+		AcknowledgedAt: alert.AcknowledgedAt,
+		AcknowledgedBy: alert.AcknowledgedBy,
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (s *HttpServer) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	filter, err := TransformQueryToAlertFilter(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	alerts, err := s.alertsHandler.ListAlerts(r.Context(), filter)
+	if err != nil {
+		s.writeServiceError(w, err, "list alerts")
+		return
+	}
+
+	result := make([]AlertDTO, 0, len(alerts))
+	for _, alert := range alerts {
+		result = append(result, TransformAlertToDTO(alert))
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *HttpServer) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid alert id", err.Error())
+		return
+	}
+
+	var req AcknowledgeAlertRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	alert, err := s.alertsHandler.AcknowledgeAlert(r.Context(), id, req.AcknowledgedBy)
+	if err != nil {
+		s.writeServiceError(w, err, "acknowledge alert")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformAlertToDTO(*alert))
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+func (s *HttpServer) handleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+	var userID *entity.UserID
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		parsedUUID, err := uuid.Parse(value)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			s.writeError(w, http.StatusBadRequest, "Invalid user_id", err.Error())
+			return
+		}
+		userID = entity.NewUserID(parsedUUID)
+	}
+
+	verification, err := s.auditHandler.Verify(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, err, "verify audit trail")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformAuditVerificationToDTO(*verification))
+}
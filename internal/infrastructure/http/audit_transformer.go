@@ -0,0 +1,30 @@
+package http
+
+import (
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+func TransformAuditVerificationToDTO(verification entity.AuditVerification) AuditVerificationDTO {
+	result := AuditVerificationDTO{
+		Valid:          verification.Valid(),
+		CheckedUsers:   verification.CheckedUsers,
+		CheckedRows:    verification.CheckedRows,
+		CheckedDigests: verification.CheckedDigests,
+	}
+	if finding := verification.TamperedRow; finding != nil {
+		result.TamperedRow = &AuditFindingDTO{
+			RowID:  finding.RowID,
+			UserID: finding.UserID.UUID.String(),
+			Reason: finding.Reason,
+		}
+	}
+	if finding := verification.TamperedDigest; finding != nil {
+		result.TamperedDigest = &AuditDigestFindingDTO{
+			Day:    finding.Day.Format(time.DateOnly),
+			Reason: finding.Reason,
+		}
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+)
+
+var errTenantForbidden = errors.New("tenant_id does not match the tenant of the API key")
+
+type callerContextKey struct{}
+
+// caller is the authenticated client of a request. An empty tenant means an
+// operator with access to every tenant.
+type caller struct {
+	tenantID string
+}
+
+// authenticate resolves the caller from the X-API-Key header, or a bearer
+// token in the Authorization header, and rejects requests without a known
+// key.
+func (s *HttpServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		tenantID, ok := s.apiKeys[key]
+		if key == "" || !ok {
+			w.Header().Set("Content-Type", "application/json")
+			s.writeError(w, http.StatusUnauthorized, "Unauthorized", "a valid API key is required")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), callerContextKey{}, caller{tenantID: tenantID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireOperator rejects callers scoped to a tenant.
+func (s *HttpServer) requireOperator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenantID, scoped := callerTenant(r); scoped {
+			w.Header().Set("Content-Type", "application/json")
+			s.writeError(w, http.StatusForbidden, "Forbidden", "endpoint is not available to tenant "+tenantID)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireOperatorKey rejects requests that were not authenticated with an
+// operator key, also when the server runs without API keys.
+func (s *HttpServer) requireOperatorKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, ok := r.Context().Value(callerContextKey{}).(caller)
+		if !ok || c.tenantID != "" {
+			w.Header().Set("Content-Type", "application/json")
+			s.writeError(w, http.StatusUnauthorized, "Unauthorized", "an operator API key is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// callerTenant returns the tenant the caller of the request is scoped to.
+// Without authentication every caller is an operator.
+func callerTenant(r *http.Request) (string, bool) {
+	c, ok := r.Context().Value(callerContextKey{}).(caller)
+	if !ok || c.tenantID == "" {
+		return "", false
+	}
+	return c.tenantID, true
+}
+
+// scopeFilter restricts the filter to the tenant of the caller. Asking for
+// another tenant is an invalid argument.
+func scopeFilter(r *http.Request, filter *entity.TransactionEventFilter) error {
+	tenantID, scoped := callerTenant(r)
+	if !scoped {
+		return nil
+	}
+	if filter.TenantID != nil && *filter.TenantID != tenantID {
+		return errTenantForbidden
+	}
+	filter.TenantID = &tenantID
+	return nil
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHttpServer_TenantScoping(t *testing.T) {
+	conf := &config.Http{APIKeys: []config.APIKey{
+		{Key: "operator-key"},
+		{Key: "brand-a-key", Tenant: "brand-a"},
+	}}
+
+	newServer := func(t *testing.T) (*HttpServer, *mocks.MockpostTransactionsMessageHandler) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMockpostTransactionsMessageHandler(ctrl)
+		server := NewHttpServer(handler, nil, conf)
+		server.SetAlertsHandler(mocks.NewMockalertsHandler(ctrl))
+		return server, handler
+	}
+
+	search := func(server *HttpServer, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("missing or unknown key is rejected", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		assert.Equal(t, http.StatusUnauthorized, search(server, "", `{}`).Code)
+		assert.Equal(t, http.StatusUnauthorized, search(server, "unknown", `{}`).Code)
+	})
+
+	t.Run("health check needs no key", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("tenant key is scoped to its tenant", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, "brand-a", *filter.TenantID)
+				return nil, nil
+			})
+
+		assert.Equal(t, http.StatusOK, search(server, "brand-a-key", `{}`).Code)
+	})
+
+	t.Run("tenant key cannot query another tenant", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		assert.Equal(t, http.StatusForbidden, search(server, "brand-a-key", `{"tenant_id":"brand-b"}`).Code)
+	})
+
+	t.Run("operator key queries any tenant", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				assert.Equal(t, "brand-b", *filter.TenantID)
+				return nil, nil
+			})
+
+		req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{"tenant_id":"brand-b"}`))
+		req.Header.Set("Authorization", "Bearer operator-key")
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("tenant key cannot reach operator endpoints", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		req := httptest.NewRequest(http.MethodGet, "/alerts", nil)
+		req.Header.Set(apiKeyHeader, "brand-a-key")
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestHttpServer_WebhookAdminAuth(t *testing.T) {
+	listWebhooks := func(server *HttpServer, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/webhooks/", nil)
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("server without API keys rejects every request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		server := NewHttpServer(nil, nil, &config.Http{})
+		server.SetWebhookAdminHandler(mocks.NewMockwebhookAdminHandler(ctrl))
+
+		assert.Equal(t, http.StatusUnauthorized, listWebhooks(server, ""))
+		assert.Equal(t, http.StatusUnauthorized, listWebhooks(server, "any-key"))
+	})
+
+	t.Run("only operator keys are accepted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMockwebhookAdminHandler(ctrl)
+		handler.EXPECT().ListSubscriptions(gomock.Any()).Return(nil, nil).Times(1)
+		server := NewHttpServer(nil, nil, &config.Http{APIKeys: []config.APIKey{
+			{Key: "operator-key"},
+			{Key: "brand-a-key", Tenant: "brand-a"},
+		}})
+		server.SetWebhookAdminHandler(handler)
+
+		assert.Equal(t, http.StatusForbidden, listWebhooks(server, "brand-a-key"))
+		assert.Equal(t, http.StatusOK, listWebhooks(server, "operator-key"))
+	})
+}
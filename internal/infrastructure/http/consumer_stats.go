@@ -0,0 +1,7 @@
+package http
+
+import "net/http"
+
+func (s *HttpServer) handleListTopicStats(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, TransformTopicStatsToDTO(s.consumerStatsHandler.TopicStats()))
+}
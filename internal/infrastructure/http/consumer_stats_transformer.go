@@ -0,0 +1,28 @@
+package http
+
+import "github.com/bsko/casino-transaction-system/internal/entity"
+
+func TransformTopicStatsToDTO(stats []entity.TopicStats) []TopicStatsDTO {
+	result := make([]TopicStatsDTO, 0, len(stats))
+	for _, topic := range stats {
+		dto := TopicStatsDTO{
+			Topic:     topic.Topic,
+			Received:  topic.Received,
+			Rejected:  topic.Rejected,
+			Stored:    topic.Stored,
+			Inspected: topic.Inspected,
+		}
+		if len(topic.Faults) > 0 {
+			dto.Faults = make(map[string]FaultStatsDTO, len(topic.Faults))
+			for fault, faultStats := range topic.Faults {
+				dto.Faults[fault] = FaultStatsDTO{
+					Received: faultStats.Received,
+					Rejected: faultStats.Rejected,
+					Stored:   faultStats.Stored,
+				}
+			}
+		}
+		result = append(result, dto)
+	}
+	return result
+}
@@ -1,8 +1,12 @@
 package http
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type TransactionSearchRequest struct {
+	TenantID        *string    `json:"tenant_id,omitempty"`
 	UserID          *string    `json:"user_id,omitempty"`
 	TransactionType *string    `json:"transaction_type,omitempty"`
 	AmountFrom      *float64   `json:"amount_from,omitempty"`
@@ -14,6 +18,8 @@ type TransactionSearchRequest struct {
 }
 
 type TransactionDTO struct {
+	EventID         string    `json:"event_id,omitempty"`
+	TenantID        string    `json:"tenant_id,omitempty"`
 	UserID          string    `json:"user_id"`
 	TransactionType string    `json:"transaction_type"`
 	Amount          float64   `json:"amount"`
@@ -31,3 +37,195 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 }
+
+type WebhookSubscriptionRequest struct {
+	Name             string `json:"name"`
+	FilterExpression string `json:"filter_expression"`
+	TargetURL        string `json:"target_url"`
+	Secret           string `json:"secret,omitempty"`
+	Active           *bool  `json:"active,omitempty"`
+}
+
+type WebhookSubscriptionDTO struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	FilterExpression string    `json:"filter_expression"`
+	TargetURL        string    `json:"target_url"`
+	Secret           string    `json:"secret,omitempty"`
+	Active           bool      `json:"active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryAttemptDTO struct {
+	ID          int64     `json:"id"`
+	DeliveryID  int64     `json:"delivery_id"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type UserLimitRequest struct {
+	LimitType string  `json:"limit_type"`
+	Period    string  `json:"period"`
+	Amount    float64 `json:"amount"`
+}
+
+type UserLimitDTO struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"user_id"`
+	LimitType string    `json:"limit_type"`
+	Period    string    `json:"period"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LimitUsageDTO struct {
+	LimitID     int64     `json:"limit_id"`
+	LimitType   string    `json:"limit_type"`
+	Period      string    `json:"period"`
+	Limit       float64   `json:"limit"`
+	Consumed    float64   `json:"consumed"`
+	Remaining   float64   `json:"remaining"`
+	Exceeded    bool      `json:"exceeded"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+type LimitBreachDTO struct {
+	ID          int64     `json:"id"`
+	LimitID     int64     `json:"limit_id"`
+	UserID      string    `json:"user_id"`
+	LimitType   string    `json:"limit_type"`
+	Period      string    `json:"period"`
+	Limit       float64   `json:"limit"`
+	Consumed    float64   `json:"consumed"`
+	WindowStart time.Time `json:"window_start"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+type AlertDTO struct {
+	ID             int64      `json:"id"`
+	RuleName       string     `json:"rule_name"`
+	Severity       string     `json:"severity"`
+	UserID         string     `json:"user_id"`
+	Description    string     `json:"description,omitempty"`
+	Value          float64    `json:"value"`
+	Threshold      float64    `json:"threshold"`
+	EventCount     int        `json:"event_count"`
+	WindowStart    time.Time  `json:"window_start"`
+	WindowEnd      time.Time  `json:"window_end"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+}
+
+type AcknowledgeAlertRequest struct {
+	AcknowledgedBy string `json:"acknowledged_by"`
+}
+
+type AuditVerificationDTO struct {
+	Valid          bool                   `json:"valid"`
+	CheckedUsers   int                    `json:"checked_users"`
+	CheckedRows    int64                  `json:"checked_rows"`
+	CheckedDigests int                    `json:"checked_digests"`
+	TamperedRow    *AuditFindingDTO       `json:"tampered_row,omitempty"`
+	TamperedDigest *AuditDigestFindingDTO `json:"tampered_digest,omitempty"`
+}
+
+type AuditFindingDTO struct {
+	RowID  int64  `json:"row_id"`
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type AuditDigestFindingDTO struct {
+	Day    string `json:"day"`
+	Reason string `json:"reason"`
+}
+
+type UserErasureRequest struct {
+	RequestedBy string `json:"requested_by"`
+}
+
+type UserErasureDTO struct {
+	RequestedBy      string     `json:"requested_by"`
+	ErasedAt         time.Time  `json:"erased_at"`
+	VaultDestroyedAt *time.Time `json:"vault_destroyed_at,omitempty"`
+	Pseudonym        string     `json:"pseudonym,omitempty"`
+}
+
+type TopicStatsDTO struct {
+	Topic     string `json:"topic"`
+	Received  int64  `json:"received"`
+	Rejected  int64  `json:"rejected"`
+	Stored    int64  `json:"stored"`
+	Inspected int64  `json:"inspected"`
+	// Faults counts the messages tagged with an injected fault by kind.
+	Faults map[string]FaultStatsDTO `json:"faults,omitempty"`
+}
+
+type FaultStatsDTO struct {
+	Received int64 `json:"received"`
+	Rejected int64 `json:"rejected"`
+	Stored   int64 `json:"stored"`
+}
+
+type ProducerSettingsDTO struct {
+	CreationRPS   int `json:"creation_rps"`
+	DistinctUsers int `json:"distinct_users"`
+	AmountFrom    int `json:"amount_from"`
+	AmountTo      int `json:"amount_to"`
+}
+
+// ProducerSettingsRequest changes the settings that are present.
+type ProducerSettingsRequest struct {
+	CreationRPS   *int `json:"creation_rps"`
+	DistinctUsers *int `json:"distinct_users"`
+	AmountFrom    *int `json:"amount_from"`
+	AmountTo      *int `json:"amount_to"`
+}
+
+type ProducerBurstRequest struct {
+	Count int `json:"count"`
+}
+
+type ProducerStatusDTO struct {
+	Running     bool                `json:"running"`
+	Paused      bool                `json:"paused"`
+	Settings    ProducerSettingsDTO `json:"settings"`
+	Workers     int                 `json:"workers"`
+	TargetRate  float64             `json:"target_rate"`
+	CurrentRate float64             `json:"current_rate"`
+	Scheduled   int64               `json:"scheduled"`
+	Dropped     int64               `json:"dropped"`
+	Published   int64               `json:"published"`
+	Failed      int64               `json:"failed"`
+}
+
+type IngestItemResultDTO struct {
+	Index   int    `json:"index"`
+	EventID string `json:"event_id,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+type IngestResponse struct {
+	Accepted int                   `json:"accepted"`
+	Rejected int                   `json:"rejected"`
+	Failed   int                   `json:"failed"`
+	Results  []IngestItemResultDTO `json:"results"`
+}
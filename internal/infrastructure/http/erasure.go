@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *HttpServer) erasureRoutes(r chi.Router) {
+	r.Post("/", s.handleEraseUser)
+	r.Get("/", s.handleGetUserErasure)
+	r.Delete("/vault", s.handleDestroyPseudonymVault)
+}
+
+func (s *HttpServer) handleEraseUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := pathUserID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	var req UserErasureRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	erasure, err := s.erasureHandler.Erase(r.Context(), userID, req.RequestedBy)
+	if err != nil {
+		s.writeServiceError(w, err, "erase user")
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, TransformUserErasureToDTO(*erasure))
+}
+
+func (s *HttpServer) handleGetUserErasure(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathUserID(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	erasure, err := s.erasureHandler.GetErasure(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, err, "get user erasure")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformUserErasureToDTO(*erasure))
+}
+
+func (s *HttpServer) handleDestroyPseudonymVault(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathUserID(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	if err = s.erasureHandler.DestroyVault(r.Context(), userID); err != nil {
+		s.writeServiceError(w, err, "destroy pseudonym vault entry")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,15 @@
+package http
+
+import "github.com/bsko/casino-transaction-system/internal/entity"
+
+func TransformUserErasureToDTO(erasure entity.UserErasure) UserErasureDTO {
+	result := UserErasureDTO{
+		RequestedBy:      erasure.RequestedBy,
+		ErasedAt:         erasure.ErasedAt,
+		VaultDestroyedAt: erasure.VaultDestroyedAt,
+	}
+	if erasure.Pseudonym != nil {
+		result.Pseudonym = erasure.Pseudonym.UUID.String()
+	}
+	return result
+}
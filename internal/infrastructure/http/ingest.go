@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	contentTypeProtobuf       = "application/x-protobuf"
+	contentTypeProtobufStream = "application/x-protobuf-delimited"
+	// maxIngestBodyBytes bounds the body of a POST /events request.
+	maxIngestBodyBytes = 10 << 20
+)
+
+var errUnsupportedMediaType = errors.New("content type must be application/json, " +
+	contentTypeProtobuf + " or " + contentTypeProtobufStream)
+
+// handleIngestEvents publishes posted events. A JSON body is an event or an
+// array of events in the shape of the search results; a protobuf body is a
+// message of the topic, or a batch of them each prefixed with its varint
+// encoded length. Events of a tenant caller get the tenant of its API key.
+func (s *HttpServer) handleIngestEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.writeError(w, http.StatusRequestEntityTooLarge, "Request body too large", err.Error())
+			return
+		}
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	contentType := r.Header.Get("Content-Type")
+	items, err := decodeIngestBody(contentType, body)
+	if errors.Is(err, errUnsupportedMediaType) {
+		s.writeError(w, http.StatusUnsupportedMediaType, "Unsupported media type", err.Error())
+		return
+	}
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	tenantID, scoped := callerTenant(r)
+	for i, item := range items {
+		if item.Event == nil || !scoped {
+			continue
+		}
+		switch item.Event.TenantID {
+		case "":
+			item.Event.TenantID = tenantID
+		case tenantID:
+		default:
+			items[i] = entity.IngestItem{Err: errTenantForbidden}
+		}
+	}
+
+	fingerprint := sha256.New()
+	fingerprint.Write([]byte(contentType + "\n"))
+	fingerprint.Write(body)
+	result, err := s.ingestHandler.Ingest(r.Context(), entity.IngestRequest{
+		TenantID:       tenantID,
+		IdempotencyKey: r.Header.Get(idempotencyKeyHeader),
+		Fingerprint:    hex.EncodeToString(fingerprint.Sum(nil)),
+		Items:          items,
+	})
+	if err != nil {
+		s.writeServiceError(w, err, "ingest events")
+		return
+	}
+
+	if result.Replayed {
+		w.Header().Set(idempotentReplayedHeader, "true")
+	}
+	s.writeJSON(w, http.StatusOK, TransformIngestResultToResponse(*result))
+}
+
+// decodeIngestBody returns an item per event of the body. An event that
+// cannot be decoded on its own is a rejected item, a body that cannot be
+// split into events is an error.
+func decodeIngestBody(contentType string, body []byte) ([]entity.IngestItem, error) {
+	mediaType := "application/json"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, errUnsupportedMediaType
+		}
+	}
+
+	switch mediaType {
+	case "application/json":
+		trimmed := bytes.TrimSpace(body)
+		values := []json.RawMessage{trimmed}
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &values); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+			}
+		}
+		items := make([]entity.IngestItem, 0, len(values))
+		for _, value := range values {
+			var dto TransactionDTO
+			if err := json.Unmarshal(value, &dto); err != nil {
+				items = append(items, entity.IngestItem{Err: fmt.Errorf("failed to unmarshal json: %w", err)})
+				continue
+			}
+			items = append(items, TransformIngestDTOToItem(dto))
+		}
+		return items, nil
+	case contentTypeProtobuf:
+		var dto api.TransactionEvent
+		if err := proto.Unmarshal(body, &dto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
+		}
+		return []entity.IngestItem{TransformIngestProtoToItem(&dto)}, nil
+	case contentTypeProtobufStream:
+		var items []entity.IngestItem
+		reader := bytes.NewReader(body)
+		for reader.Len() > 0 {
+			var dto api.TransactionEvent
+			if err := protodelim.UnmarshalFrom(reader, &dto); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal protobuf message %d: %w", len(items), err)
+			}
+			items = append(items, TransformIngestProtoToItem(&dto))
+		}
+		return items, nil
+	default:
+		return nil, errUnsupportedMediaType
+	}
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHttpServer_IngestEvents(t *testing.T) {
+	conf := &config.Http{APIKeys: []config.APIKey{
+		{Key: "operator-key"},
+		{Key: "brand-a-key", Tenant: "brand-a"},
+	}}
+	userID := uuid.New()
+
+	newServer := func(t *testing.T) (*HttpServer, *mocks.MockingestHandler) {
+		handler := mocks.NewMockingestHandler(gomock.NewController(t))
+		server := NewHttpServer(nil, nil, conf)
+		server.SetIngestHandler(handler)
+		return server, handler
+	}
+	post := func(server *HttpServer, contentType string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
+		req.Header.Set(apiKeyHeader, "operator-key")
+		req.Header.Set("Content-Type", contentType)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+	accept := func(_ context.Context, req entity.IngestRequest) (*entity.IngestResult, error) {
+		result := &entity.IngestResult{}
+		for _, item := range req.Items {
+			if item.Err != nil {
+				result.Items = append(result.Items, entity.IngestItemResult{Status: entity.IngestRejected, Error: item.Err.Error()})
+				continue
+			}
+			result.Items = append(result.Items, entity.IngestItemResult{EventID: item.Event.EventID, Status: entity.IngestAccepted})
+		}
+		return result, nil
+	}
+
+	t.Run("single json event", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req entity.IngestRequest) (*entity.IngestResult, error) {
+				require.Len(t, req.Items, 1)
+				require.NoError(t, req.Items[0].Err)
+				assert.Equal(t, entity.TransactionTypeBet, req.Items[0].Event.TransactionType)
+				assert.Equal(t, entity.ToMoney(12.5), req.Items[0].Event.Amount)
+				assert.NotEmpty(t, req.Fingerprint)
+				return accept(ctx, req)
+			})
+
+		rec := post(server, "application/json", []byte(`{"event_id":"e1","user_id":"`+userID.String()+
+			`","transaction_type":"bet","amount":12.5,"timestamp":"2024-05-01T12:00:00Z"}`), nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response IngestResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Equal(t, 1, response.Accepted)
+		assert.Equal(t, []IngestItemResultDTO{{Index: 0, EventID: "e1", Status: entity.IngestAccepted}}, response.Results)
+	})
+
+	t.Run("json batch with invalid items", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).DoAndReturn(accept)
+
+		rec := post(server, "application/json; charset=utf-8", []byte(`[
+			{"user_id":"`+userID.String()+`","transaction_type":"win","amount":1},
+			{"user_id":"not-a-uuid","transaction_type":"bet","amount":1},
+			{"user_id":"`+userID.String()+`","transaction_type":"refund","amount":1},
+			{"user_id":42}
+		]`), nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response IngestResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Equal(t, 1, response.Accepted)
+		assert.Equal(t, 3, response.Rejected)
+		assert.Len(t, response.Results, 4)
+	})
+
+	t.Run("protobuf event and batch", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).DoAndReturn(accept).Times(2)
+		dto := &api.TransactionEvent{
+			EventId:         "e1",
+			UserId:          userID.String(),
+			TransactionType: api.TransactionType_TRANSACTION_TYPE_WIN,
+			Amount:          3,
+			Timestamp:       timestamppb.Now(),
+		}
+
+		single, err := proto.Marshal(dto)
+		require.NoError(t, err)
+		rec := post(server, contentTypeProtobuf, single, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"accepted":1`)
+
+		var batch bytes.Buffer
+		for i := 0; i < 3; i++ {
+			_, err = protodelim.MarshalTo(&batch, dto)
+			require.NoError(t, err)
+		}
+		rec = post(server, contentTypeProtobufStream, batch.Bytes(), nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"accepted":3`)
+	})
+
+	t.Run("tenant callers publish to their tenant", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req entity.IngestRequest) (*entity.IngestResult, error) {
+				assert.Equal(t, "brand-a", req.TenantID)
+				assert.Equal(t, "brand-a", req.Items[0].Event.TenantID)
+				assert.ErrorIs(t, req.Items[1].Err, errTenantForbidden)
+				return accept(ctx, req)
+			})
+
+		rec := post(server, "application/json", []byte(`[
+			{"user_id":"`+userID.String()+`","transaction_type":"bet","amount":1},
+			{"tenant_id":"brand-b","user_id":"`+userID.String()+`","transaction_type":"bet","amount":1}
+		]`), map[string]string{apiKeyHeader: "brand-a-key"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("idempotency key and replays", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req entity.IngestRequest) (*entity.IngestResult, error) {
+				assert.Equal(t, "key-1", req.IdempotencyKey)
+				result, err := accept(ctx, req)
+				result.Replayed = true
+				return result, err
+			})
+
+		rec := post(server, "application/json", []byte(`{"user_id":"`+userID.String()+`","transaction_type":"bet","amount":1}`),
+			map[string]string{idempotencyKeyHeader: "key-1"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(idempotentReplayedHeader))
+	})
+
+	t.Run("key conflict", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Ingest(gomock.Any(), gomock.Any()).Return(nil, entity.ErrConflict)
+
+		rec := post(server, "application/json", []byte(`{}`), map[string]string{idempotencyKeyHeader: "key-1"})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("invalid bodies", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		assert.Equal(t, http.StatusBadRequest, post(server, "application/json", []byte(`[{]`), nil).Code)
+		assert.Equal(t, http.StatusBadRequest, post(server, contentTypeProtobuf, []byte{0xff, 0xff}, nil).Code)
+		assert.Equal(t, http.StatusUnsupportedMediaType, post(server, "text/plain", []byte(`x`), nil).Code)
+	})
+}
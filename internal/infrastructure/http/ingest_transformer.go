@@ -0,0 +1,62 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ingestTypeMap = map[string]api.TransactionType{
+	string(entity.TransactionTypeBet):     api.TransactionType_TRANSACTION_TYPE_BET,
+	string(entity.TransactionTypeWin):     api.TransactionType_TRANSACTION_TYPE_WIN,
+	string(entity.TransactionTypeDeposit): api.TransactionType_TRANSACTION_TYPE_DEPOSIT,
+}
+
+// TransformIngestDTOToItem validates a posted event with the rules of the
+// messages of the topic.
+func TransformIngestDTOToItem(dto TransactionDTO) entity.IngestItem {
+	transactionType, ok := ingestTypeMap[dto.TransactionType]
+	if !ok {
+		return entity.IngestItem{Err: fmt.Errorf("invalid transaction type: %s", dto.TransactionType)}
+	}
+	return TransformIngestProtoToItem(&api.TransactionEvent{
+		EventId:         dto.EventID,
+		TenantId:        dto.TenantID,
+		UserId:          dto.UserID,
+		TransactionType: transactionType,
+		Amount:          dto.Amount,
+		Timestamp:       timestamppb.New(dto.Timestamp),
+	})
+}
+
+func TransformIngestProtoToItem(dto *api.TransactionEvent) entity.IngestItem {
+	event, err := kafka.TransformFromDTO(dto)
+	if err != nil {
+		return entity.IngestItem{Err: err}
+	}
+	return entity.IngestItem{Event: event}
+}
+
+func TransformIngestResultToResponse(result entity.IngestResult) IngestResponse {
+	response := IngestResponse{Results: make([]IngestItemResultDTO, 0, len(result.Items))}
+	for i, item := range result.Items {
+		switch item.Status {
+		case entity.IngestAccepted:
+			response.Accepted++
+		case entity.IngestRejected:
+			response.Rejected++
+		case entity.IngestFailed:
+			response.Failed++
+		}
+		response.Results = append(response.Results, IngestItemResultDTO{
+			Index:   i,
+			EventID: item.EventID,
+			Status:  item.Status,
+			Error:   item.Error,
+		})
+	}
+	return response
+}
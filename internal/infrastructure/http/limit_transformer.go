@@ -0,0 +1,67 @@
+package http
+
+import "github.com/bsko/casino-transaction-system/internal/entity"
+
+func TransformLimitRequestToLimit(userID entity.UserID, req UserLimitRequest) entity.UserLimit {
+	return entity.UserLimit{
+		UserID: userID,
+		Type:   entity.LimitType(req.LimitType),
+		Period: entity.LimitPeriod(req.Period),
+		Amount: entity.ToMoney(req.Amount),
+	}
+}
+
+func TransformLimitToDTO(limit entity.UserLimit) UserLimitDTO {
+	return UserLimitDTO{
+		ID:        limit.ID,
+		UserID:    limit.UserID.UUID.String(),
+		LimitType: string(limit.Type),
+		Period:    string(limit.Period),
+		Amount:    limit.Amount.ToFloat(),
+		CreatedAt: limit.CreatedAt,
+		UpdatedAt: limit.UpdatedAt,
+	}
+}
+
+func TransformLimitsToDTO(limits []entity.UserLimit) []UserLimitDTO {
+	result := make([]UserLimitDTO, 0, len(limits))
+	for _, limit := range limits {
+		result = append(result, TransformLimitToDTO(limit))
+	}
+	return result
+}
+
+func TransformLimitUsageToDTO(usages []entity.LimitUsage) []LimitUsageDTO {
+	result := make([]LimitUsageDTO, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, LimitUsageDTO{
+			LimitID:     usage.Limit.ID,
+			LimitType:   string(usage.Limit.Type),
+			Period:      string(usage.Limit.Period),
+			Limit:       usage.Limit.Amount.ToFloat(),
+			Consumed:    usage.Consumed.ToFloat(),
+			Remaining:   usage.Remaining().ToFloat(),
+			Exceeded:    usage.Exceeded(),
+			WindowStart: usage.WindowStart,
+		})
+	}
+	return result
+}
+
+func TransformLimitBreachesToDTO(breaches []entity.LimitBreach) []LimitBreachDTO {
+	result := make([]LimitBreachDTO, 0, len(breaches))
+	for _, breach := range breaches {
+		result = append(result, LimitBreachDTO{
+			ID:          breach.ID,
+			LimitID:     breach.LimitID,
+			UserID:      breach.UserID.UUID.String(),
+			LimitType:   string(breach.Type),
+			Period:      string(breach.Period),
+			Limit:       breach.Amount.ToFloat(),
+			Consumed:    breach.Consumed.ToFloat(),
+			WindowStart: breach.WindowStart,
+			DetectedAt:  breach.DetectedAt,
+		})
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (s *HttpServer) limitRoutes(r chi.Router) {
+	r.Get("/", s.handleListLimits)
+	r.Post("/", s.handleCreateLimit)
+	r.Get("/usage", s.handleGetLimitUsage)
+	r.Get("/breaches", s.handleListLimitBreaches)
+	r.Get("/{id}", s.handleGetLimit)
+	r.Put("/{id}", s.handleUpdateLimit)
+	r.Delete("/{id}", s.handleDeleteLimit)
+}
+
+func (s *HttpServer) handleListLimits(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathUserID(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	limits, err := s.limitsHandler.ListLimits(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, err, "list user limits")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformLimitsToDTO(limits))
+}
+
+func (s *HttpServer) handleCreateLimit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := pathUserID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	var req UserLimitRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	created, err := s.limitsHandler.CreateLimit(r.Context(), TransformLimitRequestToLimit(userID, req))
+	if err != nil {
+		s.writeServiceError(w, err, "create user limit")
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, TransformLimitToDTO(*created))
+}
+
+func (s *HttpServer) handleGetLimit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, id, err := limitPathParams(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid path parameters", err.Error())
+		return
+	}
+
+	limit, err := s.limitsHandler.GetLimit(r.Context(), userID, id)
+	if err != nil {
+		s.writeServiceError(w, err, "get user limit")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformLimitToDTO(*limit))
+}
+
+func (s *HttpServer) handleUpdateLimit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, id, err := limitPathParams(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid path parameters", err.Error())
+		return
+	}
+
+	var req UserLimitRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	limit := TransformLimitRequestToLimit(userID, req)
+	limit.ID = id
+
+	updated, err := s.limitsHandler.UpdateLimit(r.Context(), limit)
+	if err != nil {
+		s.writeServiceError(w, err, "update user limit")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformLimitToDTO(*updated))
+}
+
+func (s *HttpServer) handleDeleteLimit(w http.ResponseWriter, r *http.Request) {
+	userID, id, err := limitPathParams(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid path parameters", err.Error())
+		return
+	}
+
+	if err = s.limitsHandler.DeleteLimit(r.Context(), userID, id); err != nil {
+		s.writeServiceError(w, err, "delete user limit")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *HttpServer) handleGetLimitUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathUserID(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	usage, err := s.limitsHandler.GetUsage(r.Context(), userID)
+	if err != nil {
+		s.writeServiceError(w, err, "get user limit usage")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformLimitUsageToDTO(usage))
+}
+
+func (s *HttpServer) handleListLimitBreaches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := pathUserID(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid user id", err.Error())
+		return
+	}
+
+	limit, offset, err := pagination(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	breaches, err := s.limitsHandler.ListBreaches(r.Context(), userID, limit, offset)
+	if err != nil {
+		s.writeServiceError(w, err, "list limit breaches")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformLimitBreachesToDTO(breaches))
+}
+
+func pathUserID(r *http.Request) (entity.UserID, error) {
+	parsedUUID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		return entity.UserID{}, fmt.Errorf("userID must be a valid UUID")
+	}
+	return entity.UserID{UUID: parsedUUID}, nil
+}
+
+func limitPathParams(r *http.Request) (entity.UserID, int64, error) {
+	userID, err := pathUserID(r)
+	if err != nil {
+		return entity.UserID{}, 0, err
+	}
+	id, err := pathID(r, "id")
+	if err != nil {
+		return entity.UserID{}, 0, err
+	}
+	return userID, id, nil
+}
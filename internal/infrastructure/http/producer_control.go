@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *HttpServer) producerControlRoutes(r chi.Router) {
+	r.Get("/", s.handleGetProducerStatus)
+	r.Post("/pause", s.handlePauseProducer)
+	r.Post("/resume", s.handleResumeProducer)
+	r.Patch("/settings", s.handleUpdateProducerSettings)
+	r.Post("/burst", s.handleProducerBurst)
+}
+
+func (s *HttpServer) handleGetProducerStatus(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, TransformProducerStatusToDTO(s.producerControlHandler.Status()))
+}
+
+func (s *HttpServer) handlePauseProducer(w http.ResponseWriter, _ *http.Request) {
+	s.producerControlHandler.Pause()
+	s.writeJSON(w, http.StatusOK, TransformProducerStatusToDTO(s.producerControlHandler.Status()))
+}
+
+func (s *HttpServer) handleResumeProducer(w http.ResponseWriter, _ *http.Request) {
+	s.producerControlHandler.Resume()
+	s.writeJSON(w, http.StatusOK, TransformProducerStatusToDTO(s.producerControlHandler.Status()))
+}
+
+func (s *HttpServer) handleUpdateProducerSettings(w http.ResponseWriter, r *http.Request) {
+	var req ProducerSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	status, err := s.producerControlHandler.UpdateSettings(TransformRequestToProducerSettingsUpdate(req))
+	if err != nil {
+		s.writeServiceError(w, err, "update producer settings")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformProducerStatusToDTO(*status))
+}
+
+func (s *HttpServer) handleProducerBurst(w http.ResponseWriter, r *http.Request) {
+	var req ProducerBurstRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	if err := s.producerControlHandler.Burst(req.Count); err != nil {
+		s.writeServiceError(w, err, "queue producer burst")
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, TransformProducerStatusToDTO(s.producerControlHandler.Status()))
+}
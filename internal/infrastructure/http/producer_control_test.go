@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHttpServer_ProducerControl(t *testing.T) {
+	newServer := func(t *testing.T) (*HttpServer, *mocks.MockproducerControlHandler) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMockproducerControlHandler(ctrl)
+		server := NewHttpServer(nil, nil, &config.Http{})
+		server.SetProducerControlHandler(handler)
+		return server, handler
+	}
+	serve := func(server *HttpServer, method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("transaction search is not served", func(t *testing.T) {
+		server, _ := newServer(t)
+		assert.Equal(t, http.StatusNotFound, serve(server, http.MethodPost, "/transactions", `{}`).Code)
+	})
+
+	t.Run("pause reports the status", func(t *testing.T) {
+		server, handler := newServer(t)
+		gomock.InOrder(
+			handler.EXPECT().Pause(),
+			handler.EXPECT().Status().Return(entity.ProducerStatus{Running: true, Paused: true, Published: 42}),
+		)
+
+		rec := serve(server, http.MethodPost, "/admin/producer/pause", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var status ProducerStatusDTO
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&status))
+		assert.True(t, status.Paused)
+		assert.Equal(t, int64(42), status.Published)
+	})
+
+	t.Run("settings change only the given fields", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().UpdateSettings(gomock.Any()).
+			DoAndReturn(func(update entity.ProducerSettingsUpdate) (*entity.ProducerStatus, error) {
+				require.NotNil(t, update.CreationRPS)
+				assert.Equal(t, 250, *update.CreationRPS)
+				assert.Nil(t, update.DistinctUsers)
+				return &entity.ProducerStatus{Settings: entity.ProducerSettings{CreationRPS: 250}}, nil
+			})
+
+		rec := serve(server, http.MethodPatch, "/admin/producer/settings", `{"creation_rps":250}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"creation_rps":250`)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().UpdateSettings(gomock.Any()).Return(nil, entity.ErrInvalidArgument)
+
+		rec := serve(server, http.MethodPatch, "/admin/producer/settings", `{"distinct_users":0}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("burst is accepted", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().Burst(500).Return(nil)
+		handler.EXPECT().Status().Return(entity.ProducerStatus{Running: true})
+
+		rec := serve(server, http.MethodPost, "/admin/producer/burst", `{"count":500}`)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	})
+}
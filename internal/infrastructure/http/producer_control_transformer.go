@@ -0,0 +1,32 @@
+package http
+
+import "github.com/bsko/casino-transaction-system/internal/entity"
+
+func TransformProducerStatusToDTO(status entity.ProducerStatus) ProducerStatusDTO {
+	return ProducerStatusDTO{
+		Running: status.Running,
+		Paused:  status.Paused,
+		Settings: ProducerSettingsDTO{
+			CreationRPS:   status.Settings.CreationRPS,
+			DistinctUsers: status.Settings.DistinctUsers,
+			AmountFrom:    status.Settings.AmountFrom,
+			AmountTo:      status.Settings.AmountTo,
+		},
+		Workers:     status.Workers,
+		TargetRate:  status.TargetRate,
+		CurrentRate: status.CurrentRate,
+		Scheduled:   status.Scheduled,
+		Dropped:     status.Dropped,
+		Published:   status.Published,
+		Failed:      status.Failed,
+	}
+}
+
+func TransformRequestToProducerSettingsUpdate(req ProducerSettingsRequest) entity.ProducerSettingsUpdate {
+	return entity.ProducerSettingsUpdate{
+		CreationRPS:   req.CreationRPS,
+		DistinctUsers: req.DistinctUsers,
+		AmountFrom:    req.AmountFrom,
+		AmountTo:      req.AmountTo,
+	}
+}
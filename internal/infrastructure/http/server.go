@@ -10,37 +10,94 @@ import (
 	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 type HttpServer struct {
 	postTransactionsMessageHandler postTransactionsMessageHandler
+	transactionStreamHandler       transactionStreamHandler
+	webhookAdminHandler            webhookAdminHandler
+	limitsHandler                  limitsHandler
+	alertsHandler                  alertsHandler
+	auditHandler                   auditHandler
+	erasureHandler                 erasureHandler
+	consumerStatsHandler           consumerStatsHandler
+	producerControlHandler         producerControlHandler
+	ingestHandler                  ingestHandler
+	apiKeys                        map[string]string
+	routeTimeouts                  map[string]time.Duration
 	server                         *http.Server
 	port                           int
 }
 
-func NewHttpServer(postTransactionsMessageHandler postTransactionsMessageHandler, conf *config.Http) *HttpServer {
+// NewHttpServer creates the server. Without a postTransactionsMessageHandler
+// the transaction search is not served, as in the producer admin server.
+func NewHttpServer(
+	postTransactionsMessageHandler postTransactionsMessageHandler,
+	transactionStreamHandler transactionStreamHandler,
+	conf *config.Http,
+) *HttpServer {
+	var apiKeys map[string]string
+	if len(conf.APIKeys) > 0 {
+		apiKeys = make(map[string]string, len(conf.APIKeys))
+		for _, apiKey := range conf.APIKeys {
+			apiKeys[apiKey.Key] = apiKey.Tenant
+		}
+	}
+	var routeTimeouts map[string]time.Duration
+	if len(conf.RouteTimeouts) > 0 {
+		routeTimeouts = make(map[string]time.Duration, len(conf.RouteTimeouts))
+		for _, routeTimeout := range conf.RouteTimeouts {
+			routeTimeouts[routeTimeout.Route] = time.Duration(routeTimeout.TimeoutMs) * time.Millisecond
+		}
+	}
 	return &HttpServer{
 		postTransactionsMessageHandler: postTransactionsMessageHandler,
+		transactionStreamHandler:       transactionStreamHandler,
+		apiKeys:                        apiKeys,
+		routeTimeouts:                  routeTimeouts,
 		port:                           conf.Port,
 	}
 }
 
-func (s *HttpServer) Start(ctx context.Context) error {
-	router := chi.NewRouter()
+func (s *HttpServer) SetWebhookAdminHandler(webhookAdminHandler webhookAdminHandler) {
+	s.webhookAdminHandler = webhookAdminHandler
+}
 
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Timeout(60 * time.Second))
+func (s *HttpServer) SetLimitsHandler(limitsHandler limitsHandler) {
+	s.limitsHandler = limitsHandler
+}
+
+func (s *HttpServer) SetAlertsHandler(alertsHandler alertsHandler) {
+	s.alertsHandler = alertsHandler
+}
 
-	router.Get("/health", s.handleHealthCheck)
-	router.Post("/transactions", s.handlePostTransactions)
+func (s *HttpServer) SetAuditHandler(auditHandler auditHandler) {
+	s.auditHandler = auditHandler
+}
 
+func (s *HttpServer) SetErasureHandler(erasureHandler erasureHandler) {
+	s.erasureHandler = erasureHandler
+}
+
+func (s *HttpServer) SetConsumerStatsHandler(consumerStatsHandler consumerStatsHandler) {
+	s.consumerStatsHandler = consumerStatsHandler
+}
+
+func (s *HttpServer) SetProducerControlHandler(producerControlHandler producerControlHandler) {
+	s.producerControlHandler = producerControlHandler
+}
+
+func (s *HttpServer) SetIngestHandler(ingestHandler ingestHandler) {
+	s.ingestHandler = ingestHandler
+}
+
+func (s *HttpServer) Start(ctx context.Context) error {
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      router,
+		Handler:      s.Router(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -55,6 +112,77 @@ func (s *HttpServer) Start(ctx context.Context) error {
 	return nil
 }
 
+func (s *HttpServer) Router() http.Handler {
+	router := chi.NewRouter()
+
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.RequestID)
+
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+		if s.routeTimeouts != nil {
+			r.Use(s.routeTimeout)
+		}
+
+		r.Get("/health", s.handleHealthCheck)
+
+		r.Group(func(r chi.Router) {
+			if s.apiKeys != nil {
+				r.Use(s.authenticate)
+			}
+
+			if s.postTransactionsMessageHandler != nil {
+				r.Post("/transactions", s.handlePostTransactions)
+			}
+			if s.ingestHandler != nil {
+				r.Post("/events", s.handleIngestEvents)
+			}
+
+			// data of these endpoints is not scoped by tenant
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireOperator)
+
+				if s.webhookAdminHandler != nil {
+					// subscriptions hold signing secrets and target URLs
+					r.With(s.requireOperatorKey).Route("/admin/webhooks", s.webhookRoutes)
+				}
+				if s.limitsHandler != nil {
+					r.Route("/users/{userID}/limits", s.limitRoutes)
+				}
+				if s.alertsHandler != nil {
+					r.Get("/alerts", s.handleListAlerts)
+					r.Post("/alerts/{id}/acknowledge", s.handleAcknowledgeAlert)
+				}
+				if s.auditHandler != nil {
+					r.Get("/admin/audit/verify", s.handleVerifyAudit)
+				}
+				if s.erasureHandler != nil {
+					r.Route("/admin/users/{userID}/erasure", s.erasureRoutes)
+				}
+				if s.consumerStatsHandler != nil {
+					r.Get("/admin/consumer/topics", s.handleListTopicStats)
+				}
+				if s.producerControlHandler != nil {
+					r.Route("/admin/producer", s.producerControlRoutes)
+				}
+			})
+		})
+	})
+
+	// long-lived streams must not be cut by the request timeout
+	if s.transactionStreamHandler != nil {
+		router.Group(func(r chi.Router) {
+			if s.apiKeys != nil {
+				r.Use(s.authenticate)
+			}
+			r.Get("/transactions/stream", s.handleTransactionsStream)
+		})
+	}
+
+	return router
+}
+
 func (s *HttpServer) Shutdown(ctx context.Context) error {
 	if s.server == nil {
 		return nil
@@ -102,10 +230,14 @@ func (s *HttpServer) handlePostTransactions(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	transactions, err := s.postTransactionsMessageHandler.GetListByFilter(filter)
+	if err = scopeFilter(r, &filter); err != nil {
+		s.writeError(w, http.StatusForbidden, "Forbidden", err.Error())
+		return
+	}
+
+	transactions, err := s.postTransactionsMessageHandler.GetListByFilter(r.Context(), filter)
 	if err != nil {
-		log.Printf("Failed to get transactions: %v", err)
-		s.writeError(w, http.StatusInternalServerError, "Failed to retrieve transactions", "")
+		s.writeServiceError(w, err, "retrieve transactions")
 		return
 	}
 
@@ -127,3 +259,35 @@ func (s *HttpServer) writeError(w http.ResponseWriter, statusCode int, error, me
 		log.Printf("Failed to encode error response: %v", err)
 	}
 }
+
+func (s *HttpServer) writeJSON(w http.ResponseWriter, statusCode int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// writeServiceError maps service layer errors to HTTP responses.
+func (s *HttpServer) writeServiceError(w http.ResponseWriter, err error, action string) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, entity.ErrNotFound):
+		s.writeError(w, http.StatusNotFound, "Not found", err.Error())
+	case errors.Is(err, entity.ErrInvalidArgument):
+		s.writeError(w, http.StatusBadRequest, "Invalid request", err.Error())
+	case errors.Is(err, entity.ErrConflict):
+		s.writeError(w, http.StatusConflict, "Conflict", err.Error())
+	case errors.Is(err, entity.ErrUserErased):
+		s.writeError(w, http.StatusGone, "User erased", err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("Failed to %s: %v", action, err)
+		s.writeError(w, http.StatusGatewayTimeout, "Timeout", "failed to "+action+" in time")
+	case errors.Is(err, context.Canceled):
+		// the client is gone and never reads the response
+		s.writeError(w, statusClientClosedRequest, "Client closed request", "")
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to "+action, "")
+	}
+}
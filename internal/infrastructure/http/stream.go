@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/services/feed"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeatPeriod = 15 * time.Second
+	websocketWriteWait    = 10 * time.Second
+	websocketPongWait     = 2 * streamHeartbeatPeriod
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// handleTransactionsStream serves the live feed over WebSocket when the client
+// asks for an upgrade and over Server-Sent Events otherwise.
+func (s *HttpServer) handleTransactionsStream(w http.ResponseWriter, r *http.Request) {
+	req, err := TransformQueryToRequest(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	filter, err := TransformRequestToFilter(req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid filter parameters", err.Error())
+		return
+	}
+
+	if err = scopeFilter(r, &filter); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusForbidden, "Forbidden", err.Error())
+		return
+	}
+
+	subscription := s.transactionStreamHandler.Subscribe(filter)
+	defer s.transactionStreamHandler.Unsubscribe(subscription)
+
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r, subscription)
+		return
+	}
+	s.serveSSE(w, r, subscription)
+}
+
+func (s *HttpServer) serveSSE(w http.ResponseWriter, r *http.Request, subscription *feed.Subscription) {
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to reset write deadline for stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		log.Printf("Streaming is not supported: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-subscription.Done():
+			if err := subscription.Err(); err != nil {
+				_ = writeSSEEvent(w, "error", ErrorResponse{Error: "Stream closed", Message: err.Error()})
+				_ = controller.Flush()
+			}
+			return
+		case event := <-subscription.Events():
+			if err := writeSSEEvent(w, "transaction", TransformEventToDTO(event)); err != nil {
+				log.Printf("Failed to write stream event: %v", err)
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, name string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream event: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+func (s *HttpServer) serveWebSocket(w http.ResponseWriter, r *http.Request, subscription *feed.Subscription) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade to websocket: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	// the read loop only serves control frames and detects the client going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		_ = conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-subscription.Done():
+			code, reason := websocket.CloseNormalClosure, ""
+			if err := subscription.Err(); err != nil {
+				code, reason = websocket.CloseTryAgainLater, err.Error()
+				if !errors.Is(err, feed.ErrSlowSubscriber) {
+					code = websocket.CloseGoingAway
+				}
+			}
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(websocketWriteWait))
+			return
+		case event := <-subscription.Events():
+			_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteJSON(TransformEventToDTO(event)); err != nil {
+				log.Printf("Failed to write websocket event: %v", err)
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
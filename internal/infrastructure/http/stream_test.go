@@ -0,0 +1,114 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/feed"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForSubscribers(t *testing.T, hub *feed.Hub, count int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return hub.SubscribersCount() == count
+	}, time.Second, 5*time.Millisecond)
+}
+
+func newStreamTestServer(hub *feed.Hub) *HttpServer {
+	server := NewHttpServer(nil, hub, &config.Http{})
+	return server
+}
+
+func TestHttpServer_TransactionsStream(t *testing.T) {
+	t.Run("server-sent events deliver matching transactions", func(t *testing.T) {
+		hub := feed.NewHub(10)
+		server := httptest.NewServer(newStreamTestServer(hub).Router())
+		defer server.Close()
+
+		userID := uuid.New()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			server.URL+"/transactions/stream?user_id="+userID.String()+"&amount_from=100", nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		waitForSubscribers(t, hub, 1)
+		hub.Publish([]entity.TransactionEvent{
+			{UserID: *entity.NewUserID(userID), TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(10.0), CreatedAt: time.Now()},
+			{UserID: *entity.NewUserID(userID), TransactionType: entity.TransactionTypeWin, Amount: entity.ToMoney(250.0), CreatedAt: time.Now()},
+		})
+
+		reader := bufio.NewReader(resp.Body)
+		eventLine, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event: transaction\n", eventLine)
+
+		dataLine, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		var dto TransactionDTO
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &dto))
+		assert.Equal(t, userID.String(), dto.UserID)
+		assert.Equal(t, "win", dto.TransactionType)
+		assert.Equal(t, 250.0, dto.Amount)
+	})
+
+	t.Run("websocket upgrade delivers transactions", func(t *testing.T) {
+		hub := feed.NewHub(10)
+		server := httptest.NewServer(newStreamTestServer(hub).Router())
+		defer server.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/transactions/stream?transaction_type=bet"
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		waitForSubscribers(t, hub, 1)
+		hub.Publish([]entity.TransactionEvent{
+			{UserID: *entity.NewUserID(uuid.New()), TransactionType: entity.TransactionTypeWin, Amount: entity.ToMoney(5.0), CreatedAt: time.Now()},
+			{UserID: *entity.NewUserID(uuid.New()), TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(7.0), CreatedAt: time.Now()},
+		})
+
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		var dto TransactionDTO
+		require.NoError(t, conn.ReadJSON(&dto))
+		assert.Equal(t, "bet", dto.TransactionType)
+		assert.Equal(t, 7.0, dto.Amount)
+
+		require.NoError(t, conn.Close())
+		waitForSubscribers(t, hub, 0)
+	})
+
+	t.Run("invalid filter returns bad request", func(t *testing.T) {
+		hub := feed.NewHub(10)
+		server := httptest.NewServer(newStreamTestServer(hub).Router())
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/transactions/stream?amount_from=abc")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, 0, hub.SubscribersCount())
+	})
+}
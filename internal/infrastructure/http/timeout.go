@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// statusClientClosedRequest is the non-standard status of nginx for requests
+// the client closed before the response.
+const statusClientClosedRequest = 499
+
+// routeTimeout cancels the context of requests to routes with a configured
+// timeout, which cancels the queries of the request.
+func (s *HttpServer) routeTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, ok := s.routeTimeouts[routePattern(r)]
+		if !ok || timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routePattern returns the full pattern of the route the request is routed
+// to. While a middleware runs, the pattern of the request is the part routed
+// so far, like /admin/webhooks/* for a route of a subrouter, so the route is
+// looked up in the whole router.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	if rctx.Routes != nil {
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+			return pattern
+		}
+	}
+	return rctx.RoutePattern()
+}
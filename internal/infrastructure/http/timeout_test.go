@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/http/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHttpServer_QueryCancellation(t *testing.T) {
+	conf := &config.Http{RouteTimeouts: []config.RouteTimeout{
+		{Route: "/transactions", TimeoutMs: 20},
+	}}
+
+	newServer := func(t *testing.T) (*HttpServer, *mocks.MockpostTransactionsMessageHandler) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMockpostTransactionsMessageHandler(ctrl)
+		return NewHttpServer(handler, nil, conf), handler
+	}
+
+	// waitForCancel blocks like a query until the context of the request is
+	// done.
+	waitForCancel := func(ctx context.Context, _ entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("failed to get transactions: %w", ctx.Err())
+	}
+
+	t.Run("query exceeding the route timeout is a gateway timeout", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(waitForCancel)
+
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	})
+
+	t.Run("query of a closed request is cancelled", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(waitForCancel)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{}`)).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		assert.Equal(t, statusClientClosedRequest, rec.Code)
+	})
+
+	t.Run("route timeout applies to the configured route only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		handler := mocks.NewMockpostTransactionsMessageHandler(ctrl)
+		limits := mocks.NewMocklimitsHandler(ctrl)
+		server := NewHttpServer(handler, nil, &config.Http{RouteTimeouts: []config.RouteTimeout{
+			{Route: "/users/{userID}/limits/usage", TimeoutMs: 20},
+		}})
+		server.SetLimitsHandler(limits)
+
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				assert.WithinDuration(t, time.Now().Add(60*time.Second), deadline, time.Second, "only the timeout of every request applies")
+				return nil, nil
+			})
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// routes of a subrouter are configured with their full pattern
+		limits.EXPECT().
+			GetUsage(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ entity.UserID) ([]entity.LimitUsage, error) {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 20*time.Millisecond)
+				return nil, nil
+			})
+		rec = httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString()+"/limits/usage", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("route timeout sets the deadline of the query", func(t *testing.T) {
+		server, handler := newServer(t)
+		handler.EXPECT().
+			GetListByFilter(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 20*time.Millisecond)
+				return nil, nil
+			})
+
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
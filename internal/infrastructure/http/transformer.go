@@ -2,6 +2,8 @@ package http
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/google/uuid"
@@ -13,6 +15,10 @@ func TransformRequestToFilter(req TransactionSearchRequest) (entity.TransactionE
 		Offset: req.Offset,
 	}
 
+	if req.TenantID != nil && *req.TenantID != "" {
+		filter.TenantID = req.TenantID
+	}
+
 	if req.UserID != nil && *req.UserID != "" {
 		parsedUUID, err := uuid.Parse(*req.UserID)
 		if err != nil {
@@ -51,12 +57,7 @@ func TransformTransactionsToResponse(transactions []entity.TransactionEvent, lim
 	resultList := make([]TransactionDTO, 0, len(transactions))
 
 	for _, transaction := range transactions {
-		resultList = append(resultList, TransactionDTO{
-			UserID:          transaction.UserID.UUID.String(),
-			TransactionType: string(transaction.TransactionType),
-			Amount:          transaction.Amount.ToFloat(),
-			Timestamp:       transaction.CreatedAt,
-		})
+		resultList = append(resultList, TransformEventToDTO(transaction))
 	}
 
 	return TransactionListResponse{
@@ -66,3 +67,48 @@ func TransformTransactionsToResponse(transactions []entity.TransactionEvent, lim
 		Transactions: resultList,
 	}
 }
+
+func TransformEventToDTO(transaction entity.TransactionEvent) TransactionDTO {
+	return TransactionDTO{
+		EventID:         transaction.EventID,
+		TenantID:        transaction.TenantID,
+		UserID:          transaction.UserID.UUID.String(),
+		TransactionType: string(transaction.TransactionType),
+		Amount:          transaction.Amount.ToFloat(),
+		Timestamp:       transaction.CreatedAt,
+	}
+}
+
+func TransformQueryToRequest(query url.Values) (TransactionSearchRequest, error) {
+	var req TransactionSearchRequest
+
+	if tenantID := query.Get("tenant_id"); tenantID != "" {
+		req.TenantID = &tenantID
+	}
+
+	if userID := query.Get("user_id"); userID != "" {
+		req.UserID = &userID
+	}
+
+	if transactionType := query.Get("transaction_type"); transactionType != "" {
+		req.TransactionType = &transactionType
+	}
+
+	if amountFrom := query.Get("amount_from"); amountFrom != "" {
+		amount, err := strconv.ParseFloat(amountFrom, 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid amount_from: %w", err)
+		}
+		req.AmountFrom = &amount
+	}
+
+	if amountTo := query.Get("amount_to"); amountTo != "" {
+		amount, err := strconv.ParseFloat(amountTo, 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid amount_to: %w", err)
+		}
+		req.AmountTo = &amount
+	}
+
+	return req, nil
+}
@@ -1,8 +1,69 @@
 //go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
 package http
 
-import "github.com/bsko/casino-transaction-system/internal/entity"
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/feed"
+)
 
 type postTransactionsMessageHandler interface {
-	GetListByFilter(filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+}
+
+type transactionStreamHandler interface {
+	Subscribe(filter entity.TransactionEventFilter) *feed.Subscription
+	Unsubscribe(subscription *feed.Subscription)
+}
+
+type webhookAdminHandler interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]entity.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID int64) ([]entity.WebhookDeliveryAttempt, error)
+}
+
+type limitsHandler interface {
+	CreateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error)
+	UpdateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error)
+	DeleteLimit(ctx context.Context, userID entity.UserID, id int64) error
+	GetLimit(ctx context.Context, userID entity.UserID, id int64) (*entity.UserLimit, error)
+	ListLimits(ctx context.Context, userID entity.UserID) ([]entity.UserLimit, error)
+	GetUsage(ctx context.Context, userID entity.UserID) ([]entity.LimitUsage, error)
+	ListBreaches(ctx context.Context, userID entity.UserID, limit, offset int) ([]entity.LimitBreach, error)
+}
+
+type alertsHandler interface {
+	ListAlerts(ctx context.Context, filter entity.AlertFilter) ([]entity.Alert, error)
+	AcknowledgeAlert(ctx context.Context, id int64, acknowledgedBy string) (*entity.Alert, error)
+}
+
+type auditHandler interface {
+	Verify(ctx context.Context, userID *entity.UserID) (*entity.AuditVerification, error)
+}
+
+type erasureHandler interface {
+	Erase(ctx context.Context, userID entity.UserID, requestedBy string) (*entity.UserErasure, error)
+	GetErasure(ctx context.Context, userID entity.UserID) (*entity.UserErasure, error)
+	DestroyVault(ctx context.Context, userID entity.UserID) error
+}
+
+type consumerStatsHandler interface {
+	TopicStats() []entity.TopicStats
+}
+
+type producerControlHandler interface {
+	Pause()
+	Resume()
+	UpdateSettings(update entity.ProducerSettingsUpdate) (*entity.ProducerStatus, error)
+	Burst(count int) error
+	Status() entity.ProducerStatus
+}
+
+type ingestHandler interface {
+	Ingest(ctx context.Context, req entity.IngestRequest) (*entity.IngestResult, error)
 }
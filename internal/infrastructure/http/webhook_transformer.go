@@ -0,0 +1,69 @@
+package http
+
+import "github.com/bsko/casino-transaction-system/internal/entity"
+
+func TransformWebhookRequestToSubscription(req WebhookSubscriptionRequest) entity.WebhookSubscription {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return entity.WebhookSubscription{
+		Name:             req.Name,
+		FilterExpression: req.FilterExpression,
+		TargetURL:        req.TargetURL,
+		Secret:           req.Secret,
+		Active:           active,
+	}
+}
+
+// TransformWebhookSubscriptionToDTO exposes the secret only when asked to, so
+// it is shown once on creation and never in listings.
+func TransformWebhookSubscriptionToDTO(subscription entity.WebhookSubscription, withSecret bool) WebhookSubscriptionDTO {
+	dto := WebhookSubscriptionDTO{
+		ID:               subscription.ID,
+		Name:             subscription.Name,
+		FilterExpression: subscription.FilterExpression,
+		TargetURL:        subscription.TargetURL,
+		Active:           subscription.Active,
+		CreatedAt:        subscription.CreatedAt,
+		UpdatedAt:        subscription.UpdatedAt,
+	}
+	if withSecret {
+		dto.Secret = subscription.Secret
+	}
+	return dto
+}
+
+func TransformWebhookDeliveriesToDTO(deliveries []entity.WebhookDelivery) []WebhookDeliveryDTO {
+	result := make([]WebhookDeliveryDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, WebhookDeliveryDTO{
+			ID:             delivery.ID,
+			SubscriptionID: delivery.SubscriptionID,
+			Payload:        delivery.Payload,
+			Status:         string(delivery.Status),
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		})
+	}
+	return result
+}
+
+func TransformWebhookAttemptsToDTO(attempts []entity.WebhookDeliveryAttempt) []WebhookDeliveryAttemptDTO {
+	result := make([]WebhookDeliveryAttemptDTO, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, WebhookDeliveryAttemptDTO{
+			ID:          attempt.ID,
+			DeliveryID:  attempt.DeliveryID,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.Duration.Milliseconds(),
+			AttemptedAt: attempt.AttemptedAt,
+		})
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *HttpServer) webhookRoutes(r chi.Router) {
+	r.Get("/", s.handleListWebhooks)
+	r.Post("/", s.handleCreateWebhook)
+	r.Get("/{id}", s.handleGetWebhook)
+	r.Put("/{id}", s.handleUpdateWebhook)
+	r.Delete("/{id}", s.handleDeleteWebhook)
+	r.Get("/{id}/deliveries", s.handleListWebhookDeliveries)
+	r.Get("/deliveries/{deliveryID}/attempts", s.handleListWebhookAttempts)
+}
+
+func (s *HttpServer) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := s.webhookAdminHandler.ListSubscriptions(r.Context())
+	if err != nil {
+		s.writeServiceError(w, err, "list webhook subscriptions")
+		return
+	}
+
+	result := make([]WebhookSubscriptionDTO, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, TransformWebhookSubscriptionToDTO(subscription, false))
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *HttpServer) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	created, err := s.webhookAdminHandler.CreateSubscription(r.Context(), TransformWebhookRequestToSubscription(req))
+	if err != nil {
+		s.writeServiceError(w, err, "create webhook subscription")
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, TransformWebhookSubscriptionToDTO(*created, true))
+}
+
+func (s *HttpServer) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid webhook id", err.Error())
+		return
+	}
+
+	subscription, err := s.webhookAdminHandler.GetSubscription(r.Context(), id)
+	if err != nil {
+		s.writeServiceError(w, err, "get webhook subscription")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformWebhookSubscriptionToDTO(*subscription, false))
+}
+
+func (s *HttpServer) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid webhook id", err.Error())
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	subscription := TransformWebhookRequestToSubscription(req)
+	subscription.ID = id
+
+	updated, err := s.webhookAdminHandler.UpdateSubscription(r.Context(), subscription)
+	if err != nil {
+		s.writeServiceError(w, err, "update webhook subscription")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformWebhookSubscriptionToDTO(*updated, req.Secret != ""))
+}
+
+func (s *HttpServer) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid webhook id", err.Error())
+		return
+	}
+
+	if err = s.webhookAdminHandler.DeleteSubscription(r.Context(), id); err != nil {
+		s.writeServiceError(w, err, "delete webhook subscription")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *HttpServer) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid webhook id", err.Error())
+		return
+	}
+
+	limit, offset, err := pagination(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid pagination parameters", err.Error())
+		return
+	}
+
+	deliveries, err := s.webhookAdminHandler.ListDeliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		s.writeServiceError(w, err, "list webhook deliveries")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformWebhookDeliveriesToDTO(deliveries))
+}
+
+func (s *HttpServer) handleListWebhookAttempts(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := pathID(r, "deliveryID")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.writeError(w, http.StatusBadRequest, "Invalid delivery id", err.Error())
+		return
+	}
+
+	attempts, err := s.webhookAdminHandler.ListAttempts(r.Context(), deliveryID)
+	if err != nil {
+		s.writeServiceError(w, err, "list webhook delivery attempts")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, TransformWebhookAttemptsToDTO(attempts))
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return id, nil
+}
+
+func pagination(r *http.Request) (limit int, offset int, err error) {
+	query := r.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("invalid offset: %w", err)
+		}
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DecoderProtobuf = "protobuf"
+	DecoderJSON     = "json"
+)
+
+type decoder func(value []byte) (*entity.TransactionEvent, error)
+
+// jsonEvent is the JSON form of a transaction event, the same shape the HTTP
+// API returns.
+type jsonEvent struct {
+	EventID         string    `json:"event_id"`
+	TenantID        string    `json:"tenant_id"`
+	UserID          string    `json:"user_id"`
+	TransactionType string    `json:"transaction_type"`
+	Amount          float64   `json:"amount"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+func newDecoder(name string) (decoder, error) {
+	switch name {
+	case "", DecoderProtobuf:
+		return decodeProtobuf, nil
+	case DecoderJSON:
+		return decodeJSON, nil
+	default:
+		return nil, fmt.Errorf("unknown decoder %q, must be one of protobuf, json", name)
+	}
+}
+
+// UnmarshalEvent decodes a protobuf message of the topic.
+func UnmarshalEvent(value []byte) (*entity.TransactionEvent, error) {
+	return decodeProtobuf(value)
+}
+
+func decodeProtobuf(value []byte) (*entity.TransactionEvent, error) {
+	var dto api.TransactionEvent
+	if err := proto.Unmarshal(value, &dto); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
+	}
+
+	event, err := TransformFromDTO(&dto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform DTO to event: %w", err)
+	}
+	return event, nil
+}
+
+func decodeJSON(value []byte) (*entity.TransactionEvent, error) {
+	var dto jsonEvent
+	if err := json.Unmarshal(value, &dto); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	return dto.toEntity()
+}
+
+func (dto jsonEvent) toEntity() (*entity.TransactionEvent, error) {
+	userID, err := uuid.Parse(dto.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user_id: %w", err)
+	}
+
+	transactionType := entity.TransactionType(dto.TransactionType)
+	if !transactionType.IsValid() {
+		return nil, fmt.Errorf("invalid transaction type: %s", dto.TransactionType)
+	}
+
+	return &entity.TransactionEvent{
+		EventID:         dto.EventID,
+		TenantID:        dto.TenantID,
+		UserID:          entity.UserID{UUID: userID},
+		TransactionType: transactionType,
+		Amount:          entity.ToMoney(dto.Amount),
+		CreatedAt:       dto.Timestamp,
+	}, nil
+}
+
+func newJSONEvent(event entity.TransactionEvent) jsonEvent {
+	return jsonEvent{
+		EventID:         event.EventID,
+		TenantID:        event.TenantID,
+		UserID:          event.UserID.UUID.String(),
+		TransactionType: string(event.TransactionType),
+		Amount:          event.Amount.ToFloat(),
+		Timestamp:       event.CreatedAt,
+	}
+}
@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecoders(t *testing.T) {
+	event := entity.TransactionEvent{
+		EventID:         "round-1",
+		TenantID:        "brand-a",
+		UserID:          entity.UserID{UUID: uuid.New()},
+		TransactionType: entity.TransactionTypeWin,
+		Amount:          entity.ToMoney(12.5),
+		CreatedAt:       time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		dto, err := TransformToDTO(event)
+		require.NoError(t, err)
+		value, err := proto.Marshal(dto)
+		require.NoError(t, err)
+
+		decoded, err := decodeProtobuf(value)
+		require.NoError(t, err)
+		assert.Equal(t, event, *decoded)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		value := []byte(`{"event_id":"round-1","tenant_id":"brand-a","user_id":"` + event.UserID.UUID.String() +
+			`","transaction_type":"win","amount":12.5,"timestamp":"2025-01-02T10:00:00Z"}`)
+
+		decoded, err := decodeJSON(value)
+		require.NoError(t, err)
+		assert.Equal(t, event, *decoded)
+	})
+
+	t.Run("json with unknown transaction type", func(t *testing.T) {
+		value := []byte(`{"user_id":"` + event.UserID.UUID.String() + `","transaction_type":"chargeback","amount":1}`)
+
+		_, err := decodeJSON(value)
+		assert.ErrorContains(t, err, "invalid transaction type")
+	})
+}
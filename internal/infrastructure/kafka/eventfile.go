@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/api"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// Event files hold transaction events in the json format, one object per
+// line (NDJSON), or in the protobuf format of the topic, every message
+// prefixed with its varint encoded length.
+
+// EventFileWriter appends events to an event file.
+type EventFileWriter struct {
+	file   *os.File
+	format string
+	mu     sync.Mutex
+}
+
+// EventFileReader reads the events of an event file in order.
+type EventFileReader struct {
+	file   *os.File
+	reader *bufio.Reader
+	format string
+	line   int
+}
+
+// CreateEventFile opens the file for appending, creating it when missing. The
+// format defaults to json.
+func CreateEventFile(path, format string) (*EventFileWriter, error) {
+	format, err := eventFileFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &EventFileWriter{file: file, format: format}, nil
+}
+
+func (w *EventFileWriter) Write(event entity.TransactionEvent) error {
+	var data []byte
+	if w.format == DecoderProtobuf {
+		dto, err := TransformToDTO(event)
+		if err != nil {
+			return fmt.Errorf("failed to transform event to DTO: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err = protodelim.MarshalTo(&buf, dto); err != nil {
+			return fmt.Errorf("failed to marshal protobuf: %w", err)
+		}
+		data = buf.Bytes()
+	} else {
+		line, err := json.Marshal(newJSONEvent(event))
+		if err != nil {
+			return fmt.Errorf("failed to marshal json: %w", err)
+		}
+		data = append(line, '\n')
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	return nil
+}
+
+func (w *EventFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// OpenEventFile opens the file for reading. The format defaults to json.
+func OpenEventFile(path, format string) (*EventFileReader, error) {
+	format, err := eventFileFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &EventFileReader{file: file, reader: bufio.NewReader(file), format: format}, nil
+}
+
+// Read returns the next event of the file and io.EOF after the last one.
+func (r *EventFileReader) Read() (*entity.TransactionEvent, error) {
+	if r.format == DecoderProtobuf {
+		var dto api.TransactionEvent
+		if err := protodelim.UnmarshalFrom(r.reader, &dto); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
+		}
+		event, err := TransformFromDTO(&dto)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform DTO to event: %w", err)
+		}
+		return event, nil
+	}
+
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read event file: %w", err)
+		}
+		r.line++
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			event, decodeErr := decodeJSON(trimmed)
+			if decodeErr != nil {
+				return nil, fmt.Errorf("line %d: %w", r.line, decodeErr)
+			}
+			return event, nil
+		}
+		if err != nil {
+			return nil, io.EOF
+		}
+	}
+}
+
+func (r *EventFileReader) Close() error {
+	return r.file.Close()
+}
+
+func eventFileFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return DecoderJSON, nil
+	case DecoderJSON, DecoderProtobuf:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown event file format %q, must be one of json, protobuf", format)
+	}
+}
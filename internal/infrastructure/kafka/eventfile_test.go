@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFile(t *testing.T) {
+	events := []entity.TransactionEvent{
+		{
+			EventID:         "e1",
+			TenantID:        "brand-a",
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(12.5),
+			CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			EventID:         "e2",
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeWin,
+			Amount:          entity.ToMoney(30),
+			CreatedAt:       time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC),
+		},
+	}
+
+	for _, format := range []string{DecoderJSON, DecoderProtobuf} {
+		t.Run(format+" round trip", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events")
+
+			writer, err := CreateEventFile(path, format)
+			require.NoError(t, err)
+			for _, event := range events {
+				require.NoError(t, writer.Write(event))
+			}
+			require.NoError(t, writer.Close())
+
+			reader, err := OpenEventFile(path, format)
+			require.NoError(t, err)
+			defer reader.Close()
+			for _, expected := range events {
+				event, err := reader.Read()
+				require.NoError(t, err)
+				assert.Equal(t, expected.EventID, event.EventID)
+				assert.Equal(t, expected.TenantID, event.TenantID)
+				assert.Equal(t, expected.UserID, event.UserID)
+				assert.Equal(t, expected.TransactionType, event.TransactionType)
+				assert.Equal(t, expected.Amount, event.Amount)
+				assert.True(t, expected.CreatedAt.Equal(event.CreatedAt))
+			}
+			_, err = reader.Read()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+
+	t.Run("json skips blank lines and reports the bad line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.ndjson")
+		content := "\n" + `{"user_id":"` + uuid.NewString() + `","transaction_type":"bet","amount":1,"timestamp":"2024-05-01T12:00:00Z"}` +
+			"\n\n" + `{"user_id":"nope","transaction_type":"bet"}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		reader, err := OpenEventFile(path, "")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		_, err = reader.Read()
+		require.NoError(t, err)
+		_, err = reader.Read()
+		assert.ErrorContains(t, err, "line 4")
+	})
+
+	t.Run("truncated protobuf", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.pb")
+		writer, err := CreateEventFile(path, DecoderProtobuf)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(events[0]))
+		require.NoError(t, writer.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)-3], 0o644))
+
+		reader, err := OpenEventFile(path, DecoderProtobuf)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		_, err = reader.Read()
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := CreateEventFile(filepath.Join(t.TempDir(), "events"), "csv")
+		assert.ErrorContains(t, err, "unknown event file format")
+	})
+}
@@ -0,0 +1,273 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+const offsetsRequestTimeout = 10 * time.Second
+
+// KafkaOffsets inspects and moves the committed offsets of the consumer group
+// and reads ranges of a partition without joining the group.
+type KafkaOffsets struct {
+	conf      config.Kafka
+	client    *kafka.Client
+	transport *kafka.Transport
+	decoder   *messageDecoder
+}
+
+func NewKafkaOffsets(conf config.Kafka) *KafkaOffsets {
+	return &KafkaOffsets{
+		conf: conf,
+	}
+}
+
+func (k *KafkaOffsets) Connect(_ context.Context) error {
+	decoder, err := newMessageDecoder(k.conf)
+	if err != nil {
+		return err
+	}
+	k.decoder = decoder
+
+	transport := &kafka.Transport{}
+	if k.conf.User != "" && k.conf.Password != "" {
+		transport.SASL = plain.Mechanism{
+			Username: k.conf.User,
+			Password: k.conf.Password,
+		}
+	}
+	k.client = &kafka.Client{
+		Addr:      kafka.TCP(k.conf.ConnectionString),
+		Timeout:   offsetsRequestTimeout,
+		Transport: transport,
+	}
+	k.transport = transport
+	return nil
+}
+
+// Offsets returns the first, last and committed offsets of every partition of
+// the topic, ordered by partition.
+func (k *KafkaOffsets) Offsets(ctx context.Context, topic string) ([]entity.PartitionOffsets, error) {
+	if k.client == nil {
+		return nil, fmt.Errorf("kafka offsets client is not initialized, call Connect() first")
+	}
+
+	partitions, err := k.partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := k.listOffsets(ctx, topic, partitions, kafka.FirstOffset)
+	if err != nil {
+		return nil, err
+	}
+	last, err := k.listOffsets(ctx, topic, partitions, kafka.LastOffset)
+	if err != nil {
+		return nil, err
+	}
+	committed, err := k.committedOffsets(ctx, topic, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.PartitionOffsets, 0, len(partitions))
+	for _, partition := range partitions {
+		result = append(result, entity.PartitionOffsets{
+			Partition: partition,
+			First:     first[partition],
+			Last:      last[partition],
+			Committed: committed[partition],
+		})
+	}
+	return result, nil
+}
+
+// OffsetsAt returns the offset of the first message of every partition with
+// a timestamp at or after the given time, -1 for partitions without one.
+func (k *KafkaOffsets) OffsetsAt(ctx context.Context, topic string, partitions []int, at time.Time) (map[int]int64, error) {
+	if k.client == nil {
+		return nil, fmt.Errorf("kafka offsets client is not initialized, call Connect() first")
+	}
+	return k.listOffsets(ctx, topic, partitions, at.UnixMilli())
+}
+
+// CommitOffsets commits the offsets for the consumer group. Kafka accepts the
+// commit only while no member of the group is running.
+func (k *KafkaOffsets) CommitOffsets(ctx context.Context, topic string, offsets map[int]int64) error {
+	if k.client == nil {
+		return fmt.Errorf("kafka offsets client is not initialized, call Connect() first")
+	}
+	if k.conf.GroupID == "" {
+		return fmt.Errorf("no consumer group configured")
+	}
+
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	resp, err := k.client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      k.conf.GroupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			if errors.Is(partition.Error, kafka.UnknownMemberId) || errors.Is(partition.Error, kafka.IllegalGeneration) ||
+				errors.Is(partition.Error, kafka.RebalanceInProgress) {
+				return fmt.Errorf("failed to commit offset of partition %d, stop the consumers of group %s first: %w",
+					partition.Partition, k.conf.GroupID, partition.Error)
+			}
+			return fmt.Errorf("failed to commit offset of partition %d: %w", partition.Partition, partition.Error)
+		}
+	}
+	return nil
+}
+
+// ReadRange reads the messages of a partition from the start offset up to,
+// not including, the end offset and passes every decoded event to handle. A
+// message that cannot be decoded is passed as an entity.InvalidMessageError.
+// The consumer group offsets are not touched.
+func (k *KafkaOffsets) ReadRange(ctx context.Context, topic string, partition int, start, end int64,
+	handle func(event *entity.ConsumedEvent, err error) error) error {
+	if k.decoder == nil {
+		return fmt.Errorf("kafka offsets client is not initialized, call Connect() first")
+	}
+	if start >= end {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        []string{k.conf.ConnectionString},
+		Topic:          topic,
+		Partition:      partition,
+		MinBytes:       1,
+		MaxBytes:       10e6,
+		MaxWait:        1 * time.Second,
+		ReadBackoffMin: 100 * time.Millisecond,
+		ReadBackoffMax: 1 * time.Second,
+		Dialer:         newDialer(k.conf),
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return fmt.Errorf("failed to seek partition %d: %w", partition, err)
+	}
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read message from kafka: %w", err)
+		}
+		if msg.Offset >= end {
+			return nil
+		}
+		if err = handle(k.decoder.decode(msg)); err != nil {
+			return err
+		}
+		if msg.Offset+1 >= end {
+			return nil
+		}
+	}
+}
+
+func (k *KafkaOffsets) Close() error {
+	if k.transport != nil {
+		k.transport.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (k *KafkaOffsets) partitions(ctx context.Context, topic string) ([]int, error) {
+	resp, err := k.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", err)
+	}
+	for _, t := range resp.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("failed to fetch metadata of topic %s: %w", topic, t.Error)
+		}
+		partitions := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			partitions = append(partitions, p.ID)
+		}
+		sort.Ints(partitions)
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("topic %s does not exist", topic)
+}
+
+// listOffsets resolves the offset of every partition for a timestamp in
+// milliseconds or one of kafka.FirstOffset and kafka.LastOffset.
+func (k *KafkaOffsets) listOffsets(ctx context.Context, topic string, partitions []int, timestamp int64) (map[int]int64, error) {
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, partition := range partitions {
+		requests = append(requests, kafka.OffsetRequest{Partition: partition, Timestamp: timestamp})
+	}
+	resp, err := k.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets: %w", err)
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of partition %d: %w", partition.Partition, partition.Error)
+		}
+		switch timestamp {
+		case kafka.FirstOffset:
+			offsets[partition.Partition] = partition.FirstOffset
+		case kafka.LastOffset:
+			offsets[partition.Partition] = partition.LastOffset
+		default:
+			offsets[partition.Partition] = -1
+			for offset := range partition.Offsets {
+				offsets[partition.Partition] = offset
+			}
+		}
+	}
+	return offsets, nil
+}
+
+func (k *KafkaOffsets) committedOffsets(ctx context.Context, topic string, partitions []int) (map[int]int64, error) {
+	committed := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		committed[partition] = entity.NoCommittedOffset
+	}
+	if k.conf.GroupID == "" {
+		return committed, nil
+	}
+
+	resp, err := k.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: k.conf.GroupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", resp.Error)
+	}
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("failed to fetch committed offset of partition %d: %w", partition.Partition, partition.Error)
+		}
+		committed[partition.Partition] = partition.CommittedOffset
+	}
+	return committed, nil
+}
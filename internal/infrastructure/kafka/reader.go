@@ -6,36 +6,52 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bsko/casino-transaction-system/api"
 	"github.com/bsko/casino-transaction-system/internal/config"
 	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
-	"google.golang.org/protobuf/proto"
 )
 
 type KafkaReader struct {
-	conf        config.Kafka
-	reader      *kafka.Reader
-	lastMessage kafka.Message
-	mu          sync.Mutex
+	conf    config.Kafka
+	reader  *kafka.Reader
+	decoder *messageDecoder
+	// last read message of every partition, committed together
+	pending map[topicPartition]kafka.Message
+	mu      sync.Mutex
+}
+
+// messageDecoder decodes the messages of the configured topics.
+type messageDecoder struct {
+	conf   config.Kafka
+	topics map[string]topicReader
+}
+
+type topicReader struct {
+	decode decoder
+	tenant string
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
 }
 
 func NewKafkaReader(conf config.Kafka) *KafkaReader {
 	return &KafkaReader{
-		conf: conf,
+		conf:    conf,
+		pending: make(map[topicPartition]kafka.Message),
 	}
 }
 
 func (k *KafkaReader) Connect(_ context.Context) error {
-	var dialer kafka.Dialer
-	if k.conf.User != "" && k.conf.Password != "" {
-		dialer.SASLMechanism = plain.Mechanism{
-			Username: k.conf.User,
-			Password: k.conf.Password,
-		}
+	decoder, err := newMessageDecoder(k.conf)
+	if err != nil {
+		return err
 	}
-	k.reader = kafka.NewReader(kafka.ReaderConfig{
+	k.decoder = decoder
+
+	readerConf := kafka.ReaderConfig{
 		Brokers:        []string{k.conf.ConnectionString},
 		GroupID:        k.conf.GroupID,
 		Topic:          k.conf.Topic,
@@ -44,52 +60,155 @@ func (k *KafkaReader) Connect(_ context.Context) error {
 		MaxWait:        1 * time.Second,
 		ReadBackoffMin: 100 * time.Millisecond,
 		ReadBackoffMax: 1 * time.Second,
-		Dialer:         &dialer,
-	})
+		Dialer:         newDialer(k.conf),
+	}
+	if len(decoder.topics) > 1 || k.conf.Topic == "" {
+		if k.conf.GroupID == "" {
+			return fmt.Errorf("consuming several topics requires a consumer group")
+		}
+		readerConf.Topic = ""
+		for topic := range decoder.topics {
+			readerConf.GroupTopics = append(readerConf.GroupTopics, topic)
+		}
+	}
+	k.reader = kafka.NewReader(readerConf)
 	return nil
 }
 
-func (k *KafkaReader) Read(ctx context.Context) (*entity.TransactionEvent, error) {
+func newMessageDecoder(conf config.Kafka) (*messageDecoder, error) {
+	topics, err := readerTopics(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &messageDecoder{conf: conf, topics: topics}, nil
+}
+
+// readerTopics collects the main topic, the tenant topics and the configured
+// topics with their decoders.
+func readerTopics(conf config.Kafka) (map[string]topicReader, error) {
+	topics := make(map[string]topicReader)
+	add := func(name, decoderName, tenant string) error {
+		if name == "" {
+			return fmt.Errorf("topic name is required")
+		}
+		if _, ok := topics[name]; ok {
+			return fmt.Errorf("topic %s is configured twice", name)
+		}
+		decode, err := newDecoder(decoderName)
+		if err != nil {
+			return fmt.Errorf("topic %s: %w", name, err)
+		}
+		topics[name] = topicReader{decode: decode, tenant: tenant}
+		return nil
+	}
+
+	if conf.Topic != "" {
+		if err := add(conf.Topic, DecoderProtobuf, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, tenantTopic := range conf.TenantTopics {
+		if err := add(tenantTopic.Topic, DecoderProtobuf, tenantTopic.Tenant); err != nil {
+			return nil, err
+		}
+	}
+	for _, topic := range conf.Topics {
+		if err := add(topic.Name, topic.Decoder, topic.Tenant); err != nil {
+			return nil, err
+		}
+	}
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("no kafka topic configured")
+	}
+	return topics, nil
+}
+
+// Read returns the next event. A message that cannot be decoded is reported
+// with an entity.InvalidMessageError and is committed with the next batch.
+func (k *KafkaReader) Read(ctx context.Context) (*entity.ConsumedEvent, error) {
 	if k.reader == nil {
 		return nil, fmt.Errorf("kafka reader is not initialized, call Connect() first")
 	}
 
-	msg, err := k.reader.ReadMessage(ctx)
+	msg, err := k.reader.FetchMessage(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read message from kafka: %w", err)
 	}
 
 	k.mu.Lock()
-	k.lastMessage = msg
+	k.pending[topicPartition{topic: msg.Topic, partition: msg.Partition}] = msg
 	k.mu.Unlock()
 
-	var dto api.TransactionEvent
-	if err = proto.Unmarshal(msg.Value, &dto); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal protobuf: %w", err)
+	return k.decoder.decode(msg)
+}
+
+// decode decodes a message of a configured topic, a message that cannot be
+// decoded is reported with an entity.InvalidMessageError.
+func (d *messageDecoder) decode(msg kafka.Message) (*entity.ConsumedEvent, error) {
+	fault := headerValue(msg, faultHeader)
+	topic, ok := d.topics[msg.Topic]
+	if !ok {
+		return nil, &entity.InvalidMessageError{Topic: msg.Topic, Fault: fault, Err: fmt.Errorf("topic is not configured")}
 	}
 
-	event, err := TransformFromDTO(&dto)
+	event, err := topic.decode(msg.Value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to transform DTO to event: %w", err)
+		return nil, &entity.InvalidMessageError{Topic: msg.Topic, Fault: fault, Err: err}
 	}
+	event.TenantID = d.tenantOf(msg, topic, event.TenantID)
+
+	return &entity.ConsumedEvent{Topic: msg.Topic, Event: *event, Fault: fault}, nil
+}
 
-	return event, nil
+// tenantOf resolves the tenant of a message: the tenant of its topic when the
+// topic has one, otherwise the tenant header, otherwise the tenant of the
+// payload and the default tenant when none names one.
+func (d *messageDecoder) tenantOf(msg kafka.Message, topic topicReader, payloadTenant string) string {
+	if topic.tenant != "" {
+		return topic.tenant
+	}
+	if tenant := headerValue(msg, tenantHeader(d.conf)); tenant != "" {
+		return tenant
+	}
+	if payloadTenant != "" {
+		return payloadTenant
+	}
+	return entity.DefaultTenantID
 }
 
+// headerValue returns the value of the first header with the key.
+func headerValue(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key && len(h.Value) > 0 {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Commit commits the offsets of every message read so far.
 func (k *KafkaReader) Commit(ctx context.Context) error {
 	if k.reader == nil {
 		return fmt.Errorf("kafka reader is not initialized")
 	}
+	// without a consumer group there are no offsets to commit
+	if k.conf.GroupID == "" {
+		return nil
+	}
 
 	k.mu.Lock()
-	msg := k.lastMessage
+	messages := make([]kafka.Message, 0, len(k.pending))
+	for key, msg := range k.pending {
+		messages = append(messages, msg)
+		delete(k.pending, key)
+	}
 	k.mu.Unlock()
 
-	if msg.Topic == "" {
+	if len(messages) == 0 {
 		return nil
 	}
 
-	return k.reader.CommitMessages(ctx, msg)
+	return k.reader.CommitMessages(ctx, messages...)
 }
 
 func (k *KafkaReader) Close() error {
@@ -100,3 +219,14 @@ func (k *KafkaReader) Close() error {
 	}
 	return nil
 }
+
+func newDialer(conf config.Kafka) *kafka.Dialer {
+	var dialer kafka.Dialer
+	if conf.User != "" && conf.Password != "" {
+		dialer.SASLMechanism = plain.Mechanism{
+			Username: conf.User,
+			Password: conf.Password,
+		}
+	}
+	return &dialer
+}
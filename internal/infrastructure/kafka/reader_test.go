@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderTopics(t *testing.T) {
+	t.Run("collects main, tenant and configured topics", func(t *testing.T) {
+		topics, err := readerTopics(config.Kafka{
+			Topic:        "transactions",
+			TenantTopics: []config.TenantTopic{{Tenant: "brand-a", Topic: "transactions-brand-a"}},
+			Topics:       []config.KafkaTopic{{Name: "cashier", Decoder: DecoderJSON, Tenant: "brand-b"}},
+		})
+		require.NoError(t, err)
+		assert.Len(t, topics, 3)
+		assert.Equal(t, "brand-a", topics["transactions-brand-a"].tenant)
+		assert.Equal(t, "brand-b", topics["cashier"].tenant)
+	})
+
+	t.Run("unknown decoder", func(t *testing.T) {
+		_, err := readerTopics(config.Kafka{Topics: []config.KafkaTopic{{Name: "cashier", Decoder: "xml"}}})
+		assert.ErrorContains(t, err, "unknown decoder")
+	})
+
+	t.Run("duplicate topic", func(t *testing.T) {
+		_, err := readerTopics(config.Kafka{Topic: "transactions", Topics: []config.KafkaTopic{{Name: "transactions"}}})
+		assert.ErrorContains(t, err, "configured twice")
+	})
+
+	t.Run("no topic", func(t *testing.T) {
+		_, err := readerTopics(config.Kafka{})
+		assert.Error(t, err)
+	})
+}
+
+func TestMessageDecoder_TenantOf(t *testing.T) {
+	decoder := &messageDecoder{}
+	header := []kafka.Header{{Key: defaultTenantHeader, Value: []byte("brand-h")}}
+
+	tests := []struct {
+		name          string
+		topic         topicReader
+		message       kafka.Message
+		payloadTenant string
+		expected      string
+	}{
+		{"tenant topic wins", topicReader{tenant: "brand-a"}, kafka.Message{Headers: header}, "brand-p", "brand-a"},
+		{"header before payload", topicReader{}, kafka.Message{Headers: header}, "brand-p", "brand-h"},
+		{"payload", topicReader{}, kafka.Message{}, "brand-p", "brand-p"},
+		{"default tenant", topicReader{}, kafka.Message{}, "", entity.DefaultTenantID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decoder.tenantOf(tt.message, tt.topic, tt.payloadTenant))
+		})
+	}
+}
@@ -11,13 +11,15 @@ import (
 
 var (
 	dtoToEntityTypeMap = map[api.TransactionType]entity.TransactionType{
-		api.TransactionType_TRANSACTION_TYPE_BET: entity.TransactionTypeBet,
-		api.TransactionType_TRANSACTION_TYPE_WIN: entity.TransactionTypeWin,
+		api.TransactionType_TRANSACTION_TYPE_BET:     entity.TransactionTypeBet,
+		api.TransactionType_TRANSACTION_TYPE_WIN:     entity.TransactionTypeWin,
+		api.TransactionType_TRANSACTION_TYPE_DEPOSIT: entity.TransactionTypeDeposit,
 	}
 
 	entityToDTOTypeMap = map[entity.TransactionType]api.TransactionType{
-		entity.TransactionTypeBet: api.TransactionType_TRANSACTION_TYPE_BET,
-		entity.TransactionTypeWin: api.TransactionType_TRANSACTION_TYPE_WIN,
+		entity.TransactionTypeBet:     api.TransactionType_TRANSACTION_TYPE_BET,
+		entity.TransactionTypeWin:     api.TransactionType_TRANSACTION_TYPE_WIN,
+		entity.TransactionTypeDeposit: api.TransactionType_TRANSACTION_TYPE_DEPOSIT,
 	}
 )
 
@@ -33,6 +35,8 @@ func TransformFromDTO(dto *api.TransactionEvent) (*entity.TransactionEvent, erro
 	}
 
 	return &entity.TransactionEvent{
+		EventID:  dto.EventId,
+		TenantID: dto.TenantId,
 		UserID: entity.UserID{
 			UUID: userId,
 		},
@@ -53,5 +57,7 @@ func TransformToDTO(event entity.TransactionEvent) (*api.TransactionEvent, error
 		TransactionType: transactionType,
 		Amount:          event.Amount.ToFloat(),
 		Timestamp:       timestamppb.New(event.CreatedAt),
+		EventId:         event.EventID,
+		TenantId:        event.TenantID,
 	}, nil
 }
@@ -3,19 +3,52 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/bsko/casino-transaction-system/api"
 	"github.com/bsko/casino-transaction-system/internal/config"
 	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionLz4    = "lz4"
+	CompressionZstd   = "zstd"
+
+	// BalancerHash routes messages by key, keeping messages with the same key
+	// in one partition and therefore in order.
+	BalancerHash = "hash"
+
+	eventTypeHeader = "event-type"
+	// faultHeader tags the messages with an injected fault with its kind.
+	faultHeader = "fault"
+
+	defaultTenantHeader = "tenant-id"
+
+	// paddingField is the field number of the padding of oversized
+	// messages, unknown to the TransactionEvent message.
+	paddingField = 1000
+	// unknownTransactionType is outside the TransactionType enum.
+	unknownTransactionType = api.TransactionType(99)
+	invalidUUID            = "not-a-uuid"
+)
+
 type KafkaWriter struct {
 	conf   config.Kafka
 	writer *kafka.Writer
+	async  bool
 }
 
+// delivery is called with the outcome of an asynchronously written message.
+type delivery func(err error)
+
 func NewKafkaWriter(conf config.Kafka) *KafkaWriter {
 	return &KafkaWriter{
 		conf: conf,
@@ -34,10 +67,25 @@ func (k *KafkaWriter) Connect(ctx context.Context) error {
 	k.writer = &kafka.Writer{
 		Addr:         kafka.TCP(k.conf.ConnectionString),
 		Topic:        k.conf.Topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     newBalancer(k.conf.Balancer),
 		RequiredAcks: kafka.RequiredAcks(k.conf.RequiredAcks),
 		MaxAttempts:  k.conf.MaxAttempts,
 	}
+	if conf := k.conf.Writer; conf != nil {
+		compression, err := newCompression(conf.Compression)
+		if err != nil {
+			return err
+		}
+		k.writer.Compression = compression
+		k.writer.BatchSize = conf.BatchSize
+		k.writer.BatchBytes = int64(conf.BatchBytes)
+		k.writer.BatchTimeout = time.Duration(conf.LingerMs) * time.Millisecond
+		if conf.Async {
+			k.writer.Async = true
+			k.writer.Completion = reportDeliveries
+			k.async = true
+		}
+	}
 	return nil
 }
 
@@ -46,24 +94,134 @@ func (k *KafkaWriter) Publish(ctx context.Context, event entity.TransactionEvent
 		return fmt.Errorf("kafka writer is not initialized, call Connect() first")
 	}
 
-	dto, err := TransformToDTO(event)
+	message, err := k.eventMessage(event, entity.Fault{})
 	if err != nil {
-		return fmt.Errorf("failed to transform event to DTO: %w", err)
+		return err
+	}
+	err = k.write(ctx, message)
+	if err != nil {
+		return fmt.Errorf("failed to write message to kafka: %w", err)
 	}
 
-	data, err := proto.Marshal(dto)
+	return nil
+}
+
+// PublishFault publishes the event with the fault injected into its message
+// and the fault kind in the fault header. Faults of the event itself, like
+// its timestamp, are applied by the caller.
+func (k *KafkaWriter) PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	if k.writer == nil {
+		return fmt.Errorf("kafka writer is not initialized, call Connect() first")
+	}
+
+	message, err := k.eventMessage(event, fault)
 	if err != nil {
-		return fmt.Errorf("failed to marshal protobuf: %w", err)
+		return err
+	}
+	if err = k.write(ctx, message); err != nil {
+		return fmt.Errorf("failed to write %s message to kafka: %w", fault.Kind, err)
+	}
+	return nil
+}
+
+// PublishAsync queues the event, with the fault when it has a kind, and
+// calls delivered with the outcome of its delivery. delivered is called
+// exactly once, from a goroutine of the writer, unless the event could not
+// be queued and PublishAsync returns an error. Without async mode the event
+// is written before PublishAsync returns.
+func (k *KafkaWriter) PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error {
+	if k.writer == nil {
+		return fmt.Errorf("kafka writer is not initialized, call Connect() first")
+	}
+
+	message, err := k.eventMessage(event, fault)
+	if err != nil {
+		return err
+	}
+	if !k.async {
+		delivered(k.writer.WriteMessages(ctx, message))
+		return nil
 	}
 
-	err = k.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(event.UserID.UUID.String()),
+	message.WriterData = delivery(delivered)
+	if err = k.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+	return nil
+}
+
+// eventMessage encodes the event, injecting the fault when it has a kind.
+func (k *KafkaWriter) eventMessage(event entity.TransactionEvent, fault entity.Fault) (kafka.Message, error) {
+	data, err := MarshalEvent(event, fault)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	key := []byte(event.UserID.UUID.String())
+	if fault.Kind == entity.FaultInvalidUUID {
+		key = []byte(invalidUUID)
+	}
+
+	message := kafka.Message{
+		Key:   key,
 		Value: data,
-	})
+	}
+	if event.TenantID != "" {
+		message.Headers = append(message.Headers, kafka.Header{Key: tenantHeader(k.conf), Value: []byte(event.TenantID)})
+	}
+	if fault.Kind != "" {
+		message.Headers = append(message.Headers, kafka.Header{Key: faultHeader, Value: []byte(fault.Kind)})
+	}
+	return message, nil
+}
+
+// MarshalEvent encodes the event as a protobuf message of the topic,
+// injecting the fault into the payload when it has a kind. The message
+// format is shared by every transport.
+func MarshalEvent(event entity.TransactionEvent, fault entity.Fault) ([]byte, error) {
+	dto, err := TransformToDTO(event)
 	if err != nil {
-		return fmt.Errorf("failed to write message to kafka: %w", err)
+		return nil, fmt.Errorf("failed to transform event to DTO: %w", err)
+	}
+	switch fault.Kind {
+	case entity.FaultUnknownEnum:
+		dto.TransactionType = unknownTransactionType
+	case entity.FaultInvalidUUID:
+		dto.UserId = invalidUUID
+	}
+
+	data, err := proto.Marshal(dto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+	switch fault.Kind {
+	case entity.FaultMalformed:
+		// cut the payload and end it with field number 0, which is invalid
+		data = append(data[:len(data)/2:len(data)/2], 0x07)
+	case entity.FaultOversized:
+		data = pad(data, fault.Size)
+	}
+	return data, nil
+}
+
+// PublishOutbox writes outbox messages in the given order, keyed by the
+// message key and with the event type in the event-type header.
+func (k *KafkaWriter) PublishOutbox(ctx context.Context, messages []entity.OutboxMessage) error {
+	if k.writer == nil {
+		return fmt.Errorf("kafka writer is not initialized, call Connect() first")
+	}
+
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		kafkaMessages = append(kafkaMessages, kafka.Message{
+			Key:     []byte(message.Key),
+			Value:   message.Payload,
+			Headers: []kafka.Header{{Key: eventTypeHeader, Value: []byte(message.EventType)}},
+		})
 	}
 
+	if err := k.write(ctx, kafkaMessages...); err != nil {
+		return fmt.Errorf("failed to write outbox messages to kafka: %w", err)
+	}
 	return nil
 }
 
@@ -75,3 +233,98 @@ func (k *KafkaWriter) Close() error {
 	}
 	return nil
 }
+
+// write writes the messages and waits for their delivery, also in async
+// mode.
+func (k *KafkaWriter) write(ctx context.Context, messages ...kafka.Message) error {
+	if !k.async || len(messages) == 0 {
+		return k.writer.WriteMessages(ctx, messages...)
+	}
+
+	var (
+		mu        sync.Mutex
+		remaining = len(messages)
+		failed    error
+		done      = make(chan struct{})
+	)
+	for i := range messages {
+		messages[i].WriterData = delivery(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && failed == nil {
+				failed = err
+			}
+			if remaining--; remaining == 0 {
+				close(done)
+			}
+		})
+	}
+	if err := k.writer.WriteMessages(ctx, messages...); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		mu.Lock()
+		defer mu.Unlock()
+		return failed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reportDeliveries passes the outcome of a written batch to the delivery of
+// every message.
+func reportDeliveries(messages []kafka.Message, err error) {
+	for _, message := range messages {
+		if report, ok := message.WriterData.(delivery); ok {
+			report(err)
+		}
+	}
+}
+
+func newCompression(name string) (kafka.Compression, error) {
+	switch name {
+	case "", CompressionNone:
+		return 0, nil
+	case CompressionGzip:
+		return kafka.Gzip, nil
+	case CompressionSnappy:
+		return kafka.Snappy, nil
+	case CompressionLz4:
+		return kafka.Lz4, nil
+	case CompressionZstd:
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unknown compression %q, must be one of none, gzip, snappy, lz4, zstd", name)
+	}
+}
+
+// pad appends an unknown field to the protobuf payload until it is size
+// bytes long. Decoders skip unknown fields, so the event stays valid.
+func pad(data []byte, size int) []byte {
+	rest := size - len(data) - protowire.SizeTag(paddingField)
+	if rest <= 1 {
+		return data
+	}
+	n := rest - protowire.SizeVarint(uint64(rest))
+	for n+protowire.SizeVarint(uint64(n)) < rest {
+		n++
+	}
+	data = protowire.AppendTag(data, paddingField, protowire.BytesType)
+	return protowire.AppendBytes(data, make([]byte, n))
+}
+
+func tenantHeader(conf config.Kafka) string {
+	if conf.TenantHeader != "" {
+		return conf.TenantHeader
+	}
+	return defaultTenantHeader
+}
+
+func newBalancer(name string) kafka.Balancer {
+	if name == BalancerHash {
+		return &kafka.Hash{}
+	}
+	return &kafka.LeastBytes{}
+}
@@ -0,0 +1,170 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaWriter_EventMessageFaults(t *testing.T) {
+	conf := config.Kafka{Topic: "transactions"}
+	writer := NewKafkaWriter(conf)
+	decoder, err := newMessageDecoder(conf)
+	require.NoError(t, err)
+
+	event := entity.TransactionEvent{
+		EventID:         "e1",
+		TenantID:        "brand-a",
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.ToMoney(12.5),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		fault    entity.Fault
+		rejected bool
+	}{
+		{entity.Fault{}, false},
+		{entity.Fault{Kind: entity.FaultMalformed}, true},
+		{entity.Fault{Kind: entity.FaultUnknownEnum}, true},
+		{entity.Fault{Kind: entity.FaultInvalidUUID}, true},
+		{entity.Fault{Kind: entity.FaultDuplicate}, false},
+		{entity.Fault{Kind: entity.FaultOversized, Size: 64 * 1024}, false},
+	}
+	for _, tt := range tests {
+		t.Run("fault "+tt.fault.Kind, func(t *testing.T) {
+			message, err := writer.eventMessage(event, tt.fault)
+			require.NoError(t, err)
+			assert.Equal(t, tt.fault.Kind, headerValue(message, faultHeader))
+			message.Topic = conf.Topic
+
+			consumed, err := decoder.decode(message)
+			if tt.rejected {
+				var invalid *entity.InvalidMessageError
+				require.ErrorAs(t, err, &invalid)
+				assert.Equal(t, tt.fault.Kind, invalid.Fault)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.fault.Kind, consumed.Fault)
+			assert.Equal(t, event, consumed.Event)
+		})
+	}
+
+	t.Run("oversized payload has the requested size", func(t *testing.T) {
+		for _, size := range []int{200, 1 << 14, 2 << 20} {
+			message, err := writer.eventMessage(event, entity.Fault{Kind: entity.FaultOversized, Size: size})
+			require.NoError(t, err)
+			assert.Len(t, message.Value, size)
+		}
+	})
+}
+
+func TestKafkaWriter_Async(t *testing.T) {
+	newWriter := func(t *testing.T, produceError int16) *KafkaWriter {
+		writer := NewKafkaWriter(config.Kafka{
+			ConnectionString: "broker:9092",
+			Topic:            "transactions",
+			RequiredAcks:     int(kafka.RequireAll),
+			MaxAttempts:      1,
+			Writer:           &config.KafkaWriter{Async: true, BatchSize: 2, LingerMs: 5, Compression: CompressionZstd},
+		})
+		require.NoError(t, writer.Connect(context.Background()))
+		writer.writer.Transport = &brokerStub{produceError: produceError}
+		t.Cleanup(func() { _ = writer.Close() })
+		return writer
+	}
+	event := entity.TransactionEvent{
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeBet,
+		CreatedAt:       time.Now(),
+	}
+	awaitDelivery := func(t *testing.T, delivered <-chan error) error {
+		select {
+		case err := <-delivered:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("delivery was not reported")
+			return nil
+		}
+	}
+
+	t.Run("every event gets its delivery report", func(t *testing.T) {
+		writer := newWriter(t, 0)
+		delivered := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, writer.PublishAsync(context.Background(), event, entity.Fault{}, func(err error) {
+				delivered <- err
+			}))
+		}
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, awaitDelivery(t, delivered))
+		}
+	})
+
+	t.Run("failed delivery is reported", func(t *testing.T) {
+		writer := newWriter(t, int16(kafka.MessageSizeTooLarge))
+		delivered := make(chan error, 1)
+		require.NoError(t, writer.PublishAsync(context.Background(), event, entity.Fault{Kind: entity.FaultOversized}, func(err error) {
+			delivered <- err
+		}))
+		assert.ErrorIs(t, awaitDelivery(t, delivered), kafka.MessageSizeTooLarge)
+	})
+
+	t.Run("publish waits for the delivery", func(t *testing.T) {
+		assert.NoError(t, newWriter(t, 0).Publish(context.Background(), event))
+		assert.ErrorIs(t, newWriter(t, int16(kafka.MessageSizeTooLarge)).Publish(context.Background(), event), kafka.MessageSizeTooLarge)
+	})
+}
+
+// brokerStub answers the requests of the writer like a single broker
+// leading the only partition of every topic.
+type brokerStub struct {
+	produceError int16
+}
+
+func (b *brokerStub) RoundTrip(_ context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		response := &metadata.Response{Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "broker", Port: 9092}}}
+		for _, topic := range req.TopicNames {
+			response.Topics = append(response.Topics, metadata.ResponseTopic{
+				Name:       topic,
+				Partitions: []metadata.ResponsePartition{{PartitionIndex: 0, LeaderID: 1}},
+			})
+		}
+		return response, nil
+	case *produce.Request:
+		response := &produce.Response{}
+		for _, topic := range req.Topics {
+			response.Topics = append(response.Topics, produce.ResponseTopic{
+				Topic:      topic.Topic,
+				Partitions: []produce.ResponsePartition{{Partition: 0, ErrorCode: b.produceError}},
+			})
+		}
+		return response, nil
+	default:
+		return nil, fmt.Errorf("unexpected request %T", req)
+	}
+}
+
+func TestNewCompression(t *testing.T) {
+	for _, name := range []string{"", CompressionNone, CompressionGzip, CompressionSnappy, CompressionLz4, CompressionZstd} {
+		_, err := newCompression(name)
+		assert.NoError(t, err, name)
+	}
+	_, err := newCompression("brotli")
+	assert.ErrorContains(t, err, "unknown compression")
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"sync"
+)
+
+// Topic is the topic of the events read from a broker.
+const Topic = "transactions"
+
+var (
+	sharedOnce   sync.Once
+	sharedBroker *Broker
+)
+
+// message is a published event, encoded like a Kafka message so that
+// injected faults reach the reader as they would through Kafka.
+type message struct {
+	data     []byte
+	tenantID string
+	fault    string
+}
+
+// Broker is an in-process queue of published events with a single committed
+// offset, the consumer group of Kafka. Readers start at the committed offset,
+// so events read and not committed are read again by the next reader.
+// Committed events are dropped.
+type Broker struct {
+	mu sync.Mutex
+	// offset of messages[0]
+	base      int64
+	messages  []message
+	committed int64
+	// published is closed and replaced on every publish
+	published chan struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{published: make(chan struct{})}
+}
+
+// Shared returns the broker of the process, which connects the memory
+// transport of a producer and a consumer running in the same process.
+func Shared() *Broker {
+	sharedOnce.Do(func() {
+		sharedBroker = NewBroker()
+	})
+	return sharedBroker
+}
+
+// Pending returns the number of published events not committed yet.
+func (b *Broker) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.messages)
+}
+
+func (b *Broker) publish(m message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, m)
+	close(b.published)
+	b.published = make(chan struct{})
+}
+
+// fetch returns the message at the offset, skipping committed messages. When
+// the offset is not published yet it returns a channel closed on the next
+// publish instead.
+func (b *Broker) fetch(offset int64) (message, int64, <-chan struct{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	offset = max(offset, b.base)
+	if i := offset - b.base; i < int64(len(b.messages)) {
+		return b.messages[i], offset, nil, true
+	}
+	return message{}, offset, b.published, false
+}
+
+func (b *Broker) committedOffset() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed
+}
+
+// commit moves the committed offset forward and drops the committed
+// messages.
+func (b *Broker) commit(offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if offset <= b.committed {
+		return
+	}
+	b.committed = offset
+	n := min(offset-b.base, int64(len(b.messages)))
+	clear(b.messages[:n])
+	b.messages = b.messages[n:]
+	b.base += n
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer"
+	"github.com/bsko/casino-transaction-system/internal/services/producer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(eventID, tenantID string) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		EventID:         eventID,
+		TenantID:        tenantID,
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeWin,
+		Amount:          entity.ToMoney(12.5),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func connectReader(t *testing.T, broker *Broker) *MemoryReader {
+	reader := NewMemoryReader(broker)
+	require.NoError(t, reader.Connect(context.Background()))
+	return reader
+}
+
+func read(t *testing.T, reader *MemoryReader) (*entity.ConsumedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return reader.Read(ctx)
+}
+
+func TestMemory_PublishAndRead(t *testing.T) {
+	broker := NewBroker()
+	writer := NewMemoryWriter(broker)
+	reader := connectReader(t, broker)
+
+	win := testEvent("e1", "brand-a")
+	require.NoError(t, writer.Publish(context.Background(), win))
+	require.NoError(t, writer.PublishFault(context.Background(), testEvent("e2", ""), entity.Fault{Kind: entity.FaultMalformed}))
+	var delivered error = errors.New("not delivered")
+	require.NoError(t, writer.PublishAsync(context.Background(), testEvent("e3", ""), entity.Fault{}, func(err error) { delivered = err }))
+	assert.NoError(t, delivered)
+
+	consumed, err := read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, Topic, consumed.Topic)
+	assert.Equal(t, win.EventID, consumed.Event.EventID)
+	assert.Equal(t, win.UserID, consumed.Event.UserID)
+	assert.Equal(t, win.Amount, consumed.Event.Amount)
+	assert.Equal(t, "brand-a", consumed.Event.TenantID)
+
+	_, err = read(t, reader)
+	var invalid *entity.InvalidMessageError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, entity.FaultMalformed, invalid.Fault)
+
+	consumed, err = read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, "e3", consumed.Event.EventID)
+	assert.Equal(t, entity.DefaultTenantID, consumed.Event.TenantID)
+}
+
+func TestMemory_ReadWaitsForPublish(t *testing.T) {
+	broker := NewBroker()
+	reader := connectReader(t, broker)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = NewMemoryWriter(broker).Publish(context.Background(), testEvent("e1", ""))
+	}()
+	consumed, err := read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, "e1", consumed.Event.EventID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = reader.Read(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemory_UncommittedEventsAreReadAgain(t *testing.T) {
+	broker := NewBroker()
+	writer := NewMemoryWriter(broker)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		require.NoError(t, writer.Publish(context.Background(), testEvent(id, "")))
+	}
+
+	reader := connectReader(t, broker)
+	_, err := read(t, reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Commit(context.Background()))
+	_, err = read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, 2, broker.Pending())
+
+	// a new reader starts at the commit, e2 was read and not committed
+	reader = connectReader(t, broker)
+	consumed, err := read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, "e2", consumed.Event.EventID)
+	consumed, err = read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, "e3", consumed.Event.EventID)
+	require.NoError(t, reader.Commit(context.Background()))
+	assert.Equal(t, 0, broker.Pending())
+}
+
+func TestMemory_NotConnected(t *testing.T) {
+	reader := NewMemoryReader(NewBroker())
+	_, err := read(t, reader)
+	assert.Error(t, err)
+	assert.Error(t, reader.Commit(context.Background()))
+}
+
+// TestMemory_ProducerToAPI runs the producer, the consumer and the query
+// processor of the API in one process.
+func TestMemory_ProducerToAPI(t *testing.T) {
+	broker := NewBroker()
+	repo := NewTransactionEventRepository()
+
+	consumerService := consumer.NewConsumer(connectReader(t, broker), repo)
+	consumerService.SetBatchSize(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumed := make(chan error, 1)
+	go func() { consumed <- consumerService.Start(ctx) }()
+
+	producerService := producer.NewProducer(config.Producer{
+		InitialBatchSize: 10,
+		DistinctUsers:    3,
+		AmountFrom:       10,
+		AmountTo:         100,
+		TenantID:         "brand-a",
+	}, NewMemoryWriter(broker))
+	runCtx, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer stop()
+	require.NoError(t, producerService.Start(runCtx))
+
+	processor := consumer.NewGetListProcessor(repo)
+	tenantID := "brand-a"
+	require.Eventually(t, func() bool {
+		events, err := processor.GetListByFilter(ctx, entity.TransactionEventFilter{TenantID: &tenantID})
+		return err == nil && len(events) == 10
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, broker.Pending())
+
+	stats, err := processor.GetStats(ctx, entity.TransactionEventFilter{TenantID: &tenantID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), stats.BetCount+stats.WinCount)
+
+	cancel()
+	<-consumed
+}
+
+// TestMemory_InjectedFaults runs the producer injecting every fault the
+// consumer copes with and checks how the consumer counted each of them.
+func TestMemory_InjectedFaults(t *testing.T) {
+	broker := NewBroker()
+	repo := NewTransactionEventRepository()
+
+	consumerService := consumer.NewConsumer(connectReader(t, broker), repo)
+	// the number of messages depends on the duplicates injected
+	consumerService.SetBatchSize(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumed := make(chan error, 1)
+	go func() { consumed <- consumerService.Start(ctx) }()
+
+	producerService := producer.NewProducer(config.Producer{
+		InitialBatchSize: 60,
+		DistinctUsers:    3,
+		AmountFrom:       10,
+		AmountTo:         100,
+	}, NewMemoryWriter(broker))
+	require.NoError(t, producerService.SetFaults(config.ProducerFaults{
+		MalformedPercent:   15,
+		UnknownEnumPercent: 15,
+		InvalidUUIDPercent: 15,
+		DuplicatePercent:   15,
+		OutOfOrderPercent:  20,
+		FarFuturePercent:   20,
+	}))
+	runCtx, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer stop()
+	require.NoError(t, producerService.Start(runCtx))
+	report := producerService.Report()
+
+	var injected int64
+	for _, count := range report.Faults {
+		injected += count
+	}
+	require.Equal(t, int64(60), injected, "every event carries a fault")
+	require.Eventually(t, func() bool { return broker.Pending() == 0 }, time.Second, 10*time.Millisecond)
+
+	stats := consumerService.TopicStats()
+	require.Len(t, stats, 1)
+	faults := stats[0].Faults
+	for _, kind := range []string{entity.FaultMalformed, entity.FaultUnknownEnum, entity.FaultInvalidUUID} {
+		assert.Equal(t, entity.FaultStats{Rejected: report.Faults[kind]}, faults[kind], "%s messages cannot be decoded", kind)
+	}
+	// the first copy of a duplicate carries no fault
+	for _, kind := range []string{entity.FaultDuplicate, entity.FaultOutOfOrder, entity.FaultFarFuture} {
+		count := report.Faults[kind]
+		assert.Equal(t, entity.FaultStats{Received: count, Stored: count}, faults[kind], "%s events are stored", kind)
+	}
+
+	events, err := repo.GetListByFilter(ctx, entity.TransactionEventFilter{})
+	require.NoError(t, err)
+	stored := report.Faults[entity.FaultOutOfOrder] + report.Faults[entity.FaultFarFuture] + 2*report.Faults[entity.FaultDuplicate]
+	assert.Len(t, events, int(stored))
+
+	cancel()
+	<-consumed
+}
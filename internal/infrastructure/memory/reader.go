@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+)
+
+// MemoryReader reads the events of a broker. Like the offsets of the Kafka
+// reader, the events read are committed by Commit only.
+type MemoryReader struct {
+	broker *Broker
+
+	mu sync.Mutex
+	// next offset to read, -1 until Connect
+	offset int64
+}
+
+func NewMemoryReader(broker *Broker) *MemoryReader {
+	return &MemoryReader{broker: broker, offset: -1}
+}
+
+// Connect starts reading at the committed offset of the broker.
+func (r *MemoryReader) Connect(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offset = r.broker.committedOffset()
+	return nil
+}
+
+// Read returns the next event, waiting for it to be published. A message that
+// cannot be decoded is reported with an entity.InvalidMessageError.
+func (r *MemoryReader) Read(ctx context.Context) (*entity.ConsumedEvent, error) {
+	for {
+		r.mu.Lock()
+		if r.offset < 0 {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("memory reader is not initialized, call Connect() first")
+		}
+		m, offset, published, ok := r.broker.fetch(r.offset)
+		if ok {
+			r.offset = offset + 1
+		}
+		r.mu.Unlock()
+		if ok {
+			return decodeMessage(m)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to read message from memory: %w", ctx.Err())
+		case <-published:
+		}
+	}
+}
+
+func decodeMessage(m message) (*entity.ConsumedEvent, error) {
+	event, err := kafka.UnmarshalEvent(m.data)
+	if err != nil {
+		return nil, &entity.InvalidMessageError{Topic: Topic, Fault: m.fault, Err: err}
+	}
+	switch {
+	case m.tenantID != "":
+		event.TenantID = m.tenantID
+	case event.TenantID == "":
+		event.TenantID = entity.DefaultTenantID
+	}
+	return &entity.ConsumedEvent{Topic: Topic, Event: *event, Fault: m.fault}, nil
+}
+
+// Commit commits the events read so far.
+func (r *MemoryReader) Commit(_ context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.offset < 0 {
+		return fmt.Errorf("memory reader is not initialized")
+	}
+	r.broker.commit(r.offset)
+	return nil
+}
+
+func (r *MemoryReader) Close() error {
+	return nil
+}
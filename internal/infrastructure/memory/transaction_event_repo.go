@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// defaultLimit is the page size of the SQL repositories.
+const defaultLimit = 1000
+
+// TransactionEventRepository keeps the stored events in memory. Its filters,
+// ordering and paging are those of the SQL repositories, which the
+// repotest suite verifies for both.
+type TransactionEventRepository struct {
+	mu sync.RWMutex
+	// events in the order they were stored
+	events  []entity.TransactionEvent
+	derived entity.DerivedRecords
+}
+
+func NewTransactionEventRepository() *TransactionEventRepository {
+	return &TransactionEventRepository{}
+}
+
+// GetListByFilter returns the events of the filter, newest first.
+func (t *TransactionEventRepository) GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mu.RLock()
+	// newest stored first among events created at the same time
+	events := make([]entity.TransactionEvent, 0)
+	for i := len(t.events) - 1; i >= 0; i-- {
+		if filter.Matches(t.events[i]) && (filter.After == nil || filter.After.Precedes(t.events[i])) {
+			events = append(events, t.events[i])
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+
+	limit := filter.Limit
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
+	}
+	offset := min(max(filter.Offset, 0), len(events))
+	events = events[offset:]
+	return events[:min(limit, len(events))], nil
+}
+
+func (t *TransactionEventRepository) GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error) {
+	stats, err := t.GetStats(ctx, entity.TransactionEventFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.UserBalance{
+		UserID:   userID,
+		TotalBet: stats.BetAmount,
+		TotalWin: stats.WinAmount,
+	}, nil
+}
+
+func (t *TransactionEventRepository) GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var stats entity.TransactionStats
+	for _, event := range t.events {
+		if !filter.Matches(event) {
+			continue
+		}
+		switch event.TransactionType {
+		case entity.TransactionTypeBet:
+			stats.BetCount++
+			stats.BetAmount += event.Amount
+		case entity.TransactionTypeWin:
+			stats.WinCount++
+			stats.WinAmount += event.Amount
+		}
+	}
+	return &stats, nil
+}
+
+// HasTransaction reports whether the user has a stored transaction of the
+// given type.
+func (t *TransactionEventRepository) HasTransaction(_ context.Context, userID entity.UserID, transactionType entity.TransactionType) (bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, event := range t.events {
+		if event.UserID == userID && event.TransactionType == transactionType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// StoredEventIDs returns the given event ids that are already stored.
+func (t *TransactionEventRepository) StoredEventIDs(_ context.Context, eventIDs []string) (map[string]struct{}, error) {
+	wanted := make(map[string]struct{}, len(eventIDs))
+	for _, id := range eventIDs {
+		wanted[id] = struct{}{}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stored := make(map[string]struct{}, len(eventIDs))
+	for _, event := range t.events {
+		if _, ok := wanted[event.EventID]; ok {
+			stored[event.EventID] = struct{}{}
+		}
+	}
+	return stored, nil
+}
+
+// BatchStore stores the batch like the SQL repositories do: without tenant
+// in the default tenant and with the creation time in microseconds.
+func (t *TransactionEventRepository) BatchStore(_ context.Context, batch []entity.TransactionEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store(batch)
+	return nil
+}
+
+// BatchStoreWithDerived stores the batch and keeps the records derived from
+// it, nothing relays them. Limits need postgres and are not checked.
+func (t *TransactionEventRepository) BatchStoreWithDerived(_ context.Context, batch []entity.TransactionEvent, derived entity.DerivedRecords) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store(batch)
+	t.derived.OutboxMessages = append(t.derived.OutboxMessages, derived.OutboxMessages...)
+	t.derived.WebhookDeliveries = append(t.derived.WebhookDeliveries, derived.WebhookDeliveries...)
+	return nil
+}
+
+// Outbox returns the outbox messages stored with the batches.
+func (t *TransactionEventRepository) Outbox() []entity.OutboxMessage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]entity.OutboxMessage(nil), t.derived.OutboxMessages...)
+}
+
+// store must be called with t.mu held.
+func (t *TransactionEventRepository) store(batch []entity.TransactionEvent) {
+	for _, event := range batch {
+		if event.TenantID == "" {
+			event.TenantID = entity.DefaultTenantID
+		}
+		event.CreatedAt = event.CreatedAt.Round(time.Microsecond)
+		event.ID = int64(len(t.events) + 1)
+		t.events = append(t.events, event)
+	}
+}
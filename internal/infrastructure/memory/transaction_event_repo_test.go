@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionEventRepository_Conformance(t *testing.T) {
+	repotest.RunTransactionEventRepository(t, NewTransactionEventRepository())
+}
+
+func TestTransactionEventRepository_BatchStoreWithDerived(t *testing.T) {
+	repo := NewTransactionEventRepository()
+	event := entity.TransactionEvent{UserID: entity.UserID{UUID: uuid.New()}, TransactionType: entity.TransactionTypeWin, Amount: 100, CreatedAt: time.Now()}
+	message := entity.OutboxMessage{Key: event.UserID.UUID.String(), EventType: entity.OutboxEventBalanceChanged}
+
+	require.NoError(t, repo.BatchStoreWithDerived(context.Background(), []entity.TransactionEvent{event}, entity.DerivedRecords{OutboxMessages: []entity.OutboxMessage{message}}))
+
+	events, err := repo.GetListByFilter(context.Background(), entity.TransactionEventFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, []entity.OutboxMessage{message}, repo.Outbox())
+}
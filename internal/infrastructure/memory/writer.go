@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+)
+
+// MemoryWriter publishes events to a broker. A publish is delivered once it
+// returns.
+type MemoryWriter struct {
+	broker *Broker
+}
+
+func NewMemoryWriter(broker *Broker) *MemoryWriter {
+	return &MemoryWriter{broker: broker}
+}
+
+func (w *MemoryWriter) Connect(_ context.Context) error {
+	return nil
+}
+
+func (w *MemoryWriter) Publish(ctx context.Context, event entity.TransactionEvent) error {
+	return w.PublishFault(ctx, event, entity.Fault{})
+}
+
+// PublishFault publishes the event with the fault injected into its message,
+// like the Kafka writer.
+func (w *MemoryWriter) PublishFault(_ context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	data, err := kafka.MarshalEvent(event, fault)
+	if err != nil {
+		return err
+	}
+	w.broker.publish(message{data: data, tenantID: event.TenantID, fault: fault.Kind})
+	return nil
+}
+
+// PublishAsync publishes the event and calls delivered before it returns.
+func (w *MemoryWriter) PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error {
+	delivered(w.PublishFault(ctx, event, fault))
+	return nil
+}
+
+func (w *MemoryWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,202 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer runs an embedded server for the test and returns the config
+// of a client of it.
+func startServer(t *testing.T) config.Nats {
+	srv, err := StartEmbeddedServer(config.NatsEmbedded{Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		srv.Shutdown()
+		srv.WaitForShutdown()
+	})
+	return config.Nats{URL: srv.ClientURL(), AckWaitSeconds: 1}
+}
+
+func testEvent(eventID, tenantID string) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		EventID:         eventID,
+		TenantID:        tenantID,
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeWin,
+		Amount:          entity.ToMoney(12.5),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func connectWriter(t *testing.T, conf config.Nats) *NatsWriter {
+	writer := NewNatsWriter(conf)
+	require.NoError(t, writer.Connect(context.Background()))
+	t.Cleanup(func() { _ = writer.Close() })
+	return writer
+}
+
+func connectReader(t *testing.T, conf config.Nats) *NatsReader {
+	reader := NewNatsReader(conf)
+	require.NoError(t, reader.Connect(context.Background()))
+	t.Cleanup(func() { _ = reader.Close() })
+	return reader
+}
+
+func read(t *testing.T, reader *NatsReader) (*entity.ConsumedEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return reader.Read(ctx)
+}
+
+func TestNats_PublishAndRead(t *testing.T) {
+	conf := startServer(t)
+	writer := connectWriter(t, conf)
+	reader := connectReader(t, conf)
+
+	bet := testEvent("e1", "brand-a")
+	require.NoError(t, writer.Publish(context.Background(), bet))
+	require.NoError(t, writer.PublishFault(context.Background(), testEvent("e2", ""), entity.Fault{Kind: entity.FaultMalformed}))
+	require.NoError(t, writer.Publish(context.Background(), testEvent("e3", "")))
+
+	consumed, err := read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSubject, consumed.Topic)
+	assert.Equal(t, bet.EventID, consumed.Event.EventID)
+	assert.Equal(t, bet.UserID, consumed.Event.UserID)
+	assert.Equal(t, bet.Amount, consumed.Event.Amount)
+	assert.Equal(t, "brand-a", consumed.Event.TenantID)
+
+	_, err = read(t, reader)
+	var invalid *entity.InvalidMessageError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, entity.FaultMalformed, invalid.Fault)
+
+	consumed, err = read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, entity.DefaultTenantID, consumed.Event.TenantID)
+	require.NoError(t, reader.Commit(context.Background()))
+}
+
+func TestNats_UncommittedEventsAreRedelivered(t *testing.T) {
+	conf := startServer(t)
+	writer := connectWriter(t, conf)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		require.NoError(t, writer.Publish(context.Background(), testEvent(id, "")))
+	}
+
+	first := NewNatsReader(conf)
+	require.NoError(t, first.Connect(context.Background()))
+	consumed, err := read(t, first)
+	require.NoError(t, err)
+	assert.Equal(t, "e1", consumed.Event.EventID)
+	require.NoError(t, first.Commit(context.Background()))
+	consumed, err = read(t, first)
+	require.NoError(t, err)
+	assert.Equal(t, "e2", consumed.Event.EventID)
+	// stops before committing e2
+	require.NoError(t, first.Close())
+
+	second := connectReader(t, conf)
+	var ids []string
+	for len(ids) < 2 {
+		consumed, err = read(t, second)
+		require.NoError(t, err)
+		ids = append(ids, consumed.Event.EventID)
+	}
+	assert.ElementsMatch(t, []string{"e2", "e3"}, ids, "e1 is committed, e2 is redelivered after the ack wait")
+	require.NoError(t, second.Commit(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	_, err = second.Read(ctx)
+	assert.Error(t, err, "nothing is left after the commit")
+}
+
+func TestNats_AsyncWriter(t *testing.T) {
+	conf := startServer(t)
+	conf.Async = true
+	writer := connectWriter(t, conf)
+	reader := connectReader(t, conf)
+
+	delivered := make(chan error, 3)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		require.NoError(t, writer.PublishAsync(context.Background(), testEvent(id, ""), entity.Fault{}, func(err error) {
+			delivered <- err
+		}))
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-delivered:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("delivery was not reported")
+		}
+	}
+
+	for _, id := range []string{"e1", "e2", "e3"} {
+		consumed, err := read(t, reader)
+		require.NoError(t, err)
+		assert.Equal(t, id, consumed.Event.EventID)
+	}
+}
+
+func TestNats_EmbeddedServer(t *testing.T) {
+	conf := config.Nats{
+		Embedded: &config.NatsEmbedded{Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()},
+		Stream:   "EDGE",
+		Subject:  "edge.transactions",
+	}
+	writer := connectWriter(t, conf)
+	require.NoError(t, writer.Publish(context.Background(), testEvent("e1", "")))
+
+	// other processes connect through the port of the embedded server
+	reader := connectReader(t, config.Nats{URL: writer.server.ClientURL(), Stream: "EDGE", Subject: "edge.transactions"})
+	consumed, err := read(t, reader)
+	require.NoError(t, err)
+	assert.Equal(t, "edge.transactions", consumed.Topic)
+	assert.Equal(t, "e1", consumed.Event.EventID)
+}
+
+func TestNats_InvalidMessagesAreNotRedelivered(t *testing.T) {
+	conf := startServer(t)
+	writer := connectWriter(t, conf)
+	require.NoError(t, writer.PublishFault(context.Background(), testEvent("e1", ""), entity.Fault{Kind: entity.FaultMalformed}))
+
+	first := NewNatsReader(conf)
+	require.NoError(t, first.Connect(context.Background()))
+	_, err := read(t, first)
+	var invalid *entity.InvalidMessageError
+	require.True(t, errors.As(err, &invalid))
+	// stops without committing
+	require.NoError(t, first.Close())
+
+	second := connectReader(t, conf)
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	_, err = second.Read(ctx)
+	assert.Error(t, err, "the invalid message is terminated when read")
+}
+
+func TestNats_EmbeddedServerIsShared(t *testing.T) {
+	conf := config.Nats{Embedded: &config.NatsEmbedded{Host: "127.0.0.1", Port: -1, StoreDir: t.TempDir()}}
+	writer := connectWriter(t, conf)
+	reader := NewNatsReader(conf)
+	require.NoError(t, reader.Connect(context.Background()))
+	require.Same(t, writer.server, reader.server, "readers and writers of the process share the server")
+
+	// the server keeps running for the writer when the reader closes
+	require.NoError(t, reader.Close())
+	require.NoError(t, writer.Publish(context.Background(), testEvent("e1", "")))
+
+	srv := writer.server
+	require.NoError(t, writer.Close())
+	assert.False(t, srv.Running(), "the last one to close shuts the server down")
+}
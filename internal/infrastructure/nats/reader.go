@@ -0,0 +1,170 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// pullBatch is the number of messages requested from the server at once.
+	pullBatch = 500
+	// maxParallelAcks bounds the acknowledgements waited for at once.
+	maxParallelAcks = 64
+)
+
+// NatsReader reads the events of the stream through a durable pull consumer.
+// Like the offsets of the Kafka reader, messages are acknowledged by Commit
+// only; messages read and not committed are redelivered after the ack wait.
+type NatsReader struct {
+	conf     config.Nats
+	server   *server.Server
+	conn     *nats.Conn
+	messages jetstream.MessagesContext
+	pending  []jetstream.Msg
+	mu       sync.Mutex
+}
+
+func NewNatsReader(conf config.Nats) *NatsReader {
+	return &NatsReader{conf: conf}
+}
+
+func (n *NatsReader) Connect(ctx context.Context) error {
+	conn, srv, err := connect(n.conf)
+	if err != nil {
+		return err
+	}
+	n.conn, n.server = conn, srv
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	if err = ensureStream(ctx, js, n.conf); err != nil {
+		return err
+	}
+
+	ackWait := time.Duration(n.conf.AckWaitSeconds) * time.Second
+	if ackWait <= 0 {
+		ackWait = defaultAckWait
+	}
+	maxAckPending := n.conf.MaxAckPending
+	if maxAckPending <= 0 {
+		maxAckPending = defaultMaxAckPending
+	}
+	durable := n.conf.Durable
+	if durable == "" {
+		durable = defaultDurable
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, streamName(n.conf), jetstream.ConsumerConfig{
+		Durable:       durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+		MaxAckPending: maxAckPending,
+		FilterSubject: subject(n.conf),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create nats consumer: %w", err)
+	}
+	n.messages, err = consumer.Messages(jetstream.PullMaxMessages(min(pullBatch, maxAckPending)))
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to nats consumer: %w", err)
+	}
+	return nil
+}
+
+// Read returns the next event. A message that cannot be decoded is
+// terminated right away, so it is not redelivered, and reported with an
+// entity.InvalidMessageError.
+func (n *NatsReader) Read(ctx context.Context) (*entity.ConsumedEvent, error) {
+	if n.messages == nil {
+		return nil, fmt.Errorf("nats reader is not initialized, call Connect() first")
+	}
+
+	msg, err := n.messages.Next(jetstream.NextContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message from nats: %w", err)
+	}
+
+	topic := msg.Subject()
+	fault := msg.Headers().Get(faultHeader)
+	event, err := kafka.UnmarshalEvent(msg.Data())
+	if err != nil {
+		if termErr := msg.Term(); termErr != nil {
+			return nil, fmt.Errorf("failed to terminate invalid nats message: %w", termErr)
+		}
+		return nil, &entity.InvalidMessageError{Topic: topic, Fault: fault, Err: err}
+	}
+
+	n.mu.Lock()
+	n.pending = append(n.pending, msg)
+	n.mu.Unlock()
+
+	switch {
+	case msg.Headers().Get(tenantHeader) != "":
+		event.TenantID = msg.Headers().Get(tenantHeader)
+	case event.TenantID == "":
+		event.TenantID = entity.DefaultTenantID
+	}
+	return &entity.ConsumedEvent{Topic: topic, Event: *event, Fault: fault}, nil
+}
+
+// Commit acknowledges every message read so far and waits for the server to
+// confirm. Messages whose acknowledgement failed are redelivered.
+func (n *NatsReader) Commit(ctx context.Context) error {
+	if n.messages == nil {
+		return fmt.Errorf("nats reader is not initialized")
+	}
+
+	n.mu.Lock()
+	messages := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed error
+		slots  = make(chan struct{}, maxParallelAcks)
+	)
+	for _, msg := range messages {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := msg.DoubleAck(ctx); err != nil {
+				mu.Lock()
+				if failed == nil {
+					failed = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if failed != nil {
+		return fmt.Errorf("failed to acknowledge nats messages: %w", failed)
+	}
+	return nil
+}
+
+func (n *NatsReader) Close() error {
+	if n.messages != nil {
+		n.messages.Stop()
+	}
+	disconnect(n.conn, n.server)
+	// a second Close must not release the shared server again
+	n.conn, n.server = nil, nil
+	return nil
+}
@@ -0,0 +1,161 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultStream        = "TRANSACTIONS"
+	defaultSubject       = "transactions"
+	defaultDurable       = "consumer"
+	defaultAckWait       = 30 * time.Second
+	defaultMaxAckPending = 10000
+
+	// faultHeader and tenantHeader carry what the Kafka headers carry.
+	faultHeader  = "fault"
+	tenantHeader = "tenant-id"
+
+	readyTimeout = 10 * time.Second
+)
+
+// StartEmbeddedServer runs a JetStream enabled server in the process.
+func StartEmbeddedServer(conf config.NatsEmbedded) (*server.Server, error) {
+	opts := &server.Options{
+		Host:      conf.Host,
+		Port:      conf.Port,
+		JetStream: true,
+		StoreDir:  conf.StoreDir,
+		NoSigs:    true,
+		NoLog:     true,
+	}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nats server: %w", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(readyTimeout) {
+		srv.Shutdown()
+		return nil, fmt.Errorf("nats server is not ready after %s", readyTimeout)
+	}
+	return srv, nil
+}
+
+// embeddedServers are the servers started by connect, keyed by their config.
+// Readers and writers of the same config share one server, which is shut
+// down when the last of them disconnects.
+var embeddedServers = struct {
+	mu      sync.Mutex
+	servers map[config.NatsEmbedded]*sharedServer
+}{servers: make(map[config.NatsEmbedded]*sharedServer)}
+
+type sharedServer struct {
+	srv  *server.Server
+	refs int
+}
+
+// acquireServer returns the embedded server of the config, starting it when
+// it is not running yet.
+func acquireServer(conf config.NatsEmbedded) (*server.Server, error) {
+	embeddedServers.mu.Lock()
+	defer embeddedServers.mu.Unlock()
+
+	if shared, ok := embeddedServers.servers[conf]; ok {
+		shared.refs++
+		return shared.srv, nil
+	}
+	srv, err := StartEmbeddedServer(conf)
+	if err != nil {
+		return nil, err
+	}
+	embeddedServers.servers[conf] = &sharedServer{srv: srv, refs: 1}
+	return srv, nil
+}
+
+// releaseServer shuts the embedded server down once no reader or writer
+// uses it anymore.
+func releaseServer(srv *server.Server) {
+	embeddedServers.mu.Lock()
+	defer embeddedServers.mu.Unlock()
+
+	for conf, shared := range embeddedServers.servers {
+		if shared.srv != srv {
+			continue
+		}
+		if shared.refs--; shared.refs > 0 {
+			return
+		}
+		delete(embeddedServers.servers, conf)
+		srv.Shutdown()
+		srv.WaitForShutdown()
+		return
+	}
+}
+
+// connect connects to the server of the config, starting the embedded server
+// unless another reader or writer of the process already did.
+func connect(conf config.Nats) (*nats.Conn, *server.Server, error) {
+	if conf.Embedded == nil {
+		conn, err := nats.Connect(conf.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to nats: %w", err)
+		}
+		return conn, nil, nil
+	}
+
+	srv, err := acquireServer(*conf.Embedded)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := nats.Connect(srv.ClientURL(), nats.InProcessServer(srv))
+	if err != nil {
+		releaseServer(srv)
+		return nil, nil, fmt.Errorf("failed to connect to embedded nats: %w", err)
+	}
+	return conn, srv, nil
+}
+
+// ensureStream creates the stream of the subject, or updates it to the
+// config.
+func ensureStream(ctx context.Context, js jetstream.JetStream, conf config.Nats) error {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName(conf),
+		Subjects: []string{subject(conf)},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create nats stream: %w", err)
+	}
+	return nil
+}
+
+// disconnect closes the connection and releases the embedded server.
+func disconnect(conn *nats.Conn, srv *server.Server) {
+	if conn != nil {
+		conn.Close()
+	}
+	if srv != nil {
+		releaseServer(srv)
+	}
+}
+
+func streamName(conf config.Nats) string {
+	if conf.Stream != "" {
+		return conf.Stream
+	}
+	return defaultStream
+}
+
+func subject(conf config.Nats) string {
+	if conf.Subject != "" {
+		return conf.Subject
+	}
+	return defaultSubject
+}
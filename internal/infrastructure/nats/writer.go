@@ -0,0 +1,126 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// closeTimeout bounds the wait for pending async publishes on Close.
+const closeTimeout = 10 * time.Second
+
+// NatsWriter publishes events to the stream in the message format of the
+// Kafka topic. A publish succeeds once the stream has stored the message.
+type NatsWriter struct {
+	conf   config.Nats
+	server *server.Server
+	conn   *nats.Conn
+	js     jetstream.JetStream
+}
+
+func NewNatsWriter(conf config.Nats) *NatsWriter {
+	return &NatsWriter{conf: conf}
+}
+
+func (n *NatsWriter) Connect(ctx context.Context) error {
+	conn, srv, err := connect(n.conf)
+	if err != nil {
+		return err
+	}
+	n.conn, n.server = conn, srv
+
+	n.js, err = jetstream.New(conn)
+	if err != nil {
+		return fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	return ensureStream(ctx, n.js, n.conf)
+}
+
+func (n *NatsWriter) Publish(ctx context.Context, event entity.TransactionEvent) error {
+	return n.PublishFault(ctx, event, entity.Fault{})
+}
+
+// PublishFault publishes the event with the fault injected into its message
+// and the fault kind in the fault header.
+func (n *NatsWriter) PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	if n.js == nil {
+		return fmt.Errorf("nats writer is not initialized, call Connect() first")
+	}
+
+	msg, err := n.eventMessage(event, fault)
+	if err != nil {
+		return err
+	}
+	if _, err = n.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish message to nats: %w", err)
+	}
+	return nil
+}
+
+// PublishAsync publishes the event and calls delivered with the outcome
+// once the stream acknowledged it. Without async mode the event is
+// published before PublishAsync returns.
+func (n *NatsWriter) PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error {
+	if n.js == nil {
+		return fmt.Errorf("nats writer is not initialized, call Connect() first")
+	}
+	if !n.conf.Async {
+		delivered(n.PublishFault(ctx, event, fault))
+		return nil
+	}
+
+	msg, err := n.eventMessage(event, fault)
+	if err != nil {
+		return err
+	}
+	future, err := n.js.PublishMsgAsync(msg)
+	if err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+	go func() {
+		select {
+		case <-future.Ok():
+			delivered(nil)
+		case err := <-future.Err():
+			delivered(err)
+		}
+	}()
+	return nil
+}
+
+func (n *NatsWriter) eventMessage(event entity.TransactionEvent, fault entity.Fault) (*nats.Msg, error) {
+	data, err := kafka.MarshalEvent(event, fault)
+	if err != nil {
+		return nil, err
+	}
+	msg := nats.NewMsg(subject(n.conf))
+	msg.Data = data
+	if event.TenantID != "" {
+		msg.Header.Set(tenantHeader, event.TenantID)
+	}
+	if fault.Kind != "" {
+		msg.Header.Set(faultHeader, fault.Kind)
+	}
+	return msg, nil
+}
+
+// Close waits for the pending async publishes and disconnects.
+func (n *NatsWriter) Close() error {
+	if n.js != nil {
+		select {
+		case <-n.js.PublishAsyncComplete():
+		case <-time.After(closeTimeout):
+		}
+	}
+	disconnect(n.conn, n.server)
+	// a second Close must not release the shared server again
+	n.conn, n.server = nil, nil
+	return nil
+}
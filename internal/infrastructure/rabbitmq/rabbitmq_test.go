@@ -0,0 +1,84 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTopology(t *testing.T) {
+	assert.Equal(t, topology{
+		exchange:           "transactions",
+		queue:              "transactions",
+		routingKey:         "transactions",
+		deadLetterExchange: "transactions.dlx",
+		deadLetterQueue:    "transactions.dead",
+	}, newTopology(config.RabbitMQ{}))
+
+	assert.Equal(t, topology{
+		exchange:           "events",
+		queue:              "consumer",
+		routingKey:         "bets",
+		deadLetterExchange: "poison",
+		deadLetterQueue:    "consumer.dead",
+	}, newTopology(config.RabbitMQ{Exchange: "events", Queue: "consumer", RoutingKey: "bets", DeadLetterExchange: "poison"}))
+}
+
+func TestDecodeDelivery(t *testing.T) {
+	event := entity.TransactionEvent{
+		EventID:         "e1",
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.ToMoney(7.5),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("valid message with tenant header", func(t *testing.T) {
+		body, err := kafka.MarshalEvent(event, entity.Fault{})
+		require.NoError(t, err)
+
+		consumed, err := decodeDelivery(amqp.Delivery{
+			RoutingKey: "transactions",
+			Headers:    amqp.Table{tenantHeader: "brand-a"},
+			Body:       body,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "transactions", consumed.Topic)
+		assert.Equal(t, event.EventID, consumed.Event.EventID)
+		assert.Equal(t, event.UserID, consumed.Event.UserID)
+		assert.Equal(t, event.Amount, consumed.Event.Amount)
+		assert.Equal(t, "brand-a", consumed.Event.TenantID)
+	})
+
+	t.Run("default tenant without header", func(t *testing.T) {
+		body, err := kafka.MarshalEvent(event, entity.Fault{})
+		require.NoError(t, err)
+
+		consumed, err := decodeDelivery(amqp.Delivery{Body: body})
+		require.NoError(t, err)
+		assert.Equal(t, entity.DefaultTenantID, consumed.Event.TenantID)
+	})
+
+	t.Run("malformed message", func(t *testing.T) {
+		body, err := kafka.MarshalEvent(event, entity.Fault{Kind: entity.FaultMalformed})
+		require.NoError(t, err)
+
+		_, err = decodeDelivery(amqp.Delivery{
+			RoutingKey: "transactions",
+			Headers:    amqp.Table{faultHeader: entity.FaultMalformed},
+			Body:       body,
+		})
+		var invalid *entity.InvalidMessageError
+		require.True(t, errors.As(err, &invalid))
+		assert.Equal(t, entity.FaultMalformed, invalid.Fault)
+		assert.Equal(t, "transactions", invalid.Topic)
+	})
+}
@@ -0,0 +1,185 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// RabbitMQReader consumes the queue with manual acknowledgements. Like the
+// offsets of the Kafka reader, deliveries are acknowledged by Commit only,
+// when the consumer flushed a batch; deliveries not acknowledged are
+// redelivered once the channel closes. Messages that cannot be decoded are
+// rejected into the dead-letter exchange as soon as they are read. When the
+// connection is lost the reader reconnects with backoff.
+type RabbitMQReader struct {
+	conf       config.RabbitMQ
+	conn       *amqp.Connection
+	deliveries <-chan amqp.Delivery
+
+	mu      sync.Mutex
+	channel *amqp.Channel
+	// last delivery of every valid message read, acknowledged together
+	lastValid *amqp.Delivery
+	closed    bool
+}
+
+func NewRabbitMQReader(conf config.RabbitMQ) *RabbitMQReader {
+	return &RabbitMQReader{conf: conf}
+}
+
+func (r *RabbitMQReader) Connect(_ context.Context) error {
+	conn, ch, err := dial(r.conf)
+	if err != nil {
+		return err
+	}
+
+	prefetch := r.conf.Prefetch
+	if prefetch <= 0 {
+		prefetch = defaultPrefetch
+	}
+	if err = ch.Qos(prefetch, 0, false); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to set rabbitmq prefetch: %w", err)
+	}
+	deliveries, err := ch.Consume(newTopology(r.conf).queue, "", false, false, false, false, nil)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to consume rabbitmq queue: %w", err)
+	}
+
+	r.conn, r.deliveries = conn, deliveries
+	r.mu.Lock()
+	r.channel = ch
+	r.mu.Unlock()
+	return nil
+}
+
+// Read returns the next event. A message that cannot be decoded is
+// dead-lettered and reported with an entity.InvalidMessageError.
+func (r *RabbitMQReader) Read(ctx context.Context) (*entity.ConsumedEvent, error) {
+	if r.deliveries == nil {
+		return nil, fmt.Errorf("rabbitmq reader is not initialized, call Connect() first")
+	}
+
+	var delivery amqp.Delivery
+	for received := false; !received; {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to read message from rabbitmq: %w", ctx.Err())
+		case d, ok := <-r.deliveries:
+			if !ok {
+				if err := r.reconnect(ctx); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			delivery, received = d, true
+		}
+	}
+
+	consumed, err := decodeDelivery(delivery)
+	if err != nil {
+		if rejectErr := delivery.Reject(false); rejectErr != nil {
+			return nil, fmt.Errorf("failed to dead-letter rabbitmq message: %w", rejectErr)
+		}
+		return nil, err
+	}
+	r.mu.Lock()
+	r.lastValid = &delivery
+	r.mu.Unlock()
+	return consumed, nil
+}
+
+// reconnect replaces the closed connection, retrying with backoff until it
+// succeeds or the context is done. Deliveries of the closed channel cannot be
+// acknowledged anymore, the broker redelivers them.
+func (r *RabbitMQReader) reconnect(ctx context.Context) error {
+	r.mu.Lock()
+	closed := r.closed
+	r.channel, r.lastValid = nil, nil
+	r.mu.Unlock()
+	if closed {
+		return fmt.Errorf("failed to read message from rabbitmq: reader is closed")
+	}
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+
+	backoff := minReconnectBackoff
+	for {
+		log.Printf("RabbitMQ channel closed, reconnecting in %s", backoff)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to reconnect to rabbitmq: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		err := r.Connect(ctx)
+		if err == nil {
+			log.Println("Reconnected to rabbitmq")
+			return nil
+		}
+		log.Printf("Failed to reconnect to rabbitmq: %v", err)
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
+}
+
+func decodeDelivery(delivery amqp.Delivery) (*entity.ConsumedEvent, error) {
+	topic := delivery.RoutingKey
+	fault := headerValue(delivery.Headers, faultHeader)
+	event, err := kafka.UnmarshalEvent(delivery.Body)
+	if err != nil {
+		return nil, &entity.InvalidMessageError{Topic: topic, Fault: fault, Err: err}
+	}
+	switch tenant := headerValue(delivery.Headers, tenantHeader); {
+	case tenant != "":
+		event.TenantID = tenant
+	case event.TenantID == "":
+		event.TenantID = entity.DefaultTenantID
+	}
+	return &entity.ConsumedEvent{Topic: topic, Event: *event, Fault: fault}, nil
+}
+
+// Commit acknowledges the messages read so far with a single multiple
+// acknowledgement.
+func (r *RabbitMQReader) Commit(_ context.Context) error {
+	r.mu.Lock()
+	channel, lastValid := r.channel, r.lastValid
+	r.lastValid = nil
+	r.mu.Unlock()
+
+	if channel == nil {
+		return fmt.Errorf("rabbitmq reader is not connected")
+	}
+	if lastValid != nil {
+		if err := lastValid.Ack(true); err != nil {
+			return fmt.Errorf("failed to acknowledge rabbitmq messages: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *RabbitMQReader) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	if r.conn != nil {
+		if err := r.conn.Close(); err != nil && err != amqp.ErrClosed {
+			return fmt.Errorf("failed to close rabbitmq connection: %w", err)
+		}
+	}
+	return nil
+}
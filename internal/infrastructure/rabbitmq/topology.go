@@ -0,0 +1,104 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	defaultExchange = "transactions"
+	defaultQueue    = "transactions"
+	defaultPrefetch = 1000
+
+	// faultHeader and tenantHeader carry what the Kafka headers carry.
+	faultHeader  = "fault"
+	tenantHeader = "tenant-id"
+
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// topology is the exchange, queue and dead-letter routing of a config.
+type topology struct {
+	exchange           string
+	queue              string
+	routingKey         string
+	deadLetterExchange string
+	deadLetterQueue    string
+}
+
+func newTopology(conf config.RabbitMQ) topology {
+	t := topology{
+		exchange:           conf.Exchange,
+		queue:              conf.Queue,
+		routingKey:         conf.RoutingKey,
+		deadLetterExchange: conf.DeadLetterExchange,
+	}
+	if t.exchange == "" {
+		t.exchange = defaultExchange
+	}
+	if t.queue == "" {
+		t.queue = defaultQueue
+	}
+	if t.routingKey == "" {
+		t.routingKey = t.queue
+	}
+	if t.deadLetterExchange == "" {
+		t.deadLetterExchange = t.exchange + ".dlx"
+	}
+	t.deadLetterQueue = t.queue + ".dead"
+	return t
+}
+
+// declare creates the durable exchanges and queues of the topology when
+// missing. Rejected messages of the queue go to the dead-letter queue.
+func (t topology) declare(ch *amqp.Channel) error {
+	if err := ch.ExchangeDeclare(t.exchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+	if err := ch.ExchangeDeclare(t.deadLetterExchange, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+	if _, err := ch.QueueDeclare(t.deadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	if err := ch.QueueBind(t.deadLetterQueue, "", t.deadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+	_, err := ch.QueueDeclare(t.queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange": t.deadLetterExchange,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	if err = ch.QueueBind(t.queue, t.routingKey, t.exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+	return nil
+}
+
+// dial connects to the broker and opens a channel with the topology
+// declared.
+func dial(conf config.RabbitMQ) (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(conf.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	if err = newTopology(conf).declare(ch); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return conn, ch, nil
+}
+
+// headerValue returns the string value of the header.
+func headerValue(headers amqp.Table, key string) string {
+	value, _ := headers[key].(string)
+	return value
+}
@@ -0,0 +1,130 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQWriter publishes persistent messages to the exchange with
+// publisher confirms. A publish succeeds once the broker confirmed it.
+type RabbitMQWriter struct {
+	conf     config.RabbitMQ
+	topology topology
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	// pending async publishes, waited for on Close
+	pending sync.WaitGroup
+}
+
+func NewRabbitMQWriter(conf config.RabbitMQ) *RabbitMQWriter {
+	return &RabbitMQWriter{conf: conf, topology: newTopology(conf)}
+}
+
+func (w *RabbitMQWriter) Connect(_ context.Context) error {
+	conn, ch, err := dial(w.conf)
+	if err != nil {
+		return err
+	}
+	w.conn, w.channel = conn, ch
+
+	if err = ch.Confirm(false); err != nil {
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	return nil
+}
+
+func (w *RabbitMQWriter) Publish(ctx context.Context, event entity.TransactionEvent) error {
+	return w.PublishFault(ctx, event, entity.Fault{})
+}
+
+// PublishFault publishes the event with the fault injected into its message
+// and the fault kind in the fault header.
+func (w *RabbitMQWriter) PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	confirmation, err := w.publish(ctx, event, fault)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for rabbitmq confirm: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("rabbitmq did not confirm the message")
+	}
+	return nil
+}
+
+// PublishAsync publishes the event and calls delivered with the outcome
+// once the broker confirmed it. Without async mode the event is published
+// before PublishAsync returns.
+func (w *RabbitMQWriter) PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error {
+	if !w.conf.Async {
+		delivered(w.PublishFault(ctx, event, fault))
+		return nil
+	}
+
+	confirmation, err := w.publish(ctx, event, fault)
+	if err != nil {
+		return err
+	}
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		<-confirmation.Done()
+		if !confirmation.Acked() {
+			delivered(fmt.Errorf("rabbitmq did not confirm the message"))
+			return
+		}
+		delivered(nil)
+	}()
+	return nil
+}
+
+func (w *RabbitMQWriter) publish(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) (*amqp.DeferredConfirmation, error) {
+	if w.channel == nil {
+		return nil, fmt.Errorf("rabbitmq writer is not initialized, call Connect() first")
+	}
+
+	data, err := kafka.MarshalEvent(event, fault)
+	if err != nil {
+		return nil, err
+	}
+	headers := amqp.Table{}
+	if event.TenantID != "" {
+		headers[tenantHeader] = event.TenantID
+	}
+	if fault.Kind != "" {
+		headers[faultHeader] = fault.Kind
+	}
+
+	confirmation, err := w.channel.PublishWithDeferredConfirmWithContext(ctx, w.topology.exchange, w.topology.routingKey,
+		false, false, amqp.Publishing{
+			Headers:      headers,
+			ContentType:  contentTypeProtobuf,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    event.EventID,
+			Body:         data,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish message to rabbitmq: %w", err)
+	}
+	return confirmation, nil
+}
+
+// Close waits for the confirms of the pending async publishes and closes
+// the connection.
+func (w *RabbitMQWriter) Close() error {
+	w.pending.Wait()
+	if w.conn != nil {
+		if err := w.conn.Close(); err != nil && err != amqp.ErrClosed {
+			return fmt.Errorf("failed to close rabbitmq connection: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+var alertColumns = []string{
+	"id", "rule_name", "severity", "user_id", "description", "value", "threshold", "event_count",
+	"window_start", "window_end", "created_at", "acknowledged_at", "acknowledged_by",
+}
+
+type AlertRepository struct {
+	masterDB *DB
+	slaveDB  *DB
+}
+
+type alertRow struct {
+	ID             int64        `db:"id"`
+	RuleName       string       `db:"rule_name"`
+	Severity       string       `db:"severity"`
+	UserID         string       `db:"user_id"`
+	Description    string       `db:"description"`
+	Value          float64      `db:"value"`
+	Threshold      float64      `db:"threshold"`
+	EventCount     int          `db:"event_count"`
+	WindowStart    time.Time    `db:"window_start"`
+	WindowEnd      time.Time    `db:"window_end"`
+	CreatedAt      time.Time    `db:"created_at"`
+	AcknowledgedAt sql.NullTime `db:"acknowledged_at"`
+	AcknowledgedBy string       `db:"acknowledged_by"`
+}
+
+func NewAlertRepository(master *DB, slave *DB) *AlertRepository {
+	return &AlertRepository{
+		masterDB: master,
+		slaveDB:  slave,
+	}
+}
+
+func (r *AlertRepository) CreateAlerts(ctx context.Context, alerts []entity.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	qb := sq.Insert("alerts").
+		Columns("rule_name", "severity", "user_id", "description", "value", "threshold", "event_count", "window_start", "window_end").
+		PlaceholderFormat(sq.Dollar)
+	for _, alert := range alerts {
+		qb = qb.Values(
+			alert.RuleName,
+			string(alert.Severity),
+			alert.UserID.UUID.String(),
+			alert.Description,
+			alert.Value,
+			alert.Threshold,
+			alert.EventCount,
+			alert.WindowStart,
+			alert.WindowEnd,
+		)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	if _, err = r.masterDB.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert alerts: %w", err)
+	}
+	return nil
+}
+
+func (r *AlertRepository) ListAlerts(ctx context.Context, filter entity.AlertFilter) ([]entity.Alert, error) {
+	qb := sq.Select(alertColumns...).
+		From("alerts").
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if filter.UserID != nil {
+		qb = qb.Where(sq.Eq{"user_id": filter.UserID.UUID.String()})
+	}
+	if filter.RuleName != nil {
+		qb = qb.Where(sq.Eq{"rule_name": *filter.RuleName})
+	}
+	if filter.Severity != nil {
+		qb = qb.Where(sq.Eq{"severity": string(*filter.Severity)})
+	}
+	if filter.Acknowledged != nil {
+		if *filter.Acknowledged {
+			qb = qb.Where(sq.NotEq{"acknowledged_at": nil})
+		} else {
+			qb = qb.Where(sq.Eq{"acknowledged_at": nil})
+		}
+	}
+	if filter.CreatedFrom != nil {
+		qb = qb.Where(sq.GtOrEq{"created_at": *filter.CreatedFrom})
+	}
+	if filter.CreatedTo != nil {
+		qb = qb.Where(sq.LtOrEq{"created_at": *filter.CreatedTo})
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
+	}
+	qb = qb.Limit(uint64(limit))
+	if filter.Offset > 0 {
+		qb = qb.Offset(uint64(filter.Offset))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []alertRow
+	if err = r.slaveDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+
+	alerts := make([]entity.Alert, 0, len(rows))
+	for _, row := range rows {
+		alert, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+	return alerts, nil
+}
+
+// AcknowledgeAlert marks the alert acknowledged. Acknowledging it again keeps
+// the original time and author.
+func (r *AlertRepository) AcknowledgeAlert(ctx context.Context, id int64, acknowledgedBy string) (*entity.Alert, error) {
+	query, args, err := sq.Update("alerts").
+		Set("acknowledged_at", sq.Expr("COALESCE(acknowledged_at, CURRENT_TIMESTAMP)")).
+		Set("acknowledged_by", sq.Expr("CASE WHEN acknowledged_at IS NULL THEN ? ELSE acknowledged_by END", acknowledgedBy)).
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING " + joinColumns(alertColumns)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	var row alertRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("alert %d: %w", id, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+	return row.toEntity()
+}
+
+func (row alertRow) toEntity() (*entity.Alert, error) {
+	parsedUUID, err := uuid.Parse(row.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user_id: %w", err)
+	}
+
+	alert := &entity.Alert{
+		ID:             row.ID,
+		RuleName:       row.RuleName,
+		Severity:       entity.AlertSeverity(row.Severity),
+		UserID:         entity.UserID{UUID: parsedUUID},
+		Description:    row.Description,
+		Value:          row.Value,
+		Threshold:      row.Threshold,
+		EventCount:     row.EventCount,
+		WindowStart:    row.WindowStart,
+		WindowEnd:      row.WindowEnd,
+		CreatedAt:      row.CreatedAt,
+		AcknowledgedBy: row.AcknowledgedBy,
+	}
+	if row.AcknowledgedAt.Valid {
+		alert.AcknowledgedAt = &row.AcknowledgedAt.Time
+	}
+	return alert, nil
+}
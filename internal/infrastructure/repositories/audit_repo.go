@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+var (
+	auditChainHeadColumns = []string{"user_id", "last_hash", "erased_at IS NOT NULL AS erased"}
+	auditedEventColumns   = append(append([]string{}, transactionEventColumns...), "prev_hash", "row_hash", "ingested_at")
+	auditDigestColumns    = []string{"day", "row_count", "prev_digest", "digest", "signature", "created_at"}
+)
+
+type AuditRepository struct {
+	masterDB *DB
+}
+
+type auditChainHeadRow struct {
+	UserID   string `db:"user_id"`
+	LastHash string `db:"last_hash"`
+	Erased   bool   `db:"erased"`
+}
+
+type auditedEventRow struct {
+	transactionEventRow
+	PrevHash   string    `db:"prev_hash"`
+	RowHash    string    `db:"row_hash"`
+	IngestedAt time.Time `db:"ingested_at"`
+}
+
+type auditDigestRow struct {
+	Day        time.Time `db:"day"`
+	RowCount   int64     `db:"row_count"`
+	PrevDigest string    `db:"prev_digest"`
+	Digest     string    `db:"digest"`
+	Signature  string    `db:"signature"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// NewAuditRepository reads from the master only: verification must see the
+// rows as they are, not as a lagging replica has them.
+func NewAuditRepository(master *DB) *AuditRepository {
+	return &AuditRepository{
+		masterDB: master,
+	}
+}
+
+// ListChainHeads returns up to limit chain heads ordered by user, starting
+// after the given user when it is not nil.
+func (r *AuditRepository) ListChainHeads(ctx context.Context, after *entity.UserID, limit int) ([]entity.AuditChainHead, error) {
+	qb := sq.Select(auditChainHeadColumns...).
+		From("audit_chain_heads").
+		OrderBy("user_id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+	if after != nil {
+		qb = qb.Where(sq.Gt{"user_id": after.UUID.String()})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []auditChainHeadRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch audit chain heads: %w", err)
+	}
+
+	heads := make([]entity.AuditChainHead, 0, len(rows))
+	for _, row := range rows {
+		head, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		heads = append(heads, *head)
+	}
+	return heads, nil
+}
+
+func (r *AuditRepository) GetChainHead(ctx context.Context, userID entity.UserID) (*entity.AuditChainHead, error) {
+	query, args, err := sq.Select(auditChainHeadColumns...).
+		From("audit_chain_heads").
+		Where(sq.Eq{"user_id": userID.UUID.String()}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row auditChainHeadRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("audit chain of user %s: %w", userID.UUID, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch audit chain head: %w", err)
+	}
+	return row.toEntity()
+}
+
+// ListChainRows returns up to limit events of the user with an ID above
+// afterID in ID order, which is the order of the user's chain.
+func (r *AuditRepository) ListChainRows(ctx context.Context, userID entity.UserID, afterID int64, limit int) ([]entity.AuditedEvent, error) {
+	return r.listAuditedEvents(ctx, sq.And{
+		sq.Eq{"user_id": userID.UUID.String()},
+		sq.Gt{"id": afterID},
+	}, limit)
+}
+
+// ListIngestedRows returns up to limit events ingested within [from, to) with
+// an ID above afterID in ID order.
+func (r *AuditRepository) ListIngestedRows(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]entity.AuditedEvent, error) {
+	return r.listAuditedEvents(ctx, sq.And{
+		sq.GtOrEq{"ingested_at": from},
+		sq.Lt{"ingested_at": to},
+		sq.Gt{"id": afterID},
+	}, limit)
+}
+
+// GetFirstIngestedAt returns when the first chained event was ingested, or nil
+// when there is none yet.
+func (r *AuditRepository) GetFirstIngestedAt(ctx context.Context) (*time.Time, error) {
+	query, args, err := sq.Select("MIN(ingested_at)").
+		From("transaction_events").
+		Where(sq.NotEq{"row_hash": ""}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var first sql.NullTime
+	if err = r.masterDB.GetContext(ctx, &first, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch first ingestion time: %w", err)
+	}
+	if !first.Valid {
+		return nil, nil
+	}
+	return &first.Time, nil
+}
+
+// GetLastDigest returns the digest of the latest sealed day.
+func (r *AuditRepository) GetLastDigest(ctx context.Context) (*entity.AuditDigest, error) {
+	query, args, err := sq.Select(auditDigestColumns...).
+		From("audit_digests").
+		OrderBy("day DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row auditDigestRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("audit digest: %w", entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch audit digest: %w", err)
+	}
+	return row.toEntity(), nil
+}
+
+// ListDigests returns up to limit digests of days after the given one in day
+// order, all of them from the first when after is nil.
+func (r *AuditRepository) ListDigests(ctx context.Context, after *time.Time, limit int) ([]entity.AuditDigest, error) {
+	qb := sq.Select(auditDigestColumns...).
+		From("audit_digests").
+		OrderBy("day").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+	if after != nil {
+		qb = qb.Where(sq.Gt{"day": after.Format(time.DateOnly)})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []auditDigestRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch audit digests: %w", err)
+	}
+
+	digests := make([]entity.AuditDigest, 0, len(rows))
+	for _, row := range rows {
+		digests = append(digests, *row.toEntity())
+	}
+	return digests, nil
+}
+
+// CreateDigest stores the digest unless the day is sealed already, which
+// happens when several consumers seal concurrently. It reports whether the
+// digest was stored.
+func (r *AuditRepository) CreateDigest(ctx context.Context, digest entity.AuditDigest) (bool, error) {
+	query, args, err := sq.Insert("audit_digests").
+		Columns("day", "row_count", "prev_digest", "digest", "signature").
+		Values(digest.Day.Format(time.DateOnly), digest.RowCount, digest.PrevDigest, digest.Digest, digest.Signature).
+		Suffix("ON CONFLICT (day) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	res, err := r.masterDB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert audit digest: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert audit digest: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (r *AuditRepository) listAuditedEvents(ctx context.Context, where sq.Sqlizer, limit int) ([]entity.AuditedEvent, error) {
+	query, args, err := sq.Select(auditedEventColumns...).
+		From("transaction_events").
+		Where(where).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []auditedEventRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	events := make([]entity.AuditedEvent, 0, len(rows))
+	for _, row := range rows {
+		event, err := row.transactionEventRow.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, entity.AuditedEvent{
+			ID:         row.ID,
+			Event:      *event,
+			PrevHash:   row.PrevHash,
+			RowHash:    row.RowHash,
+			IngestedAt: row.IngestedAt,
+		})
+	}
+	return events, nil
+}
+
+func (row auditChainHeadRow) toEntity() (*entity.AuditChainHead, error) {
+	parsedUUID, err := uuid.Parse(row.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user_id: %w", err)
+	}
+	return &entity.AuditChainHead{
+		UserID:   entity.UserID{UUID: parsedUUID},
+		LastHash: row.LastHash,
+		Erased:   row.Erased,
+	}, nil
+}
+
+func (row auditDigestRow) toEntity() *entity.AuditDigest {
+	return &entity.AuditDigest{
+		Day:        time.Date(row.Day.Year(), row.Day.Month(), row.Day.Day(), 0, 0, 0, 0, time.UTC),
+		RowCount:   row.RowCount,
+		PrevDigest: row.PrevDigest,
+		Digest:     row.Digest,
+		Signature:  row.Signature,
+		CreatedAt:  row.CreatedAt,
+	}
+}
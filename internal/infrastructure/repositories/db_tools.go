@@ -3,13 +3,27 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+const (
+	DriverPostgres = "postgres"
+	// DriverMySQL backs the transaction repository only, the other
+	// repositories need postgres.
+	DriverMySQL = "mysql"
+	// DriverMemory keeps the transactions in the memory of the consumer, see
+	// memory.TransactionEventRepository. There is nothing to connect to.
+	DriverMemory = "memory"
+)
+
 type DB struct {
 	conn *sqlx.DB
 }
@@ -21,18 +35,30 @@ func NewDB(conn *sqlx.DB) *DB {
 }
 
 func (db *DB) Connect(conf *config.Postgres) error {
-	// Build DSN connection string
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conf.Host,
-		conf.Port,
-		conf.User,
-		conf.Password,
-		conf.Database,
-		conf.SSLMode,
-	)
-
-	conn, err := sqlx.Connect("postgres", dsn)
+	driver := conf.Driver
+	if driver == "" {
+		driver = DriverPostgres
+	}
+
+	var dsn string
+	switch driver {
+	case DriverPostgres:
+		dsn = fmt.Sprintf(
+			"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			conf.Host,
+			conf.Port,
+			conf.User,
+			conf.Password,
+			conf.Database,
+			conf.SSLMode,
+		)
+	case DriverMySQL:
+		dsn = mysqlDSN(conf)
+	default:
+		return fmt.Errorf("unknown database driver %q, must be one of postgres, mysql", conf.Driver)
+	}
+
+	conn, err := sqlx.Connect(driver, dsn)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -44,6 +70,15 @@ func (db *DB) Connect(conf *config.Postgres) error {
 	return nil
 }
 
+// Driver returns the driver of the connection, DriverPostgres or
+// DriverMySQL.
+func (db *DB) Driver() string {
+	if db.conn == nil {
+		return DriverPostgres
+	}
+	return db.conn.DriverName()
+}
+
 func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	tx, err := db.conn.BeginTxx(ctx, nil)
 	if err != nil {
@@ -60,8 +95,34 @@ func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.R
 	return res, nil
 }
 
-func (db *DB) Select(dest interface{}, query string, args ...interface{}) error {
-	return db.conn.Select(dest, query, args...)
+// SelectContext runs the query, which is cancelled with the context. The
+// error of a cancelled query wraps the error of the context.
+func (db *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return contextError(ctx, db.conn.SelectContext(ctx, dest, query, args...))
+}
+
+// GetContext runs the query, which is cancelled with the context. The error
+// of a cancelled query wraps the error of the context.
+func (db *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return contextError(ctx, db.conn.GetContext(ctx, dest, query, args...))
+}
+
+// WithTransaction runs fn inside a single transaction, committing it when fn
+// succeeds and rolling it back otherwise.
+func (db *DB) WithTransaction(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 func (db *DB) Close() error {
@@ -70,3 +131,47 @@ func (db *DB) Close() error {
 	}
 	return nil
 }
+
+// contextError wraps the error of the done context into err. Drivers report a
+// query cancelled with its context with their own errors, like the
+// query_canceled error of postgres.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
+
+func joinColumns(columns []string) string {
+	return strings.Join(columns, ", ")
+}
+
+// mysqlDSN returns the DSN of the MySQL connection. Times are read and
+// written in UTC, like the TIMESTAMP WITH TIME ZONE columns of postgres.
+func mysqlDSN(conf *config.Postgres) string {
+	dsn := mysql.NewConfig()
+	dsn.User = conf.User
+	dsn.Passwd = conf.Password
+	dsn.Net = "tcp"
+	dsn.Addr = fmt.Sprintf("%s:%d", conf.Host, conf.Port)
+	dsn.DBName = conf.Database
+	dsn.ParseTime = true
+	dsn.Loc = time.UTC
+	switch conf.SSLMode {
+	case "", "disable":
+	case "verify-ca", "verify-full":
+		dsn.TLSConfig = "true"
+	default:
+		dsn.TLSConfig = "skip-verify"
+	}
+	return dsn.FormatDSN()
+}
+
+// placeholderFormat returns the placeholders of the driver, $1 for postgres
+// and ? for mysql.
+func placeholderFormat(driver string) sq.PlaceholderFormat {
+	if driver == DriverMySQL {
+		return sq.Question
+	}
+	return sq.Dollar
+}
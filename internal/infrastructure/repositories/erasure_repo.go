@@ -0,0 +1,250 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	erasureColumns = []string{"e.user_hash", "e.requested_by", "e.erased_at", "e.vault_destroyed_at", "v.pseudonym"}
+
+	// tables whose user_id column is replaced by the pseudonym on erasure
+	pseudonymizedTables = []string{"transaction_events", "user_limits", "limit_breaches", "alerts", "reconciliation_items"}
+)
+
+type ErasureRepository struct {
+	masterDB *DB
+}
+
+// selecter runs a query on a connection or within a transaction.
+type selecter interface {
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type erasureRow struct {
+	UserHash         string         `db:"user_hash"`
+	RequestedBy      string         `db:"requested_by"`
+	ErasedAt         time.Time      `db:"erased_at"`
+	VaultDestroyedAt sql.NullTime   `db:"vault_destroyed_at"`
+	Pseudonym        sql.NullString `db:"pseudonym"`
+}
+
+func NewErasureRepository(master *DB) *ErasureRepository {
+	return &ErasureRepository{
+		masterDB: master,
+	}
+}
+
+// Erase records the erasure, stores the vault entry and replaces the user id
+// with the pseudonym in every table that references users, all in one
+// transaction. The audit chain head is created or updated first: its row lock
+// makes a batch of the user being stored concurrently either commit before the
+// erasure, so its rows are pseudonymized too, or wait for it and find the user
+// erased.
+func (r *ErasureRepository) Erase(ctx context.Context, erasure entity.UserErasure, vault entity.PseudonymVaultEntry) error {
+	userID := vault.UserID.UUID.String()
+	pseudonym := vault.Pseudonym.UUID.String()
+
+	statements := []sq.Sqlizer{
+		sq.Insert("audit_chain_heads").
+			Columns("user_id").
+			Values(userID).
+			Suffix("ON CONFLICT (user_id) DO NOTHING"),
+		sq.Update("audit_chain_heads").
+			Set("user_id", pseudonym).
+			Set("erased_at", sq.Expr("CURRENT_TIMESTAMP")).
+			Where(sq.Eq{"user_id": userID}),
+		sq.Insert("erased_users").
+			Columns("user_hash", "requested_by").
+			Values(erasure.UserHash, erasure.RequestedBy),
+		sq.Insert("pseudonym_vault").
+			Columns("user_hash", "user_id", "pseudonym", "pseudonym_key").
+			Values(vault.UserHash, userID, pseudonym, vault.PseudonymKey),
+	}
+	for _, table := range pseudonymizedTables {
+		statements = append(statements, sq.Update(table).
+			Set("user_id", pseudonym).
+			Where(sq.Eq{"user_id": userID}))
+	}
+	statements = append(statements,
+		sq.Update("outbox_messages").
+			Set("message_key", pseudonym).
+			Set("payload", sq.Expr("jsonb_set(payload, '{user_id}', to_jsonb(?::text))", pseudonym)).
+			Where(sq.Eq{"message_key": userID}),
+		sq.Update("webhook_deliveries").
+			Set("payload", sq.Expr("jsonb_set(payload, '{transaction,user_id}', to_jsonb(?::text))", pseudonym)).
+			Where(sq.Expr("payload->'transaction'->>'user_id' = ?", userID)),
+	)
+
+	return r.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		for _, statement := range statements {
+			query, args, err := statement.ToSql()
+			if err == nil {
+				query, err = sq.Dollar.ReplacePlaceholders(query)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build query: %w", err)
+			}
+			if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+				var pqErr *pq.Error
+				if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+					return fmt.Errorf("%w: user is already erased", entity.ErrInvalidArgument)
+				}
+				return fmt.Errorf("failed to erase user: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetErasure returns the erasure of the user with the given hash, with the
+// pseudonym filled in while the vault entry exists.
+func (r *ErasureRepository) GetErasure(ctx context.Context, userHash string) (*entity.UserErasure, error) {
+	query, args, err := sq.Select(erasureColumns...).
+		From("erased_users e").
+		LeftJoin("pseudonym_vault v ON v.user_hash = e.user_hash").
+		Where(sq.Eq{"e.user_hash": userHash}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row erasureRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("erasure: %w", entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch erasure: %w", err)
+	}
+	return row.toEntity()
+}
+
+// IsErased reports whether the user with the given hash has been erased.
+func (r *ErasureRepository) IsErased(ctx context.Context, userHash string) (bool, error) {
+	query, args, err := sq.Select("1").
+		From("erased_users").
+		Where(sq.Eq{"user_hash": userHash}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var erased bool
+	if err = r.masterDB.GetContext(ctx, &erased, query, args...); err != nil {
+		return false, fmt.Errorf("failed to check erasure: %w", err)
+	}
+	return erased, nil
+}
+
+// ErasedHashes returns the given user hashes that belong to erased users.
+func (r *ErasureRepository) ErasedHashes(ctx context.Context, userHashes []string) (map[string]struct{}, error) {
+	return erasedHashes(ctx, r.masterDB, userHashes)
+}
+
+func erasedHashes(ctx context.Context, db selecter, userHashes []string) (map[string]struct{}, error) {
+	erased := make(map[string]struct{})
+	if len(userHashes) == 0 {
+		return erased, nil
+	}
+
+	query, args, err := sq.Select("user_hash").
+		From("erased_users").
+		Where(sq.Eq{"user_hash": userHashes}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var hashes []string
+	if err = db.SelectContext(ctx, &hashes, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to check erasures: %w", err)
+	}
+	for _, hash := range hashes {
+		erased[hash] = struct{}{}
+	}
+	return erased, nil
+}
+
+// checkNotErased refuses to store a batch in the transaction when one of its
+// users has been erased since the batch was checked.
+func checkNotErased(ctx context.Context, tx *sqlx.Tx, userHashes []string) error {
+	erased, err := erasedHashes(ctx, tx, userHashes)
+	if err != nil {
+		return err
+	}
+	if len(erased) > 0 {
+		return fmt.Errorf("%w: %d users of the batch were erased", entity.ErrUserErased, len(erased))
+	}
+	return nil
+}
+
+// DestroyVault deletes the vault entry of the user, after which the
+// pseudonym can no longer be linked to the user.
+func (r *ErasureRepository) DestroyVault(ctx context.Context, userHash string) error {
+	return r.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		query, args, err := sq.Update("erased_users").
+			Set("vault_destroyed_at", sq.Expr("COALESCE(vault_destroyed_at, CURRENT_TIMESTAMP)")).
+			Where(sq.Eq{"user_hash": userHash}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build update query: %w", err)
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to update erasure: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to update erasure: %w", err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("erasure: %w", entity.ErrNotFound)
+		}
+
+		query, args, err = sq.Delete("pseudonym_vault").
+			Where(sq.Eq{"user_hash": userHash}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build delete query: %w", err)
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to destroy vault entry: %w", err)
+		}
+		return nil
+	})
+}
+
+func (row erasureRow) toEntity() (*entity.UserErasure, error) {
+	erasure := &entity.UserErasure{
+		UserHash:    row.UserHash,
+		RequestedBy: row.RequestedBy,
+		ErasedAt:    row.ErasedAt,
+	}
+	if row.VaultDestroyedAt.Valid {
+		erasure.VaultDestroyedAt = &row.VaultDestroyedAt.Time
+	}
+	if row.Pseudonym.Valid {
+		parsedUUID, err := uuid.Parse(row.Pseudonym.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pseudonym: %w", err)
+		}
+		erasure.Pseudonym = entity.NewUserID(parsedUUID)
+	}
+	return erasure, nil
+}
@@ -0,0 +1,337 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolationCode = "23505"
+)
+
+var (
+	userLimitColumns   = []string{"id", "user_id", "limit_type", "period", "amount", "created_at", "updated_at"}
+	limitBreachColumns = []string{"id", "limit_id", "user_id", "limit_type", "period", "amount", "consumed", "window_start", "detected_at"}
+)
+
+type LimitRepository struct {
+	masterDB *DB
+	slaveDB  *DB
+}
+
+type userLimitRow struct {
+	ID        int64     `db:"id"`
+	UserID    string    `db:"user_id"`
+	LimitType string    `db:"limit_type"`
+	Period    string    `db:"period"`
+	Amount    int64     `db:"amount"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type getter interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type userTotalsRow struct {
+	BetAmount     int64 `db:"bet_amount"`
+	WinAmount     int64 `db:"win_amount"`
+	DepositAmount int64 `db:"deposit_amount"`
+}
+
+type limitBreachRow struct {
+	ID          int64     `db:"id"`
+	LimitID     int64     `db:"limit_id"`
+	UserID      string    `db:"user_id"`
+	LimitType   string    `db:"limit_type"`
+	Period      string    `db:"period"`
+	Amount      int64     `db:"amount"`
+	Consumed    int64     `db:"consumed"`
+	WindowStart time.Time `db:"window_start"`
+	DetectedAt  time.Time `db:"detected_at"`
+}
+
+func NewLimitRepository(master *DB, slave *DB) *LimitRepository {
+	return &LimitRepository{
+		masterDB: master,
+		slaveDB:  slave,
+	}
+}
+
+func (r *LimitRepository) CreateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error) {
+	query, args, err := sq.Insert("user_limits").
+		Columns("user_id", "limit_type", "period", "amount").
+		Values(limit.UserID.UUID.String(), string(limit.Type), string(limit.Period), int64(limit.Amount)).
+		Suffix("RETURNING " + joinColumns(userLimitColumns)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	var row userLimitRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+			return nil, fmt.Errorf("%s %s limit already exists for the user: %w", limit.Period, limit.Type, entity.ErrInvalidArgument)
+		}
+		return nil, fmt.Errorf("failed to insert user limit: %w", err)
+	}
+	return row.toEntity()
+}
+
+func (r *LimitRepository) UpdateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error) {
+	query, args, err := sq.Update("user_limits").
+		Set("amount", int64(limit.Amount)).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": limit.ID, "user_id": limit.UserID.UUID.String()}).
+		Suffix("RETURNING " + joinColumns(userLimitColumns)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	var row userLimitRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user limit %d: %w", limit.ID, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update user limit: %w", err)
+	}
+	return row.toEntity()
+}
+
+func (r *LimitRepository) DeleteLimit(ctx context.Context, userID entity.UserID, id int64) error {
+	query, args, err := sq.Delete("user_limits").
+		Where(sq.Eq{"id": id, "user_id": userID.UUID.String()}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	res, err := r.masterDB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete user limit: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("user limit %d: %w", id, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *LimitRepository) GetLimit(ctx context.Context, userID entity.UserID, id int64) (*entity.UserLimit, error) {
+	query, args, err := sq.Select(userLimitColumns...).
+		From("user_limits").
+		Where(sq.Eq{"id": id, "user_id": userID.UUID.String()}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row userLimitRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user limit %d: %w", id, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch user limit: %w", err)
+	}
+	return row.toEntity()
+}
+
+// ListLimits returns the limits of all the given users.
+func (r *LimitRepository) ListLimits(ctx context.Context, userIDs []entity.UserID) ([]entity.UserLimit, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		ids = append(ids, userID.UUID.String())
+	}
+
+	query, args, err := sq.Select(userLimitColumns...).
+		From("user_limits").
+		Where(sq.Eq{"user_id": ids}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []userLimitRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch user limits: %w", err)
+	}
+
+	limits := make([]entity.UserLimit, 0, len(rows))
+	for _, row := range rows {
+		limit, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, *limit)
+	}
+	return limits, nil
+}
+
+// GetTotalsSince sums the user's bets, wins and deposits created at or after
+// since. It reads from the master so a batch that was just stored is included.
+func (r *LimitRepository) GetTotalsSince(ctx context.Context, userID entity.UserID, since time.Time) (*entity.UserBalance, error) {
+	return totalsSince(ctx, r.masterDB, userID, since)
+}
+
+func totalsSince(ctx context.Context, db getter, userID entity.UserID, since time.Time) (*entity.UserBalance, error) {
+	query, args, err := sq.Select(
+		"COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'bet'), 0) AS bet_amount",
+		"COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'win'), 0) AS win_amount",
+		"COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'deposit'), 0) AS deposit_amount",
+	).
+		From("transaction_events").
+		Where(sq.Eq{"user_id": userID.UUID.String()}).
+		Where(sq.GtOrEq{"created_at": since}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row userTotalsRow
+	if err = db.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch user totals: %w", err)
+	}
+
+	return &entity.UserBalance{
+		UserID:       userID,
+		TotalBet:     entity.Money(row.BetAmount),
+		TotalWin:     entity.Money(row.WinAmount),
+		TotalDeposit: entity.Money(row.DepositAmount),
+	}, nil
+}
+
+// txTotals reads the totals of users in the store transaction of a batch.
+type txTotals struct {
+	tx *sqlx.Tx
+}
+
+func (t txTotals) GetTotalsSince(ctx context.Context, userID entity.UserID, since time.Time) (*entity.UserBalance, error) {
+	return totalsSince(ctx, t.tx, userID, since)
+}
+
+// recordLimitBreaches runs the limit check of a batch in its store
+// transaction, which holds the batch and the locks on the audit chain heads
+// of its users, and stores the breaches it finds.
+func recordLimitBreaches(ctx context.Context, tx *sqlx.Tx, check entity.LimitCheck) error {
+	if check == nil {
+		return nil
+	}
+	breaches, err := check.Breaches(ctx, txTotals{tx: tx})
+	if err != nil {
+		return fmt.Errorf("failed to evaluate user limits: %w", err)
+	}
+	return insertLimitBreaches(ctx, tx, breaches)
+}
+
+// insertLimitBreaches stores every breach unless one was already recorded for
+// the same limit within the limit's window.
+func insertLimitBreaches(ctx context.Context, tx *sqlx.Tx, breaches []entity.LimitBreach) error {
+	query := `
+		INSERT INTO limit_breaches (limit_id, user_id, limit_type, period, amount, consumed, window_start, detected_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		WHERE NOT EXISTS (
+			SELECT 1 FROM limit_breaches WHERE limit_id = $1 AND detected_at > $9
+		)`
+
+	for _, breach := range breaches {
+		_, err := tx.ExecContext(ctx, query,
+			breach.LimitID,
+			breach.UserID.UUID.String(),
+			string(breach.Type),
+			string(breach.Period),
+			int64(breach.Amount),
+			int64(breach.Consumed),
+			breach.WindowStart,
+			breach.DetectedAt,
+			breach.DetectedAt.Add(-breach.Period.Window()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record limit breach: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *LimitRepository) ListBreaches(ctx context.Context, userID entity.UserID, limit, offset int) ([]entity.LimitBreach, error) {
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
+	}
+
+	qb := sq.Select(limitBreachColumns...).
+		From("limit_breaches").
+		Where(sq.Eq{"user_id": userID.UUID.String()}).
+		OrderBy("detected_at DESC", "id DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+	if offset > 0 {
+		qb = qb.Offset(uint64(offset))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []limitBreachRow
+	if err = r.slaveDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch limit breaches: %w", err)
+	}
+
+	breaches := make([]entity.LimitBreach, 0, len(rows))
+	for _, row := range rows {
+		parsedUUID, err := uuid.Parse(row.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse user_id: %w", err)
+		}
+		breaches = append(breaches, entity.LimitBreach{
+			ID:          row.ID,
+			LimitID:     row.LimitID,
+			UserID:      entity.UserID{UUID: parsedUUID},
+			Type:        entity.LimitType(row.LimitType),
+			Period:      entity.LimitPeriod(row.Period),
+			Amount:      entity.Money(row.Amount),
+			Consumed:    entity.Money(row.Consumed),
+			WindowStart: row.WindowStart,
+			DetectedAt:  row.DetectedAt,
+		})
+	}
+	return breaches, nil
+}
+
+func (row userLimitRow) toEntity() (*entity.UserLimit, error) {
+	parsedUUID, err := uuid.Parse(row.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user_id: %w", err)
+	}
+
+	return &entity.UserLimit{
+		ID:        row.ID,
+		UserID:    entity.UserID{UUID: parsedUUID},
+		Type:      entity.LimitType(row.LimitType),
+		Period:    entity.LimitPeriod(row.Period),
+		Amount:    entity.Money(row.Amount),
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+var outboxMessageColumns = []string{"id", "message_key", "event_type", "payload", "created_at", "sent_at"}
+
+type OutboxRepository struct {
+	masterDB *DB
+}
+
+type outboxMessageRow struct {
+	ID        int64      `db:"id"`
+	Key       string     `db:"message_key"`
+	EventType string     `db:"event_type"`
+	Payload   []byte     `db:"payload"`
+	CreatedAt time.Time  `db:"created_at"`
+	SentAt    *time.Time `db:"sent_at"`
+}
+
+func NewOutboxRepository(master *DB) *OutboxRepository {
+	return &OutboxRepository{
+		masterDB: master,
+	}
+}
+
+// ProcessUnsent locks up to limit of the oldest unsent messages, passes them
+// to fn in ID order and marks them sent when fn succeeds. The rows stay
+// locked while fn runs, so concurrent relays wait instead of overtaking each
+// other, which keeps messages with the same key in order.
+func (r *OutboxRepository) ProcessUnsent(ctx context.Context, limit int, fn func(messages []entity.OutboxMessage) error) (int, error) {
+	if r.masterDB == nil {
+		return 0, fmt.Errorf("master database connection is not initialized, call Connect() first")
+	}
+
+	query, args, err := sq.Select(outboxMessageColumns...).
+		From("outbox_messages").
+		Where(sq.Eq{"sent_at": nil}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var processed int
+	err = r.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var rows []outboxMessageRow
+		if err := tx.SelectContext(ctx, &rows, query, args...); err != nil {
+			return fmt.Errorf("failed to fetch outbox messages: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		messages := make([]entity.OutboxMessage, 0, len(rows))
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			messages = append(messages, row.toEntity())
+			ids = append(ids, row.ID)
+		}
+
+		if err := fn(messages); err != nil {
+			return err
+		}
+
+		updateQuery, updateArgs, err := sq.Update("outbox_messages").
+			Set("sent_at", sq.Expr("CURRENT_TIMESTAMP")).
+			Where(sq.Eq{"id": ids}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("failed to build update query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, updateQuery, updateArgs...); err != nil {
+			return fmt.Errorf("failed to mark outbox messages sent: %w", err)
+		}
+
+		processed = len(messages)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return processed, nil
+}
+
+func insertOutboxMessages(ctx context.Context, tx *sqlx.Tx, messages []entity.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	qb := sq.Insert("outbox_messages").
+		Columns("message_key", "event_type", "payload").
+		PlaceholderFormat(placeholderFormat(tx.DriverName()))
+	for _, message := range messages {
+		qb = qb.Values(message.Key, string(message.EventType), string(message.Payload))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build outbox insert query: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert outbox messages: %w", err)
+	}
+	return nil
+}
+
+func (row outboxMessageRow) toEntity() entity.OutboxMessage {
+	return entity.OutboxMessage{
+		ID:        row.ID,
+		Key:       row.Key,
+		EventType: entity.OutboxEventType(row.EventType),
+		Payload:   row.Payload,
+		CreatedAt: row.CreatedAt,
+		SentAt:    row.SentAt,
+	}
+}
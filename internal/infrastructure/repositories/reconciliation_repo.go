@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// keeps a single insert well below the bind parameter limit of postgres
+	reconciliationItemsChunkSize = 1000
+)
+
+type ReconciliationRepository struct {
+	masterDB *DB
+}
+
+type reconciliationRunRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func NewReconciliationRepository(master *DB) *ReconciliationRepository {
+	return &ReconciliationRepository{
+		masterDB: master,
+	}
+}
+
+// ListEventsBetween returns up to limit events created within [from, to] in
+// time and id order, starting after the cursor when it is not nil.
+func (r *ReconciliationRepository) ListEventsBetween(ctx context.Context, from, to time.Time, after *entity.EventCursor, limit int) ([]entity.TransactionEvent, error) {
+	if r.masterDB == nil {
+		return nil, fmt.Errorf("master database connection is not initialized, call Connect() first")
+	}
+
+	qb := sq.Select(transactionEventColumns...).
+		From("transaction_events").
+		Where(sq.GtOrEq{"created_at": from}).
+		Where(sq.LtOrEq{"created_at": to})
+	if after != nil {
+		qb = qb.Where(sq.Or{
+			sq.Gt{"created_at": after.CreatedAt},
+			sq.And{sq.Eq{"created_at": after.CreatedAt}, sq.Gt{"id": after.ID}},
+		})
+	}
+	query, args, err := qb.
+		OrderBy("created_at", "id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []transactionEventRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	return transactionEventRowsToEntities(rows)
+}
+
+// CreateRun stores the run and its items in one transaction and fills in the
+// generated ID and creation time.
+func (r *ReconciliationRepository) CreateRun(ctx context.Context, run *entity.ReconciliationRun) error {
+	if r.masterDB == nil {
+		return fmt.Errorf("master database connection is not initialized, call Connect() first")
+	}
+
+	query, args, err := sq.Insert("reconciliation_runs").
+		Columns("provider", "file_name", "period_from", "period_to", "provider_records", "stored_records",
+			"matched", "missing", "extra", "mismatched").
+		Values(run.Provider, run.FileName, run.PeriodFrom, run.PeriodTo, run.ProviderRecords, run.StoredRecords,
+			run.Matched, run.Missing, run.Extra, run.Mismatched).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	return r.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		var row reconciliationRunRow
+		if err := tx.GetContext(ctx, &row, query, args...); err != nil {
+			return fmt.Errorf("failed to insert reconciliation run: %w", err)
+		}
+
+		for start := 0; start < len(run.Items); start += reconciliationItemsChunkSize {
+			end := min(start+reconciliationItemsChunkSize, len(run.Items))
+			if err := insertReconciliationItems(ctx, tx, row.ID, run.Items[start:end]); err != nil {
+				return err
+			}
+		}
+
+		run.ID = row.ID
+		run.CreatedAt = row.CreatedAt
+		return nil
+	})
+}
+
+func insertReconciliationItems(ctx context.Context, tx *sqlx.Tx, runID int64, items []entity.ReconciliationItem) error {
+	qb := sq.Insert("reconciliation_items").
+		Columns("run_id", "status", "line", "event_id", "user_id", "transaction_type",
+			"provider_amount", "stored_amount", "provider_time", "stored_time", "reason").
+		PlaceholderFormat(sq.Dollar)
+	for _, item := range items {
+		qb = qb.Values(
+			runID,
+			string(item.Status),
+			item.Line,
+			item.EventID,
+			item.UserID.UUID.String(),
+			string(item.TransactionType),
+			nullableMoney(item.ProviderAmount),
+			nullableMoney(item.StoredAmount),
+			item.ProviderTime,
+			item.StoredTime,
+			item.Reason,
+		)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert reconciliation items: %w", err)
+	}
+	return nil
+}
+
+func nullableMoney(m *entity.Money) *int64 {
+	if m == nil {
+		return nil
+	}
+	value := int64(*m)
+	return &value
+}
@@ -5,11 +5,13 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
@@ -17,6 +19,17 @@ const (
 	defaultLimit = 1000
 )
 
+var transactionEventColumns = []string{"id", "event_id", "tenant_id", "user_id", "transaction_type", "amount", "created_at"}
+
+// Aggregates of the balance and stats queries, written with CASE instead of
+// FILTER so that they run on both postgres and mysql.
+const (
+	betCountColumn  = "COALESCE(SUM(CASE WHEN transaction_type = 'bet' THEN 1 ELSE 0 END), 0) AS bet_count"
+	winCountColumn  = "COALESCE(SUM(CASE WHEN transaction_type = 'win' THEN 1 ELSE 0 END), 0) AS win_count"
+	betAmountColumn = "COALESCE(SUM(CASE WHEN transaction_type = 'bet' THEN amount ELSE 0 END), 0) AS bet_amount"
+	winAmountColumn = "COALESCE(SUM(CASE WHEN transaction_type = 'win' THEN amount ELSE 0 END), 0) AS win_amount"
+)
+
 type TransactionEventRepository struct {
 	masterDB *DB
 	slaveDB  *DB
@@ -24,12 +37,21 @@ type TransactionEventRepository struct {
 
 type transactionEventRow struct {
 	ID              int64     `db:"id"`
+	EventID         string    `db:"event_id"`
+	TenantID        string    `db:"tenant_id"`
 	UserID          string    `db:"user_id"`
 	TransactionType string    `db:"transaction_type"`
 	Amount          int64     `db:"amount"`
 	CreatedAt       time.Time `db:"created_at"`
 }
 
+type transactionStatsRow struct {
+	BetCount  int64 `db:"bet_count"`
+	WinCount  int64 `db:"win_count"`
+	BetAmount int64 `db:"bet_amount"`
+	WinAmount int64 `db:"win_amount"`
+}
+
 func NewTransactionEventRepository(master *DB, slave *DB) *TransactionEventRepository {
 	return &TransactionEventRepository{
 		masterDB: master,
@@ -37,82 +59,163 @@ func NewTransactionEventRepository(master *DB, slave *DB) *TransactionEventRepos
 	}
 }
 
-func (t *TransactionEventRepository) GetListByFilter(filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+func (t *TransactionEventRepository) GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
 	if t.slaveDB == nil {
 		log.Printf("failed to connect to slave database")
 		return nil, sql.ErrConnDone
 	}
 
-	qb := sq.Select("id", "user_id", "transaction_type", "amount", "created_at").
+	qb := sq.Select(transactionEventColumns...).
 		From("transaction_events").
-		PlaceholderFormat(sq.Dollar).
-		OrderBy("created_at DESC")
+		PlaceholderFormat(placeholderFormat(t.slaveDB.Driver())).
+		OrderBy("created_at DESC", "id DESC")
 
-	if filter.UserID != nil {
-		qb = qb.Where(sq.Eq{"user_id": filter.UserID.UUID.String()})
+	qb = applyFilter(qb, filter)
+	if filter.After != nil {
+		qb = qb.Where(sq.Or{
+			sq.Lt{"created_at": filter.After.CreatedAt},
+			sq.And{sq.Eq{"created_at": filter.After.CreatedAt}, sq.Lt{"id": filter.After.ID}},
+		})
 	}
 
-	if filter.TransactionType != nil {
-		qb = qb.Where(sq.Eq{"transaction_type": string(*filter.TransactionType)})
+	limit := filter.Limit
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
 	}
+	qb = qb.Limit(uint64(limit))
 
-	if filter.AmountFrom != nil {
-		qb = qb.Where(sq.GtOrEq{"amount": int64(*filter.AmountFrom)})
+	if filter.Offset > 0 {
+		qb = qb.Offset(uint64(filter.Offset))
 	}
 
-	if filter.AmountTo != nil {
-		qb = qb.Where(sq.LtOrEq{"amount": int64(*filter.AmountTo)})
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	if filter.CreatedFrom != nil {
-		qb = qb.Where(sq.GtOrEq{"created_at": *filter.CreatedFrom})
+	var rows []transactionEventRow
+	err = t.slaveDB.SelectContext(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
 	}
 
-	if filter.CreatedTo != nil {
-		qb = qb.Where(sq.LtOrEq{"created_at": *filter.CreatedTo})
+	return transactionEventRowsToEntities(rows)
+}
+
+func (t *TransactionEventRepository) GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error) {
+	if t.slaveDB == nil {
+		log.Printf("failed to connect to slave database")
+		return nil, sql.ErrConnDone
 	}
 
-	limit := filter.Limit
-	if limit <= 0 || limit > defaultLimit {
-		limit = defaultLimit
+	query, args, err := sq.Select(betAmountColumn, winAmountColumn).
+		From("transaction_events").
+		Where(sq.Eq{"user_id": userID.UUID.String()}).
+		PlaceholderFormat(placeholderFormat(t.slaveDB.Driver())).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
-	qb = qb.Limit(uint64(limit))
 
-	if filter.Offset > 0 {
-		qb = qb.Offset(uint64(filter.Offset))
+	var row transactionStatsRow
+	if err = t.slaveDB.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %w", err)
 	}
 
+	return &entity.UserBalance{
+		UserID:   userID,
+		TotalBet: entity.Money(row.BetAmount),
+		TotalWin: entity.Money(row.WinAmount),
+	}, nil
+}
+
+func (t *TransactionEventRepository) GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+	if t.slaveDB == nil {
+		log.Printf("failed to connect to slave database")
+		return nil, sql.ErrConnDone
+	}
+
+	qb := sq.Select(betCountColumn, winCountColumn, betAmountColumn, winAmountColumn).
+		From("transaction_events").
+		PlaceholderFormat(placeholderFormat(t.slaveDB.Driver()))
+	qb = applyFilter(qb, filter)
+
 	query, args, err := qb.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	var rows []transactionEventRow
-	err = t.slaveDB.Select(&rows, query, args...)
+	var row transactionStatsRow
+	if err = t.slaveDB.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch stats: %w", err)
+	}
+
+	return &entity.TransactionStats{
+		BetCount:  row.BetCount,
+		WinCount:  row.WinCount,
+		BetAmount: entity.Money(row.BetAmount),
+		WinAmount: entity.Money(row.WinAmount),
+	}, nil
+}
+
+// HasTransaction reports whether the user has a stored transaction of the
+// given type.
+func (t *TransactionEventRepository) HasTransaction(ctx context.Context, userID entity.UserID, transactionType entity.TransactionType) (bool, error) {
+	if t.masterDB == nil {
+		return false, fmt.Errorf("master database connection is not initialized, call Connect() first")
+	}
+
+	query, args, err := sq.Select("1").
+		From("transaction_events").
+		Where(sq.Eq{"user_id": userID.UUID.String(), "transaction_type": string(transactionType)}).
+		Limit(1).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		PlaceholderFormat(placeholderFormat(t.masterDB.Driver())).
+		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		return false, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	events := make([]entity.TransactionEvent, 0, len(rows))
-	for _, row := range rows {
-		parsedUUID, err := uuid.Parse(row.UserID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse user_id: %w", err)
-		}
+	var exists bool
+	if err = t.masterDB.GetContext(ctx, &exists, query, args...); err != nil {
+		return false, fmt.Errorf("failed to check transaction history: %w", err)
+	}
+	return exists, nil
+}
 
-		events = append(events, entity.TransactionEvent{
-			UserID: entity.UserID{
-				UUID: parsedUUID,
-			},
-			TransactionType: entity.TransactionType(row.TransactionType),
-			Amount:          entity.Money(row.Amount),
-			CreatedAt:       row.CreatedAt,
-		})
+// StoredEventIDs returns the given event ids that are already stored.
+func (t *TransactionEventRepository) StoredEventIDs(ctx context.Context, eventIDs []string) (map[string]struct{}, error) {
+	if t.masterDB == nil {
+		return nil, fmt.Errorf("master database connection is not initialized, call Connect() first")
 	}
 
-	return events, nil
+	stored := make(map[string]struct{}, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return stored, nil
+	}
+
+	query, args, err := sq.Select("DISTINCT event_id").
+		From("transaction_events").
+		Where(sq.Eq{"event_id": eventIDs}).
+		PlaceholderFormat(placeholderFormat(t.masterDB.Driver())).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var ids []string
+	if err = t.masterDB.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch stored event ids: %w", err)
+	}
+	for _, id := range ids {
+		stored[id] = struct{}{}
+	}
+	return stored, nil
 }
 
+// BatchStore stores the batch and extends the audit chains of its users, see
+// insertChainedBatch.
 func (t *TransactionEventRepository) BatchStore(ctx context.Context, batch []entity.TransactionEvent) error {
 	if t.masterDB == nil {
 		return fmt.Errorf("master database connection is not initialized, call Connect() first")
@@ -122,16 +225,73 @@ func (t *TransactionEventRepository) BatchStore(ctx context.Context, batch []ent
 		return nil
 	}
 
-	qb := sq.Insert("transaction_events").
-		Columns("user_id", "transaction_type", "amount", "created_at").
-		PlaceholderFormat(sq.Dollar)
+	return t.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		return insertChainedBatch(ctx, tx, batch)
+	})
+}
 
+// BatchStoreWithDerived stores the batch and the records derived from it in
+// one transaction, so a message is never published for a batch that was not
+// saved and never lost for one that was.
+func (t *TransactionEventRepository) BatchStoreWithDerived(ctx context.Context, batch []entity.TransactionEvent, derived entity.DerivedRecords) error {
+	if t.masterDB == nil {
+		return fmt.Errorf("master database connection is not initialized, call Connect() first")
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return t.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if err := insertChainedBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+		// after the chain heads are locked, so an erasure running
+		// concurrently has committed
+		if err := checkNotErased(ctx, tx, derived.UserHashes); err != nil {
+			return err
+		}
+		if err := insertOutboxMessages(ctx, tx, derived.OutboxMessages); err != nil {
+			return err
+		}
+		// after the batch is written, so the limits count it, and with the
+		// chain heads locked, so batches of a user are checked one by one
+		if err := recordLimitBreaches(ctx, tx, derived.Limits); err != nil {
+			return err
+		}
+		return insertWebhookDeliveries(ctx, tx, derived.WebhookDeliveries)
+	})
+}
+
+// insertChainedBatch inserts the batch with every event hashed onto the
+// previous event of its user. The chain heads of the batch users are locked
+// first, in user order to avoid deadlocks, so concurrent batches of the same
+// user extend the chain one after another instead of forking it.
+func insertChainedBatch(ctx context.Context, tx *sqlx.Tx, batch []entity.TransactionEvent) error {
+	heads, err := lockChainHeads(ctx, tx, batch)
+	if err != nil {
+		return err
+	}
+
+	qb := sq.Insert("transaction_events").
+		Columns("event_id", "tenant_id", "user_id", "transaction_type", "amount", "created_at", "prev_hash", "row_hash").
+		PlaceholderFormat(placeholderFormat(tx.DriverName()))
 	for _, event := range batch {
+		// store exactly what is hashed
+		event.CreatedAt = event.CreatedAt.Round(time.Microsecond)
+		prevHash := heads[event.UserID]
+		rowHash := entity.ChainHash(prevHash, event)
+		heads[event.UserID] = rowHash
+
 		qb = qb.Values(
+			event.EventID,
+			tenantOrDefault(event.TenantID),
 			event.UserID.UUID.String(),
 			string(event.TransactionType),
 			int64(event.Amount),
 			event.CreatedAt,
+			prevHash,
+			rowHash,
 		)
 	}
 
@@ -139,10 +299,160 @@ func (t *TransactionEventRepository) BatchStore(ctx context.Context, batch []ent
 	if err != nil {
 		return fmt.Errorf("failed to build insert query: %w", err)
 	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert transactions: %w", err)
+	}
+
+	return saveChainHeads(ctx, tx, heads)
+}
 
-	_, err = t.masterDB.ExecContext(ctx, query, args...)
+func lockChainHeads(ctx context.Context, tx *sqlx.Tx, batch []entity.TransactionEvent) (map[entity.UserID]string, error) {
+	seen := make(map[entity.UserID]struct{}, len(batch))
+	userIDs := make([]string, 0, len(batch))
+	for _, event := range batch {
+		if _, ok := seen[event.UserID]; ok {
+			continue
+		}
+		seen[event.UserID] = struct{}{}
+		userIDs = append(userIDs, event.UserID.UUID.String())
+	}
+	sort.Strings(userIDs)
+
+	qb := sq.Insert("audit_chain_heads").
+		Columns("user_id").
+		PlaceholderFormat(placeholderFormat(tx.DriverName()))
+	if tx.DriverName() == DriverMySQL {
+		qb = qb.Suffix("ON DUPLICATE KEY UPDATE user_id = user_id")
+	} else {
+		qb = qb.Suffix("ON CONFLICT (user_id) DO NOTHING")
+	}
+	for _, userID := range userIDs {
+		qb = qb.Values(userID)
+	}
+	query, args, err := qb.ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to insert transactions: %w", err)
+		return nil, fmt.Errorf("failed to build insert query: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to create audit chain heads: %w", err)
+	}
+
+	query, args, err = sq.Select(auditChainHeadColumns...).
+		From("audit_chain_heads").
+		Where(sq.Eq{"user_id": userIDs}).
+		OrderBy("user_id").
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(placeholderFormat(tx.DriverName())).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []auditChainHeadRow
+	if err = tx.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to lock audit chain heads: %w", err)
+	}
+
+	heads := make(map[entity.UserID]string, len(rows))
+	for _, row := range rows {
+		head, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		heads[head.UserID] = head.LastHash
+	}
+	return heads, nil
+}
+
+func saveChainHeads(ctx context.Context, tx *sqlx.Tx, heads map[entity.UserID]string) error {
+	qb := sq.Insert("audit_chain_heads").
+		Columns("user_id", "last_hash").
+		PlaceholderFormat(placeholderFormat(tx.DriverName()))
+	if tx.DriverName() == DriverMySQL {
+		qb = qb.Suffix("ON DUPLICATE KEY UPDATE last_hash = VALUES(last_hash), updated_at = CURRENT_TIMESTAMP(6)")
+	} else {
+		qb = qb.Suffix("ON CONFLICT (user_id) DO UPDATE SET last_hash = EXCLUDED.last_hash, updated_at = CURRENT_TIMESTAMP")
+	}
+	for userID, lastHash := range heads {
+		qb = qb.Values(userID.UUID.String(), lastHash)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update audit chain heads: %w", err)
 	}
 	return nil
 }
+
+func transactionEventRowsToEntities(rows []transactionEventRow) ([]entity.TransactionEvent, error) {
+	events := make([]entity.TransactionEvent, 0, len(rows))
+	for _, row := range rows {
+		event, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+func (row transactionEventRow) toEntity() (*entity.TransactionEvent, error) {
+	parsedUUID, err := uuid.Parse(row.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user_id: %w", err)
+	}
+
+	return &entity.TransactionEvent{
+		ID:       row.ID,
+		EventID:  row.EventID,
+		TenantID: row.TenantID,
+		UserID: entity.UserID{
+			UUID: parsedUUID,
+		},
+		TransactionType: entity.TransactionType(row.TransactionType),
+		Amount:          entity.Money(row.Amount),
+		CreatedAt:       row.CreatedAt,
+	}, nil
+}
+
+func applyFilter(qb sq.SelectBuilder, filter entity.TransactionEventFilter) sq.SelectBuilder {
+	if filter.TenantID != nil {
+		qb = qb.Where(sq.Eq{"tenant_id": *filter.TenantID})
+	}
+
+	if filter.UserID != nil {
+		qb = qb.Where(sq.Eq{"user_id": filter.UserID.UUID.String()})
+	}
+
+	if filter.TransactionType != nil {
+		qb = qb.Where(sq.Eq{"transaction_type": string(*filter.TransactionType)})
+	}
+
+	if filter.AmountFrom != nil {
+		qb = qb.Where(sq.GtOrEq{"amount": int64(*filter.AmountFrom)})
+	}
+
+	if filter.AmountTo != nil {
+		qb = qb.Where(sq.LtOrEq{"amount": int64(*filter.AmountTo)})
+	}
+
+	if filter.CreatedFrom != nil {
+		qb = qb.Where(sq.GtOrEq{"created_at": *filter.CreatedFrom})
+	}
+
+	if filter.CreatedTo != nil {
+		qb = qb.Where(sq.LtOrEq{"created_at": *filter.CreatedTo})
+	}
+
+	return qb
+}
+
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return entity.DefaultTenantID
+	}
+	return tenantID
+}
@@ -0,0 +1,376 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository struct {
+	masterDB *DB
+	slaveDB  *DB
+}
+
+type webhookSubscriptionRow struct {
+	ID               int64     `db:"id"`
+	Name             string    `db:"name"`
+	FilterExpression string    `db:"filter_expression"`
+	TargetURL        string    `db:"target_url"`
+	Secret           string    `db:"secret"`
+	Active           bool      `db:"active"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+type webhookDeliveryRow struct {
+	ID             int64        `db:"id"`
+	SubscriptionID int64        `db:"subscription_id"`
+	Payload        []byte       `db:"payload"`
+	Status         string       `db:"status"`
+	Attempts       int          `db:"attempts"`
+	NextAttemptAt  time.Time    `db:"next_attempt_at"`
+	LastError      string       `db:"last_error"`
+	CreatedAt      time.Time    `db:"created_at"`
+	DeliveredAt    sql.NullTime `db:"delivered_at"`
+}
+
+type webhookDispatchRow struct {
+	webhookDeliveryRow
+	TargetURL string `db:"target_url"`
+	Secret    string `db:"secret"`
+}
+
+type webhookDeliveryAttemptRow struct {
+	ID          int64     `db:"id"`
+	DeliveryID  int64     `db:"delivery_id"`
+	StatusCode  int       `db:"status_code"`
+	Error       string    `db:"error"`
+	DurationMs  int64     `db:"duration_ms"`
+	AttemptedAt time.Time `db:"attempted_at"`
+}
+
+var (
+	webhookSubscriptionColumns = []string{"id", "name", "filter_expression", "target_url", "secret", "active", "created_at", "updated_at"}
+	webhookDeliveryColumns     = []string{"id", "subscription_id", "payload", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at"}
+)
+
+func NewWebhookRepository(master *DB, slave *DB) *WebhookRepository {
+	return &WebhookRepository{
+		masterDB: master,
+		slaveDB:  slave,
+	}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+	query, args, err := sq.Insert("webhook_subscriptions").
+		Columns("name", "filter_expression", "target_url", "secret", "active").
+		Values(subscription.Name, subscription.FilterExpression, subscription.TargetURL, subscription.Secret, subscription.Active).
+		Suffix("RETURNING " + joinColumns(webhookSubscriptionColumns)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	var row webhookSubscriptionRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook subscription: %w", err)
+	}
+	return row.toEntity(), nil
+}
+
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+	query, args, err := sq.Update("webhook_subscriptions").
+		Set("name", subscription.Name).
+		Set("filter_expression", subscription.FilterExpression).
+		Set("target_url", subscription.TargetURL).
+		Set("secret", subscription.Secret).
+		Set("active", subscription.Active).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"id": subscription.ID}).
+		Suffix("RETURNING " + joinColumns(webhookSubscriptionColumns)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	var row webhookSubscriptionRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("webhook subscription %d: %w", subscription.ID, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return row.toEntity(), nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	query, args, err := sq.Delete("webhook_subscriptions").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	res, err := r.masterDB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, entity.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error) {
+	query, args, err := sq.Select(webhookSubscriptionColumns...).
+		From("webhook_subscriptions").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var row webhookSubscriptionRow
+	if err = r.masterDB.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("webhook subscription %d: %w", id, entity.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch webhook subscription: %w", err)
+	}
+	return row.toEntity(), nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	return r.listSubscriptions(ctx, sq.Select(webhookSubscriptionColumns...))
+}
+
+func (r *WebhookRepository) ListActiveSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	return r.listSubscriptions(ctx, sq.Select(webhookSubscriptionColumns...).Where(sq.Eq{"active": true}))
+}
+
+func (r *WebhookRepository) listSubscriptions(ctx context.Context, qb sq.SelectBuilder) ([]entity.WebhookSubscription, error) {
+	query, args, err := qb.From("webhook_subscriptions").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []webhookSubscriptionRow
+	if err = r.masterDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]entity.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subscriptions = append(subscriptions, *row.toEntity())
+	}
+	return subscriptions, nil
+}
+
+// insertWebhookDeliveries enqueues the deliveries derived from a batch in the
+// transaction that stores it.
+func insertWebhookDeliveries(ctx context.Context, tx *sqlx.Tx, deliveries []entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	qb := sq.Insert("webhook_deliveries").
+		Columns("subscription_id", "payload", "status").
+		PlaceholderFormat(placeholderFormat(tx.DriverName()))
+	for _, delivery := range deliveries {
+		qb = qb.Values(delivery.SubscriptionID, string(delivery.Payload), string(entity.WebhookDeliveryStatusPending))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries picks pending deliveries whose next attempt is due and
+// pushes their next_attempt_at forward by the lease, so concurrent senders
+// skip them while they are being sent.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDispatch, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = CURRENT_TIMESTAMP + $1::bigint * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = $2 AND next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY next_attempt_at, id
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + joinColumns(webhookDeliveryColumns) + `
+		)
+		SELECT c.*, s.target_url, s.secret
+		FROM claimed c
+		JOIN webhook_subscriptions s ON s.id = c.subscription_id
+		ORDER BY c.id`
+
+	var rows []webhookDispatchRow
+	err := r.masterDB.SelectContext(ctx, &rows, query, lease.Milliseconds(), string(entity.WebhookDeliveryStatusPending), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	dispatches := make([]entity.WebhookDispatch, 0, len(rows))
+	for _, row := range rows {
+		dispatches = append(dispatches, entity.WebhookDispatch{
+			Delivery:  row.toEntity(),
+			TargetURL: row.TargetURL,
+			Secret:    row.Secret,
+		})
+	}
+	return dispatches, nil
+}
+
+// RecordAttempt stores the attempt and the resulting delivery state in one
+// transaction.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery entity.WebhookDelivery, attempt entity.WebhookDeliveryAttempt) error {
+	ub := sq.Update("webhook_deliveries").
+		Set("status", string(delivery.Status)).
+		Set("attempts", delivery.Attempts).
+		Set("last_error", delivery.LastError).
+		Set("delivered_at", delivery.DeliveredAt).
+		Where(sq.Eq{"id": delivery.ID}).
+		PlaceholderFormat(sq.Dollar)
+	if delivery.Status == entity.WebhookDeliveryStatusPending {
+		ub = ub.Set("next_attempt_at", delivery.NextAttemptAt)
+	}
+
+	updateQuery, updateArgs, err := ub.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	insertQuery, insertArgs, err := sq.Insert("webhook_delivery_attempts").
+		Columns("delivery_id", "status_code", "error", "duration_ms", "attempted_at").
+		Values(attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds(), attempt.AttemptedAt).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	return r.masterDB.WithTransaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, updateQuery, updateArgs...); err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, insertQuery, insertArgs...); err != nil {
+			return fmt.Errorf("failed to insert webhook delivery attempt: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]entity.WebhookDelivery, error) {
+	if limit <= 0 || limit > defaultLimit {
+		limit = defaultLimit
+	}
+
+	qb := sq.Select(webhookDeliveryColumns...).
+		From("webhook_deliveries").
+		Where(sq.Eq{"subscription_id": subscriptionID}).
+		OrderBy("id DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar)
+	if offset > 0 {
+		qb = qb.Offset(uint64(offset))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []webhookDeliveryRow
+	if err = r.slaveDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]entity.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.toEntity())
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID int64) ([]entity.WebhookDeliveryAttempt, error) {
+	query, args, err := sq.Select("id", "delivery_id", "status_code", "error", "duration_ms", "attempted_at").
+		From("webhook_delivery_attempts").
+		Where(sq.Eq{"delivery_id": deliveryID}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var rows []webhookDeliveryAttemptRow
+	if err = r.slaveDB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook delivery attempts: %w", err)
+	}
+
+	attempts := make([]entity.WebhookDeliveryAttempt, 0, len(rows))
+	for _, row := range rows {
+		attempts = append(attempts, entity.WebhookDeliveryAttempt{
+			ID:          row.ID,
+			DeliveryID:  row.DeliveryID,
+			StatusCode:  row.StatusCode,
+			Error:       row.Error,
+			Duration:    time.Duration(row.DurationMs) * time.Millisecond,
+			AttemptedAt: row.AttemptedAt,
+		})
+	}
+	return attempts, nil
+}
+
+func (row webhookSubscriptionRow) toEntity() *entity.WebhookSubscription {
+	return &entity.WebhookSubscription{
+		ID:               row.ID,
+		Name:             row.Name,
+		FilterExpression: row.FilterExpression,
+		TargetURL:        row.TargetURL,
+		Secret:           row.Secret,
+		Active:           row.Active,
+		CreatedAt:        row.CreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+	}
+}
+
+func (row webhookDeliveryRow) toEntity() entity.WebhookDelivery {
+	delivery := entity.WebhookDelivery{
+		ID:             row.ID,
+		SubscriptionID: row.SubscriptionID,
+		Payload:        json.RawMessage(row.Payload),
+		Status:         entity.WebhookDeliveryStatus(row.Status),
+		Attempts:       row.Attempts,
+		NextAttemptAt:  row.NextAttemptAt,
+		LastError:      row.LastError,
+		CreatedAt:      row.CreatedAt,
+	}
+	if row.DeliveredAt.Valid {
+		delivery.DeliveredAt = &row.DeliveredAt.Time
+	}
+	return delivery
+}
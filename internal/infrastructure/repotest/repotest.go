@@ -0,0 +1,188 @@
+// Package repotest is the conformance suite of the transaction repositories.
+// The SQL and in-memory repositories run the same suite, so their filters,
+// ordering, paging and aggregates cannot drift apart.
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Repository is the transaction repository under test.
+type Repository interface {
+	GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error)
+	GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error)
+	HasTransaction(ctx context.Context, userID entity.UserID, transactionType entity.TransactionType) (bool, error)
+	StoredEventIDs(ctx context.Context, eventIDs []string) (map[string]struct{}, error)
+	BatchStore(ctx context.Context, batch []entity.TransactionEvent) error
+}
+
+// RunTransactionEventRepository stores a fixed set of events in the empty
+// repository and checks the queries on it.
+func RunTransactionEventRepository(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	user1 := entity.UserID{UUID: uuid.New()}
+	user2 := entity.UserID{UUID: uuid.New()}
+
+	require.NoError(t, repo.BatchStore(ctx, []entity.TransactionEvent{
+		{EventID: "e1", UserID: user1, TransactionType: entity.TransactionTypeBet, Amount: 100, CreatedAt: base.Add(-3 * time.Hour)},
+		{EventID: "e2", UserID: user1, TransactionType: entity.TransactionTypeWin, Amount: 250, CreatedAt: base.Add(-2 * time.Hour)},
+		{EventID: "e3", TenantID: "brand-a", UserID: user2, TransactionType: entity.TransactionTypeBet, Amount: 300, CreatedAt: base.Add(-time.Hour)},
+	}))
+	require.NoError(t, repo.BatchStore(ctx, []entity.TransactionEvent{
+		{EventID: "e4", TenantID: "brand-a", UserID: user2, TransactionType: entity.TransactionTypeWin, Amount: 1000, CreatedAt: base},
+		// stored in microseconds, like a TIMESTAMP column
+		{EventID: "e5", TenantID: "brand-a", UserID: user1, TransactionType: entity.TransactionTypeBet, Amount: 500, CreatedAt: base.Add(time.Hour + 1234567*time.Nanosecond)},
+	}))
+
+	list := func(t *testing.T, filter entity.TransactionEventFilter) []string {
+		events, err := repo.GetListByFilter(ctx, filter)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.EventID)
+		}
+		return ids
+	}
+	ptr := func(v string) *string { return &v }
+	money := func(v entity.Money) *entity.Money { return &v }
+	at := func(v time.Time) *time.Time { return &v }
+	transactionType := func(v entity.TransactionType) *entity.TransactionType { return &v }
+
+	t.Run("Newest first without filter", func(t *testing.T) {
+		assert.Equal(t, []string{"e5", "e4", "e3", "e2", "e1"}, list(t, entity.TransactionEventFilter{}))
+	})
+
+	t.Run("Stored fields", func(t *testing.T) {
+		events, err := repo.GetListByFilter(ctx, entity.TransactionEventFilter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		event := events[0]
+		assert.Equal(t, "brand-a", event.TenantID)
+		assert.Equal(t, user1, event.UserID)
+		assert.Equal(t, entity.TransactionTypeBet, event.TransactionType)
+		assert.Equal(t, entity.Money(500), event.Amount)
+		assert.True(t, event.CreatedAt.Equal(base.Add(time.Hour+1235*time.Microsecond)), "created at %s", event.CreatedAt)
+	})
+
+	t.Run("Filter by tenant", func(t *testing.T) {
+		assert.Equal(t, []string{"e5", "e4", "e3"}, list(t, entity.TransactionEventFilter{TenantID: ptr("brand-a")}))
+		assert.Equal(t, []string{"e2", "e1"}, list(t, entity.TransactionEventFilter{TenantID: ptr(entity.DefaultTenantID)}))
+	})
+
+	t.Run("Filter by user and type", func(t *testing.T) {
+		assert.Equal(t, []string{"e5", "e2", "e1"}, list(t, entity.TransactionEventFilter{UserID: &user1}))
+		assert.Equal(t, []string{"e4", "e2"}, list(t, entity.TransactionEventFilter{TransactionType: transactionType(entity.TransactionTypeWin)}))
+	})
+
+	t.Run("Ranges are inclusive", func(t *testing.T) {
+		assert.Equal(t, []string{"e5", "e3", "e2"}, list(t, entity.TransactionEventFilter{AmountFrom: money(250), AmountTo: money(500)}))
+		assert.Equal(t, []string{"e4", "e3", "e2"}, list(t, entity.TransactionEventFilter{
+			CreatedFrom: at(base.Add(-2 * time.Hour)),
+			CreatedTo:   at(base),
+		}))
+	})
+
+	t.Run("Combined filters", func(t *testing.T) {
+		assert.Equal(t, []string{"e4"}, list(t, entity.TransactionEventFilter{
+			UserID:          &user2,
+			TransactionType: transactionType(entity.TransactionTypeWin),
+			AmountFrom:      money(1000),
+		}))
+		assert.Empty(t, list(t, entity.TransactionEventFilter{UserID: &user2, TenantID: ptr(entity.DefaultTenantID)}))
+	})
+
+	t.Run("Limit and offset", func(t *testing.T) {
+		assert.Equal(t, []string{"e4", "e3"}, list(t, entity.TransactionEventFilter{Limit: 2, Offset: 1}))
+		assert.Equal(t, []string{"e1"}, list(t, entity.TransactionEventFilter{Limit: 2, Offset: 4}))
+		assert.Empty(t, list(t, entity.TransactionEventFilter{Offset: 5}))
+	})
+
+	t.Run("Balance", func(t *testing.T) {
+		balance, err := repo.GetBalance(ctx, user1)
+		require.NoError(t, err)
+		assert.Equal(t, entity.Money(600), balance.TotalBet)
+		assert.Equal(t, entity.Money(250), balance.TotalWin)
+
+		balance, err = repo.GetBalance(ctx, entity.UserID{UUID: uuid.New()})
+		require.NoError(t, err)
+		assert.Equal(t, entity.Money(0), balance.TotalBet)
+		assert.Equal(t, entity.Money(0), balance.TotalWin)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := repo.GetStats(ctx, entity.TransactionEventFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, entity.TransactionStats{BetCount: 3, WinCount: 2, BetAmount: 900, WinAmount: 1250}, *stats)
+
+		stats, err = repo.GetStats(ctx, entity.TransactionEventFilter{TenantID: ptr("brand-a"), CreatedFrom: at(base)})
+		require.NoError(t, err)
+		assert.Equal(t, entity.TransactionStats{BetCount: 1, WinCount: 1, BetAmount: 500, WinAmount: 1000}, *stats)
+
+		stats, err = repo.GetStats(ctx, entity.TransactionEventFilter{TenantID: ptr("brand-b")})
+		require.NoError(t, err)
+		assert.Equal(t, entity.TransactionStats{}, *stats)
+	})
+
+	t.Run("Has transaction of type", func(t *testing.T) {
+		exists, err := repo.HasTransaction(ctx, user1, entity.TransactionTypeWin)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = repo.HasTransaction(ctx, user2, entity.TransactionTypeDeposit)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		exists, err = repo.HasTransaction(ctx, entity.UserID{UUID: uuid.New()}, entity.TransactionTypeBet)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Stored event ids", func(t *testing.T) {
+		stored, err := repo.StoredEventIDs(ctx, []string{"e1", "e4", "missing"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"e1": {}, "e4": {}}, stored)
+
+		stored, err = repo.StoredEventIDs(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+	})
+
+	// runs last, the events it stores are not in the fixed set
+	t.Run("Pages after a cursor over equal timestamps", func(t *testing.T) {
+		batch := make([]entity.TransactionEvent, 0, 5)
+		for _, eventID := range []string{"p1", "p2", "p3", "p4", "p5"} {
+			batch = append(batch, entity.TransactionEvent{
+				EventID: eventID, TenantID: "brand-c", UserID: user1, TransactionType: entity.TransactionTypeBet, Amount: 10, CreatedAt: base,
+			})
+		}
+		require.NoError(t, repo.BatchStore(ctx, batch))
+
+		var listed []string
+		filter := entity.TransactionEventFilter{TenantID: ptr("brand-c"), Limit: 2}
+		for {
+			events, err := repo.GetListByFilter(ctx, filter)
+			require.NoError(t, err)
+			for _, event := range events {
+				listed = append(listed, event.EventID)
+			}
+			if len(events) < filter.Limit {
+				break
+			}
+			filter.After = entity.CursorOf(events[len(events)-1])
+		}
+		assert.ElementsMatch(t, []string{"p1", "p2", "p3", "p4", "p5"}, listed)
+		assert.Len(t, listed, 5, "no event is listed twice")
+
+		older := list(t, entity.TransactionEventFilter{After: &entity.EventCursor{CreatedAt: base}, Limit: 2})
+		assert.Equal(t, []string{"e3", "e2"}, older)
+	})
+}
@@ -0,0 +1,518 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	OverflowReject     = "reject"
+	OverflowDropOldest = "drop_oldest"
+	OverflowBlock      = "block"
+
+	defaultMaxBytes        = 1 << 30
+	defaultMaxSegmentBytes = 16 << 20
+
+	segmentSuffix = ".seg"
+	cursorFile    = "cursor"
+)
+
+// ErrFull is returned when an event does not fit in the spool.
+var ErrFull = errors.New("spool is full")
+
+// Spool is an append-only log of events kept in segment files of a
+// directory. Events are read back in the order they were appended and
+// deleted once acknowledged; the read position is kept in the cursor file,
+// so unacknowledged events survive restarts. An event read but not
+// acknowledged before a crash is read again.
+//
+// A segment is synced to disk when it is rolled, the events of the active
+// segment only with fsync: without it a process crash loses nothing, but the
+// events appended since the last roll can be lost with the machine.
+type Spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	overflow     string
+	fsync        bool
+
+	mu       sync.Mutex
+	segments []*segment
+	active   *os.File
+	reader   *bufio.Reader
+	readFile *os.File
+	partial  []byte
+	cursor   *os.File
+	// offset is the read position in the oldest segment
+	offset  int64
+	next    *pendingEvent
+	pending int64
+	dropped int64
+	skipped int64
+	space   chan struct{}
+}
+
+type segment struct {
+	id   int64
+	size int64
+}
+
+type pendingEvent struct {
+	event entity.TransactionEvent
+	size  int64
+}
+
+// record is the form of an event in a segment, one JSON object per line.
+type record struct {
+	EventID         string    `json:"event_id,omitempty"`
+	TenantID        string    `json:"tenant_id,omitempty"`
+	UserID          uuid.UUID `json:"user_id"`
+	TransactionType string    `json:"transaction_type"`
+	Amount          int64     `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Open opens the spool in the directory of the config, creating it when
+// missing.
+func Open(conf config.Spool) (*Spool, error) {
+	s := &Spool{
+		dir:          conf.Dir,
+		maxBytes:     conf.MaxBytes,
+		segmentBytes: conf.SegmentBytes,
+		overflow:     conf.Overflow,
+		fsync:        conf.Fsync,
+		space:        make(chan struct{}, 1),
+	}
+	if s.dir == "" {
+		return nil, fmt.Errorf("spool dir is required")
+	}
+	if s.maxBytes == 0 {
+		s.maxBytes = defaultMaxBytes
+	}
+	if s.segmentBytes == 0 {
+		s.segmentBytes = min(s.maxBytes/4, defaultMaxSegmentBytes)
+	}
+	if s.maxBytes < 0 || s.segmentBytes <= 0 || s.segmentBytes > s.maxBytes {
+		return nil, fmt.Errorf("spool sizes must satisfy 0 < segmentBytes <= maxBytes")
+	}
+	switch s.overflow {
+	case "":
+		s.overflow = OverflowReject
+	case OverflowReject, OverflowDropOldest, OverflowBlock:
+	default:
+		return nil, fmt.Errorf("unknown spool overflow %q, must be one of reject, drop_oldest, block", s.overflow)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	if err := s.load(); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load restores the segments and the read position of a previous run.
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool dir: %w", err)
+	}
+	for _, entry := range entries {
+		id, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), segmentSuffix), 10, 64)
+		if err != nil || !strings.HasSuffix(entry.Name(), segmentSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat spool segment: %w", err)
+		}
+		s.segments = append(s.segments, &segment{id: id, size: info.Size()})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].id < s.segments[j].id })
+
+	s.cursor, err = os.OpenFile(filepath.Join(s.dir, cursorFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool cursor: %w", err)
+	}
+	buf := make([]byte, 16)
+	var cursorSegment int64
+	if n, _ := s.cursor.ReadAt(buf, 0); n == len(buf) {
+		cursorSegment = int64(binary.BigEndian.Uint64(buf[:8]))
+		s.offset = int64(binary.BigEndian.Uint64(buf[8:]))
+	}
+
+	// segments before the cursor were read completely
+	for len(s.segments) > 0 && s.segments[0].id < cursorSegment {
+		if err = os.Remove(s.segmentPath(s.segments[0].id)); err != nil {
+			return fmt.Errorf("failed to remove spool segment: %w", err)
+		}
+		s.segments = s.segments[1:]
+	}
+	if len(s.segments) == 0 || s.segments[0].id != cursorSegment {
+		s.offset = 0
+	}
+	if len(s.segments) == 0 {
+		s.segments = []*segment{{id: max(cursorSegment, 1)}}
+	}
+	if err = s.repair(s.segments[len(s.segments)-1]); err != nil {
+		return err
+	}
+	s.offset = min(s.offset, s.segments[0].size)
+
+	last := s.segments[len(s.segments)-1]
+	s.active, err = os.OpenFile(s.segmentPath(last.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	if err = syncDir(s.dir); err != nil {
+		return err
+	}
+	for _, seg := range s.segments {
+		s.pending += seg.size
+	}
+	s.pending -= s.offset
+
+	if err = s.openReader(); err != nil {
+		return err
+	}
+	return s.writeCursor()
+}
+
+// repair truncates a record cut short by a crash from the end of the segment.
+func (s *Spool) repair(seg *segment) error {
+	data, err := os.ReadFile(s.segmentPath(seg.id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	size := int64(bytes.LastIndexByte(data, '\n') + 1)
+	if size == int64(len(data)) {
+		return nil
+	}
+	if err = os.Truncate(s.segmentPath(seg.id), size); err != nil {
+		return fmt.Errorf("failed to repair spool segment: %w", err)
+	}
+	seg.size = size
+	return nil
+}
+
+// Append adds the event to the end of the spool. When the spool is full the
+// overflow policy applies; with block, Append waits until the context is done.
+func (s *Spool) Append(ctx context.Context, event entity.TransactionEvent) error {
+	return s.append(ctx, event, s.overflow == OverflowBlock)
+}
+
+// TryAppend is Append without waiting, a full spool with the block policy
+// rejects the event.
+func (s *Spool) TryAppend(event entity.TransactionEvent) error {
+	return s.append(context.Background(), event, false)
+}
+
+func (s *Spool) append(ctx context.Context, event entity.TransactionEvent, wait bool) error {
+	data, err := json.Marshal(record{
+		EventID:         event.EventID,
+		TenantID:        event.TenantID,
+		UserID:          event.UserID.UUID,
+		TransactionType: string(event.TransactionType),
+		Amount:          int64(event.Amount),
+		CreatedAt:       event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spool record: %w", err)
+	}
+	data = append(data, '\n')
+	size := int64(len(data))
+	if size > s.segmentBytes {
+		return fmt.Errorf("%w: event is larger than a segment", ErrFull)
+	}
+
+	s.mu.Lock()
+	for s.pending+size > s.maxBytes {
+		switch {
+		case s.overflow == OverflowDropOldest:
+			if err = s.dropOldest(); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+		case wait:
+			s.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w: %w", ErrFull, ctx.Err())
+			case <-s.space:
+			}
+			s.mu.Lock()
+		default:
+			s.mu.Unlock()
+			return ErrFull
+		}
+	}
+	defer s.mu.Unlock()
+
+	last := s.segments[len(s.segments)-1]
+	if last.size > 0 && last.size+size > s.segmentBytes {
+		if err = s.roll(); err != nil {
+			return err
+		}
+		last = s.segments[len(s.segments)-1]
+	}
+	if _, err = s.active.Write(data); err != nil {
+		return fmt.Errorf("failed to write spool segment: %w", err)
+	}
+	if s.fsync {
+		if err = s.active.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool segment: %w", err)
+		}
+	}
+	last.size += size
+	s.pending += size
+	return nil
+}
+
+// roll syncs the active segment and starts a new segment to append to.
+func (s *Spool) roll() error {
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	id := s.segments[len(s.segments)-1].id + 1
+	file, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	if err = syncDir(s.dir); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = s.active.Close(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	s.active = file
+	s.segments = append(s.segments, &segment{id: id})
+	return nil
+}
+
+// dropOldest deletes the oldest segment with the events not yet read.
+func (s *Spool) dropOldest() error {
+	if len(s.segments) == 1 {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+
+	oldest := s.segments[0]
+	data, err := os.ReadFile(s.segmentPath(oldest.id))
+	if err != nil {
+		return fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	s.dropped += int64(bytes.Count(data[min(s.offset, int64(len(data))):], []byte{'\n'}))
+	if err = s.readFile.Close(); err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	if err = os.Remove(s.segmentPath(oldest.id)); err != nil {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+	s.pending -= oldest.size - s.offset
+	s.segments = s.segments[1:]
+	s.offset, s.next, s.partial = 0, nil, nil
+	if err = s.openReader(); err != nil {
+		return err
+	}
+	return s.writeCursor()
+}
+
+// Peek returns the oldest event not yet acknowledged, the same event until
+// it is, and io.EOF when the spool is empty. Records that cannot be decoded
+// are skipped.
+func (s *Spool) Peek() (*entity.TransactionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.next == nil {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read spool segment: %w", err)
+		}
+		s.partial = append(s.partial, line...)
+		if err == nil {
+			line, s.partial = s.partial, nil
+			var rec record
+			if decodeErr := json.Unmarshal(line, &rec); decodeErr != nil {
+				s.skipped++
+				s.advance(int64(len(line)))
+				continue
+			}
+			s.next = &pendingEvent{
+				event: entity.TransactionEvent{
+					EventID:         rec.EventID,
+					TenantID:        rec.TenantID,
+					UserID:          entity.UserID{UUID: rec.UserID},
+					TransactionType: entity.TransactionType(rec.TransactionType),
+					Amount:          entity.Money(rec.Amount),
+					CreatedAt:       rec.CreatedAt,
+				},
+				size: int64(len(line)),
+			}
+			break
+		}
+
+		// the oldest segment is read completely
+		if len(s.segments) == 1 || s.offset < s.segments[0].size {
+			return nil, io.EOF
+		}
+		if err = s.removeOldest(); err != nil {
+			return nil, err
+		}
+	}
+	event := s.next.event
+	return &event, nil
+}
+
+// Ack deletes the event returned by Peek from the spool.
+func (s *Spool) Ack() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == nil {
+		return nil
+	}
+	s.advance(s.next.size)
+	s.next = nil
+	return s.writeCursor()
+}
+
+// advance moves the read position past size bytes and signals a writer
+// waiting for space.
+func (s *Spool) advance(size int64) {
+	s.offset += size
+	s.pending -= size
+	select {
+	case s.space <- struct{}{}:
+	default:
+	}
+}
+
+// removeOldest deletes the oldest segment once it is read completely.
+func (s *Spool) removeOldest() error {
+	if err := s.readFile.Close(); err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	if err := os.Remove(s.segmentPath(s.segments[0].id)); err != nil {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+	s.segments = s.segments[1:]
+	s.offset = 0
+	if err := s.openReader(); err != nil {
+		return err
+	}
+	return s.writeCursor()
+}
+
+func (s *Spool) openReader() error {
+	file, err := os.OpenFile(s.segmentPath(s.segments[0].id), os.O_CREATE|os.O_RDONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	if _, err = file.Seek(s.offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to seek spool segment: %w", err)
+	}
+	s.readFile = file
+	s.reader = bufio.NewReader(file)
+	return nil
+}
+
+func (s *Spool) writeCursor() error {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], uint64(s.segments[0].id))
+	binary.BigEndian.PutUint64(buf[8:], uint64(s.offset))
+	if _, err := s.cursor.WriteAt(buf, 0); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	// a cursor behind the events read only makes them be read again
+	if s.fsync {
+		if err := s.cursor.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool cursor: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncDir makes the files created in the directory survive a crash of the
+// machine.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open spool dir: %w", err)
+	}
+	defer func() { _ = d.Close() }()
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool dir: %w", err)
+	}
+	return nil
+}
+
+func (s *Spool) segmentPath(id int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, segmentSuffix))
+}
+
+// Empty reports whether every event of the spool was acknowledged.
+func (s *Spool) Empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending == 0
+}
+
+// Bytes returns the size of the events not yet acknowledged.
+func (s *Spool) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Dropped returns the number of events deleted by the drop_oldest policy and
+// of records skipped because they could not be decoded.
+func (s *Spool) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped + s.skipped
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, file := range []*os.File{s.active, s.readFile, s.cursor} {
+		if file != nil {
+			if err := file.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close spool: %v", errs)
+	}
+	return nil
+}
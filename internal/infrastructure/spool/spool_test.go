@@ -0,0 +1,225 @@
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = uuid.MustParse("0b7e8f1c-2a3d-4e5f-8a9b-0c1d2e3f4a5b")
+
+func testEvent(i int) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		EventID:         fmt.Sprintf("event-%03d", i),
+		TenantID:        "brand-a",
+		UserID:          *entity.NewUserID(testUser),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.Money(100 + i),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, i, 0, time.UTC),
+	}
+}
+
+// recordSize is the size of a test event in a segment, all test events have
+// the same size.
+func recordSize(t *testing.T) int64 {
+	event := testEvent(0)
+	data, err := json.Marshal(record{
+		EventID:         event.EventID,
+		TenantID:        event.TenantID,
+		UserID:          event.UserID.UUID,
+		TransactionType: string(event.TransactionType),
+		Amount:          int64(event.Amount),
+		CreatedAt:       event.CreatedAt,
+	})
+	require.NoError(t, err)
+	return int64(len(data)) + 1
+}
+
+func readAll(t *testing.T, s *Spool) []string {
+	var ids []string
+	for {
+		event, err := s.Peek()
+		if err == io.EOF {
+			return ids
+		}
+		require.NoError(t, err)
+		ids = append(ids, event.EventID)
+		require.NoError(t, s.Ack())
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	return files
+}
+
+func TestSpool_Order(t *testing.T) {
+	s, err := Open(config.Spool{Dir: t.TempDir(), MaxBytes: 100 * recordSize(t), SegmentBytes: 3 * recordSize(t)})
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.True(t, s.Empty())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.Append(context.Background(), testEvent(i)))
+	}
+	assert.Equal(t, 10*recordSize(t), s.Bytes())
+
+	event, err := s.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, testEvent(0), *event)
+	again, err := s.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, event, again, "an event is returned until it is acknowledged")
+	require.NoError(t, s.Ack())
+
+	ids := readAll(t, s)
+	require.Len(t, ids, 9)
+	assert.Equal(t, "event-001", ids[0])
+	assert.Equal(t, "event-009", ids[8])
+	assert.True(t, s.Empty())
+	assert.Len(t, segmentFiles(t, s.dir), 1, "read segments are deleted")
+}
+
+func TestSpool_Restart(t *testing.T) {
+	for _, fsync := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fsync %t", fsync), func(t *testing.T) {
+			dir := t.TempDir()
+			conf := config.Spool{Dir: dir, MaxBytes: 100 * recordSize(t), SegmentBytes: 3 * recordSize(t), Fsync: fsync}
+
+			s, err := Open(conf)
+			require.NoError(t, err)
+			for i := 0; i < 7; i++ {
+				require.NoError(t, s.Append(context.Background(), testEvent(i)))
+			}
+			for i := 0; i < 4; i++ {
+				_, err = s.Peek()
+				require.NoError(t, err)
+				require.NoError(t, s.Ack())
+			}
+			// read but not acknowledged before the restart
+			_, err = s.Peek()
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+
+			s, err = Open(conf)
+			require.NoError(t, err)
+			defer s.Close()
+			assert.Equal(t, 3*recordSize(t), s.Bytes())
+			require.NoError(t, s.Append(context.Background(), testEvent(7)))
+			assert.Equal(t, []string{"event-004", "event-005", "event-006", "event-007"}, readAll(t, s))
+		})
+	}
+}
+
+func TestSpool_RepairsTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.Spool{Dir: dir, MaxBytes: 100 * recordSize(t), SegmentBytes: 10 * recordSize(t)}
+
+	s, err := Open(conf)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(context.Background(), testEvent(0)))
+	require.NoError(t, s.Close())
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	file, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"event_id":"torn`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	s, err = Open(conf)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, recordSize(t), s.Bytes())
+	require.NoError(t, s.Append(context.Background(), testEvent(1)))
+	assert.Equal(t, []string{"event-000", "event-001"}, readAll(t, s))
+}
+
+func TestSpool_Overflow(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		s, err := Open(config.Spool{Dir: t.TempDir(), MaxBytes: 4 * recordSize(t), SegmentBytes: 2 * recordSize(t)})
+		require.NoError(t, err)
+		defer s.Close()
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, s.Append(context.Background(), testEvent(i)))
+		}
+		assert.ErrorIs(t, s.Append(context.Background(), testEvent(4)), ErrFull)
+		assert.Equal(t, []string{"event-000", "event-001", "event-002", "event-003"}, readAll(t, s))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		s, err := Open(config.Spool{
+			Dir:          t.TempDir(),
+			MaxBytes:     4 * recordSize(t),
+			SegmentBytes: 2 * recordSize(t),
+			Overflow:     OverflowDropOldest,
+		})
+		require.NoError(t, err)
+		defer s.Close()
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, s.Append(context.Background(), testEvent(i)))
+		}
+		assert.Equal(t, int64(2), s.Dropped(), "the oldest segment is dropped as a whole")
+		assert.Equal(t, []string{"event-002", "event-003", "event-004"}, readAll(t, s))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		s, err := Open(config.Spool{
+			Dir:          t.TempDir(),
+			MaxBytes:     2 * recordSize(t),
+			SegmentBytes: 2 * recordSize(t),
+			Overflow:     OverflowBlock,
+		})
+		require.NoError(t, err)
+		defer s.Close()
+
+		require.NoError(t, s.Append(context.Background(), testEvent(0)))
+		require.NoError(t, s.Append(context.Background(), testEvent(1)))
+		assert.ErrorIs(t, s.TryAppend(testEvent(2)), ErrFull)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, s.Append(ctx, testEvent(2)), context.DeadlineExceeded)
+
+		appended := make(chan error, 1)
+		go func() { appended <- s.Append(context.Background(), testEvent(2)) }()
+		_, err = s.Peek()
+		require.NoError(t, err)
+		require.NoError(t, s.Ack())
+		select {
+		case err = <-appended:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("append did not resume after an ack")
+		}
+		assert.Equal(t, []string{"event-001", "event-002"}, readAll(t, s))
+	})
+}
+
+func TestOpen_InvalidConfig(t *testing.T) {
+	for name, conf := range map[string]config.Spool{
+		"no dir":           {},
+		"unknown overflow": {Dir: t.TempDir(), Overflow: "spill"},
+		"segment too big":  {Dir: t.TempDir(), MaxBytes: 10, SegmentBytes: 20},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Open(conf)
+			assert.Error(t, err)
+		})
+	}
+}
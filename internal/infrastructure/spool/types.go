@@ -0,0 +1,15 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package spool
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// publisher is the Kafka writer the spool is put in front of.
+type publisher interface {
+	Publish(ctx context.Context, event entity.TransactionEvent) error
+	PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error
+	PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error
+}
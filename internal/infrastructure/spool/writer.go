@@ -0,0 +1,139 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const defaultRetryInterval = time.Second
+
+// Writer publishes events through the next writer and appends the events it
+// fails to publish to the spool. Start replays the spool in order once the
+// next writer accepts events again; while the spool is not empty new events
+// are appended behind the spooled ones. Faulty messages are test traffic and
+// are never spooled.
+type Writer struct {
+	next          publisher
+	spool         *Spool
+	retryInterval time.Duration
+
+	spooled  atomic.Int64
+	replayed atomic.Int64
+}
+
+func NewWriter(next publisher, spool *Spool, retryInterval time.Duration) *Writer {
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	return &Writer{
+		next:          next,
+		spool:         spool,
+		retryInterval: retryInterval,
+	}
+}
+
+// Publish returns an error only when the event could neither be published
+// nor spooled.
+func (w *Writer) Publish(ctx context.Context, event entity.TransactionEvent) error {
+	if w.spool.Empty() {
+		err := w.next.Publish(ctx, event)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		log.Printf("Failed to publish event %s, spooling it: %v", event.EventID, err)
+	}
+	if err := w.spool.Append(ctx, event); err != nil {
+		return fmt.Errorf("failed to spool event %s: %w", event.EventID, err)
+	}
+	w.spooled.Add(1)
+	return nil
+}
+
+func (w *Writer) PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	return w.next.PublishFault(ctx, event, fault)
+}
+
+// PublishAsync queues the event on the next writer and spools it when its
+// delivery fails. A spooled event is reported as delivered. The delivery
+// callback does not wait for space, so a full spool with the block policy
+// fails the delivery.
+func (w *Writer) PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error {
+	if fault.Kind != "" {
+		return w.next.PublishAsync(ctx, event, fault, delivered)
+	}
+	if !w.spool.Empty() {
+		if err := w.spool.Append(ctx, event); err != nil {
+			return fmt.Errorf("failed to spool event %s: %w", event.EventID, err)
+		}
+		w.spooled.Add(1)
+		delivered(nil)
+		return nil
+	}
+	return w.next.PublishAsync(ctx, event, fault, func(err error) {
+		if err != nil {
+			if spoolErr := w.spool.TryAppend(event); spoolErr != nil {
+				err = fmt.Errorf("failed to spool event: %w, delivery error: %w", spoolErr, err)
+			} else {
+				w.spooled.Add(1)
+				err = nil
+			}
+		}
+		delivered(err)
+	})
+}
+
+// Start replays the spool every retry interval until the context is done.
+// Events not replayed stay in the spool for the next start.
+func (w *Writer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.retryInterval)
+	defer ticker.Stop()
+
+	unavailable := false
+	for {
+		err := w.replay(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil && !unavailable:
+			unavailable = true
+			log.Printf("Kafka is unavailable, %d bytes spooled: %v", w.spool.Bytes(), err)
+		case err == nil && unavailable:
+			unavailable = false
+			log.Printf("Kafka is available again, spool replayed")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Spool stopped: %d events spooled, %d replayed, %d dropped, %d bytes left",
+				w.spooled.Load(), w.replayed.Load(), w.spool.Dropped(), w.spool.Bytes())
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// replay publishes the spooled events in order until the spool is empty or
+// publishing fails.
+func (w *Writer) replay(ctx context.Context) error {
+	for {
+		event, err := w.spool.Peek()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = w.next.Publish(ctx, *event); err != nil {
+			return fmt.Errorf("failed to replay spooled event: %w", err)
+		}
+		if err = w.spool.Ack(); err != nil {
+			return err
+		}
+		w.replayed.Add(1)
+	}
+}
@@ -0,0 +1,115 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/spool/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWriter(t *testing.T) {
+	kafkaErr := errors.New("kafka unavailable")
+
+	newWriter := func(t *testing.T) (*Writer, *mocks.Mockpublisher) {
+		s, err := Open(config.Spool{Dir: t.TempDir()})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = s.Close() })
+		next := mocks.NewMockpublisher(gomock.NewController(t))
+		return NewWriter(next, s, time.Millisecond), next
+	}
+
+	t.Run("publishes while kafka is available", func(t *testing.T) {
+		writer, next := newWriter(t)
+		next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(nil)
+
+		require.NoError(t, writer.Publish(context.Background(), testEvent(0)))
+		assert.True(t, writer.spool.Empty())
+	})
+
+	t.Run("spools failed events and replays them in order", func(t *testing.T) {
+		writer, next := newWriter(t)
+		gomock.InOrder(
+			next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(kafkaErr),
+			next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(kafkaErr),
+			next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(nil),
+			next.EXPECT().Publish(gomock.Any(), testEvent(1)).Return(nil),
+		)
+
+		require.NoError(t, writer.Publish(context.Background(), testEvent(0)))
+		// queued behind the spooled event without trying kafka
+		require.NoError(t, writer.Publish(context.Background(), testEvent(1)))
+		assert.Error(t, writer.replay(context.Background()))
+		require.NoError(t, writer.replay(context.Background()))
+
+		assert.True(t, writer.spool.Empty())
+		assert.Equal(t, int64(2), writer.spooled.Load())
+		assert.Equal(t, int64(2), writer.replayed.Load())
+	})
+
+	t.Run("does not spool when the context is done", func(t *testing.T) {
+		writer, next := newWriter(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(context.Canceled)
+
+		assert.ErrorIs(t, writer.Publish(ctx, testEvent(0)), context.Canceled)
+		assert.True(t, writer.spool.Empty())
+	})
+
+	t.Run("spools failed async deliveries", func(t *testing.T) {
+		writer, next := newWriter(t)
+		next.EXPECT().PublishAsync(gomock.Any(), testEvent(0), entity.Fault{}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ entity.TransactionEvent, _ entity.Fault, delivered func(error)) error {
+				delivered(kafkaErr)
+				return nil
+			})
+
+		var deliveryErr error = kafkaErr
+		require.NoError(t, writer.PublishAsync(context.Background(), testEvent(0), entity.Fault{}, func(err error) {
+			deliveryErr = err
+		}))
+		assert.NoError(t, deliveryErr, "a spooled event is delivered")
+		assert.False(t, writer.spool.Empty())
+	})
+
+	t.Run("does not spool faults", func(t *testing.T) {
+		writer, next := newWriter(t)
+		fault := entity.Fault{Kind: entity.FaultMalformed}
+		next.EXPECT().PublishFault(gomock.Any(), testEvent(0), fault).Return(kafkaErr)
+
+		assert.ErrorIs(t, writer.PublishFault(context.Background(), testEvent(0), fault), kafkaErr)
+		assert.True(t, writer.spool.Empty())
+	})
+
+	t.Run("start replays until the context is done", func(t *testing.T) {
+		writer, next := newWriter(t)
+		next.EXPECT().Publish(gomock.Any(), testEvent(0)).Return(kafkaErr)
+		require.NoError(t, writer.Publish(context.Background(), testEvent(0)))
+
+		replayed := make(chan struct{})
+		next.EXPECT().Publish(gomock.Any(), testEvent(0)).DoAndReturn(
+			func(context.Context, entity.TransactionEvent) error {
+				close(replayed)
+				return nil
+			})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- writer.Start(ctx) }()
+		select {
+		case <-replayed:
+		case <-time.After(time.Second):
+			t.Fatal("spooled event was not replayed")
+		}
+		cancel()
+		require.NoError(t, <-done)
+		assert.True(t, writer.spool.Empty())
+	})
+}
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/kafka"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/memory"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/nats"
+	"github.com/bsko/casino-transaction-system/internal/infrastructure/rabbitmq"
+)
+
+const (
+	TypeKafka    = "kafka"
+	TypeNats     = "nats"
+	TypeRabbitMQ = "rabbitmq"
+	// TypeMemory connects a producer and a consumer running in one process,
+	// see memory.Shared.
+	TypeMemory = "memory"
+)
+
+// Reader reads the events the producer published. Read events are
+// committed by Commit only, events read and not committed are read again
+// after a restart.
+type Reader interface {
+	Connect(ctx context.Context) error
+	Read(ctx context.Context) (*entity.ConsumedEvent, error)
+	Commit(ctx context.Context) error
+	Close() error
+}
+
+// Writer publishes the events of the producer.
+type Writer interface {
+	Connect(ctx context.Context) error
+	Publish(ctx context.Context, event entity.TransactionEvent) error
+	PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error
+	PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error
+	Close() error
+}
+
+// NewReader returns the reader of the transport of the config.
+func NewReader(conf *config.App) (Reader, error) {
+	switch transportType(conf) {
+	case TypeKafka:
+		if conf.Kafka == nil {
+			return nil, fmt.Errorf("no kafka config provided")
+		}
+		return kafka.NewKafkaReader(*conf.Kafka), nil
+	case TypeNats:
+		if conf.Transport.Nats == nil {
+			return nil, fmt.Errorf("no nats config provided")
+		}
+		return nats.NewNatsReader(*conf.Transport.Nats), nil
+	case TypeRabbitMQ:
+		if conf.Transport.RabbitMQ == nil {
+			return nil, fmt.Errorf("no rabbitmq config provided")
+		}
+		return rabbitmq.NewRabbitMQReader(*conf.Transport.RabbitMQ), nil
+	case TypeMemory:
+		return memory.NewMemoryReader(memory.Shared()), nil
+	default:
+		return nil, unknownType(conf)
+	}
+}
+
+// NewWriter returns the writer of the transport of the config.
+func NewWriter(conf *config.App) (Writer, error) {
+	switch transportType(conf) {
+	case TypeKafka:
+		if conf.Kafka == nil {
+			return nil, fmt.Errorf("no kafka config provided")
+		}
+		return kafka.NewKafkaWriter(*conf.Kafka), nil
+	case TypeNats:
+		if conf.Transport.Nats == nil {
+			return nil, fmt.Errorf("no nats config provided")
+		}
+		return nats.NewNatsWriter(*conf.Transport.Nats), nil
+	case TypeRabbitMQ:
+		if conf.Transport.RabbitMQ == nil {
+			return nil, fmt.Errorf("no rabbitmq config provided")
+		}
+		return rabbitmq.NewRabbitMQWriter(*conf.Transport.RabbitMQ), nil
+	case TypeMemory:
+		return memory.NewMemoryWriter(memory.Shared()), nil
+	default:
+		return nil, unknownType(conf)
+	}
+}
+
+// Async reports whether the writer of the config queues events and reports
+// their delivery later.
+func Async(conf *config.App) bool {
+	switch transportType(conf) {
+	case TypeKafka:
+		return conf.Kafka != nil && conf.Kafka.Writer != nil && conf.Kafka.Writer.Async
+	case TypeNats:
+		return conf.Transport.Nats != nil && conf.Transport.Nats.Async
+	case TypeRabbitMQ:
+		return conf.Transport.RabbitMQ != nil && conf.Transport.RabbitMQ.Async
+	default:
+		return false
+	}
+}
+
+func transportType(conf *config.App) string {
+	if conf.Transport == nil || conf.Transport.Type == "" {
+		return TypeKafka
+	}
+	return conf.Transport.Type
+}
+
+func unknownType(conf *config.App) error {
+	return fmt.Errorf("unknown transport %q, must be one of kafka, nats, rabbitmq, memory", conf.Transport.Type)
+}
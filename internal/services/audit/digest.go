@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	pageSize = 1000
+	day      = 24 * time.Hour
+)
+
+// computeDigest hashes the previous digest, the day and the row hashes of
+// every event ingested on that day in ID order, and signs the result.
+func computeDigest(ctx context.Context, repository ingestedRowsRepository, signingKey []byte, dayStart time.Time, prevDigest string) (*entity.AuditDigest, error) {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s|%s", prevDigest, dayStart.Format(time.DateOnly))
+
+	var rowCount int64
+	var afterID int64
+	for {
+		rows, err := repository.ListIngestedRows(ctx, dayStart, dayStart.Add(day), afterID, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			_, _ = io.WriteString(h, "|"+row.RowHash)
+			afterID = row.ID
+		}
+		rowCount += int64(len(rows))
+		if len(rows) < pageSize {
+			break
+		}
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	return &entity.AuditDigest{
+		Day:        dayStart,
+		RowCount:   rowCount,
+		PrevDigest: prevDigest,
+		Digest:     digest,
+		Signature:  sign(signingKey, digest),
+	}, nil
+}
+
+func sign(signingKey []byte, digest string) string {
+	return hex.EncodeToString(mac(signingKey, digest))
+}
+
+// validSignature compares the signature with the one of the digest in
+// constant time. A signature that is not hex does not match.
+func validSignature(signingKey []byte, digest, signature string) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, mac(signingKey, digest))
+}
+
+func mac(signingKey []byte, digest string) []byte {
+	h := hmac.New(sha256.New, signingKey)
+	_, _ = io.WriteString(h, digest)
+	return h.Sum(nil)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultSealInterval = time.Hour
+	defaultSealGrace    = 10 * time.Minute
+)
+
+// Sealer stores a signed digest for every finished UTC day. A day is sealed
+// once the grace period after its end has passed, so that batches still in
+// flight at midnight are committed before their day is digested.
+type Sealer struct {
+	repository   digestRepository
+	signingKey   []byte
+	sealInterval time.Duration
+	sealGrace    time.Duration
+}
+
+func NewSealer(repository digestRepository, conf config.Audit) *Sealer {
+	sealer := &Sealer{
+		repository:   repository,
+		signingKey:   []byte(conf.SigningKey),
+		sealInterval: defaultSealInterval,
+		sealGrace:    defaultSealGrace,
+	}
+	if conf.SealIntervalMs > 0 {
+		sealer.sealInterval = time.Duration(conf.SealIntervalMs) * time.Millisecond
+	}
+	if conf.SealGraceMs > 0 {
+		sealer.sealGrace = time.Duration(conf.SealGraceMs) * time.Millisecond
+	}
+	return sealer
+}
+
+func (s *Sealer) Start(ctx context.Context) error {
+	log.Println("Starting audit digest sealer")
+	defer func() { log.Println("Stopping audit digest sealer") }()
+
+	ticker := time.NewTicker(s.sealInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SealPending(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to seal audit digests: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// SealPending seals every unsealed day that ended at least the grace period
+// before now and returns how many days it sealed. Sealing starts on the day
+// of the first chained event and continues without gaps, days without events
+// get a digest too.
+func (s *Sealer) SealPending(ctx context.Context, now time.Time) (int, error) {
+	var next time.Time
+	var prevDigest string
+
+	last, err := s.repository.GetLastDigest(ctx)
+	switch {
+	case errors.Is(err, entity.ErrNotFound):
+		first, err := s.repository.GetFirstIngestedAt(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if first == nil {
+			return 0, nil
+		}
+		next = startOfDay(*first)
+	case err != nil:
+		return 0, err
+	default:
+		next = last.Day.Add(day)
+		prevDigest = last.Digest
+	}
+
+	sealed := 0
+	for !next.Add(day + s.sealGrace).After(now) {
+		if ctx.Err() != nil {
+			return sealed, ctx.Err()
+		}
+
+		digest, err := computeDigest(ctx, s.repository, s.signingKey, next, prevDigest)
+		if err != nil {
+			return sealed, fmt.Errorf("failed to compute digest of %s: %w", next.Format(time.DateOnly), err)
+		}
+		created, err := s.repository.CreateDigest(ctx, *digest)
+		if err != nil {
+			return sealed, err
+		}
+		if !created {
+			// another consumer sealed the day, continue from its digest next time
+			return sealed, nil
+		}
+
+		sealed++
+		prevDigest = digest.Digest
+		next = next.Add(day)
+	}
+	return sealed, nil
+}
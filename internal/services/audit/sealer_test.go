@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/audit/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSealer_SealPending(t *testing.T) {
+	conf := config.Audit{SigningKey: "key", SealGraceMs: int(time.Hour / time.Millisecond)}
+	firstDay := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	rows := chain(entity.UserID{UUID: uuid.New()}, 1, 100, 200)
+
+	t.Run("seals every finished day from the first chained event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdigestRepository(ctrl)
+		sealer := NewSealer(mockRepo, conf)
+
+		firstIngested := firstDay.Add(15 * time.Hour)
+		mockRepo.EXPECT().GetLastDigest(gomock.Any()).Return(nil, fmt.Errorf("audit digest: %w", entity.ErrNotFound))
+		mockRepo.EXPECT().GetFirstIngestedAt(gomock.Any()).Return(&firstIngested, nil)
+		mockRepo.EXPECT().
+			ListIngestedRows(gomock.Any(), firstDay, firstDay.Add(day), int64(0), pageSize).
+			Return(rows, nil)
+		mockRepo.EXPECT().
+			ListIngestedRows(gomock.Any(), firstDay.Add(day), firstDay.Add(2*day), int64(0), pageSize).
+			Return(nil, nil)
+
+		var stored []entity.AuditDigest
+		mockRepo.EXPECT().
+			CreateDigest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, digest entity.AuditDigest) (bool, error) {
+				stored = append(stored, digest)
+				return true, nil
+			}).
+			Times(2)
+
+		// the third day ended, but its grace period has not passed yet
+		sealed, err := sealer.SealPending(context.Background(), firstDay.Add(3*day+30*time.Minute))
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, sealed)
+		require.Len(t, stored, 2)
+		assert.Equal(t, firstDay, stored[0].Day)
+		assert.Equal(t, int64(2), stored[0].RowCount)
+		assert.Empty(t, stored[0].PrevDigest)
+		assert.Equal(t, sign([]byte("key"), stored[0].Digest), stored[0].Signature)
+		assert.Equal(t, int64(0), stored[1].RowCount)
+		assert.Equal(t, stored[0].Digest, stored[1].PrevDigest)
+	})
+
+	t.Run("continues after the last digest and stops when another consumer sealed the day", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdigestRepository(ctrl)
+		sealer := NewSealer(mockRepo, conf)
+
+		mockRepo.EXPECT().GetLastDigest(gomock.Any()).Return(&entity.AuditDigest{Day: firstDay, Digest: "abc"}, nil)
+		mockRepo.EXPECT().
+			ListIngestedRows(gomock.Any(), firstDay.Add(day), firstDay.Add(2*day), int64(0), pageSize).
+			Return(rows, nil)
+		mockRepo.EXPECT().
+			CreateDigest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, digest entity.AuditDigest) (bool, error) {
+				assert.Equal(t, "abc", digest.PrevDigest)
+				return false, nil
+			})
+
+		sealed, err := sealer.SealPending(context.Background(), firstDay.Add(5*day))
+
+		require.NoError(t, err)
+		assert.Zero(t, sealed)
+	})
+
+	t.Run("nothing to seal without chained events", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdigestRepository(ctrl)
+		sealer := NewSealer(mockRepo, conf)
+
+		mockRepo.EXPECT().GetLastDigest(gomock.Any()).Return(nil, entity.ErrNotFound)
+		mockRepo.EXPECT().GetFirstIngestedAt(gomock.Any()).Return(nil, nil)
+
+		sealed, err := sealer.SealPending(context.Background(), time.Now())
+
+		require.NoError(t, err)
+		assert.Zero(t, sealed)
+	})
+}
@@ -0,0 +1,28 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type ingestedRowsRepository interface {
+	ListIngestedRows(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]entity.AuditedEvent, error)
+}
+
+type digestRepository interface {
+	ingestedRowsRepository
+	GetFirstIngestedAt(ctx context.Context) (*time.Time, error)
+	GetLastDigest(ctx context.Context) (*entity.AuditDigest, error)
+	CreateDigest(ctx context.Context, digest entity.AuditDigest) (bool, error)
+}
+
+type verificationRepository interface {
+	ingestedRowsRepository
+	ListChainHeads(ctx context.Context, after *entity.UserID, limit int) ([]entity.AuditChainHead, error)
+	GetChainHead(ctx context.Context, userID entity.UserID) (*entity.AuditChainHead, error)
+	ListChainRows(ctx context.Context, userID entity.UserID, afterID int64, limit int) ([]entity.AuditedEvent, error)
+	ListDigests(ctx context.Context, after *time.Time, limit int) ([]entity.AuditDigest, error)
+}
@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type Verifier struct {
+	repository verificationRepository
+	signingKey []byte
+}
+
+func NewVerifier(repository verificationRepository, conf config.Audit) *Verifier {
+	return &Verifier{
+		repository: repository,
+		signingKey: []byte(conf.SigningKey),
+	}
+}
+
+// Verify walks the audit chain of every user and the chain of daily digests
+// and reports the first tampered row and digest. When userID is set only the
+// chain of that user is walked and digests are not checked.
+func (v *Verifier) Verify(ctx context.Context, userID *entity.UserID) (*entity.AuditVerification, error) {
+	result := &entity.AuditVerification{}
+
+	if userID != nil {
+		head, err := v.repository.GetChainHead(ctx, *userID)
+		if err != nil {
+			return nil, err
+		}
+		if err = v.verifyChain(ctx, *head, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	var after *entity.UserID
+	for {
+		heads, err := v.repository.ListChainHeads(ctx, after, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, head := range heads {
+			if err = v.verifyChain(ctx, head, result); err != nil {
+				return nil, err
+			}
+		}
+		if len(heads) < pageSize {
+			break
+		}
+		after = &heads[len(heads)-1].UserID
+	}
+
+	if err := v.verifyDigests(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// verifyChain checks that every chained row of the user hashes to its stored
+// hash, links to the hash of the row before it and that the last row is the
+// recorded chain head. Rows stored before the audit trail existed precede
+// the chain and are skipped. Rows of erased users are only checked for their
+// links, the user id they were hashed with is gone.
+func (v *Verifier) verifyChain(ctx context.Context, head entity.AuditChainHead, result *entity.AuditVerification) error {
+	result.CheckedUsers++
+
+	var prevHash string
+	var lastID, afterID int64
+	started := false
+	for {
+		rows, err := v.repository.ListChainRows(ctx, head.UserID, afterID, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			afterID = row.ID
+			result.CheckedRows++
+
+			var reason string
+			switch {
+			case row.RowHash == "":
+				if started {
+					reason = "audit hash was removed"
+				}
+			case row.PrevHash != prevHash:
+				reason = "chain is broken, rows before it were deleted, inserted or reordered"
+			case !head.Erased && entity.ChainHash(row.PrevHash, row.Event) != row.RowHash:
+				reason = "row contents do not match its hash"
+			}
+			if reason != "" {
+				reportRow(result, row.ID, head.UserID, reason)
+				return nil
+			}
+			if row.RowHash != "" {
+				started = true
+				prevHash = row.RowHash
+				lastID = row.ID
+			}
+		}
+		if len(rows) < pageSize {
+			break
+		}
+	}
+
+	if prevHash != head.LastHash {
+		reportRow(result, lastID, head.UserID, "chain head does not match the last row, rows were deleted from the end of the chain")
+	}
+	return nil
+}
+
+// verifyDigests recomputes every digest from the stored rows and checks the
+// digest chain and signatures.
+func (v *Verifier) verifyDigests(ctx context.Context, result *entity.AuditVerification) error {
+	var prevDigest string
+	var after *entity.AuditDigest
+	for {
+		var afterDay *time.Time
+		if after != nil {
+			afterDay = &after.Day
+		}
+		digests, err := v.repository.ListDigests(ctx, afterDay, pageSize)
+		if err != nil {
+			return err
+		}
+		for i := range digests {
+			stored := digests[i]
+			result.CheckedDigests++
+
+			expected, err := computeDigest(ctx, v.repository, v.signingKey, stored.Day, prevDigest)
+			if err != nil {
+				return fmt.Errorf("failed to compute digest of %s: %w", stored.Day.Format(time.DateOnly), err)
+			}
+
+			var reason string
+			switch {
+			case after != nil && !stored.Day.Equal(after.Day.Add(day)):
+				reason = "digests of preceding days were deleted"
+			case stored.PrevDigest != prevDigest:
+				reason = "digest chain is broken"
+			case !validSignature(v.signingKey, stored.Digest, stored.Signature):
+				reason = "signature does not match the digest"
+			case stored.RowCount != expected.RowCount:
+				reason = fmt.Sprintf("day has %d rows, %d were sealed", expected.RowCount, stored.RowCount)
+			case stored.Digest != expected.Digest:
+				reason = "rows of the day do not match the digest"
+			}
+			if reason != "" {
+				result.TamperedDigest = &entity.AuditDigestFinding{Day: stored.Day, Reason: reason}
+				return nil
+			}
+
+			prevDigest = stored.Digest
+			after = &digests[i]
+		}
+		if len(digests) < pageSize {
+			return nil
+		}
+	}
+}
+
+func reportRow(result *entity.AuditVerification, rowID int64, userID entity.UserID, reason string) {
+	if result.TamperedRow != nil && result.TamperedRow.RowID <= rowID {
+		return
+	}
+	result.TamperedRow = &entity.AuditFinding{
+		RowID:  rowID,
+		UserID: userID,
+		Reason: reason,
+	}
+}
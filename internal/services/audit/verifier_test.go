@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/audit/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// chain builds the stored rows of a user's chain the way BatchStore does.
+func chain(userID entity.UserID, firstID int64, amounts ...entity.Money) []entity.AuditedEvent {
+	rows := make([]entity.AuditedEvent, 0, len(amounts))
+	prevHash := ""
+	createdAt := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i, amount := range amounts {
+		event := entity.TransactionEvent{
+			EventID:         uuid.NewString(),
+			UserID:          userID,
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          amount,
+			CreatedAt:       createdAt.Add(time.Duration(i) * time.Second),
+		}
+		rowHash := entity.ChainHash(prevHash, event)
+		rows = append(rows, entity.AuditedEvent{ID: firstID + int64(i), Event: event, PrevHash: prevHash, RowHash: rowHash})
+		prevHash = rowHash
+	}
+	return rows
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	userID := entity.UserID{UUID: uuid.New()}
+
+	verifyUser := func(t *testing.T, rows []entity.AuditedEvent, lastHash string) *entity.AuditVerification {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockverificationRepository(ctrl)
+		mockRepo.EXPECT().
+			GetChainHead(gomock.Any(), userID).
+			Return(&entity.AuditChainHead{UserID: userID, LastHash: lastHash}, nil)
+		mockRepo.EXPECT().
+			ListChainRows(gomock.Any(), userID, int64(0), pageSize).
+			Return(rows, nil)
+
+		verification, err := NewVerifier(mockRepo, config.Audit{SigningKey: "key"}).Verify(context.Background(), &userID)
+		require.NoError(t, err)
+		return verification
+	}
+
+	t.Run("intact chain", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200, 300)
+
+		verification := verifyUser(t, rows, rows[2].RowHash)
+
+		assert.True(t, verification.Valid())
+		assert.Equal(t, 1, verification.CheckedUsers)
+		assert.Equal(t, int64(3), verification.CheckedRows)
+	})
+
+	t.Run("rows stored before the audit trail are skipped", func(t *testing.T) {
+		legacy := entity.AuditedEvent{ID: 1, Event: entity.TransactionEvent{UserID: userID, Amount: 50}}
+		rows := append([]entity.AuditedEvent{legacy}, chain(userID, 2, 100)...)
+
+		verification := verifyUser(t, rows, rows[1].RowHash)
+
+		assert.True(t, verification.Valid())
+	})
+
+	t.Run("altered amount", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200, 300)
+		rows[1].Event.Amount = 20000
+
+		verification := verifyUser(t, rows, rows[2].RowHash)
+
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(2), verification.TamperedRow.RowID)
+		assert.Equal(t, "row contents do not match its hash", verification.TamperedRow.Reason)
+	})
+
+	t.Run("altered tenant", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200, 300)
+		rows[1].Event.TenantID = "brand-b"
+
+		verification := verifyUser(t, rows, rows[2].RowHash)
+
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(2), verification.TamperedRow.RowID)
+		assert.Equal(t, "row contents do not match its hash", verification.TamperedRow.Reason)
+	})
+
+	t.Run("deleted row breaks the chain", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200, 300)
+		rows = append(rows[:1], rows[2:]...)
+
+		verification := verifyUser(t, rows, rows[1].RowHash)
+
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(3), verification.TamperedRow.RowID)
+		assert.Contains(t, verification.TamperedRow.Reason, "chain is broken")
+	})
+
+	t.Run("removed hash", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200)
+		rows[1].RowHash = ""
+
+		verification := verifyUser(t, rows, rows[1].RowHash)
+
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(2), verification.TamperedRow.RowID)
+	})
+
+	t.Run("deleted tail", func(t *testing.T) {
+		rows := chain(userID, 1, 100, 200, 300)
+
+		verification := verifyUser(t, rows[:2], rows[2].RowHash)
+
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(2), verification.TamperedRow.RowID)
+		assert.Contains(t, verification.TamperedRow.Reason, "deleted from the end")
+	})
+
+	t.Run("erased chain is checked for its links only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		pseudonym := entity.UserID{UUID: uuid.New()}
+		rows := chain(userID, 1, 100, 200)
+		for i := range rows {
+			rows[i].Event.UserID = pseudonym
+		}
+
+		mockRepo := mocks.NewMockverificationRepository(ctrl)
+		mockRepo.EXPECT().
+			GetChainHead(gomock.Any(), pseudonym).
+			Return(&entity.AuditChainHead{UserID: pseudonym, LastHash: rows[1].RowHash, Erased: true}, nil)
+		mockRepo.EXPECT().
+			ListChainRows(gomock.Any(), pseudonym, int64(0), pageSize).
+			Return(rows, nil)
+
+		verification, err := NewVerifier(mockRepo, config.Audit{SigningKey: "key"}).Verify(context.Background(), &pseudonym)
+		require.NoError(t, err)
+		assert.True(t, verification.Valid())
+	})
+}
+
+func TestVerifier_VerifyAll(t *testing.T) {
+	first := entity.UserID{UUID: uuid.New()}
+	second := entity.UserID{UUID: uuid.New()}
+	firstRows := chain(first, 10, 100, 200)
+	secondRows := chain(second, 1, 100, 200)
+	secondRows[1].Event.Amount = 1
+	dayStart := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("reports the tampered row with the lowest id and a forged digest", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockverificationRepository(ctrl)
+		verifier := NewVerifier(mockRepo, config.Audit{SigningKey: "key"})
+
+		firstRows[0].Event.Amount = 1
+		mockRepo.EXPECT().
+			ListChainHeads(gomock.Any(), nil, pageSize).
+			Return([]entity.AuditChainHead{
+				{UserID: first, LastHash: firstRows[1].RowHash},
+				{UserID: second, LastHash: secondRows[1].RowHash},
+			}, nil)
+		mockRepo.EXPECT().ListChainRows(gomock.Any(), first, int64(0), pageSize).Return(firstRows, nil)
+		mockRepo.EXPECT().ListChainRows(gomock.Any(), second, int64(0), pageSize).Return(secondRows, nil)
+
+		allRows := append(append([]entity.AuditedEvent{}, secondRows...), firstRows...)
+		genuine, err := computeDigest(context.Background(), staticRows(allRows), []byte("key"), dayStart, "")
+		require.NoError(t, err)
+		forged := *genuine
+		forged.Signature = sign([]byte("other key"), forged.Digest)
+
+		mockRepo.EXPECT().
+			ListDigests(gomock.Any(), nil, pageSize).
+			Return([]entity.AuditDigest{forged}, nil)
+		mockRepo.EXPECT().
+			ListIngestedRows(gomock.Any(), dayStart, dayStart.Add(day), int64(0), pageSize).
+			Return(allRows, nil)
+
+		verification, err := verifier.Verify(context.Background(), nil)
+
+		require.NoError(t, err)
+		assert.False(t, verification.Valid())
+		assert.Equal(t, 2, verification.CheckedUsers)
+		require.NotNil(t, verification.TamperedRow)
+		assert.Equal(t, int64(2), verification.TamperedRow.RowID)
+		assert.Equal(t, second, verification.TamperedRow.UserID)
+		require.NotNil(t, verification.TamperedDigest)
+		assert.Equal(t, dayStart, verification.TamperedDigest.Day)
+		assert.Equal(t, "signature does not match the digest", verification.TamperedDigest.Reason)
+	})
+}
+
+func TestValidSignature(t *testing.T) {
+	key := []byte("key")
+	signature := sign(key, "digest")
+
+	assert.True(t, validSignature(key, "digest", signature))
+	assert.False(t, validSignature(key, "other digest", signature))
+	assert.False(t, validSignature([]byte("other key"), "digest", signature))
+	assert.False(t, validSignature(key, "digest", signature[:10]))
+	assert.False(t, validSignature(key, "digest", "not hex"))
+}
+
+type staticRows []entity.AuditedEvent
+
+func (s staticRows) ListIngestedRows(_ context.Context, _, _ time.Time, _ int64, _ int) ([]entity.AuditedEvent, error) {
+	return s, nil
+}
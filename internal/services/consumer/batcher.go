@@ -3,22 +3,20 @@ package consumer
 import (
 	"sync"
 	"time"
-
-	"github.com/bsko/casino-transaction-system/internal/entity"
 )
 
-type Batcher struct {
-	batch     []entity.TransactionEvent
+type Batcher[T any] struct {
+	batch     []T
 	batchSize int
 	timeout   time.Duration
-	flushFunc func([]entity.TransactionEvent) error
+	flushFunc func([]T) error
 	mu        sync.Mutex
 	timer     *time.Timer
 }
 
-func NewBatcher(size int, timeout time.Duration, fn func([]entity.TransactionEvent) error) *Batcher {
-	b := &Batcher{
-		batch:     make([]entity.TransactionEvent, 0, size),
+func NewBatcher[T any](size int, timeout time.Duration, fn func([]T) error) *Batcher[T] {
+	b := &Batcher[T]{
+		batch:     make([]T, 0, size),
 		batchSize: size,
 		timeout:   timeout,
 		flushFunc: fn,
@@ -27,7 +25,7 @@ func NewBatcher(size int, timeout time.Duration, fn func([]entity.TransactionEve
 	return b
 }
 
-func (b *Batcher) Add(msg entity.TransactionEvent) error {
+func (b *Batcher[T]) Add(msg T) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -40,7 +38,7 @@ func (b *Batcher) Add(msg entity.TransactionEvent) error {
 	return nil
 }
 
-func (b *Batcher) flush() error {
+func (b *Batcher[T]) flush() error {
 	defer b.resetTimer()
 	if len(b.batch) == 0 {
 		return nil
@@ -51,7 +49,7 @@ func (b *Batcher) flush() error {
 	return err
 }
 
-func (b *Batcher) resetTimer() {
+func (b *Batcher[T]) resetTimer() {
 	if b.timer != nil {
 		b.timer.Stop()
 	}
@@ -62,7 +60,7 @@ func (b *Batcher) resetTimer() {
 	})
 }
 
-func (b *Batcher) Close() error {
+func (b *Batcher[T]) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
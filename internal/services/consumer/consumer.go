@@ -2,9 +2,11 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
+	"github.com/bsko/casino-transaction-system/internal/config"
 	"github.com/bsko/casino-transaction-system/internal/entity"
 )
 
@@ -13,9 +15,23 @@ const (
 	batchTimeout = 10 * time.Second
 )
 
+// batchEntry is a message read by the consumer. Dropped messages are not
+// stored, they are batched to be committed with the batch, so that a stream of
+// messages that are never stored is committed too.
+type batchEntry struct {
+	consumed *entity.ConsumedEvent
+	dropped  bool
+}
+
 type Consumer struct {
 	reader                     kafkaReader
 	transactionEventRepository transactionEventSaveRepository
+	publisher                  transactionEventPublisher
+	derivation                 derivation
+	inspector                  eventInspector
+	capture                    eventCapture
+	routes                     map[string]topicRoute
+	stats                      *topicStats
 	batchSize                  int
 }
 
@@ -23,6 +39,7 @@ func NewConsumer(reader kafkaReader, repository transactionEventSaveRepository)
 	return &Consumer{
 		reader:                     reader,
 		transactionEventRepository: repository,
+		stats:                      newTopicStats(),
 		batchSize:                  batchSize,
 	}
 }
@@ -33,11 +50,19 @@ func (s *Consumer) Start(ctx context.Context) error {
 
 	flushCtx := context.Background()
 
-	batcher := NewBatcher(s.batchSize, batchTimeout, func(events []entity.TransactionEvent) error {
-		log.Println("Saving batch of events")
-		err := s.transactionEventRepository.BatchStore(flushCtx, events)
-		if err != nil {
-			return err
+	batcher := NewBatcher(s.batchSize, batchTimeout, func(entries []batchEntry) error {
+		batch := make([]*entity.ConsumedEvent, 0, len(entries))
+		events := make([]entity.TransactionEvent, 0, len(entries))
+		for _, entry := range entries {
+			if !entry.dropped {
+				batch = append(batch, entry.consumed)
+				events = append(events, entry.consumed.Event)
+			}
+		}
+		if len(events) > 0 {
+			if err := s.storeBatch(flushCtx, batch, events); err != nil {
+				return err
+			}
 		}
 		if commitErr := s.reader.Commit(ctx); commitErr != nil {
 			log.Printf("Failed to commit offsets: %v", commitErr)
@@ -47,6 +72,9 @@ func (s *Consumer) Start(ctx context.Context) error {
 		return nil
 	})
 	defer func() { _ = batcher.Close() }()
+	drop := func() error {
+		return batcher.Add(batchEntry{dropped: true})
+	}
 
 	for {
 		select {
@@ -57,6 +85,16 @@ func (s *Consumer) Start(ctx context.Context) error {
 		default:
 			msg, err := s.reader.Read(ctx)
 			if err != nil {
+				var invalid *entity.InvalidMessageError
+				if errors.As(err, &invalid) {
+					s.stats.update(invalid.Topic, func(stats *entity.TopicStats) { stats.Rejected++ })
+					s.stats.updateFault(invalid.Topic, invalid.Fault, func(stats *entity.FaultStats) { stats.Rejected++ })
+					log.Printf("Skipping message: %v", err)
+					if err = drop(); err != nil {
+						return err
+					}
+					continue
+				}
 				if ctx.Err() != nil {
 					log.Println("Consumer stopped by context cancellation during read")
 					_ = batcher.Close()
@@ -73,7 +111,37 @@ func (s *Consumer) Start(ctx context.Context) error {
 					}
 				}
 			}
-			if err = batcher.Add(*msg); err != nil {
+			s.stats.update(msg.Topic, func(stats *entity.TopicStats) { stats.Received++ })
+			s.stats.updateFault(msg.Topic, msg.Fault, func(stats *entity.FaultStats) { stats.Received++ })
+			if s.capture != nil {
+				if err = s.capture.Write(msg.Event); err != nil {
+					log.Printf("Failed to capture event: %v", err)
+				}
+			}
+
+			route := s.routes[msg.Topic]
+			if err = route.validator.validate(msg.Event); err != nil {
+				s.stats.update(msg.Topic, func(stats *entity.TopicStats) { stats.Rejected++ })
+				s.stats.updateFault(msg.Topic, msg.Fault, func(stats *entity.FaultStats) { stats.Rejected++ })
+				log.Printf("Skipping invalid event on topic %s: %v", msg.Topic, err)
+				if err = drop(); err != nil {
+					return err
+				}
+				continue
+			}
+			if s.inspector != nil {
+				if _, err = s.inspector.Inspect(ctx, msg.Event); err != nil {
+					log.Printf("Failed to inspect event: %v", err)
+				}
+			}
+			if route.inspect {
+				s.stats.update(msg.Topic, func(stats *entity.TopicStats) { stats.Inspected++ })
+				if err = drop(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err = batcher.Add(batchEntry{consumed: msg}); err != nil {
 				log.Printf("Failed to add message to batcher: %v", err)
 				return err
 			}
@@ -81,6 +149,93 @@ func (s *Consumer) Start(ctx context.Context) error {
 	}
 }
 
+// storeBatch stores the events of the batch and publishes them to the feed.
+// Events of erased users are not stored and count as rejected.
+func (s *Consumer) storeBatch(ctx context.Context, batch []*entity.ConsumedEvent, events []entity.TransactionEvent) error {
+	log.Println("Saving batch of events")
+	erased, err := s.derivation.store(ctx, s.transactionEventRepository, events)
+	if err != nil {
+		return err
+	}
+	stored := make([]entity.TransactionEvent, 0, len(events))
+	for _, consumed := range batch {
+		if _, ok := erased[consumed.Event.UserID]; ok {
+			s.stats.update(consumed.Topic, func(stats *entity.TopicStats) { stats.Rejected++ })
+			s.stats.updateFault(consumed.Topic, consumed.Fault, func(stats *entity.FaultStats) { stats.Rejected++ })
+			continue
+		}
+		s.stats.update(consumed.Topic, func(stats *entity.TopicStats) { stats.Stored++ })
+		s.stats.updateFault(consumed.Topic, consumed.Fault, func(stats *entity.FaultStats) { stats.Stored++ })
+		stored = append(stored, consumed.Event)
+	}
+	if len(erased) > 0 {
+		log.Printf("Skipped %d events of erased users", len(events)-len(stored))
+	}
+	if s.publisher != nil && len(stored) > 0 {
+		s.publisher.Publish(stored)
+	}
+	return nil
+}
+
 func (s *Consumer) SetBatchSize(batchSize int) {
 	s.batchSize = batchSize
 }
+
+// SetEventPublisher registers a publisher notified with every successfully
+// stored batch.
+func (s *Consumer) SetEventPublisher(publisher transactionEventPublisher) {
+	s.publisher = publisher
+}
+
+// SetOutbox makes every batch be stored together with the events derived from
+// it by the deriver.
+func (s *Consumer) SetOutbox(deriver outboxDeriver) {
+	s.derivation.outbox = deriver
+}
+
+// SetLimits makes every batch be stored together with the limit breaches it
+// causes.
+func (s *Consumer) SetLimits(evaluator limitEvaluator) {
+	s.derivation.limits = evaluator
+}
+
+// SetWebhooks makes every batch be stored together with the webhook
+// deliveries it triggers.
+func (s *Consumer) SetWebhooks(deriver webhookDeriver) {
+	s.derivation.webhooks = deriver
+}
+
+// SetTopics configures the validation and handler of every topic. Events of
+// topics that are not configured are stored without validation.
+func (s *Consumer) SetTopics(topics []config.KafkaTopic) error {
+	routes, err := newTopicRoutes(topics)
+	if err != nil {
+		return err
+	}
+	s.routes = routes
+	return nil
+}
+
+// SetErasureGuard makes batches skip the events of erased users, which are
+// counted as rejected.
+func (s *Consumer) SetErasureGuard(filter erasedUserFilter) {
+	s.derivation.erasure = filter
+}
+
+// TopicStats returns the message counts of every topic seen so far.
+func (s *Consumer) TopicStats() []entity.TopicStats {
+	return s.stats.snapshot()
+}
+
+// SetCapture tees every decoded event, before validation, to the capture.
+// Capture errors are logged and do not stop consumption.
+func (s *Consumer) SetCapture(capture eventCapture) {
+	s.capture = capture
+}
+
+// SetEventInspector registers an inspector that sees every decoded event
+// before it is batched. Inspection errors are logged and do not stop
+// consumption.
+func (s *Consumer) SetEventInspector(inspector eventInspector) {
+	s.inspector = inspector
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bsko/casino-transaction-system/internal/config"
 	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/bsko/casino-transaction-system/internal/services/consumer/mocks"
 	"github.com/google/uuid"
@@ -13,6 +14,8 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+const testTopic = "transactions"
+
 func TestConsumer_Start(t *testing.T) {
 	t.Run("successful processing and context cancellation", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -34,7 +37,7 @@ func TestConsumer_Start(t *testing.T) {
 
 		mockReader.EXPECT().
 			Read(gomock.Any()).
-			Return(event, nil).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
 			AnyTimes()
 
 		mockRepo.EXPECT().
@@ -111,7 +114,7 @@ func TestConsumer_Start(t *testing.T) {
 
 		mockReader.EXPECT().
 			Read(gomock.Any()).
-			Return(event, nil).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
 			Times(100)
 
 		mockRepo.EXPECT().
@@ -126,4 +129,411 @@ func TestConsumer_Start(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 	})
+
+	t.Run("stored batch is published to the feed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockPublisher := mocks.NewMocktransactionEventPublisher(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(2)
+		consumer.SetEventPublisher(mockPublisher)
+
+		event := &entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeWin,
+			Amount:          entity.ToMoney(10.0),
+			CreatedAt:       time.Now(),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
+			Times(2)
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).
+			AnyTimes()
+
+		gomock.InOrder(
+			mockRepo.EXPECT().
+				BatchStore(gomock.Any(), gomock.Any()).
+				Return(nil).
+				Times(1),
+			mockPublisher.EXPECT().
+				Publish(gomock.Len(2)).
+				Do(func(_ []entity.TransactionEvent) { cancel() }).
+				Times(1),
+			mockReader.EXPECT().
+				Commit(gomock.Any()).
+				Return(nil).
+				Times(1),
+		)
+
+		err := consumer.Start(ctx)
+		assert.NoError(t, err)
+	})
+	t.Run("batch is stored together with derived outbox messages", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockDeriver := mocks.NewMockoutboxDeriver(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(1)
+		consumer.SetOutbox(mockDeriver)
+
+		event := &entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeWin,
+			Amount:          entity.ToMoney(5000.0),
+			CreatedAt:       time.Now(),
+		}
+		messages := []entity.OutboxMessage{{Key: event.UserID.UUID.String(), EventType: entity.OutboxEventLargeWin}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
+			Times(1)
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).
+			AnyTimes()
+
+		gomock.InOrder(
+			mockDeriver.EXPECT().
+				Derive([]entity.TransactionEvent{*event}).
+				Return(messages, nil).
+				Times(1),
+			mockRepo.EXPECT().
+				BatchStoreWithDerived(gomock.Any(), []entity.TransactionEvent{*event}, entity.DerivedRecords{OutboxMessages: messages}).
+				Return(nil).
+				Times(1),
+			mockReader.EXPECT().
+				Commit(gomock.Any()).
+				DoAndReturn(func(context.Context) error {
+					cancel()
+					return nil
+				}).
+				Times(1),
+		)
+
+		err := consumer.Start(ctx)
+		assert.NoError(t, err)
+	})
+	t.Run("events of erased users are not stored nor published", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockPublisher := mocks.NewMocktransactionEventPublisher(ctrl)
+		mockErasure := mocks.NewMockerasedUserFilter(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(2)
+		consumer.SetEventPublisher(mockPublisher)
+		consumer.SetErasureGuard(mockErasure)
+
+		erased := entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(10.0),
+			CreatedAt:       time.Now(),
+		}
+		active := entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(20.0),
+			CreatedAt:       time.Now(),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: erased}, nil).
+			Times(1)
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: active}, nil).
+			Times(1)
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).
+			AnyTimes()
+
+		gomock.InOrder(
+			mockErasure.EXPECT().
+				ErasedUsers(gomock.Any(), []entity.UserID{erased.UserID, active.UserID}).
+				Return(map[entity.UserID]struct{}{erased.UserID: {}}, nil).
+				Times(1),
+			mockErasure.EXPECT().
+				UserHash(active.UserID).
+				Return("active-hash").
+				Times(1),
+			mockRepo.EXPECT().
+				BatchStoreWithDerived(gomock.Any(), []entity.TransactionEvent{active},
+					entity.DerivedRecords{UserHashes: []string{"active-hash"}}).
+				Return(nil).
+				Times(1),
+			mockPublisher.EXPECT().
+				Publish([]entity.TransactionEvent{active}).
+				Times(1),
+			mockReader.EXPECT().
+				Commit(gomock.Any()).
+				DoAndReturn(func(context.Context) error {
+					cancel()
+					return nil
+				}).
+				Times(1),
+		)
+
+		err := consumer.Start(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.TopicStats{{Topic: testTopic, Received: 2, Rejected: 1, Stored: 1}}, consumer.TopicStats())
+	})
+	t.Run("batch is not stored when limits cannot be evaluated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockLimits := mocks.NewMocklimitEvaluator(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(1)
+		consumer.SetLimits(mockLimits)
+
+		event := &entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(10.0),
+			CreatedAt:       time.Now(),
+		}
+		expectedErr := errors.New("db error")
+
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
+			Times(1)
+		mockLimits.EXPECT().
+			Evaluate(gomock.Any(), []entity.TransactionEvent{*event}).
+			Return(nil, expectedErr).
+			Times(1)
+
+		err := consumer.Start(context.Background())
+		assert.ErrorIs(t, err, expectedErr)
+	})
+
+	t.Run("decoded events are inspected and inspection errors do not stop consumption", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockInspector := mocks.NewMockeventInspector(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(2)
+		consumer.SetEventInspector(mockInspector)
+
+		event := &entity.TransactionEvent{
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(10.0),
+			CreatedAt:       time.Now(),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			Return(&entity.ConsumedEvent{Topic: testTopic, Event: *event}, nil).
+			Times(2)
+		mockReader.EXPECT().
+			Read(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}).
+			AnyTimes()
+
+		gomock.InOrder(
+			mockInspector.EXPECT().
+				Inspect(gomock.Any(), *event).
+				Return(nil, errors.New("alert store unavailable")),
+			mockInspector.EXPECT().
+				Inspect(gomock.Any(), *event).
+				Return([]entity.Alert{{RuleName: "bet-burst"}}, nil),
+			mockRepo.EXPECT().
+				BatchStore(gomock.Any(), gomock.Len(2)).
+				Return(nil),
+			mockReader.EXPECT().
+				Commit(gomock.Any()).
+				DoAndReturn(func(context.Context) error {
+					cancel()
+					return nil
+				}),
+		)
+
+		err := consumer.Start(ctx)
+		assert.NoError(t, err)
+	})
+}
+
+func TestConsumer_Topics(t *testing.T) {
+	t.Run("events are validated and routed by topic", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockInspector := mocks.NewMockeventInspector(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(1)
+		consumer.SetEventInspector(mockInspector)
+		assert.NoError(t, consumer.SetTopics([]config.KafkaTopic{
+			{Name: "rounds", Validation: config.TopicValidation{RequireEventID: true, MaxAmount: 100}},
+			{Name: "cashier", Handler: HandlerInspect},
+		}))
+
+		newEvent := func(eventID string, amount float64) entity.TransactionEvent {
+			return entity.TransactionEvent{
+				EventID:         eventID,
+				UserID:          *entity.NewUserID(uuid.New()),
+				TransactionType: entity.TransactionTypeBet,
+				Amount:          entity.ToMoney(amount),
+				CreatedAt:       time.Now(),
+			}
+		}
+		valid := newEvent("round-1", 10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		gomock.InOrder(
+			mockReader.EXPECT().Read(gomock.Any()).Return(nil, &entity.InvalidMessageError{Topic: "rounds", Fault: entity.FaultMalformed, Err: errors.New("bad payload")}),
+			// fault headers are not trusted, unknown kinds are not counted
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: "rounds", Event: newEvent("", 10), Fault: "forged"}, nil),
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: "rounds", Event: newEvent("round-2", 500), Fault: entity.FaultOversized}, nil),
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: "cashier", Event: newEvent("", 50)}, nil),
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: "rounds", Event: valid, Fault: entity.FaultDuplicate}, nil),
+			mockReader.EXPECT().
+				Read(gomock.Any()).
+				DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}).
+				AnyTimes(),
+		)
+		mockInspector.EXPECT().Inspect(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+		mockRepo.EXPECT().
+			BatchStore(gomock.Any(), []entity.TransactionEvent{valid}).
+			DoAndReturn(func(context.Context, []entity.TransactionEvent) error {
+				cancel()
+				return nil
+			}).
+			Times(1)
+		// messages that are not stored are committed too
+		mockReader.EXPECT().
+			Commit(gomock.Any()).
+			Return(nil).
+			Times(5)
+
+		assert.NoError(t, consumer.Start(ctx))
+		assert.Equal(t, []entity.TopicStats{
+			{Topic: "cashier", Received: 1, Inspected: 1},
+			{Topic: "rounds", Received: 3, Rejected: 3, Stored: 1, Faults: map[string]entity.FaultStats{
+				entity.FaultMalformed: {Rejected: 1},
+				entity.FaultOversized: {Received: 1, Rejected: 1},
+				entity.FaultDuplicate: {Received: 1, Stored: 1},
+			}},
+		}, consumer.TopicStats())
+	})
+
+	t.Run("unknown handler", func(t *testing.T) {
+		consumer := NewConsumer(nil, nil)
+		assert.ErrorContains(t, consumer.SetTopics([]config.KafkaTopic{{Name: "rounds", Handler: "archive"}}), "unknown handler")
+	})
+
+	t.Run("unknown transaction type", func(t *testing.T) {
+		consumer := NewConsumer(nil, nil)
+		err := consumer.SetTopics([]config.KafkaTopic{{Name: "rounds", Validation: config.TopicValidation{TransactionTypes: []string{"chargeback"}}}})
+		assert.ErrorContains(t, err, "unknown transaction type")
+	})
+}
+
+func TestConsumer_Capture(t *testing.T) {
+	t.Run("decoded events are captured before validation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockReader := mocks.NewMockkafkaReader(ctrl)
+		mockRepo := mocks.NewMocktransactionEventSaveRepository(ctrl)
+		mockCapture := mocks.NewMockeventCapture(ctrl)
+
+		consumer := NewConsumer(mockReader, mockRepo)
+		consumer.SetBatchSize(1)
+		consumer.SetCapture(mockCapture)
+		assert.NoError(t, consumer.SetTopics([]config.KafkaTopic{
+			{Name: testTopic, Validation: config.TopicValidation{RequireEventID: true}},
+		}))
+
+		rejected := entity.TransactionEvent{UserID: *entity.NewUserID(uuid.New()), TransactionType: entity.TransactionTypeBet}
+		stored := rejected
+		stored.EventID = "round-1"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		gomock.InOrder(
+			mockReader.EXPECT().Read(gomock.Any()).Return(nil, &entity.InvalidMessageError{Topic: testTopic, Err: errors.New("bad payload")}),
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: testTopic, Event: rejected}, nil),
+			mockReader.EXPECT().Read(gomock.Any()).Return(&entity.ConsumedEvent{Topic: testTopic, Event: stored}, nil),
+			mockReader.EXPECT().
+				Read(gomock.Any()).
+				DoAndReturn(func(ctx context.Context) (*entity.ConsumedEvent, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}).
+				AnyTimes(),
+		)
+		gomock.InOrder(
+			mockCapture.EXPECT().Write(rejected).Return(errors.New("disk full")),
+			mockCapture.EXPECT().Write(stored).Return(nil),
+		)
+		mockRepo.EXPECT().
+			BatchStore(gomock.Any(), []entity.TransactionEvent{stored}).
+			DoAndReturn(func(context.Context, []entity.TransactionEvent) error {
+				cancel()
+				return nil
+			})
+		mockReader.EXPECT().Commit(gomock.Any()).Return(nil).Times(3)
+
+		assert.NoError(t, consumer.Start(ctx))
+	})
 }
@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// derivation derives the records that are stored in the transaction of a
+// batch, shared by the consumer and the replayer.
+type derivation struct {
+	outbox   outboxDeriver
+	limits   limitEvaluator
+	webhooks webhookDeriver
+	erasure  erasedUserFilter
+}
+
+func (d *derivation) derive(ctx context.Context, events []entity.TransactionEvent) (entity.DerivedRecords, error) {
+	var (
+		derived entity.DerivedRecords
+		err     error
+	)
+	if d.outbox != nil {
+		if derived.OutboxMessages, err = d.outbox.Derive(events); err != nil {
+			return derived, fmt.Errorf("failed to derive outbox messages: %w", err)
+		}
+	}
+	if d.limits != nil {
+		if derived.Limits, err = d.limits.Evaluate(ctx, events); err != nil {
+			return derived, fmt.Errorf("failed to evaluate user limits: %w", err)
+		}
+	}
+	if d.webhooks != nil {
+		if derived.WebhookDeliveries, err = d.webhooks.Derive(ctx, events); err != nil {
+			return derived, fmt.Errorf("failed to derive webhook deliveries: %w", err)
+		}
+	}
+	return derived, nil
+}
+
+// store stores the batch together with the records derived from it. Events of
+// erased users are not stored, their users are returned. A user erased while
+// the batch is stored makes the transaction fail, the batch is then checked
+// again once.
+func (d *derivation) store(ctx context.Context, repository derivedStore,
+	events []entity.TransactionEvent) (map[entity.UserID]struct{}, error) {
+	erased, err := d.storeOnce(ctx, repository, events)
+	if errors.Is(err, entity.ErrUserErased) {
+		erased, err = d.storeOnce(ctx, repository, events)
+	}
+	return erased, err
+}
+
+func (d *derivation) storeOnce(ctx context.Context, repository derivedStore,
+	events []entity.TransactionEvent) (map[entity.UserID]struct{}, error) {
+	erased, events, err := d.withoutErased(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return erased, nil
+	}
+
+	derived, err := d.derive(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+	if d.erasure != nil {
+		derived.UserHashes = d.userHashes(events)
+	}
+	if derived.IsEmpty() {
+		return erased, repository.BatchStore(ctx, events)
+	}
+	return erased, repository.BatchStoreWithDerived(ctx, events, derived)
+}
+
+// withoutErased drops the events of erased users from the batch.
+func (d *derivation) withoutErased(ctx context.Context,
+	events []entity.TransactionEvent) (map[entity.UserID]struct{}, []entity.TransactionEvent, error) {
+	if d.erasure == nil {
+		return nil, events, nil
+	}
+
+	userIDs := make([]entity.UserID, 0, len(events))
+	for _, event := range events {
+		userIDs = append(userIDs, event.UserID)
+	}
+	erased, err := d.erasure.ErasedUsers(ctx, userIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check erased users: %w", err)
+	}
+	if len(erased) == 0 {
+		return erased, events, nil
+	}
+
+	kept := make([]entity.TransactionEvent, 0, len(events))
+	for _, event := range events {
+		if _, ok := erased[event.UserID]; !ok {
+			kept = append(kept, event)
+		}
+	}
+	return erased, kept, nil
+}
+
+func (d *derivation) userHashes(events []entity.TransactionEvent) []string {
+	seen := make(map[entity.UserID]struct{}, len(events))
+	hashes := make([]string, 0, len(events))
+	for _, event := range events {
+		if _, ok := seen[event.UserID]; ok {
+			continue
+		}
+		seen[event.UserID] = struct{}{}
+		hashes = append(hashes, d.erasure.UserHash(event.UserID))
+	}
+	return hashes
+}
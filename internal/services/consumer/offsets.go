@@ -0,0 +1,101 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// OffsetManager reports the lag of the consumer group and moves its committed
+// offsets.
+type OffsetManager struct {
+	offsets offsetStore
+}
+
+func NewOffsetManager(offsets offsetStore) *OffsetManager {
+	return &OffsetManager{
+		offsets: offsets,
+	}
+}
+
+// Lag returns the offsets of every partition of the topic, see
+// entity.PartitionOffsets.Lag.
+func (m *OffsetManager) Lag(ctx context.Context, topic string) ([]entity.PartitionOffsets, error) {
+	offsets, err := m.offsets.Offsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets: %w", err)
+	}
+	return offsets, nil
+}
+
+// Reset commits the offsets of the reset target and returns the offsets
+// before and after, nothing is committed on a dry run. A timestamp after the
+// last message resets to the latest offset.
+func (m *OffsetManager) Reset(ctx context.Context, reset entity.OffsetReset) ([]entity.OffsetChange, error) {
+	current, err := m.offsets.Offsets(ctx, reset.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets: %w", err)
+	}
+
+	partitions := make([]entity.PartitionOffsets, 0, len(current))
+	for _, offsets := range current {
+		if reset.Partition == nil || *reset.Partition == offsets.Partition {
+			partitions = append(partitions, offsets)
+		}
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("%w: topic %s has no partition %d", entity.ErrInvalidArgument, reset.Topic, *reset.Partition)
+	}
+
+	var timestampOffsets map[int]int64
+	if reset.Target == entity.OffsetResetTimestamp {
+		ids := make([]int, 0, len(partitions))
+		for _, offsets := range partitions {
+			ids = append(ids, offsets.Partition)
+		}
+		if timestampOffsets, err = m.offsets.OffsetsAt(ctx, reset.Topic, ids, reset.Time); err != nil {
+			return nil, fmt.Errorf("failed to get offsets at %s: %w", reset.Time, err)
+		}
+	}
+
+	changes := make([]entity.OffsetChange, 0, len(partitions))
+	commits := make(map[int]int64, len(partitions))
+	for _, offsets := range partitions {
+		var target int64
+		switch reset.Target {
+		case entity.OffsetResetEarliest:
+			target = offsets.First
+		case entity.OffsetResetLatest:
+			target = offsets.Last
+		case entity.OffsetResetTimestamp:
+			target = offsets.Last
+			if offset, ok := timestampOffsets[offsets.Partition]; ok && offset >= 0 {
+				target = offset
+			}
+		case entity.OffsetResetOffset:
+			if reset.Offset < offsets.First || reset.Offset > offsets.Last {
+				return nil, fmt.Errorf("%w: offset %d is outside [%d, %d] of partition %d",
+					entity.ErrInvalidArgument, reset.Offset, offsets.First, offsets.Last, offsets.Partition)
+			}
+			target = reset.Offset
+		default:
+			return nil, fmt.Errorf("%w: unknown reset target %q", entity.ErrInvalidArgument, reset.Target)
+		}
+
+		changes = append(changes, entity.OffsetChange{
+			Partition: offsets.Partition,
+			Previous:  offsets.Committed,
+			Offset:    target,
+		})
+		commits[offsets.Partition] = target
+	}
+
+	if reset.DryRun {
+		return changes, nil
+	}
+	if err = m.offsets.CommitOffsets(ctx, reset.Topic, commits); err != nil {
+		return nil, fmt.Errorf("failed to commit offsets: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOffsetManager_Reset(t *testing.T) {
+	ctx := context.Background()
+	current := []entity.PartitionOffsets{
+		{Partition: 0, First: 10, Last: 100, Committed: 90},
+		{Partition: 1, First: 0, Last: 50, Committed: entity.NoCommittedOffset},
+	}
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	one := 1
+
+	tests := []struct {
+		name     string
+		reset    entity.OffsetReset
+		expected map[int]int64
+	}{
+		{"earliest", entity.OffsetReset{Target: entity.OffsetResetEarliest}, map[int]int64{0: 10, 1: 0}},
+		{"latest", entity.OffsetReset{Target: entity.OffsetResetLatest}, map[int]int64{0: 100, 1: 50}},
+		{"timestamp after the last message", entity.OffsetReset{Target: entity.OffsetResetTimestamp, Time: at}, map[int]int64{0: 42, 1: 50}},
+		{"offset of one partition", entity.OffsetReset{Target: entity.OffsetResetOffset, Offset: 20, Partition: &one}, map[int]int64{1: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mocks.NewMockoffsetStore(ctrl)
+			tt.reset.Topic = testTopic
+
+			store.EXPECT().Offsets(ctx, testTopic).Return(current, nil)
+			if tt.reset.Target == entity.OffsetResetTimestamp {
+				store.EXPECT().OffsetsAt(ctx, testTopic, []int{0, 1}, at).Return(map[int]int64{0: 42, 1: -1}, nil)
+			}
+			store.EXPECT().CommitOffsets(ctx, testTopic, tt.expected).Return(nil)
+
+			changes, err := NewOffsetManager(store).Reset(ctx, tt.reset)
+			require.NoError(t, err)
+			require.Len(t, changes, len(tt.expected))
+			for _, change := range changes {
+				assert.Equal(t, tt.expected[change.Partition], change.Offset)
+			}
+		})
+	}
+
+	t.Run("dry run does not commit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mocks.NewMockoffsetStore(ctrl)
+		store.EXPECT().Offsets(ctx, testTopic).Return(current, nil)
+
+		changes, err := NewOffsetManager(store).Reset(ctx, entity.OffsetReset{
+			Topic: testTopic, Target: entity.OffsetResetEarliest, DryRun: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []entity.OffsetChange{
+			{Partition: 0, Previous: 90, Offset: 10},
+			{Partition: 1, Previous: entity.NoCommittedOffset, Offset: 0},
+		}, changes)
+	})
+
+	t.Run("offset outside the partition", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mocks.NewMockoffsetStore(ctrl)
+		store.EXPECT().Offsets(ctx, testTopic).Return(current, nil)
+
+		_, err := NewOffsetManager(store).Reset(ctx, entity.OffsetReset{
+			Topic: testTopic, Target: entity.OffsetResetOffset, Offset: 5,
+		})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("unknown partition", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mocks.NewMockoffsetStore(ctrl)
+		store.EXPECT().Offsets(ctx, testTopic).Return(current, nil)
+		partition := 7
+
+		_, err := NewOffsetManager(store).Reset(ctx, entity.OffsetReset{
+			Topic: testTopic, Target: entity.OffsetResetLatest, Partition: &partition,
+		})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+}
+
+func TestPartitionOffsets_Lag(t *testing.T) {
+	assert.Equal(t, int64(10), entity.PartitionOffsets{First: 0, Last: 100, Committed: 90}.Lag())
+	assert.Equal(t, int64(90), entity.PartitionOffsets{First: 10, Last: 100, Committed: entity.NoCommittedOffset}.Lag())
+	assert.Equal(t, int64(50), entity.PartitionOffsets{First: 50, Last: 100, Committed: 20}.Lag())
+}
@@ -1,9 +1,14 @@
 package consumer
 
-import "github.com/bsko/casino-transaction-system/internal/entity"
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
 
 type GetListProcessor struct {
 	transactionEventRepository transactionEventReadRepository
+	erasureGuard               erasureGuard
 }
 
 func NewGetListProcessor(transactionEventRepository transactionEventReadRepository) *GetListProcessor {
@@ -12,7 +17,39 @@ func NewGetListProcessor(transactionEventRepository transactionEventReadReposito
 	}
 }
 
-func (s *GetListProcessor) GetListByFilter(filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
+// SetErasureGuard makes queries for erased users fail with
+// entity.ErrUserErased.
+func (s *GetListProcessor) SetErasureGuard(guard erasureGuard) {
+	s.erasureGuard = guard
+}
+
+// GetListByFilter returns the transactions of the filter. The query is
+// cancelled with the context.
+func (s *GetListProcessor) GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error) {
 	// some business logic here: validation? & transform db errors to service layer
-	return s.transactionEventRepository.GetListByFilter(filter)
+	if err := s.checkNotErased(ctx, filter.UserID); err != nil {
+		return nil, err
+	}
+	return s.transactionEventRepository.GetListByFilter(ctx, filter)
+}
+
+func (s *GetListProcessor) GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error) {
+	if err := s.checkNotErased(ctx, &userID); err != nil {
+		return nil, err
+	}
+	return s.transactionEventRepository.GetBalance(ctx, userID)
+}
+
+func (s *GetListProcessor) GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error) {
+	if err := s.checkNotErased(ctx, filter.UserID); err != nil {
+		return nil, err
+	}
+	return s.transactionEventRepository.GetStats(ctx, filter)
+}
+
+func (s *GetListProcessor) checkNotErased(ctx context.Context, userID *entity.UserID) error {
+	if s.erasureGuard == nil || userID == nil {
+		return nil
+	}
+	return s.erasureGuard.CheckNotErased(ctx, *userID)
 }
@@ -1,6 +1,7 @@
 package consumer
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ func TestGetListProcessor_GetListByFilter(t *testing.T) {
 
 	mockRepo := mocks.NewMocktransactionEventReadRepository(ctrl)
 	processor := NewGetListProcessor(mockRepo)
+	ctx := context.Background()
 
 	filter := entity.TransactionEventFilter{
 		Limit: 10,
@@ -32,12 +34,91 @@ func TestGetListProcessor_GetListByFilter(t *testing.T) {
 	}
 
 	mockRepo.EXPECT().
-		GetListByFilter(filter).
+		GetListByFilter(ctx, filter).
 		Return(expectedEvents, nil).
 		Times(1)
 
-	result, err := processor.GetListByFilter(filter)
+	result, err := processor.GetListByFilter(ctx, filter)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedEvents, result)
 }
+
+func TestGetListProcessor_GetBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMocktransactionEventReadRepository(ctrl)
+	processor := NewGetListProcessor(mockRepo)
+	ctx := context.Background()
+
+	userID := *entity.NewUserID(uuid.New())
+	expected := &entity.UserBalance{
+		UserID:   userID,
+		TotalBet: entity.ToMoney(150.0),
+		TotalWin: entity.ToMoney(100.0),
+	}
+
+	mockRepo.EXPECT().
+		GetBalance(ctx, userID).
+		Return(expected, nil).
+		Times(1)
+
+	result, err := processor.GetBalance(ctx, userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	assert.Equal(t, entity.Money(-5000), result.Balance())
+}
+
+func TestGetListProcessor_GetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMocktransactionEventReadRepository(ctrl)
+	processor := NewGetListProcessor(mockRepo)
+	ctx := context.Background()
+
+	filter := entity.TransactionEventFilter{
+		UserID: entity.NewUserID(uuid.New()),
+	}
+	expected := &entity.TransactionStats{
+		BetCount:  3,
+		WinCount:  1,
+		BetAmount: entity.ToMoney(30.0),
+		WinAmount: entity.ToMoney(50.0),
+	}
+
+	mockRepo.EXPECT().
+		GetStats(ctx, filter).
+		Return(expected, nil).
+		Times(1)
+
+	result, err := processor.GetStats(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestGetListProcessor_ErasedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := *entity.NewUserID(uuid.New())
+	mockRepo := mocks.NewMocktransactionEventReadRepository(ctrl)
+	mockGuard := mocks.NewMockerasureGuard(ctrl)
+	mockGuard.EXPECT().
+		CheckNotErased(gomock.Any(), userID).
+		Return(entity.ErrUserErased).
+		Times(2)
+
+	processor := NewGetListProcessor(mockRepo)
+	ctx := context.Background()
+	processor.SetErasureGuard(mockGuard)
+
+	_, err := processor.GetListByFilter(ctx, entity.TransactionEventFilter{UserID: &userID, Limit: 10})
+	assert.ErrorIs(t, err, entity.ErrUserErased)
+
+	_, err = processor.GetBalance(ctx, userID)
+	assert.ErrorIs(t, err, entity.ErrUserErased)
+}
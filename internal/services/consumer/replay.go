@@ -0,0 +1,193 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// Replayer reads a time range of a topic into the repository without joining
+// the consumer group, so its committed offsets stay where they are.
+type Replayer struct {
+	reader     rangeReader
+	repository replayRepository
+	derivation derivation
+	routes     map[string]topicRoute
+	batchSize  int
+}
+
+func NewReplayer(reader rangeReader, repository replayRepository) *Replayer {
+	return &Replayer{
+		reader:     reader,
+		repository: repository,
+		batchSize:  batchSize,
+	}
+}
+
+func (r *Replayer) SetBatchSize(batchSize int) {
+	r.batchSize = batchSize
+}
+
+// SetOutbox makes every replayed batch be stored together with the events
+// derived from it, like the consumer does.
+func (r *Replayer) SetOutbox(deriver outboxDeriver) {
+	r.derivation.outbox = deriver
+}
+
+// SetLimits makes every replayed batch be stored together with the limit
+// breaches it causes, like the consumer does.
+func (r *Replayer) SetLimits(evaluator limitEvaluator) {
+	r.derivation.limits = evaluator
+}
+
+// SetWebhooks makes every replayed batch be stored together with the webhook
+// deliveries it triggers, like the consumer does.
+func (r *Replayer) SetWebhooks(deriver webhookDeriver) {
+	r.derivation.webhooks = deriver
+}
+
+// SetErasureGuard makes the replay skip the events of erased users, which are
+// counted as rejected.
+func (r *Replayer) SetErasureGuard(filter erasedUserFilter) {
+	r.derivation.erasure = filter
+}
+
+// SetTopics applies the validation of the configured topics to the replayed
+// events. Inspect only topics cannot be replayed.
+func (r *Replayer) SetTopics(topics []config.KafkaTopic) error {
+	routes, err := newTopicRoutes(topics)
+	if err != nil {
+		return err
+	}
+	r.routes = routes
+	return nil
+}
+
+// Replay stores the events of the messages with a timestamp in the range.
+// Events whose event id is already stored are skipped, so a range can be
+// replayed more than once.
+func (r *Replayer) Replay(ctx context.Context, replayRange entity.ReplayRange) (*entity.ReplayResult, error) {
+	if !replayRange.From.Before(replayRange.To) {
+		return nil, fmt.Errorf("%w: replay range start must be before its end", entity.ErrInvalidArgument)
+	}
+	route := r.routes[replayRange.Topic]
+	if route.inspect {
+		return nil, fmt.Errorf("%w: events of inspect only topic %s are not stored", entity.ErrInvalidArgument, replayRange.Topic)
+	}
+
+	offsets, err := r.reader.Offsets(ctx, replayRange.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets: %w", err)
+	}
+	partitions := make([]int, 0, len(offsets))
+	for _, partition := range offsets {
+		partitions = append(partitions, partition.Partition)
+	}
+	starts, err := r.reader.OffsetsAt(ctx, replayRange.Topic, partitions, replayRange.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets at %s: %w", replayRange.From, err)
+	}
+	ends, err := r.reader.OffsetsAt(ctx, replayRange.Topic, partitions, replayRange.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offsets at %s: %w", replayRange.To, err)
+	}
+
+	result := &entity.ReplayResult{}
+	batch := make([]entity.TransactionEvent, 0, r.batchSize)
+	for _, partition := range offsets {
+		start, end := rangeOffset(starts, partition), rangeOffset(ends, partition)
+		if start >= end {
+			continue
+		}
+		log.Printf("Replaying offsets %d to %d of partition %d", start, end, partition.Partition)
+
+		err = r.reader.ReadRange(ctx, replayRange.Topic, partition.Partition, start, end,
+			func(event *entity.ConsumedEvent, err error) error {
+				result.Read++
+				if err != nil {
+					var invalid *entity.InvalidMessageError
+					if errors.As(err, &invalid) {
+						result.Rejected++
+						log.Printf("Skipping message: %v", err)
+						return nil
+					}
+					return err
+				}
+				if err = route.validator.validate(event.Event); err != nil {
+					result.Rejected++
+					log.Printf("Skipping invalid event on topic %s: %v", event.Topic, err)
+					return nil
+				}
+
+				batch = append(batch, event.Event)
+				if len(batch) < r.batchSize {
+					return nil
+				}
+				err = r.store(ctx, batch, result)
+				batch = batch[:0]
+				return err
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay partition %d: %w", partition.Partition, err)
+		}
+	}
+	if err = r.store(ctx, batch, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rangeOffset is the offset of the partition at a range boundary, the last
+// offset when no message is that recent.
+func rangeOffset(offsets map[int]int64, partition entity.PartitionOffsets) int64 {
+	if offset, ok := offsets[partition.Partition]; ok && offset >= 0 {
+		return offset
+	}
+	return partition.Last
+}
+
+func (r *Replayer) store(ctx context.Context, batch []entity.TransactionEvent, result *entity.ReplayResult) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	eventIDs := make([]string, 0, len(batch))
+	for _, event := range batch {
+		if event.EventID != "" {
+			eventIDs = append(eventIDs, event.EventID)
+		}
+	}
+	stored, err := r.repository.StoredEventIDs(ctx, eventIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check stored events: %w", err)
+	}
+
+	events := make([]entity.TransactionEvent, 0, len(batch))
+	for _, event := range batch {
+		if _, ok := stored[event.EventID]; ok && event.EventID != "" {
+			result.Skipped++
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	erased, err := r.derivation.store(ctx, r.repository, events)
+	if err != nil {
+		return fmt.Errorf("failed to store events: %w", err)
+	}
+	for _, event := range events {
+		if _, ok := erased[event.UserID]; ok {
+			result.Rejected++
+			continue
+		}
+		result.Stored++
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/consumer/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReplayer_Replay(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	replayRange := entity.ReplayRange{Topic: testTopic, From: from, To: to}
+	offsets := []entity.PartitionOffsets{
+		{Partition: 0, First: 0, Last: 100, Committed: 100},
+		{Partition: 1, First: 0, Last: 100, Committed: 100},
+	}
+
+	newEvent := func(eventID string, amount float64) *entity.ConsumedEvent {
+		return &entity.ConsumedEvent{Topic: testTopic, Event: entity.TransactionEvent{
+			EventID:         eventID,
+			UserID:          *entity.NewUserID(uuid.New()),
+			TransactionType: entity.TransactionTypeBet,
+			Amount:          entity.ToMoney(amount),
+			CreatedAt:       from.Add(time.Hour),
+		}}
+	}
+
+	t.Run("stores the range and skips stored and invalid events", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		reader := mocks.NewMockrangeReader(ctrl)
+		repo := mocks.NewMockreplayRepository(ctrl)
+
+		replayer := NewReplayer(reader, repo)
+		replayer.SetBatchSize(2)
+		require.NoError(t, replayer.SetTopics([]config.KafkaTopic{{
+			Name:       testTopic,
+			Validation: config.TopicValidation{MaxAmount: 100},
+		}}))
+
+		reader.EXPECT().Offsets(ctx, testTopic).Return(offsets, nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0, 1}, from).Return(map[int]int64{0: 10, 1: -1}, nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0, 1}, to).Return(map[int]int64{0: 15, 1: -1}, nil)
+		reader.EXPECT().ReadRange(ctx, testTopic, 0, int64(10), int64(15), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ int, _, _ int64, handle func(*entity.ConsumedEvent, error) error) error {
+				for _, message := range []struct {
+					event *entity.ConsumedEvent
+					err   error
+				}{
+					{newEvent("e1", 10), nil},
+					{newEvent("e2", 10), nil},
+					{nil, &entity.InvalidMessageError{Topic: testTopic, Err: errors.New("bad payload")}},
+					{newEvent("e3", 500), nil},
+					{newEvent("", 10), nil},
+				} {
+					if err := handle(message.event, message.err); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		repo.EXPECT().StoredEventIDs(ctx, []string{"e1", "e2"}).Return(map[string]struct{}{"e1": {}}, nil)
+		repo.EXPECT().BatchStore(ctx, gomock.Len(1)).Return(nil).Times(2)
+		repo.EXPECT().StoredEventIDs(ctx, []string{}).Return(map[string]struct{}{}, nil)
+
+		result, err := replayer.Replay(ctx, replayRange)
+		require.NoError(t, err)
+		assert.Equal(t, entity.ReplayResult{Read: 5, Rejected: 2, Skipped: 1, Stored: 2}, *result)
+	})
+
+	t.Run("user erased while the batch is stored is rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		reader := mocks.NewMockrangeReader(ctrl)
+		repo := mocks.NewMockreplayRepository(ctrl)
+		guard := mocks.NewMockerasedUserFilter(ctrl)
+
+		replayer := NewReplayer(reader, repo)
+		replayer.SetErasureGuard(guard)
+
+		event := newEvent("e1", 10)
+		reader.EXPECT().Offsets(ctx, testTopic).Return(offsets, nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0, 1}, from).Return(map[int]int64{0: 10, 1: -1}, nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0, 1}, to).Return(map[int]int64{0: 11, 1: -1}, nil)
+		reader.EXPECT().ReadRange(ctx, testTopic, 0, int64(10), int64(11), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ int, _, _ int64, handle func(*entity.ConsumedEvent, error) error) error {
+				return handle(event, nil)
+			})
+		repo.EXPECT().StoredEventIDs(ctx, []string{"e1"}).Return(map[string]struct{}{}, nil)
+		gomock.InOrder(
+			guard.EXPECT().ErasedUsers(ctx, []entity.UserID{event.Event.UserID}).Return(map[entity.UserID]struct{}{}, nil),
+			guard.EXPECT().UserHash(event.Event.UserID).Return("user-hash"),
+			repo.EXPECT().
+				BatchStoreWithDerived(ctx, []entity.TransactionEvent{event.Event},
+					entity.DerivedRecords{UserHashes: []string{"user-hash"}}).
+				Return(fmt.Errorf("failed to store batch: %w", entity.ErrUserErased)),
+			guard.EXPECT().
+				ErasedUsers(ctx, []entity.UserID{event.Event.UserID}).
+				Return(map[entity.UserID]struct{}{event.Event.UserID: {}}, nil),
+		)
+
+		result, err := replayer.Replay(ctx, replayRange)
+		require.NoError(t, err)
+		assert.Equal(t, entity.ReplayResult{Read: 1, Rejected: 1}, *result)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		reader := mocks.NewMockrangeReader(ctrl)
+		repo := mocks.NewMockreplayRepository(ctrl)
+
+		reader.EXPECT().Offsets(ctx, testTopic).Return(offsets[:1], nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0}, from).Return(map[int]int64{0: 0}, nil)
+		reader.EXPECT().OffsetsAt(ctx, testTopic, []int{0}, to).Return(map[int]int64{0: 5}, nil)
+		reader.EXPECT().ReadRange(ctx, testTopic, 0, int64(0), int64(5), gomock.Any()).Return(errors.New("broker down"))
+
+		_, err := NewReplayer(reader, repo).Replay(ctx, replayRange)
+		assert.ErrorContains(t, err, "broker down")
+	})
+
+	t.Run("inspect only topic", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		replayer := NewReplayer(mocks.NewMockrangeReader(ctrl), mocks.NewMockreplayRepository(ctrl))
+		require.NoError(t, replayer.SetTopics([]config.KafkaTopic{{Name: testTopic, Handler: HandlerInspect}}))
+
+		_, err := replayer.Replay(ctx, replayRange)
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		replayer := NewReplayer(mocks.NewMockrangeReader(ctrl), mocks.NewMockreplayRepository(ctrl))
+
+		_, err := replayer.Replay(ctx, entity.ReplayRange{Topic: testTopic, From: to, To: from})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,163 @@
+package consumer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	HandlerStore   = "store"
+	HandlerInspect = "inspect"
+)
+
+// topicRoute is what the consumer does with the events of a topic. Topics
+// without a route are stored without validation.
+type topicRoute struct {
+	validator *validator
+	inspect   bool
+}
+
+// validator rejects events that do not match the validation of their topic.
+type validator struct {
+	transactionTypes map[entity.TransactionType]struct{}
+	minAmount        entity.Money
+	maxAmount        entity.Money
+	requireEventID   bool
+}
+
+func newTopicRoutes(topics []config.KafkaTopic) (map[string]topicRoute, error) {
+	routes := make(map[string]topicRoute, len(topics))
+	for _, topic := range topics {
+		route, err := newTopicRoute(topic)
+		if err != nil {
+			return nil, err
+		}
+		routes[topic.Name] = route
+	}
+	return routes, nil
+}
+
+func newTopicRoute(topic config.KafkaTopic) (topicRoute, error) {
+	var route topicRoute
+	switch topic.Handler {
+	case "", HandlerStore:
+	case HandlerInspect:
+		route.inspect = true
+	default:
+		return route, fmt.Errorf("topic %s: unknown handler %q, must be one of store, inspect", topic.Name, topic.Handler)
+	}
+
+	validator, err := newValidator(topic.Validation)
+	if err != nil {
+		return route, fmt.Errorf("topic %s: %w", topic.Name, err)
+	}
+	route.validator = validator
+	return route, nil
+}
+
+func newValidator(conf config.TopicValidation) (*validator, error) {
+	v := &validator{
+		minAmount:      entity.ToMoney(conf.MinAmount),
+		maxAmount:      entity.ToMoney(conf.MaxAmount),
+		requireEventID: conf.RequireEventID,
+	}
+	if conf.MaxAmount > 0 && conf.MaxAmount < conf.MinAmount {
+		return nil, fmt.Errorf("maxAmount is below minAmount")
+	}
+	if len(conf.TransactionTypes) > 0 {
+		v.transactionTypes = make(map[entity.TransactionType]struct{}, len(conf.TransactionTypes))
+		for _, name := range conf.TransactionTypes {
+			transactionType := entity.TransactionType(name)
+			if !transactionType.IsValid() {
+				return nil, fmt.Errorf("unknown transaction type %q", name)
+			}
+			v.transactionTypes[transactionType] = struct{}{}
+		}
+	}
+	return v, nil
+}
+
+func (v *validator) validate(event entity.TransactionEvent) error {
+	if v == nil {
+		return nil
+	}
+	if v.transactionTypes != nil {
+		if _, ok := v.transactionTypes[event.TransactionType]; !ok {
+			return fmt.Errorf("transaction type %s is not accepted", event.TransactionType)
+		}
+	}
+	if v.minAmount > 0 && event.Amount < v.minAmount {
+		return fmt.Errorf("amount %s is below the minimum %s", event.Amount, v.minAmount)
+	}
+	if v.maxAmount > 0 && event.Amount > v.maxAmount {
+		return fmt.Errorf("amount %s is above the maximum %s", event.Amount, v.maxAmount)
+	}
+	if v.requireEventID && event.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	return nil
+}
+
+// topicStats counts the messages of every topic.
+type topicStats struct {
+	stats map[string]*entity.TopicStats
+	mu    sync.Mutex
+}
+
+func newTopicStats() *topicStats {
+	return &topicStats{
+		stats: make(map[string]*entity.TopicStats),
+	}
+}
+
+func (t *topicStats) update(topic string, fn func(stats *entity.TopicStats)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.stats[topic]
+	if !ok {
+		stats = &entity.TopicStats{Topic: topic}
+		t.stats[topic] = stats
+	}
+	fn(stats)
+}
+
+// updateFault updates the counts of the fault on the topic. The fault comes
+// from a message header, so messages without a fault or with a kind the
+// producer does not inject are not counted, which bounds the counts kept.
+func (t *topicStats) updateFault(topic, fault string, fn func(stats *entity.FaultStats)) {
+	if !entity.IsFaultKind(fault) {
+		return
+	}
+	t.update(topic, func(stats *entity.TopicStats) {
+		if stats.Faults == nil {
+			stats.Faults = make(map[string]entity.FaultStats)
+		}
+		faultStats := stats.Faults[fault]
+		fn(&faultStats)
+		stats.Faults[fault] = faultStats
+	})
+}
+
+func (t *topicStats) snapshot() []entity.TopicStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]entity.TopicStats, 0, len(t.stats))
+	for _, stats := range t.stats {
+		snapshot := *stats
+		if stats.Faults != nil {
+			snapshot.Faults = make(map[string]entity.FaultStats, len(stats.Faults))
+			for fault, faultStats := range stats.Faults {
+				snapshot.Faults[fault] = faultStats
+			}
+		}
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Topic < result[j].Topic })
+	return result
+}
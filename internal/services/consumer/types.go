@@ -3,19 +3,78 @@ package consumer
 
 import (
 	"context"
+	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/entity"
 )
 
 type kafkaReader interface {
-	Read(ctx context.Context) (*entity.TransactionEvent, error)
+	Read(ctx context.Context) (*entity.ConsumedEvent, error)
 	Commit(ctx context.Context) error
 }
 
 type transactionEventReadRepository interface {
-	GetListByFilter(filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetListByFilter(ctx context.Context, filter entity.TransactionEventFilter) ([]entity.TransactionEvent, error)
+	GetBalance(ctx context.Context, userID entity.UserID) (*entity.UserBalance, error)
+	GetStats(ctx context.Context, filter entity.TransactionEventFilter) (*entity.TransactionStats, error)
 }
 
 type transactionEventSaveRepository interface {
+	derivedStore
+}
+
+type derivedStore interface {
 	BatchStore(ctx context.Context, batch []entity.TransactionEvent) error
+	BatchStoreWithDerived(ctx context.Context, batch []entity.TransactionEvent, derived entity.DerivedRecords) error
+}
+
+type transactionEventPublisher interface {
+	Publish(events []entity.TransactionEvent)
+}
+
+type outboxDeriver interface {
+	Derive(events []entity.TransactionEvent) ([]entity.OutboxMessage, error)
+}
+
+type webhookDeriver interface {
+	Derive(ctx context.Context, events []entity.TransactionEvent) ([]entity.WebhookDelivery, error)
+}
+
+type limitEvaluator interface {
+	Evaluate(ctx context.Context, events []entity.TransactionEvent) (entity.LimitCheck, error)
+}
+
+type eventInspector interface {
+	Inspect(ctx context.Context, event entity.TransactionEvent) ([]entity.Alert, error)
+}
+
+type erasureGuard interface {
+	CheckNotErased(ctx context.Context, userID entity.UserID) error
+}
+
+type erasedUserFilter interface {
+	ErasedUsers(ctx context.Context, userIDs []entity.UserID) (map[entity.UserID]struct{}, error)
+	UserHash(userID entity.UserID) string
+}
+
+type eventCapture interface {
+	Write(event entity.TransactionEvent) error
+}
+
+type offsetStore interface {
+	Offsets(ctx context.Context, topic string) ([]entity.PartitionOffsets, error)
+	OffsetsAt(ctx context.Context, topic string, partitions []int, at time.Time) (map[int]int64, error)
+	CommitOffsets(ctx context.Context, topic string, offsets map[int]int64) error
+}
+
+type rangeReader interface {
+	Offsets(ctx context.Context, topic string) ([]entity.PartitionOffsets, error)
+	OffsetsAt(ctx context.Context, topic string, partitions []int, at time.Time) (map[int]int64, error)
+	ReadRange(ctx context.Context, topic string, partition int, start, end int64,
+		handle func(event *entity.ConsumedEvent, err error) error) error
+}
+
+type replayRepository interface {
+	derivedStore
+	StoredEventIDs(ctx context.Context, eventIDs []string) (map[string]struct{}, error)
 }
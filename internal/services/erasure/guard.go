@@ -0,0 +1,102 @@
+package erasure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// maxCachedErasedUsers bounds the erased users the guard remembers. The cache
+// is dropped as a whole when it is full, erased users are then looked up
+// again.
+const maxCachedErasedUsers = 10000
+
+// Guard refuses to resolve erased users. Erasure is permanent, so erased
+// users are remembered and looked up only once while they are cached.
+type Guard struct {
+	repository erasureLookupRepository
+	lookupKey  []byte
+	erased     map[entity.UserID]struct{}
+	mu         sync.RWMutex
+}
+
+func NewGuard(repository erasureLookupRepository, conf config.Erasure) *Guard {
+	return &Guard{
+		repository: repository,
+		lookupKey:  []byte(conf.LookupKey),
+		erased:     make(map[entity.UserID]struct{}),
+	}
+}
+
+// CheckNotErased returns entity.ErrUserErased when the user has been erased.
+func (g *Guard) CheckNotErased(ctx context.Context, userID entity.UserID) error {
+	if g.isCached(userID) {
+		return entity.ErrUserErased
+	}
+
+	erased, err := g.repository.IsErased(ctx, userHash(g.lookupKey, userID))
+	if err != nil {
+		return err
+	}
+	if erased {
+		g.remember(userID)
+		return entity.ErrUserErased
+	}
+	return nil
+}
+
+// ErasedUsers returns the erased users among the given ones, looking up the
+// users that are not cached in one query.
+func (g *Guard) ErasedUsers(ctx context.Context, userIDs []entity.UserID) (map[entity.UserID]struct{}, error) {
+	erased := make(map[entity.UserID]struct{})
+	hashes := make(map[string]entity.UserID, len(userIDs))
+	for _, userID := range userIDs {
+		if g.isCached(userID) {
+			erased[userID] = struct{}{}
+			continue
+		}
+		hashes[userHash(g.lookupKey, userID)] = userID
+	}
+	if len(hashes) == 0 {
+		return erased, nil
+	}
+
+	lookup := make([]string, 0, len(hashes))
+	for hash := range hashes {
+		lookup = append(lookup, hash)
+	}
+	erasedHashes, err := g.repository.ErasedHashes(ctx, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up erased users: %w", err)
+	}
+	for hash := range erasedHashes {
+		userID := hashes[hash]
+		g.remember(userID)
+		erased[userID] = struct{}{}
+	}
+	return erased, nil
+}
+
+// UserHash returns the hash erased users are recorded under.
+func (g *Guard) UserHash(userID entity.UserID) string {
+	return userHash(g.lookupKey, userID)
+}
+
+func (g *Guard) isCached(userID entity.UserID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.erased[userID]
+	return ok
+}
+
+func (g *Guard) remember(userID entity.UserID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.erased) >= maxCachedErasedUsers {
+		g.erased = make(map[entity.UserID]struct{})
+	}
+	g.erased[userID] = struct{}{}
+}
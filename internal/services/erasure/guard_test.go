@@ -0,0 +1,91 @@
+package erasure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/erasure/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGuard_CheckNotErased(t *testing.T) {
+	conf := config.Erasure{LookupKey: "lookup"}
+
+	t.Run("active user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userID := entity.UserID{UUID: uuid.New()}
+		mockRepo := mocks.NewMockerasureLookupRepository(ctrl)
+		mockRepo.EXPECT().IsErased(gomock.Any(), userHash([]byte(conf.LookupKey), userID)).Return(false, nil).Times(2)
+
+		guard := NewGuard(mockRepo, conf)
+		assert.NoError(t, guard.CheckNotErased(context.Background(), userID))
+		assert.NoError(t, guard.CheckNotErased(context.Background(), userID))
+	})
+
+	t.Run("erased user is looked up once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userID := entity.UserID{UUID: uuid.New()}
+		mockRepo := mocks.NewMockerasureLookupRepository(ctrl)
+		mockRepo.EXPECT().IsErased(gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+
+		guard := NewGuard(mockRepo, conf)
+		assert.ErrorIs(t, guard.CheckNotErased(context.Background(), userID), entity.ErrUserErased)
+		assert.ErrorIs(t, guard.CheckNotErased(context.Background(), userID), entity.ErrUserErased)
+	})
+}
+
+func TestGuard_ErasedUsers(t *testing.T) {
+	conf := config.Erasure{LookupKey: "lookup"}
+
+	t.Run("erased users are looked up in one query and cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		erased := entity.UserID{UUID: uuid.New()}
+		active := entity.UserID{UUID: uuid.New()}
+		erasedHash := userHash([]byte(conf.LookupKey), erased)
+		activeHash := userHash([]byte(conf.LookupKey), active)
+
+		mockRepo := mocks.NewMockerasureLookupRepository(ctrl)
+		mockRepo.EXPECT().
+			ErasedHashes(gomock.Any(), gomock.InAnyOrder([]string{erasedHash, activeHash})).
+			Return(map[string]struct{}{erasedHash: {}}, nil).
+			Times(1)
+		mockRepo.EXPECT().
+			ErasedHashes(gomock.Any(), []string{activeHash}).
+			Return(map[string]struct{}{}, nil).
+			Times(1)
+
+		guard := NewGuard(mockRepo, conf)
+		users, err := guard.ErasedUsers(context.Background(), []entity.UserID{erased, active, erased})
+		assert.NoError(t, err)
+		assert.Equal(t, map[entity.UserID]struct{}{erased: {}}, users)
+
+		users, err = guard.ErasedUsers(context.Background(), []entity.UserID{erased, active})
+		assert.NoError(t, err)
+		assert.Equal(t, map[entity.UserID]struct{}{erased: {}}, users)
+		assert.Equal(t, erasedHash, guard.UserHash(erased))
+	})
+
+	t.Run("cache is bounded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockerasureLookupRepository(ctrl)
+		mockRepo.EXPECT().IsErased(gomock.Any(), gomock.Any()).Return(true, nil).Times(maxCachedErasedUsers + 1)
+
+		guard := NewGuard(mockRepo, conf)
+		for i := 0; i <= maxCachedErasedUsers; i++ {
+			assert.ErrorIs(t, guard.CheckNotErased(context.Background(), entity.UserID{UUID: uuid.New()}), entity.ErrUserErased)
+		}
+		assert.Len(t, guard.erased, 1)
+	})
+}
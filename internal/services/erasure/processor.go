@@ -0,0 +1,60 @@
+package erasure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type Processor struct {
+	repository erasureRepository
+	lookupKey  []byte
+}
+
+func NewProcessor(repository erasureRepository, conf config.Erasure) *Processor {
+	return &Processor{
+		repository: repository,
+		lookupKey:  []byte(conf.LookupKey),
+	}
+}
+
+// Erase replaces the user id with a new pseudonym in all stored records and
+// keeps the link between them in the vault until it is destroyed.
+func (p *Processor) Erase(ctx context.Context, userID entity.UserID, requestedBy string) (*entity.UserErasure, error) {
+	if strings.TrimSpace(requestedBy) == "" {
+		return nil, fmt.Errorf("%w: requested_by is required", entity.ErrInvalidArgument)
+	}
+
+	pseudonym, pseudonymKey, err := newPseudonym(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := userHash(p.lookupKey, userID)
+	erasure := entity.UserErasure{
+		UserHash:    hash,
+		RequestedBy: requestedBy,
+	}
+	vault := entity.PseudonymVaultEntry{
+		UserHash:     hash,
+		UserID:       userID,
+		Pseudonym:    pseudonym,
+		PseudonymKey: pseudonymKey,
+	}
+	if err = p.repository.Erase(ctx, erasure, vault); err != nil {
+		return nil, err
+	}
+	return p.repository.GetErasure(ctx, hash)
+}
+
+func (p *Processor) GetErasure(ctx context.Context, userID entity.UserID) (*entity.UserErasure, error) {
+	return p.repository.GetErasure(ctx, userHash(p.lookupKey, userID))
+}
+
+// DestroyVault makes the erasure of the user irreversible.
+func (p *Processor) DestroyVault(ctx context.Context, userID entity.UserID) error {
+	return p.repository.DestroyVault(ctx, userHash(p.lookupKey, userID))
+}
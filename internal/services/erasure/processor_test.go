@@ -0,0 +1,93 @@
+package erasure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/erasure/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProcessor_Erase(t *testing.T) {
+	userID := entity.UserID{UUID: uuid.New()}
+	conf := config.Erasure{LookupKey: "lookup"}
+	hash := userHash([]byte(conf.LookupKey), userID)
+
+	t.Run("stores the erasure with a fresh pseudonym", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var stored entity.PseudonymVaultEntry
+		mockRepo := mocks.NewMockerasureRepository(ctrl)
+		mockRepo.EXPECT().
+			Erase(gomock.Any(), entity.UserErasure{UserHash: hash, RequestedBy: "dpo"}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ entity.UserErasure, vault entity.PseudonymVaultEntry) error {
+				stored = vault
+				return nil
+			})
+		mockRepo.EXPECT().
+			GetErasure(gomock.Any(), hash).
+			Return(&entity.UserErasure{UserHash: hash, RequestedBy: "dpo"}, nil)
+
+		erasure, err := NewProcessor(mockRepo, conf).Erase(context.Background(), userID, "dpo")
+		require.NoError(t, err)
+		assert.Equal(t, "dpo", erasure.RequestedBy)
+
+		assert.Equal(t, hash, stored.UserHash)
+		assert.Equal(t, userID, stored.UserID)
+		assert.NotEqual(t, userID, stored.Pseudonym)
+		assert.Equal(t, uuid.Version(8), stored.Pseudonym.UUID.Version())
+		assert.Equal(t, uuid.RFC4122, stored.Pseudonym.UUID.Variant())
+		assert.Len(t, stored.PseudonymKey, 2*pseudonymKeyBytes)
+	})
+
+	t.Run("requires the requester", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := NewProcessor(mocks.NewMockerasureRepository(ctrl), conf).Erase(context.Background(), userID, " ")
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockerasureRepository(ctrl)
+		mockRepo.EXPECT().Erase(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+		_, err := NewProcessor(mockRepo, conf).Erase(context.Background(), userID, "dpo")
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestProcessor_DestroyVault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := entity.UserID{UUID: uuid.New()}
+	conf := config.Erasure{LookupKey: "lookup"}
+
+	mockRepo := mocks.NewMockerasureRepository(ctrl)
+	mockRepo.EXPECT().DestroyVault(gomock.Any(), userHash([]byte(conf.LookupKey), userID)).Return(nil)
+
+	require.NoError(t, NewProcessor(mockRepo, conf).DestroyVault(context.Background(), userID))
+}
+
+func TestNewPseudonym(t *testing.T) {
+	userID := entity.UserID{UUID: uuid.New()}
+
+	first, firstKey, err := newPseudonym(userID)
+	require.NoError(t, err)
+	second, secondKey, err := newPseudonym(userID)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, firstKey, secondKey)
+}
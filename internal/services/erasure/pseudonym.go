@@ -0,0 +1,44 @@
+package erasure
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	pseudonymKeyBytes = 32
+)
+
+// userHash identifies an erased user without storing the user id.
+func userHash(lookupKey []byte, userID entity.UserID) string {
+	mac := hmac.New(sha256.New, lookupKey)
+	_, _ = io.WriteString(mac, userID.UUID.String())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newPseudonym derives a pseudonym from the user id with a fresh random key.
+// The key lives only in the vault, so once the vault entry is destroyed the
+// pseudonym cannot be recomputed from the user id. The pseudonym is a
+// version 8 UUID so it fits the user_id columns.
+func newPseudonym(userID entity.UserID) (entity.UserID, string, error) {
+	key := make([]byte, pseudonymKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return entity.UserID{}, "", fmt.Errorf("failed to generate pseudonym key: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	_, _ = io.WriteString(mac, userID.UUID.String())
+	var pseudonym uuid.UUID
+	copy(pseudonym[:], mac.Sum(nil))
+	pseudonym[6] = (pseudonym[6] & 0x0f) | 0x80
+	pseudonym[8] = (pseudonym[8] & 0x3f) | 0x80
+
+	return entity.UserID{UUID: pseudonym}, hex.EncodeToString(key), nil
+}
@@ -0,0 +1,19 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package erasure
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type erasureRepository interface {
+	Erase(ctx context.Context, erasure entity.UserErasure, vault entity.PseudonymVaultEntry) error
+	GetErasure(ctx context.Context, userHash string) (*entity.UserErasure, error)
+	DestroyVault(ctx context.Context, userHash string) error
+}
+
+type erasureLookupRepository interface {
+	IsErased(ctx context.Context, userHash string) (bool, error)
+	ErasedHashes(ctx context.Context, userHashes []string) (map[string]struct{}, error)
+}
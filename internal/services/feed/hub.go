@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultBufferSize = 256
+)
+
+var (
+	ErrSlowSubscriber = errors.New("subscriber buffer overflow, disconnected")
+	ErrHubClosed      = errors.New("feed hub is closed")
+)
+
+type Subscription struct {
+	filter    entity.TransactionEventFilter
+	events    chan entity.TransactionEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// Events delivers matching transactions. It is never closed, select on Done
+// to learn when the subscription has ended.
+func (s *Subscription) Events() <-chan entity.TransactionEvent {
+	return s.events
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the subscription was ended by the hub, or nil if it
+// was unsubscribed by the caller.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+func (s *Subscription) close(err error) {
+	s.closeOnce.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Hub fans out stored transactions to live subscribers. Publishing never
+// blocks: a subscriber whose buffer is full is disconnected.
+type Hub struct {
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+	closed      bool
+	mu          sync.Mutex
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (h *Hub) Subscribe(filter entity.TransactionEventFilter) *Subscription {
+	subscription := &Subscription{
+		filter: filter,
+		events: make(chan entity.TransactionEvent, h.bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		subscription.close(ErrHubClosed)
+		return subscription
+	}
+	h.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers, subscription)
+	subscription.close(nil)
+}
+
+func (h *Hub) Publish(events []entity.TransactionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers {
+		h.deliver(subscription, events)
+	}
+}
+
+func (h *Hub) deliver(subscription *Subscription, events []entity.TransactionEvent) {
+	for _, event := range events {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			log.Printf("Disconnecting slow feed subscriber")
+			delete(h.subscribers, subscription)
+			subscription.close(ErrSlowSubscriber)
+			return
+		}
+	}
+}
+
+func (h *Hub) SubscribersCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers)
+}
+
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for subscription := range h.subscribers {
+		delete(h.subscribers, subscription)
+		subscription.close(ErrHubClosed)
+	}
+}
@@ -0,0 +1,88 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_Publish(t *testing.T) {
+	t.Run("delivers only matching events", func(t *testing.T) {
+		hub := NewHub(10)
+
+		userID := uuid.New()
+		threshold := entity.ToMoney(100.0)
+		subscription := hub.Subscribe(entity.TransactionEventFilter{
+			UserID:     entity.NewUserID(userID),
+			AmountFrom: &threshold,
+		})
+		defer hub.Unsubscribe(subscription)
+
+		hub.Publish([]entity.TransactionEvent{
+			{UserID: *entity.NewUserID(userID), TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(50.0), CreatedAt: time.Now()},
+			{UserID: *entity.NewUserID(uuid.New()), TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(500.0), CreatedAt: time.Now()},
+			{UserID: *entity.NewUserID(userID), TransactionType: entity.TransactionTypeWin, Amount: entity.ToMoney(150.0), CreatedAt: time.Now()},
+		})
+
+		select {
+		case event := <-subscription.Events():
+			assert.Equal(t, userID, event.UserID.UUID)
+			assert.Equal(t, entity.ToMoney(150.0), event.Amount)
+		case <-time.After(time.Second):
+			t.Fatal("expected event")
+		}
+
+		assert.Empty(t, subscription.Events())
+		assert.NoError(t, subscription.Err())
+	})
+
+	t.Run("slow subscriber is disconnected", func(t *testing.T) {
+		hub := NewHub(2)
+
+		slow := hub.Subscribe(entity.TransactionEventFilter{})
+		fast := hub.Subscribe(entity.TransactionEventFilter{})
+		defer hub.Unsubscribe(fast)
+
+		events := make([]entity.TransactionEvent, 0, 3)
+		for i := 0; i < 3; i++ {
+			events = append(events, entity.TransactionEvent{
+				UserID:          *entity.NewUserID(uuid.New()),
+				TransactionType: entity.TransactionTypeBet,
+				Amount:          entity.ToMoney(10.0),
+				CreatedAt:       time.Now(),
+			})
+		}
+
+		hub.Publish(events[:2])
+		<-fast.Events()
+		<-fast.Events()
+		hub.Publish(events[2:])
+
+		select {
+		case <-slow.Done():
+		case <-time.After(time.Second):
+			t.Fatal("slow subscriber should be disconnected")
+		}
+		assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+		assert.NoError(t, fast.Err())
+		assert.Equal(t, 1, hub.SubscribersCount())
+	})
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub(0)
+
+	subscription := hub.Subscribe(entity.TransactionEventFilter{})
+	hub.Close()
+
+	<-subscription.Done()
+	assert.ErrorIs(t, subscription.Err(), ErrHubClosed)
+
+	late := hub.Subscribe(entity.TransactionEventFilter{})
+	require.ErrorIs(t, late.Err(), ErrHubClosed)
+	assert.Equal(t, 0, hub.SubscribersCount())
+}
@@ -0,0 +1,128 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	sweepEvery = 10000
+)
+
+type userState struct {
+	window    slidingWindow
+	lastAlert time.Time
+}
+
+type ruleState struct {
+	rule  *Rule
+	users map[entity.UserID]*userState
+}
+
+// Engine evaluates rules against the event stream. Windows are kept in
+// memory and based on event timestamps, so they are rebuilt from scratch
+// after a restart.
+type Engine struct {
+	repository alertRepository
+	mu         sync.Mutex
+	rules      []*ruleState
+	observed   int
+	watermark  time.Time
+}
+
+func NewEngine(rules []*Rule, repository alertRepository) *Engine {
+	states := make([]*ruleState, 0, len(rules))
+	for _, rule := range rules {
+		states = append(states, &ruleState{rule: rule, users: make(map[entity.UserID]*userState)})
+	}
+	return &Engine{
+		repository: repository,
+		rules:      states,
+	}
+}
+
+// Inspect feeds the event to every rule and stores the alerts it raised.
+func (e *Engine) Inspect(ctx context.Context, event entity.TransactionEvent) ([]entity.Alert, error) {
+	alerts := e.observe(event)
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	if err := e.repository.CreateAlerts(ctx, alerts); err != nil {
+		return nil, fmt.Errorf("failed to store alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+func (e *Engine) observe(event entity.TransactionEvent) []entity.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if event.CreatedAt.After(e.watermark) {
+		e.watermark = event.CreatedAt
+	}
+
+	var alerts []entity.Alert
+	for _, state := range e.rules {
+		if alert, ok := state.observe(event); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	e.observed++
+	if e.observed%sweepEvery == 0 {
+		e.sweep()
+	}
+	return alerts
+}
+
+// sweep forgets users whose windows emptied and whose cooldown has passed.
+func (e *Engine) sweep() {
+	for _, state := range e.rules {
+		for userID, user := range state.users {
+			user.window.expire(e.watermark.Add(-state.rule.window))
+			if user.window.count() == 0 && e.watermark.Sub(user.lastAlert) >= state.rule.cooldown {
+				delete(state.users, userID)
+			}
+		}
+	}
+}
+
+func (s *ruleState) observe(event entity.TransactionEvent) (entity.Alert, bool) {
+	rule := s.rule
+	if !rule.filter.Matches(event) {
+		return entity.Alert{}, false
+	}
+
+	user, ok := s.users[event.UserID]
+	if !ok {
+		user = &userState{}
+		s.users[event.UserID] = user
+	}
+	user.window.add(event)
+	user.window.expire(user.window.newest.Add(-rule.window))
+
+	value, ok := rule.value(&user.window)
+	if !ok || value < rule.threshold {
+		return entity.Alert{}, false
+	}
+	if !user.lastAlert.IsZero() && event.CreatedAt.Sub(user.lastAlert) < rule.cooldown {
+		return entity.Alert{}, false
+	}
+	user.lastAlert = event.CreatedAt
+
+	return entity.Alert{
+		RuleName:    rule.name,
+		Severity:    rule.severity,
+		UserID:      event.UserID,
+		Description: rule.description,
+		Value:       value,
+		Threshold:   rule.threshold,
+		EventCount:  user.window.count(),
+		WindowStart: user.window.oldest(),
+		WindowEnd:   user.window.newest,
+	}, true
+}
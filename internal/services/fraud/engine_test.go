@@ -0,0 +1,189 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/fraud/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func mustRule(t *testing.T, config RuleConfig) *Rule {
+	t.Helper()
+	rule, err := NewRule(config)
+	require.NoError(t, err)
+	return rule
+}
+
+func newEvent(userID entity.UserID, transactionType entity.TransactionType, amount float64, at time.Time) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		UserID:          userID,
+		TransactionType: transactionType,
+		Amount:          entity.ToMoney(amount),
+		CreatedAt:       at,
+	}
+}
+
+func TestEngine_Observe(t *testing.T) {
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	userID := *entity.NewUserID(uuid.New())
+
+	t.Run("burst of bets within the window", func(t *testing.T) {
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "bet-burst", TransactionType: "bet", Window: time.Minute, Aggregate: AggregateCount, Threshold: 3,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start)))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeWin, 1, start.Add(time.Second))))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start.Add(10*time.Second))))
+
+		alerts := engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start.Add(20*time.Second)))
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "bet-burst", alerts[0].RuleName)
+		assert.Equal(t, 3.0, alerts[0].Value)
+		assert.Equal(t, 3, alerts[0].EventCount)
+		assert.Equal(t, start, alerts[0].WindowStart)
+		assert.Equal(t, start.Add(20*time.Second), alerts[0].WindowEnd)
+
+		// cooldown suppresses repeated alerts
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start.Add(30*time.Second))))
+	})
+
+	t.Run("events outside the window expire", func(t *testing.T) {
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "bet-burst", Window: time.Minute, Aggregate: AggregateCount, Threshold: 3,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start)))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start.Add(30*time.Second))))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start.Add(70*time.Second))))
+	})
+
+	t.Run("windows are kept per user", func(t *testing.T) {
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "bet-burst", Window: time.Minute, Aggregate: AggregateCount, Threshold: 2,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1, start)))
+		assert.Empty(t, engine.observe(newEvent(*entity.NewUserID(uuid.New()), entity.TransactionTypeBet, 1, start)))
+	})
+
+	t.Run("structuring just under a threshold", func(t *testing.T) {
+		amountFrom, amountTo := 900.0, 999.99
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "structuring", AmountFrom: &amountFrom, AmountTo: &amountTo,
+			Window: 24 * time.Hour, Aggregate: AggregateCount, Threshold: 2,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 950, start)))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 1200, start.Add(time.Hour))))
+		assert.Len(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 990, start.Add(2*time.Hour))), 1)
+	})
+
+	t.Run("deposits just under a threshold", func(t *testing.T) {
+		amountFrom, amountTo := 9000.0, 9999.99
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "structuring", TransactionType: "deposit", AmountFrom: &amountFrom, AmountTo: &amountTo,
+			Window: 24 * time.Hour, Aggregate: AggregateSum, Threshold: 25000,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeDeposit, 9500, start)))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 9800, start.Add(time.Hour))))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeDeposit, 12000, start.Add(2*time.Hour))))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeDeposit, 9900, start.Add(3*time.Hour))))
+
+		alerts := engine.observe(newEvent(userID, entity.TransactionTypeDeposit, 9990, start.Add(4*time.Hour)))
+		require.Len(t, alerts, 1)
+		assert.InDelta(t, 29390.0, alerts[0].Value, 1e-9)
+		assert.Equal(t, 3, alerts[0].EventCount)
+	})
+
+	t.Run("velocity of wagered amount", func(t *testing.T) {
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "high-velocity", TransactionType: "bet", Window: time.Hour, Aggregate: AggregateSum, Threshold: 1000,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 600, start)))
+		alerts := engine.observe(newEvent(userID, entity.TransactionTypeBet, 400, start.Add(time.Minute)))
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 1000.0, alerts[0].Value)
+	})
+
+	t.Run("win ratio needs min events", func(t *testing.T) {
+		engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+			Name: "win-rate", Window: time.Hour, Aggregate: AggregateWinRatio, Threshold: 2, MinEvents: 4,
+		})}, nil)
+
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 10, start)))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeWin, 100, start.Add(time.Second))))
+		assert.Empty(t, engine.observe(newEvent(userID, entity.TransactionTypeBet, 10, start.Add(2*time.Second))))
+
+		alerts := engine.observe(newEvent(userID, entity.TransactionTypeWin, 50, start.Add(3*time.Second)))
+		require.Len(t, alerts, 1)
+		assert.InDelta(t, 7.5, alerts[0].Value, 1e-9)
+	})
+}
+
+func TestEngine_Inspect(t *testing.T) {
+	userID := *entity.NewUserID(uuid.New())
+	rule := RuleConfig{Name: "any-win", TransactionType: "win", Window: time.Minute, Aggregate: AggregateCount, Threshold: 1, Severity: "low"}
+
+	t.Run("alerts are stored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockalertRepository(ctrl)
+		engine := NewEngine([]*Rule{mustRule(t, rule)}, mockRepo)
+
+		mockRepo.EXPECT().
+			CreateAlerts(gomock.Any(), gomock.Len(1)).
+			Return(nil).
+			Times(1)
+
+		_, err := engine.Inspect(context.Background(), newEvent(userID, entity.TransactionTypeBet, 5, time.Now()))
+		require.NoError(t, err)
+
+		alerts, err := engine.Inspect(context.Background(), newEvent(userID, entity.TransactionTypeWin, 5, time.Now()))
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, entity.AlertSeverityLow, alerts[0].Severity)
+	})
+
+	t.Run("repository error is returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockalertRepository(ctrl)
+		engine := NewEngine([]*Rule{mustRule(t, rule)}, mockRepo)
+
+		expectedErr := errors.New("db error")
+		mockRepo.EXPECT().
+			CreateAlerts(gomock.Any(), gomock.Any()).
+			Return(expectedErr)
+
+		_, err := engine.Inspect(context.Background(), newEvent(userID, entity.TransactionTypeWin, 5, time.Now()))
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func TestEngine_Sweep(t *testing.T) {
+	engine := NewEngine([]*Rule{mustRule(t, RuleConfig{
+		Name: "bet-burst", Window: time.Minute, Aggregate: AggregateCount, Threshold: 100,
+	})}, nil)
+
+	start := time.Now()
+	engine.observe(newEvent(*entity.NewUserID(uuid.New()), entity.TransactionTypeBet, 1, start))
+
+	active := *entity.NewUserID(uuid.New())
+	for i := 1; i < sweepEvery; i++ {
+		engine.observe(newEvent(active, entity.TransactionTypeBet, 1, start.Add(time.Hour)))
+	}
+
+	assert.Len(t, engine.rules[0].users, 1)
+}
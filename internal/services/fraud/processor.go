@@ -0,0 +1,46 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type AlertProcessor struct {
+	repository   alertQueryRepository
+	erasureGuard erasureGuard
+}
+
+func NewAlertProcessor(repository alertQueryRepository) *AlertProcessor {
+	return &AlertProcessor{
+		repository: repository,
+	}
+}
+
+// SetErasureGuard makes alert queries for erased users fail with
+// entity.ErrUserErased.
+func (p *AlertProcessor) SetErasureGuard(guard erasureGuard) {
+	p.erasureGuard = guard
+}
+
+func (p *AlertProcessor) ListAlerts(ctx context.Context, filter entity.AlertFilter) ([]entity.Alert, error) {
+	if p.erasureGuard != nil && filter.UserID != nil {
+		if err := p.erasureGuard.CheckNotErased(ctx, *filter.UserID); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Severity != nil && !filter.Severity.IsValid() {
+		return nil, fmt.Errorf("severity must be one of low, medium, high: %w", entity.ErrInvalidArgument)
+	}
+	return p.repository.ListAlerts(ctx, filter)
+}
+
+func (p *AlertProcessor) AcknowledgeAlert(ctx context.Context, id int64, acknowledgedBy string) (*entity.Alert, error) {
+	acknowledgedBy = strings.TrimSpace(acknowledgedBy)
+	if acknowledgedBy == "" {
+		return nil, fmt.Errorf("acknowledged_by is required: %w", entity.ErrInvalidArgument)
+	}
+	return p.repository.AcknowledgeAlert(ctx, id, acknowledgedBy)
+}
@@ -0,0 +1,169 @@
+package fraud
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// AggregateCount counts matching events in the window.
+	AggregateCount = "count"
+	// AggregateSum sums the amounts of matching events in the window.
+	AggregateSum = "sum"
+	// AggregateWinRatio divides the amount won by the amount bet in the window.
+	AggregateWinRatio = "win_ratio"
+)
+
+// RuleConfig is a rule as declared in the rules file.
+type RuleConfig struct {
+	Name            string        `yaml:"name"`
+	Description     string        `yaml:"description"`
+	Severity        string        `yaml:"severity"`
+	TransactionType string        `yaml:"transaction_type"`
+	AmountFrom      *float64      `yaml:"amount_from"`
+	AmountTo        *float64      `yaml:"amount_to"`
+	Window          time.Duration `yaml:"window"`
+	Aggregate       string        `yaml:"aggregate"`
+	Threshold       float64       `yaml:"threshold"`
+	MinEvents       int           `yaml:"min_events"`
+	Cooldown        time.Duration `yaml:"cooldown"`
+}
+
+type rulesFile struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+// Rule fires for a user when the aggregate of their matching events over the
+// sliding window reaches the threshold. After firing it stays quiet for the
+// user until the cooldown, the window length by default, has passed.
+type Rule struct {
+	name        string
+	description string
+	severity    entity.AlertSeverity
+	filter      entity.TransactionEventFilter
+	window      time.Duration
+	aggregate   string
+	threshold   float64
+	minEvents   int
+	cooldown    time.Duration
+}
+
+func LoadRules(path string) ([]*Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	return ParseRules(data)
+}
+
+func ParseRules(data []byte) ([]*Rule, error) {
+	var file rulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	rules := make([]*Rule, 0, len(file.Rules))
+	for _, config := range file.Rules {
+		rule, err := NewRule(config)
+		if err != nil {
+			return nil, err
+		}
+		if names[rule.name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.name)
+		}
+		names[rule.name] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func NewRule(config RuleConfig) (*Rule, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("rule name is required")
+	}
+	if config.Window <= 0 {
+		return nil, fmt.Errorf("rule %q: window must be positive", config.Name)
+	}
+	if config.Threshold <= 0 {
+		return nil, fmt.Errorf("rule %q: threshold must be positive", config.Name)
+	}
+
+	rule := &Rule{
+		name:        config.Name,
+		description: config.Description,
+		severity:    entity.AlertSeverityMedium,
+		window:      config.Window,
+		aggregate:   config.Aggregate,
+		threshold:   config.Threshold,
+		minEvents:   config.MinEvents,
+		cooldown:    config.Cooldown,
+	}
+	if config.Severity != "" {
+		rule.severity = entity.AlertSeverity(config.Severity)
+		if !rule.severity.IsValid() {
+			return nil, fmt.Errorf("rule %q: severity must be one of low, medium, high", config.Name)
+		}
+	}
+	if rule.cooldown <= 0 {
+		rule.cooldown = rule.window
+	}
+
+	switch config.Aggregate {
+	case AggregateCount, AggregateSum:
+	case AggregateWinRatio:
+		// the ratio needs both bets and wins, so the type cannot be narrowed
+		if config.TransactionType != "" {
+			return nil, fmt.Errorf("rule %q: transaction_type is not supported for %s", config.Name, AggregateWinRatio)
+		}
+	default:
+		return nil, fmt.Errorf("rule %q: aggregate must be one of count, sum, win_ratio", config.Name)
+	}
+
+	if config.TransactionType != "" {
+		transactionType := entity.TransactionType(config.TransactionType)
+		if !transactionType.IsValid() {
+			return nil, fmt.Errorf("rule %q: transaction_type must be bet, win or deposit", config.Name)
+		}
+		rule.filter.TransactionType = &transactionType
+	}
+	if config.AmountFrom != nil {
+		amountFrom := entity.ToMoney(*config.AmountFrom)
+		rule.filter.AmountFrom = &amountFrom
+	}
+	if config.AmountTo != nil {
+		amountTo := entity.ToMoney(*config.AmountTo)
+		rule.filter.AmountTo = &amountTo
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) Name() string {
+	return r.name
+}
+
+// value returns the aggregate over the window and whether the window holds
+// enough events for it to be meaningful.
+func (r *Rule) value(w *slidingWindow) (float64, bool) {
+	if w.count() < r.minEvents {
+		return 0, false
+	}
+
+	switch r.aggregate {
+	case AggregateCount:
+		return float64(w.count()), true
+	case AggregateSum:
+		return w.sum(r.filter.TransactionType).ToFloat(), true
+	case AggregateWinRatio:
+		if w.betAmount <= 0 {
+			return 0, false
+		}
+		return float64(w.winAmount) / float64(w.betAmount), true
+	}
+	return 0, false
+}
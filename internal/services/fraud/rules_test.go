@@ -0,0 +1,72 @@
+package fraud
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`
+rules:
+  - name: bet-burst
+    description: Many bets in a short time
+    severity: high
+    transaction_type: bet
+    window: 1m
+    aggregate: count
+    threshold: 20
+  - name: structuring
+    transaction_type: bet
+    amount_from: 900
+    amount_to: 999.99
+    window: 24h
+    aggregate: count
+    threshold: 3
+    cooldown: 48h
+  - name: win-rate
+    window: 1h
+    aggregate: win_ratio
+    threshold: 3
+    min_events: 10
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	assert.Equal(t, "bet-burst", rules[0].Name())
+	assert.Equal(t, entity.AlertSeverityHigh, rules[0].severity)
+	assert.Equal(t, time.Minute, rules[0].window)
+	assert.Equal(t, time.Minute, rules[0].cooldown)
+	assert.Equal(t, entity.TransactionTypeBet, *rules[0].filter.TransactionType)
+
+	assert.Equal(t, entity.ToMoney(900.0), *rules[1].filter.AmountFrom)
+	assert.Equal(t, entity.ToMoney(999.99), *rules[1].filter.AmountTo)
+	assert.Equal(t, 48*time.Hour, rules[1].cooldown)
+
+	assert.Equal(t, entity.AlertSeverityMedium, rules[2].severity)
+	assert.Equal(t, 10, rules[2].minEvents)
+}
+
+func TestParseRules_Errors(t *testing.T) {
+	invalid := map[string]string{
+		"missing name":          `rules: [{window: 1m, aggregate: count, threshold: 1}]`,
+		"missing window":        `rules: [{name: a, aggregate: count, threshold: 1}]`,
+		"missing threshold":     `rules: [{name: a, window: 1m, aggregate: count}]`,
+		"unknown aggregate":     `rules: [{name: a, window: 1m, aggregate: avg, threshold: 1}]`,
+		"unknown severity":      `rules: [{name: a, window: 1m, aggregate: count, threshold: 1, severity: critical}]`,
+		"unknown type":          `rules: [{name: a, window: 1m, aggregate: count, threshold: 1, transaction_type: chargeback}]`,
+		"typed win ratio":       `rules: [{name: a, window: 1m, aggregate: win_ratio, threshold: 1, transaction_type: win}]`,
+		"duplicate name":        `rules: [{name: a, window: 1m, aggregate: count, threshold: 1}, {name: a, window: 1m, aggregate: sum, threshold: 1}]`,
+		"invalid window format": `rules: [{name: a, window: soon, aggregate: count, threshold: 1}]`,
+	}
+
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRules([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
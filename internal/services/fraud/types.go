@@ -0,0 +1,21 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package fraud
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type alertRepository interface {
+	CreateAlerts(ctx context.Context, alerts []entity.Alert) error
+}
+
+type alertQueryRepository interface {
+	ListAlerts(ctx context.Context, filter entity.AlertFilter) ([]entity.Alert, error)
+	AcknowledgeAlert(ctx context.Context, id int64, acknowledgedBy string) (*entity.Alert, error)
+}
+
+type erasureGuard interface {
+	CheckNotErased(ctx context.Context, userID entity.UserID) error
+}
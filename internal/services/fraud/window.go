@@ -0,0 +1,82 @@
+package fraud
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// slidingWindow keeps the events of one user for one rule ordered by time and
+// running totals over them, so adding and expiring events is cheap.
+type slidingWindow struct {
+	events        []entity.TransactionEvent
+	betAmount     entity.Money
+	winAmount     entity.Money
+	depositAmount entity.Money
+	newest        time.Time
+}
+
+func (w *slidingWindow) add(event entity.TransactionEvent) {
+	// events mostly arrive in order, late ones are inserted in place
+	i := len(w.events)
+	for i > 0 && w.events[i-1].CreatedAt.After(event.CreatedAt) {
+		i--
+	}
+	w.events = slices.Insert(w.events, i, event)
+	w.apply(event, 1)
+	if event.CreatedAt.After(w.newest) {
+		w.newest = event.CreatedAt
+	}
+}
+
+// expire drops events created before the given moment.
+func (w *slidingWindow) expire(before time.Time) {
+	expired := 0
+	for expired < len(w.events) && w.events[expired].CreatedAt.Before(before) {
+		w.apply(w.events[expired], -1)
+		expired++
+	}
+	if expired > 0 {
+		w.events = slices.Delete(w.events, 0, expired)
+	}
+}
+
+func (w *slidingWindow) apply(event entity.TransactionEvent, sign entity.Money) {
+	switch event.TransactionType {
+	case entity.TransactionTypeBet:
+		w.betAmount += sign * event.Amount
+	case entity.TransactionTypeWin:
+		w.winAmount += sign * event.Amount
+	case entity.TransactionTypeDeposit:
+		w.depositAmount += sign * event.Amount
+	}
+}
+
+// sum returns the total amount of the events of the given type, or of all
+// events when no type is given.
+func (w *slidingWindow) sum(transactionType *entity.TransactionType) entity.Money {
+	if transactionType == nil {
+		return w.betAmount + w.winAmount + w.depositAmount
+	}
+	switch *transactionType {
+	case entity.TransactionTypeBet:
+		return w.betAmount
+	case entity.TransactionTypeWin:
+		return w.winAmount
+	case entity.TransactionTypeDeposit:
+		return w.depositAmount
+	}
+	return 0
+}
+
+func (w *slidingWindow) count() int {
+	return len(w.events)
+}
+
+func (w *slidingWindow) oldest() time.Time {
+	if len(w.events) == 0 {
+		return w.newest
+	}
+	return w.events[0].CreatedAt
+}
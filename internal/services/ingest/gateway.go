@@ -0,0 +1,238 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMaxBatchSize       = 1000
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultMaxIdempotencyKeys = 10000
+	// sweepInterval is how often expired idempotency keys are forgotten.
+	sweepInterval = time.Minute
+)
+
+// eventIDNamespace is the namespace of the event IDs derived from
+// idempotency keys.
+var eventIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("casino-transaction-system/ingest"))
+
+// Gateway publishes the events posted by partners that cannot write to
+// Kafka themselves.
+type Gateway struct {
+	kafka        KafkaWriter
+	maxBatchSize int
+	maxKeys      int
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	requests  map[requestKey]*request
+	lastSweep time.Time
+}
+
+// requestKey scopes idempotency keys to a tenant, so tenants cannot see the
+// results of each other.
+type requestKey struct {
+	tenantID string
+	key      string
+}
+
+// request is a request with an idempotency key. Only the results are kept:
+// a retry carries the same items, whose IDs are derived again.
+type request struct {
+	fingerprint string
+	results     []entity.IngestItemResult
+	inProgress  bool
+	expiresAt   time.Time
+}
+
+func NewGateway(conf config.Gateway, kafka KafkaWriter) *Gateway {
+	gateway := &Gateway{
+		kafka:        kafka,
+		maxBatchSize: conf.MaxBatchSize,
+		maxKeys:      conf.MaxIdempotencyKeys,
+		ttl:          time.Duration(conf.IdempotencyTTLSeconds) * time.Second,
+		now:          time.Now,
+		requests:     make(map[requestKey]*request),
+	}
+	if gateway.maxBatchSize <= 0 {
+		gateway.maxBatchSize = defaultMaxBatchSize
+	}
+	if gateway.maxKeys <= 0 {
+		gateway.maxKeys = defaultMaxIdempotencyKeys
+	}
+	if gateway.ttl <= 0 {
+		gateway.ttl = defaultIdempotencyTTL
+	}
+	return gateway
+}
+
+// Ingest publishes the valid events of the request in order and returns a
+// result per item. Events without an ID are assigned one, derived from the
+// idempotency key when the request has one. A retry with the idempotency key
+// of an earlier request returns its results and only publishes the items
+// that failed; reusing the key for a different request, or while the first
+// one is published, is a conflict.
+func (g *Gateway) Ingest(ctx context.Context, req entity.IngestRequest) (*entity.IngestResult, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: no events in the request", entity.ErrInvalidArgument)
+	}
+	if len(req.Items) > g.maxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d events per request", entity.ErrInvalidArgument, g.maxBatchSize)
+	}
+	events := newEvents(req)
+	if req.IdempotencyKey == "" {
+		r := newRequest(req, events)
+		g.publish(ctx, r, events)
+		return &entity.IngestResult{Items: r.results}, nil
+	}
+
+	key := requestKey{tenantID: req.TenantID, key: req.IdempotencyKey}
+	r, replayed, err := g.reserve(key, req, events)
+	if err != nil {
+		return nil, err
+	}
+	g.publish(ctx, r, events)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r.inProgress = false
+	r.expiresAt = g.now().Add(g.ttl)
+	return &entity.IngestResult{Items: append([]entity.IngestItemResult(nil), r.results...), Replayed: replayed}, nil
+}
+
+// reserve returns the request of the key, created when the key is new, and
+// marks it in progress.
+func (g *Gateway) reserve(key requestKey, req entity.IngestRequest, events []*entity.TransactionEvent) (*request, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if now.Sub(g.lastSweep) >= sweepInterval {
+		g.sweep(now)
+	}
+
+	r, ok := g.requests[key]
+	if ok && !r.inProgress && now.After(r.expiresAt) {
+		ok = false
+	}
+	if !ok {
+		if _, exists := g.requests[key]; !exists && len(g.requests) >= g.maxKeys {
+			if err := g.evict(now); err != nil {
+				return nil, false, err
+			}
+		}
+		r = newRequest(req, events)
+		r.inProgress = true
+		g.requests[key] = r
+		return r, false, nil
+	}
+	switch {
+	case r.fingerprint != req.Fingerprint:
+		return nil, false, fmt.Errorf("%w: idempotency key %q was used for a different request", entity.ErrConflict, key.key)
+	case r.inProgress:
+		return nil, false, fmt.Errorf("%w: request with idempotency key %q is in progress", entity.ErrConflict, key.key)
+	}
+	r.inProgress = true
+	return r, true, nil
+}
+
+func (g *Gateway) sweep(now time.Time) {
+	for k, r := range g.requests {
+		if !r.inProgress && now.After(r.expiresAt) {
+			delete(g.requests, k)
+		}
+	}
+	g.lastSweep = now
+}
+
+// evict makes room for a key when the gateway remembers as many keys as it
+// may: expired keys are forgotten, otherwise the key that expires first.
+func (g *Gateway) evict(now time.Time) error {
+	g.sweep(now)
+	if len(g.requests) < g.maxKeys {
+		return nil
+	}
+
+	var (
+		oldest    requestKey
+		oldestReq *request
+	)
+	for k, r := range g.requests {
+		if !r.inProgress && (oldestReq == nil || r.expiresAt.Before(oldestReq.expiresAt)) {
+			oldest, oldestReq = k, r
+		}
+	}
+	if oldestReq == nil {
+		return fmt.Errorf("%w: too many requests with an idempotency key in progress", entity.ErrConflict)
+	}
+	delete(g.requests, oldest)
+	return nil
+}
+
+// newEvents returns the event of every valid item, with its ID. The IDs of
+// items without one are derived from the idempotency key and the request
+// fingerprint, so a retry publishes them under the same IDs also when the
+// key was forgotten, after a restart or on another instance.
+func newEvents(req entity.IngestRequest) []*entity.TransactionEvent {
+	events := make([]*entity.TransactionEvent, len(req.Items))
+	for i, item := range req.Items {
+		if item.Err != nil {
+			continue
+		}
+		event := *item.Event
+		if event.EventID == "" {
+			event.EventID = eventID(req, i)
+		}
+		events[i] = &event
+	}
+	return events
+}
+
+func eventID(req entity.IngestRequest, index int) string {
+	if req.IdempotencyKey == "" {
+		return uuid.NewString()
+	}
+	name := fmt.Sprintf("%s|%s|%s|%d", req.TenantID, req.IdempotencyKey, req.Fingerprint, index)
+	return uuid.NewSHA1(eventIDNamespace, []byte(name)).String()
+}
+
+func newRequest(req entity.IngestRequest, events []*entity.TransactionEvent) *request {
+	r := &request{
+		fingerprint: req.Fingerprint,
+		results:     make([]entity.IngestItemResult, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		if item.Err != nil {
+			r.results[i] = entity.IngestItemResult{Status: entity.IngestRejected, Error: item.Err.Error()}
+			continue
+		}
+		r.results[i] = entity.IngestItemResult{EventID: events[i].EventID, Status: entity.IngestFailed}
+	}
+	return r
+}
+
+// publish publishes the events of the items not accepted yet. Must be
+// called with the request in progress, as its results are not locked.
+func (g *Gateway) publish(ctx context.Context, r *request, events []*entity.TransactionEvent) {
+	for i, event := range events {
+		if event == nil || r.results[i].Status == entity.IngestAccepted {
+			continue
+		}
+		if err := g.kafka.Publish(ctx, *event); err != nil {
+			log.Printf("Failed to publish ingested event %s: %v", event.EventID, err)
+			r.results[i].Error = "failed to publish the event"
+			continue
+		}
+		r.results[i].Status = entity.IngestAccepted
+		r.results[i].Error = ""
+	}
+}
@@ -0,0 +1,209 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/ingest/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func testItem(eventID string) entity.IngestItem {
+	return entity.IngestItem{Event: &entity.TransactionEvent{
+		EventID:         eventID,
+		TenantID:        "brand-a",
+		UserID:          *entity.NewUserID(uuid.New()),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.ToMoney(10),
+		CreatedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}}
+}
+
+func TestGateway_Ingest(t *testing.T) {
+	kafkaErr := errors.New("kafka unavailable")
+
+	t.Run("publishes valid items and rejects invalid ones", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{}, kafka)
+
+		var published []entity.TransactionEvent
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event entity.TransactionEvent) error {
+				published = append(published, event)
+				return nil
+			}).Times(2)
+
+		result, err := gateway.Ingest(context.Background(), entity.IngestRequest{Items: []entity.IngestItem{
+			testItem("e1"),
+			{Err: errors.New("invalid user_id")},
+			testItem(""),
+		}})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 3)
+
+		assert.Equal(t, entity.IngestItemResult{EventID: "e1", Status: entity.IngestAccepted}, result.Items[0])
+		assert.Equal(t, entity.IngestItemResult{Status: entity.IngestRejected, Error: "invalid user_id"}, result.Items[1])
+		assert.Equal(t, entity.IngestAccepted, result.Items[2].Status)
+		assert.NotEmpty(t, result.Items[2].EventID, "an event ID is assigned")
+		require.Len(t, published, 2)
+		assert.Equal(t, result.Items[2].EventID, published[1].EventID)
+		assert.False(t, result.Replayed)
+	})
+
+	t.Run("validates the batch size", func(t *testing.T) {
+		gateway := NewGateway(config.Gateway{MaxBatchSize: 1}, mocks.NewMockKafkaWriter(gomock.NewController(t)))
+
+		_, err := gateway.Ingest(context.Background(), entity.IngestRequest{})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+		_, err = gateway.Ingest(context.Background(), entity.IngestRequest{Items: []entity.IngestItem{testItem("e1"), testItem("e2")}})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("retries with an idempotency key publish only failed items", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{}, kafka)
+		req := entity.IngestRequest{
+			TenantID:       "brand-a",
+			IdempotencyKey: "key-1",
+			Fingerprint:    "hash-1",
+			Items:          []entity.IngestItem{testItem("e1"), testItem("")},
+		}
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil)
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(kafkaErr)
+		first, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, entity.IngestAccepted, first.Items[0].Status)
+		assert.Equal(t, entity.IngestFailed, first.Items[1].Status)
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event entity.TransactionEvent) error {
+				assert.Equal(t, first.Items[1].EventID, event.EventID, "a retry keeps the assigned ID")
+				return nil
+			})
+		second, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, second.Replayed)
+		assert.Equal(t, entity.IngestAccepted, second.Items[1].Status)
+
+		// nothing left to publish
+		third, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, second.Items, third.Items)
+
+		other := req
+		other.TenantID = "brand-b"
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		result, err := gateway.Ingest(context.Background(), other)
+		require.NoError(t, err)
+		assert.False(t, result.Replayed, "keys are scoped to the tenant")
+	})
+
+	t.Run("a key reused for a different request is a conflict", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{}, kafka)
+		req := entity.IngestRequest{IdempotencyKey: "key-1", Fingerprint: "hash-1", Items: []entity.IngestItem{testItem("e1")}}
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil)
+		_, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+
+		req.Fingerprint = "hash-2"
+		_, err = gateway.Ingest(context.Background(), req)
+		assert.ErrorIs(t, err, entity.ErrConflict)
+	})
+
+	t.Run("a key in progress is a conflict", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{}, kafka)
+		req := entity.IngestRequest{IdempotencyKey: "key-1", Fingerprint: "hash-1", Items: []entity.IngestItem{testItem("e1")}}
+
+		publishing, release := make(chan struct{}), make(chan struct{})
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(context.Context, entity.TransactionEvent) error {
+				close(publishing)
+				<-release
+				return nil
+			})
+		done := make(chan error, 1)
+		go func() {
+			_, err := gateway.Ingest(context.Background(), req)
+			done <- err
+		}()
+
+		<-publishing
+		_, err := gateway.Ingest(context.Background(), req)
+		assert.ErrorIs(t, err, entity.ErrConflict)
+		close(release)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("event IDs are derived from the idempotency key", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		req := entity.IngestRequest{
+			TenantID:       "brand-a",
+			IdempotencyKey: "key-1",
+			Fingerprint:    "hash-1",
+			Items:          []entity.IngestItem{testItem(""), testItem("")},
+		}
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(4)
+		first, err := NewGateway(config.Gateway{}, kafka).Ingest(context.Background(), req)
+		require.NoError(t, err)
+		// a gateway that does not know the key, like after a restart
+		second, err := NewGateway(config.Gateway{}, kafka).Ingest(context.Background(), req)
+		require.NoError(t, err)
+
+		assert.Equal(t, first.Items, second.Items)
+		assert.NotEqual(t, first.Items[0].EventID, first.Items[1].EventID)
+	})
+
+	t.Run("remembered keys are bounded", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{MaxIdempotencyKeys: 2}, kafka)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		gateway.now = func() time.Time { return now }
+		request := func(key string) entity.IngestRequest {
+			return entity.IngestRequest{IdempotencyKey: key, Fingerprint: "hash-1", Items: []entity.IngestItem{testItem("e1")}}
+		}
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(4)
+		for _, key := range []string{"key-1", "key-2", "key-3"} {
+			_, err := gateway.Ingest(context.Background(), request(key))
+			require.NoError(t, err)
+			now = now.Add(time.Second)
+		}
+		assert.Len(t, gateway.requests, 2)
+
+		result, err := gateway.Ingest(context.Background(), request("key-3"))
+		require.NoError(t, err)
+		assert.True(t, result.Replayed)
+		result, err = gateway.Ingest(context.Background(), request("key-1"))
+		require.NoError(t, err)
+		assert.False(t, result.Replayed, "the key that expires first is forgotten")
+	})
+
+	t.Run("expired keys are forgotten", func(t *testing.T) {
+		kafka := mocks.NewMockKafkaWriter(gomock.NewController(t))
+		gateway := NewGateway(config.Gateway{IdempotencyTTLSeconds: 60}, kafka)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		gateway.now = func() time.Time { return now }
+		req := entity.IngestRequest{IdempotencyKey: "key-1", Fingerprint: "hash-1", Items: []entity.IngestItem{testItem("e1")}}
+
+		kafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		_, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Minute)
+		result, err := gateway.Ingest(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, result.Replayed)
+	})
+}
@@ -0,0 +1,91 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// Evaluator checks the limits of every user in a batch and finds a breach for
+// each limit whose consumption, including the batch, exceeds it. The totals
+// are read in the transaction that stores the batch, where the breaches are
+// stored too, at most once per limit window.
+type Evaluator struct {
+	repository usageRepository
+	now        func() time.Time
+}
+
+func NewEvaluator(repository usageRepository) *Evaluator {
+	return &Evaluator{
+		repository: repository,
+		now:        time.Now,
+	}
+}
+
+// Evaluate loads the limits of the batch users and returns the check that is
+// run in the store transaction of the batch, nil when none of them has one.
+func (e *Evaluator) Evaluate(ctx context.Context, events []entity.TransactionEvent) (entity.LimitCheck, error) {
+	userIDs := distinctUsers(events)
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	limits, err := e.repository.ListLimits(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user limits: %w", err)
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	return &limitCheck{limits: limits, now: e.now()}, nil
+}
+
+type limitCheck struct {
+	limits []entity.UserLimit
+	now    time.Time
+}
+
+// Breaches computes the usage of the limits on the totals read in the store
+// transaction, which already holds the batch.
+func (c *limitCheck) Breaches(ctx context.Context, totals entity.UserTotalsReader) ([]entity.LimitBreach, error) {
+	calculator := newUsageCalculator(totals, c.now)
+
+	var breaches []entity.LimitBreach
+	for _, limit := range c.limits {
+		usage, err := calculator.usage(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		if !usage.Exceeded() {
+			continue
+		}
+
+		breaches = append(breaches, entity.LimitBreach{
+			LimitID:     limit.ID,
+			UserID:      limit.UserID,
+			Type:        limit.Type,
+			Period:      limit.Period,
+			Amount:      limit.Amount,
+			Consumed:    usage.Consumed,
+			WindowStart: usage.WindowStart,
+			DetectedAt:  c.now,
+		})
+	}
+
+	return breaches, nil
+}
+
+func distinctUsers(events []entity.TransactionEvent) []entity.UserID {
+	seen := make(map[entity.UserID]bool, len(events))
+	var userIDs []entity.UserID
+	for _, event := range events {
+		if !seen[event.UserID] {
+			seen[event.UserID] = true
+			userIDs = append(userIDs, event.UserID)
+		}
+	}
+	return userIDs
+}
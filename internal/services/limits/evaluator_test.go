@@ -0,0 +1,174 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/limits/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	userID := *entity.NewUserID(uuid.New())
+	events := []entity.TransactionEvent{
+		{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(50.0), CreatedAt: now},
+		{UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(70.0), CreatedAt: now},
+	}
+	lossLimit := entity.UserLimit{ID: 1, UserID: userID, Type: entity.LimitTypeLoss, Period: entity.LimitPeriodDaily, Amount: entity.ToMoney(100.0)}
+	wagerLimit := entity.UserLimit{ID: 2, UserID: userID, Type: entity.LimitTypeWager, Period: entity.LimitPeriodDaily, Amount: entity.ToMoney(500.0)}
+	weeklyLimit := entity.UserLimit{ID: 3, UserID: userID, Type: entity.LimitTypeWager, Period: entity.LimitPeriodWeekly, Amount: entity.ToMoney(1000.0)}
+
+	t.Run("records breaches of exceeded limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+		evaluator.now = func() time.Time { return now }
+
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), []entity.UserID{userID}).
+			Return([]entity.UserLimit{lossLimit, wagerLimit, weeklyLimit}, nil)
+		// daily totals are fetched once for both daily limits
+		mockRepo.EXPECT().
+			GetTotalsSince(gomock.Any(), userID, now.Add(-24*time.Hour)).
+			Return(&entity.UserBalance{UserID: userID, TotalBet: entity.ToMoney(300.0), TotalWin: entity.ToMoney(150.0)}, nil).
+			Times(1)
+		mockRepo.EXPECT().
+			GetTotalsSince(gomock.Any(), userID, now.Add(-7*24*time.Hour)).
+			Return(&entity.UserBalance{UserID: userID, TotalBet: entity.ToMoney(920.0)}, nil).
+			Times(1)
+
+		check, err := evaluator.Evaluate(context.Background(), events)
+		require.NoError(t, err)
+		breaches, err := check.Breaches(context.Background(), mockRepo)
+
+		require.NoError(t, err)
+		require.Len(t, breaches, 1)
+		breach := breaches[0]
+		assert.Equal(t, int64(1), breach.LimitID)
+		assert.Equal(t, entity.LimitTypeLoss, breach.Type)
+		assert.Equal(t, entity.ToMoney(150.0), breach.Consumed)
+		assert.Equal(t, entity.ToMoney(100.0), breach.Amount)
+		assert.Equal(t, now, breach.DetectedAt)
+		assert.Equal(t, now.Add(-24*time.Hour), breach.WindowStart)
+	})
+
+	t.Run("totals are read by the check in the store transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		txTotals := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+		evaluator.now = func() time.Time { return now }
+
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), gomock.Any()).
+			Return([]entity.UserLimit{lossLimit}, nil)
+
+		check, err := evaluator.Evaluate(context.Background(), events)
+		require.NoError(t, err)
+
+		// the batch is written in the transaction before the check runs
+		txTotals.EXPECT().
+			GetTotalsSince(gomock.Any(), userID, now.Add(-24*time.Hour)).
+			Return(&entity.UserBalance{UserID: userID, TotalBet: entity.ToMoney(120.0)}, nil)
+		breaches, err := check.Breaches(context.Background(), txTotals)
+
+		require.NoError(t, err)
+		require.Len(t, breaches, 1)
+		assert.Equal(t, entity.ToMoney(120.0), breaches[0].Consumed)
+	})
+
+	t.Run("deposits count against deposit limits only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+		evaluator.now = func() time.Time { return now }
+
+		depositLimit := entity.UserLimit{ID: 4, UserID: userID, Type: entity.LimitTypeDeposit, Period: entity.LimitPeriodDaily, Amount: entity.ToMoney(100.0)}
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), gomock.Any()).
+			Return([]entity.UserLimit{depositLimit, wagerLimit}, nil)
+		mockRepo.EXPECT().
+			GetTotalsSince(gomock.Any(), userID, now.Add(-24*time.Hour)).
+			Return(&entity.UserBalance{UserID: userID, TotalDeposit: entity.ToMoney(530.0)}, nil).
+			Times(1)
+
+		check, err := evaluator.Evaluate(context.Background(), []entity.TransactionEvent{
+			{UserID: userID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.ToMoney(450.0), CreatedAt: now},
+		})
+		require.NoError(t, err)
+		breaches, err := check.Breaches(context.Background(), mockRepo)
+
+		require.NoError(t, err)
+		require.Len(t, breaches, 1)
+		assert.Equal(t, entity.LimitTypeDeposit, breaches[0].Type)
+		assert.Equal(t, entity.ToMoney(530.0), breaches[0].Consumed)
+	})
+
+	t.Run("users without limits cost one query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), gomock.Any()).
+			Return(nil, nil)
+
+		check, err := evaluator.Evaluate(context.Background(), events)
+
+		require.NoError(t, err)
+		assert.Nil(t, check)
+	})
+
+	t.Run("repository error is returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+
+		expectedErr := errors.New("db error")
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), gomock.Any()).
+			Return(nil, expectedErr)
+
+		_, err := evaluator.Evaluate(context.Background(), events)
+
+		assert.ErrorIs(t, err, expectedErr)
+	})
+	t.Run("totals error is returned by the check", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockusageRepository(ctrl)
+		evaluator := NewEvaluator(mockRepo)
+
+		expectedErr := errors.New("db error")
+		mockRepo.EXPECT().
+			ListLimits(gomock.Any(), gomock.Any()).
+			Return([]entity.UserLimit{lossLimit}, nil)
+		mockRepo.EXPECT().
+			GetTotalsSince(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, expectedErr)
+
+		check, err := evaluator.Evaluate(context.Background(), events)
+		require.NoError(t, err)
+		_, err = check.Breaches(context.Background(), mockRepo)
+
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
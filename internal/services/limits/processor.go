@@ -0,0 +1,120 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type Processor struct {
+	repository   limitRepository
+	erasureGuard erasureGuard
+	now          func() time.Time
+}
+
+func NewProcessor(repository limitRepository) *Processor {
+	return &Processor{
+		repository: repository,
+		now:        time.Now,
+	}
+}
+
+// SetErasureGuard makes requests for erased users fail with
+// entity.ErrUserErased.
+func (p *Processor) SetErasureGuard(guard erasureGuard) {
+	p.erasureGuard = guard
+}
+
+func (p *Processor) CreateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error) {
+	if err := p.checkNotErased(ctx, limit.UserID); err != nil {
+		return nil, err
+	}
+	if !limit.Type.IsValid() {
+		return nil, fmt.Errorf("limit type must be one of loss, wager, deposit: %w", entity.ErrInvalidArgument)
+	}
+	if !limit.Period.IsValid() {
+		return nil, fmt.Errorf("period must be one of daily, weekly, monthly: %w", entity.ErrInvalidArgument)
+	}
+	if err := validateAmount(limit.Amount); err != nil {
+		return nil, err
+	}
+	return p.repository.CreateLimit(ctx, limit)
+}
+
+// UpdateLimit changes the amount of an existing limit. Type and period are
+// fixed, a different limit is created instead.
+func (p *Processor) UpdateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error) {
+	if err := p.checkNotErased(ctx, limit.UserID); err != nil {
+		return nil, err
+	}
+	if err := validateAmount(limit.Amount); err != nil {
+		return nil, err
+	}
+	return p.repository.UpdateLimit(ctx, limit)
+}
+
+func (p *Processor) DeleteLimit(ctx context.Context, userID entity.UserID, id int64) error {
+	if err := p.checkNotErased(ctx, userID); err != nil {
+		return err
+	}
+	return p.repository.DeleteLimit(ctx, userID, id)
+}
+
+func (p *Processor) GetLimit(ctx context.Context, userID entity.UserID, id int64) (*entity.UserLimit, error) {
+	if err := p.checkNotErased(ctx, userID); err != nil {
+		return nil, err
+	}
+	return p.repository.GetLimit(ctx, userID, id)
+}
+
+func (p *Processor) ListLimits(ctx context.Context, userID entity.UserID) ([]entity.UserLimit, error) {
+	if err := p.checkNotErased(ctx, userID); err != nil {
+		return nil, err
+	}
+	return p.repository.ListLimits(ctx, []entity.UserID{userID})
+}
+
+// GetUsage reports the current consumption of every limit of the user.
+func (p *Processor) GetUsage(ctx context.Context, userID entity.UserID) ([]entity.LimitUsage, error) {
+	if err := p.checkNotErased(ctx, userID); err != nil {
+		return nil, err
+	}
+	limits, err := p.repository.ListLimits(ctx, []entity.UserID{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	calculator := newUsageCalculator(p.repository, p.now())
+	result := make([]entity.LimitUsage, 0, len(limits))
+	for _, limit := range limits {
+		usage, err := calculator.usage(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, usage)
+	}
+	return result, nil
+}
+
+func (p *Processor) ListBreaches(ctx context.Context, userID entity.UserID, limit, offset int) ([]entity.LimitBreach, error) {
+	if err := p.checkNotErased(ctx, userID); err != nil {
+		return nil, err
+	}
+	return p.repository.ListBreaches(ctx, userID, limit, offset)
+}
+
+func (p *Processor) checkNotErased(ctx context.Context, userID entity.UserID) error {
+	if p.erasureGuard == nil {
+		return nil
+	}
+	return p.erasureGuard.CheckNotErased(ctx, userID)
+}
+
+func validateAmount(amount entity.Money) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive: %w", entity.ErrInvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package limits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/limits/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProcessor_CreateLimit(t *testing.T) {
+	userID := *entity.NewUserID(uuid.New())
+
+	t.Run("valid limit is stored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMocklimitRepository(ctrl)
+		processor := NewProcessor(mockRepo)
+
+		limit := entity.UserLimit{UserID: userID, Type: entity.LimitTypeWager, Period: entity.LimitPeriodMonthly, Amount: entity.ToMoney(5000.0)}
+		mockRepo.EXPECT().
+			CreateLimit(gomock.Any(), limit).
+			Return(&limit, nil)
+
+		_, err := processor.CreateLimit(context.Background(), limit)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid limits are rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		processor := NewProcessor(mocks.NewMocklimitRepository(ctrl))
+
+		invalid := []entity.UserLimit{
+			{UserID: userID, Type: "session", Period: entity.LimitPeriodDaily, Amount: entity.ToMoney(10.0)},
+			{UserID: userID, Type: entity.LimitTypeLoss, Period: "yearly", Amount: entity.ToMoney(10.0)},
+			{UserID: userID, Type: entity.LimitTypeLoss, Period: entity.LimitPeriodDaily, Amount: 0},
+		}
+		for _, limit := range invalid {
+			_, err := processor.CreateLimit(context.Background(), limit)
+			assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+		}
+	})
+}
+
+func TestProcessor_GetUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMocklimitRepository(ctrl)
+	processor := NewProcessor(mockRepo)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	processor.now = func() time.Time { return now }
+
+	userID := *entity.NewUserID(uuid.New())
+	mockRepo.EXPECT().
+		ListLimits(gomock.Any(), []entity.UserID{userID}).
+		Return([]entity.UserLimit{
+			{ID: 1, UserID: userID, Type: entity.LimitTypeLoss, Period: entity.LimitPeriodWeekly, Amount: entity.ToMoney(200.0)},
+		}, nil)
+	mockRepo.EXPECT().
+		GetTotalsSince(gomock.Any(), userID, now.Add(-7*24*time.Hour)).
+		Return(&entity.UserBalance{UserID: userID, TotalBet: entity.ToMoney(100.0), TotalWin: entity.ToMoney(250.0)}, nil)
+
+	usage, err := processor.GetUsage(context.Background(), userID)
+
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	// wins above bets mean no loss
+	assert.Equal(t, entity.Money(0), usage[0].Consumed)
+	assert.Equal(t, entity.ToMoney(200.0), usage[0].Remaining())
+	assert.False(t, usage[0].Exceeded())
+}
@@ -0,0 +1,27 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package limits
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type limitRepository interface {
+	usageRepository
+	CreateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error)
+	UpdateLimit(ctx context.Context, limit entity.UserLimit) (*entity.UserLimit, error)
+	DeleteLimit(ctx context.Context, userID entity.UserID, id int64) error
+	GetLimit(ctx context.Context, userID entity.UserID, id int64) (*entity.UserLimit, error)
+	ListBreaches(ctx context.Context, userID entity.UserID, limit, offset int) ([]entity.LimitBreach, error)
+}
+
+type usageRepository interface {
+	ListLimits(ctx context.Context, userIDs []entity.UserID) ([]entity.UserLimit, error)
+	GetTotalsSince(ctx context.Context, userID entity.UserID, since time.Time) (*entity.UserBalance, error)
+}
+
+type erasureGuard interface {
+	CheckNotErased(ctx context.Context, userID entity.UserID) error
+}
@@ -0,0 +1,52 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type totalsKey struct {
+	userID entity.UserID
+	period entity.LimitPeriod
+}
+
+// usageCalculator computes limit consumption over rolling windows ending at
+// now. Totals are fetched once per user and period, so the limits of the same
+// period share one query.
+type usageCalculator struct {
+	repository entity.UserTotalsReader
+	now        time.Time
+	totals     map[totalsKey]*entity.UserBalance
+}
+
+func newUsageCalculator(repository entity.UserTotalsReader, now time.Time) *usageCalculator {
+	return &usageCalculator{
+		repository: repository,
+		now:        now,
+		totals:     make(map[totalsKey]*entity.UserBalance),
+	}
+}
+
+func (c *usageCalculator) usage(ctx context.Context, limit entity.UserLimit) (entity.LimitUsage, error) {
+	windowStart := c.now.Add(-limit.Period.Window())
+
+	key := totalsKey{userID: limit.UserID, period: limit.Period}
+	totals, ok := c.totals[key]
+	if !ok {
+		var err error
+		totals, err = c.repository.GetTotalsSince(ctx, limit.UserID, windowStart)
+		if err != nil {
+			return entity.LimitUsage{}, fmt.Errorf("failed to compute usage of limit %d: %w", limit.ID, err)
+		}
+		c.totals[key] = totals
+	}
+
+	return entity.LimitUsage{
+		Limit:       limit,
+		Consumed:    limit.Type.Consumption(*totals),
+		WindowStart: windowStart,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultLargeWinThreshold = 1000.0
+)
+
+type BalanceChangedPayload struct {
+	Type              entity.OutboxEventType `json:"type"`
+	UserID            string                 `json:"user_id"`
+	Delta             float64                `json:"delta"`
+	BetAmount         float64                `json:"bet_amount"`
+	WinAmount         float64                `json:"win_amount"`
+	Transactions      int                    `json:"transactions"`
+	LastTransactionAt time.Time              `json:"last_transaction_at"`
+}
+
+type LargeWinPayload struct {
+	Type      entity.OutboxEventType `json:"type"`
+	UserID    string                 `json:"user_id"`
+	Amount    float64                `json:"amount"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Deriver turns a stored batch into derived events: one balance_changed per
+// user in the batch and one large_win per win at or above the threshold.
+type Deriver struct {
+	largeWinThreshold entity.Money
+}
+
+func NewDeriver(largeWinThreshold float64) *Deriver {
+	if largeWinThreshold <= 0 {
+		largeWinThreshold = defaultLargeWinThreshold
+	}
+	return &Deriver{
+		largeWinThreshold: entity.ToMoney(largeWinThreshold),
+	}
+}
+
+// Derive returns the messages for the batch. Messages of one user keep the
+// order of the events they were derived from, the balance change of a user
+// comes after their large wins.
+func (d *Deriver) Derive(events []entity.TransactionEvent) ([]entity.OutboxMessage, error) {
+	var messages []entity.OutboxMessage
+	balances := make(map[string]*BalanceChangedPayload)
+	var users []string
+
+	for _, event := range events {
+		userID := event.UserID.UUID.String()
+
+		balance, ok := balances[userID]
+		if !ok {
+			balance = &BalanceChangedPayload{Type: entity.OutboxEventBalanceChanged, UserID: userID}
+			balances[userID] = balance
+			users = append(users, userID)
+		}
+		balance.Transactions++
+		if event.CreatedAt.After(balance.LastTransactionAt) {
+			balance.LastTransactionAt = event.CreatedAt
+		}
+
+		switch event.TransactionType {
+		case entity.TransactionTypeBet:
+			balance.BetAmount += event.Amount.ToFloat()
+		case entity.TransactionTypeWin:
+			balance.WinAmount += event.Amount.ToFloat()
+			if event.Amount >= d.largeWinThreshold {
+				message, err := newMessage(userID, entity.OutboxEventLargeWin, LargeWinPayload{
+					Type:      entity.OutboxEventLargeWin,
+					UserID:    userID,
+					Amount:    event.Amount.ToFloat(),
+					Timestamp: event.CreatedAt,
+				})
+				if err != nil {
+					return nil, err
+				}
+				messages = append(messages, message)
+			}
+		}
+	}
+
+	for _, userID := range users {
+		balance := balances[userID]
+		balance.Delta = balance.WinAmount - balance.BetAmount
+		message, err := newMessage(userID, entity.OutboxEventBalanceChanged, balance)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func newMessage(key string, eventType entity.OutboxEventType, payload any) (entity.OutboxMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return entity.OutboxMessage{}, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return entity.OutboxMessage{
+		Key:       key,
+		EventType: eventType,
+		Payload:   data,
+	}, nil
+}
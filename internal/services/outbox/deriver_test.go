@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriver_Derive(t *testing.T) {
+	user1 := uuid.New()
+	user2 := uuid.New()
+	now := time.Now().UTC()
+
+	deriver := NewDeriver(1000)
+	messages, err := deriver.Derive([]entity.TransactionEvent{
+		{UserID: *entity.NewUserID(user1), TransactionType: entity.TransactionTypeBet, Amount: entity.ToMoney(100.0), CreatedAt: now.Add(-time.Minute)},
+		{UserID: *entity.NewUserID(user2), TransactionType: entity.TransactionTypeWin, Amount: entity.ToMoney(999.99), CreatedAt: now},
+		{UserID: *entity.NewUserID(user1), TransactionType: entity.TransactionTypeWin, Amount: entity.ToMoney(1500.0), CreatedAt: now},
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, entity.OutboxEventLargeWin, messages[0].EventType)
+	assert.Equal(t, user1.String(), messages[0].Key)
+	var largeWin LargeWinPayload
+	require.NoError(t, json.Unmarshal(messages[0].Payload, &largeWin))
+	assert.Equal(t, 1500.0, largeWin.Amount)
+
+	assert.Equal(t, entity.OutboxEventBalanceChanged, messages[1].EventType)
+	assert.Equal(t, user1.String(), messages[1].Key)
+	var balance BalanceChangedPayload
+	require.NoError(t, json.Unmarshal(messages[1].Payload, &balance))
+	assert.Equal(t, 1400.0, balance.Delta)
+	assert.Equal(t, 100.0, balance.BetAmount)
+	assert.Equal(t, 1500.0, balance.WinAmount)
+	assert.Equal(t, 2, balance.Transactions)
+	assert.True(t, now.Equal(balance.LastTransactionAt))
+
+	assert.Equal(t, entity.OutboxEventBalanceChanged, messages[2].EventType)
+	assert.Equal(t, user2.String(), messages[2].Key)
+}
+
+func TestDeriver_EmptyBatch(t *testing.T) {
+	messages, err := NewDeriver(0).Derive(nil)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 500
+)
+
+// Relay publishes unsent outbox messages in ID order and marks them sent.
+// Delivery is at-least-once: a crash between publishing and marking the
+// messages sent publishes them again.
+type Relay struct {
+	repository   outboxRepository
+	publisher    messagePublisher
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewRelay(repository outboxRepository, publisher messagePublisher, conf config.Outbox) *Relay {
+	relay := &Relay{
+		repository:   repository,
+		publisher:    publisher,
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+	}
+	if conf.PollIntervalMs > 0 {
+		relay.pollInterval = time.Duration(conf.PollIntervalMs) * time.Millisecond
+	}
+	if conf.BatchSize > 0 {
+		relay.batchSize = conf.BatchSize
+	}
+	return relay
+}
+
+func (r *Relay) Start(ctx context.Context) error {
+	log.Println("Starting outbox relay")
+	defer func() { log.Println("Stopping outbox relay") }()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.drain(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to relay outbox messages: %v", err)
+			}
+		}
+	}
+}
+
+// drain relays full batches until the outbox is empty.
+func (r *Relay) drain(ctx context.Context) error {
+	for {
+		processed, err := r.RelayBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if processed < r.batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// RelayBatch publishes one batch of unsent messages and returns how many were
+// published.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	processed, err := r.repository.ProcessUnsent(ctx, r.batchSize, func(messages []entity.OutboxMessage) error {
+		return r.publisher.PublishOutbox(ctx, messages)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to relay outbox batch: %w", err)
+	}
+	return processed, nil
+}
@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/outbox/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRelay_RelayBatch(t *testing.T) {
+	messages := []entity.OutboxMessage{
+		{ID: 1, Key: "user-1", EventType: entity.OutboxEventLargeWin},
+		{ID: 2, Key: "user-1", EventType: entity.OutboxEventBalanceChanged},
+	}
+
+	t.Run("publishes unsent messages in order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockoutboxRepository(ctrl)
+		mockPublisher := mocks.NewMockmessagePublisher(ctrl)
+		relay := NewRelay(mockRepo, mockPublisher, config.Outbox{BatchSize: 10})
+
+		mockRepo.EXPECT().
+			ProcessUnsent(gomock.Any(), 10, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, fn func([]entity.OutboxMessage) error) (int, error) {
+				if err := fn(messages); err != nil {
+					return 0, err
+				}
+				return len(messages), nil
+			})
+		mockPublisher.EXPECT().
+			PublishOutbox(gomock.Any(), messages).
+			Return(nil)
+
+		processed, err := relay.RelayBatch(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+	})
+
+	t.Run("publish error leaves messages unsent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockoutboxRepository(ctrl)
+		mockPublisher := mocks.NewMockmessagePublisher(ctrl)
+		relay := NewRelay(mockRepo, mockPublisher, config.Outbox{})
+
+		publishErr := errors.New("kafka unavailable")
+		mockRepo.EXPECT().
+			ProcessUnsent(gomock.Any(), defaultBatchSize, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, fn func([]entity.OutboxMessage) error) (int, error) {
+				return 0, fn(messages)
+			})
+		mockPublisher.EXPECT().
+			PublishOutbox(gomock.Any(), messages).
+			Return(publishErr)
+
+		_, err := relay.RelayBatch(context.Background())
+
+		assert.ErrorIs(t, err, publishErr)
+	})
+}
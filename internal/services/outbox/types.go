@@ -0,0 +1,16 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package outbox
+
+import (
+	"context"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type outboxRepository interface {
+	ProcessUnsent(ctx context.Context, limit int, fn func(messages []entity.OutboxMessage) error) (int, error)
+}
+
+type messagePublisher interface {
+	PublishOutbox(ctx context.Context, messages []entity.OutboxMessage) error
+}
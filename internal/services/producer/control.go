@@ -0,0 +1,176 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+// maxBurst bounds a single burst, so a typo does not queue jobs for hours.
+const maxBurst = 1000000
+
+// workerPool is the set of workers of a run. Every worker has its own stop
+// channel, so the pool shrinks without interrupting the other workers. All
+// workers publish with the context of the pool, which ends only with the run.
+type workerPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   chan struct{}
+	wg     sync.WaitGroup
+	stops  []chan struct{}
+	nextID int
+}
+
+// resizeWorkers starts or stops workers until the pool has size workers. A
+// stopped worker finishes its current job first. Must be called with p.mu
+// held; a pool whose run is over is left alone, so the wait group is never
+// added to after the run has waited for it.
+func (p *Producer) resizeWorkers(size int) {
+	pool := p.pool
+	if pool == nil || pool.ctx.Err() != nil {
+		return
+	}
+	for len(pool.stops) < size {
+		pool.nextID++
+		stop := make(chan struct{})
+		pool.stops = append(pool.stops, stop)
+		pool.wg.Add(1)
+		go p.Worker(pool.ctx, pool.nextID, pool.jobs, stop, &pool.wg, pool.cancel)
+	}
+	for len(pool.stops) > size {
+		last := len(pool.stops) - 1
+		close(pool.stops[last])
+		pool.stops = pool.stops[:last]
+	}
+}
+
+// Pause stops releasing jobs until Resume. Jobs already queued are still
+// published.
+func (p *Producer) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
+		log.Println("Producer paused")
+	}
+	p.paused = true
+}
+
+func (p *Producer) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		log.Println("Producer resumed")
+	}
+	p.paused = false
+}
+
+// UpdateSettings applies the settings of the update to the running
+// producer. A new CreationRPS replaces the load profile with that constant
+// rate and resizes the workers to it. Players and amounts of a scenario are
+// set by the scenario file and cannot be changed.
+func (p *Producer) UpdateSettings(update entity.ProducerSettingsUpdate) (*entity.ProducerStatus, error) {
+	if p.rounds != nil && (update.DistinctUsers != nil || update.AmountFrom != nil || update.AmountTo != nil) {
+		return nil, fmt.Errorf("%w: users and amounts are set by the scenario", entity.ErrInvalidArgument)
+	}
+
+	p.mu.Lock()
+	settings := p.settings
+	if update.CreationRPS != nil {
+		settings.CreationRPS = *update.CreationRPS
+	}
+	if update.DistinctUsers != nil {
+		settings.DistinctUsers = *update.DistinctUsers
+	}
+	if update.AmountFrom != nil {
+		settings.AmountFrom = *update.AmountFrom
+	}
+	if update.AmountTo != nil {
+		settings.AmountTo = *update.AmountTo
+	}
+	if err := validateSettings(settings); err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	for i := len(p.usersMap); i < settings.DistinctUsers; i++ {
+		p.usersMap[i] = uuid.New()
+	}
+	if update.CreationRPS != nil {
+		p.current = constantProfile(settings.CreationRPS)
+		p.resizeWorkers(p.workersCount(float64(settings.CreationRPS)))
+	}
+	p.settings = settings
+	p.mu.Unlock()
+
+	log.Printf("Producer settings changed: %d rps, %d users, amounts %d to %d",
+		settings.CreationRPS, settings.DistinctUsers, settings.AmountFrom, settings.AmountTo)
+	status := p.Status()
+	return &status, nil
+}
+
+// Burst queues count jobs on top of the rate, also while paused. The jobs
+// wait for a free worker instead of being dropped.
+func (p *Producer) Burst(count int) error {
+	if count <= 0 || count > maxBurst {
+		return fmt.Errorf("%w: burst count must be between 1 and %d", entity.ErrInvalidArgument, maxBurst)
+	}
+
+	p.mu.RLock()
+	pool := p.pool
+	p.mu.RUnlock()
+	if pool == nil {
+		return fmt.Errorf("%w: producer is not running", entity.ErrInvalidArgument)
+	}
+
+	log.Printf("Producer burst of %d jobs", count)
+	go func() {
+		for i := 0; i < count; i++ {
+			select {
+			case <-pool.ctx.Done():
+				return
+			case pool.jobs <- struct{}{}:
+				p.stats.schedule()
+			}
+		}
+	}()
+	return nil
+}
+
+// Status returns the settings and counters of the current or last run.
+func (p *Producer) Status() entity.ProducerStatus {
+	report := p.stats.report()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status := entity.ProducerStatus{
+		Running:     p.pool != nil,
+		Paused:      p.paused,
+		Settings:    p.settings,
+		TargetRate:  p.rate,
+		CurrentRate: p.stats.currentRate(),
+		Scheduled:   report.Scheduled,
+		Dropped:     report.Dropped,
+		Published:   report.Published,
+		Failed:      report.Errors,
+	}
+	if p.pool != nil {
+		status.Workers = len(p.pool.stops)
+	}
+	return status
+}
+
+func validateSettings(settings entity.ProducerSettings) error {
+	switch {
+	case settings.CreationRPS < 0:
+		return fmt.Errorf("%w: creation rps must not be negative", entity.ErrInvalidArgument)
+	case settings.DistinctUsers <= 0:
+		return fmt.Errorf("%w: distinct users must be positive", entity.ErrInvalidArgument)
+	case settings.AmountFrom < 0 || settings.AmountTo < settings.AmountFrom:
+		return fmt.Errorf("%w: amounts must satisfy 0 <= from <= to", entity.ErrInvalidArgument)
+	}
+	return nil
+}
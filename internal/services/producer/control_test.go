@@ -0,0 +1,156 @@
+package producer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/producer/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProducer_Control(t *testing.T) {
+	conf := config.Producer{CreationRPS: 100, DistinctUsers: 2, AmountFrom: 10, AmountTo: 100}
+
+	// start runs the producer until the test ends and counts the published
+	// events.
+	start := func(t *testing.T, conf config.Producer) (*Producer, *atomic.Int64) {
+		ctrl := gomock.NewController(t)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		var published atomic.Int64
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, entity.TransactionEvent) error {
+				published.Add(1)
+				return nil
+			}).AnyTimes()
+
+		producer := NewProducer(conf, mockKafka)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- producer.Start(ctx) }()
+		t.Cleanup(func() {
+			cancel()
+			require.NoError(t, <-done)
+		})
+		require.Eventually(t, func() bool { return producer.Status().Running }, time.Second, 5*time.Millisecond)
+		return producer, &published
+	}
+
+	t.Run("pause and resume", func(t *testing.T) {
+		producer, published := start(t, conf)
+		producer.Pause()
+		time.Sleep(50 * time.Millisecond)
+
+		paused := published.Load()
+		time.Sleep(200 * time.Millisecond)
+		assert.Equal(t, paused, published.Load())
+		assert.True(t, producer.Status().Paused)
+		assert.Zero(t, producer.Status().TargetRate)
+
+		producer.Resume()
+		assert.Eventually(t, func() bool { return published.Load() > paused }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("burst publishes while paused", func(t *testing.T) {
+		producer, published := start(t, conf)
+		producer.Pause()
+		time.Sleep(50 * time.Millisecond)
+		before := published.Load()
+
+		require.NoError(t, producer.Burst(300))
+		assert.Eventually(t, func() bool { return published.Load() == before+300 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("settings resize the workers and add users", func(t *testing.T) {
+		producer, _ := start(t, conf)
+		assert.Equal(t, 100, producer.Status().Workers)
+
+		rps, users := 20, 5
+		status, err := producer.UpdateSettings(entity.ProducerSettingsUpdate{CreationRPS: &rps, DistinctUsers: &users})
+		require.NoError(t, err)
+		assert.Equal(t, 20, status.Workers)
+		assert.Equal(t, entity.ProducerSettings{CreationRPS: 20, DistinctUsers: 5, AmountFrom: 10, AmountTo: 100}, status.Settings)
+		assert.Eventually(t, func() bool { return producer.Status().TargetRate == 20 }, time.Second, 10*time.Millisecond)
+
+		producer.mu.RLock()
+		assert.Len(t, producer.usersMap, 5)
+		producer.mu.RUnlock()
+	})
+
+	t.Run("workers stopped mid-publish finish their publish", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		release := make(chan struct{})
+		var started, published atomic.Int64
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ entity.TransactionEvent) error {
+				started.Add(1)
+				<-release
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				published.Add(1)
+				return nil
+			}).AnyTimes()
+
+		producer := NewProducer(conf, mockKafka)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- producer.Start(ctx) }()
+		// most of the 100 workers are blocked in a publish
+		require.Eventually(t, func() bool { return started.Load() >= 50 }, 2*time.Second, 5*time.Millisecond)
+
+		rps := 20
+		status, err := producer.UpdateSettings(entity.ProducerSettingsUpdate{CreationRPS: &rps})
+		require.NoError(t, err)
+		assert.Equal(t, 20, status.Workers)
+		inFlight := started.Load()
+		close(release)
+
+		// the publishes of the stopped workers succeed and the run goes on
+		assert.Eventually(t, func() bool { return published.Load() > inFlight }, 3*time.Second, 10*time.Millisecond)
+		assert.True(t, producer.Status().Running)
+		assert.Zero(t, producer.Status().Failed)
+
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("invalid settings are rejected", func(t *testing.T) {
+		producer := NewProducer(conf, nil)
+		from, to, users := 50, 10, 0
+
+		_, err := producer.UpdateSettings(entity.ProducerSettingsUpdate{AmountFrom: &from, AmountTo: &to})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+		_, err = producer.UpdateSettings(entity.ProducerSettingsUpdate{DistinctUsers: &users})
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+		assert.Equal(t, 2, producer.Status().Settings.DistinctUsers)
+	})
+
+	t.Run("burst needs a running producer", func(t *testing.T) {
+		producer := NewProducer(conf, nil)
+		assert.ErrorIs(t, producer.Burst(10), entity.ErrInvalidArgument)
+		assert.ErrorIs(t, producer.Burst(0), entity.ErrInvalidArgument)
+	})
+}
+
+func TestRunStats_CurrentRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	stats := newRunStats()
+	stats.now = func() time.Time { return now }
+
+	for second := 0; second < 8; second++ {
+		now = time.Unix(int64(1000+second), 0)
+		for i := 0; i < 10*second; i++ {
+			stats.observe(time.Millisecond, nil)
+		}
+	}
+
+	// seconds 2 to 6 are complete and in the window, second 7 is running
+	assert.InDelta(t, float64(20+30+40+50+60)/rateWindow, stats.currentRate(), 0.001)
+}
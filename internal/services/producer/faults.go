@@ -0,0 +1,121 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultOversizedBytes = 2 << 20
+	// outOfOrderShift puts an out of order event behind the events
+	// published before it.
+	outOfOrderShift = time.Hour
+	farFutureShift  = 365 * 24 * time.Hour
+)
+
+type faultShare struct {
+	kind    string
+	percent float64
+}
+
+// faultInjector picks the fault of every published event.
+type faultInjector struct {
+	shares         []faultShare
+	oversizedBytes int
+	rng            *rand.Rand
+	mu             sync.Mutex
+}
+
+func newFaultInjector(conf config.ProducerFaults, seed int64) (*faultInjector, error) {
+	f := &faultInjector{
+		shares: []faultShare{
+			{entity.FaultMalformed, conf.MalformedPercent},
+			{entity.FaultUnknownEnum, conf.UnknownEnumPercent},
+			{entity.FaultInvalidUUID, conf.InvalidUUIDPercent},
+			{entity.FaultDuplicate, conf.DuplicatePercent},
+			{entity.FaultOutOfOrder, conf.OutOfOrderPercent},
+			{entity.FaultFarFuture, conf.FarFuturePercent},
+			{entity.FaultOversized, conf.OversizedPercent},
+		},
+		oversizedBytes: conf.OversizedBytes,
+		rng:            rand.New(rand.NewSource(seed)),
+	}
+	if f.oversizedBytes == 0 {
+		f.oversizedBytes = defaultOversizedBytes
+	}
+	if f.oversizedBytes < 0 {
+		return nil, fmt.Errorf("oversizedBytes must not be negative")
+	}
+
+	var total float64
+	for _, share := range f.shares {
+		if share.percent < 0 || share.percent > 100 {
+			return nil, fmt.Errorf("%s percentage must be between 0 and 100", share.kind)
+		}
+		total += share.percent
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("fault percentages add up to %.1f, more than 100", total)
+	}
+	return f, nil
+}
+
+// pick returns the kind of fault of the next event, empty for none.
+func (f *faultInjector) pick() string {
+	f.mu.Lock()
+	r := f.rng.Float64() * 100
+	f.mu.Unlock()
+
+	for _, share := range f.shares {
+		if r < share.percent {
+			return share.kind
+		}
+		r -= share.percent
+	}
+	return ""
+}
+
+// SetFaults makes the producer inject the faults of the config into the
+// events it publishes.
+func (p *Producer) SetFaults(conf config.ProducerFaults) error {
+	faults, err := newFaultInjector(conf, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	p.faults = faults
+	return nil
+}
+
+// publishFault publishes the event with the fault. A duplicate is published
+// twice, the copy tagged. Faulty messages may be refused by the broker, like
+// oversized ones, so their publish errors are logged and counted without
+// stopping the run.
+func (p *Producer) publishFault(ctx context.Context, event entity.TransactionEvent, kind string) error {
+	fault := entity.Fault{Kind: kind}
+	switch kind {
+	case entity.FaultDuplicate:
+		if err := p.publishEvent(ctx, event); err != nil {
+			return err
+		}
+	case entity.FaultOutOfOrder:
+		event.CreatedAt = event.CreatedAt.Add(-outOfOrderShift)
+	case entity.FaultFarFuture:
+		event.CreatedAt = event.CreatedAt.Add(farFutureShift)
+	case entity.FaultOversized:
+		fault.Size = p.faults.oversizedBytes
+	}
+
+	err := p.send(ctx, event, fault)
+	p.stats.inject(kind)
+	if err != nil {
+		log.Printf("Failed to publish %s fault: %v", kind, err)
+	}
+	return nil
+}
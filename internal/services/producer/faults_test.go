@@ -0,0 +1,96 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/producer/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewFaultInjector(t *testing.T) {
+	t.Run("picks faults by percentage", func(t *testing.T) {
+		faults, err := newFaultInjector(config.ProducerFaults{MalformedPercent: 10, DuplicatePercent: 30}, 1)
+		require.NoError(t, err)
+
+		counts := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			counts[faults.pick()]++
+		}
+		assert.InDelta(t, 1000, counts[entity.FaultMalformed], 150)
+		assert.InDelta(t, 3000, counts[entity.FaultDuplicate], 200)
+		assert.InDelta(t, 6000, counts[""], 200)
+		assert.Len(t, counts, 3)
+	})
+
+	t.Run("percentages above 100", func(t *testing.T) {
+		_, err := newFaultInjector(config.ProducerFaults{MalformedPercent: 60, OversizedPercent: 50}, 1)
+		assert.ErrorContains(t, err, "more than 100")
+	})
+
+	t.Run("negative percentage", func(t *testing.T) {
+		_, err := newFaultInjector(config.ProducerFaults{FarFuturePercent: -1}, 1)
+		assert.ErrorContains(t, err, "far_future")
+	})
+}
+
+func TestProducer_PublishFault(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := entity.TransactionEvent{EventID: "e1", TransactionType: entity.TransactionTypeBet, CreatedAt: created}
+
+	newProducer := func(t *testing.T, faults config.ProducerFaults) (*Producer, *mocks.MockKafkaWriter) {
+		ctrl := gomock.NewController(t)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		producer := NewProducer(config.Producer{}, mockKafka)
+		require.NoError(t, producer.SetFaults(faults))
+		return producer, mockKafka
+	}
+
+	t.Run("duplicate publishes the event and a tagged copy", func(t *testing.T) {
+		producer, mockKafka := newProducer(t, config.ProducerFaults{DuplicatePercent: 100})
+		gomock.InOrder(
+			mockKafka.EXPECT().Publish(gomock.Any(), event).Return(nil),
+			mockKafka.EXPECT().PublishFault(gomock.Any(), event, entity.Fault{Kind: entity.FaultDuplicate}).Return(nil),
+		)
+
+		require.NoError(t, producer.publish(context.Background(), event))
+		report := producer.Report()
+		assert.Equal(t, int64(2), report.Published)
+		assert.Equal(t, map[string]int64{entity.FaultDuplicate: 1}, report.Faults)
+	})
+
+	t.Run("timestamps are moved", func(t *testing.T) {
+		producer, mockKafka := newProducer(t, config.ProducerFaults{FarFuturePercent: 100})
+		mockKafka.EXPECT().PublishFault(gomock.Any(), gomock.Any(), entity.Fault{Kind: entity.FaultFarFuture}).
+			DoAndReturn(func(_ context.Context, published entity.TransactionEvent, _ entity.Fault) error {
+				assert.Equal(t, created.Add(farFutureShift), published.CreatedAt)
+				return nil
+			})
+		require.NoError(t, producer.publish(context.Background(), event))
+
+		producer, mockKafka = newProducer(t, config.ProducerFaults{OutOfOrderPercent: 100})
+		mockKafka.EXPECT().PublishFault(gomock.Any(), gomock.Any(), entity.Fault{Kind: entity.FaultOutOfOrder}).
+			DoAndReturn(func(_ context.Context, published entity.TransactionEvent, _ entity.Fault) error {
+				assert.Equal(t, created.Add(-outOfOrderShift), published.CreatedAt)
+				return nil
+			})
+		require.NoError(t, producer.publish(context.Background(), event))
+	})
+
+	t.Run("refused oversized message does not stop the run", func(t *testing.T) {
+		producer, mockKafka := newProducer(t, config.ProducerFaults{OversizedPercent: 100})
+		mockKafka.EXPECT().PublishFault(gomock.Any(), event, entity.Fault{Kind: entity.FaultOversized, Size: defaultOversizedBytes}).
+			Return(errors.New("message too large"))
+
+		require.NoError(t, producer.publish(context.Background(), event))
+		report := producer.Report()
+		assert.Equal(t, int64(1), report.Errors)
+		assert.Contains(t, report.String(), "faults injected: oversized 1")
+	})
+}
@@ -0,0 +1,88 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+)
+
+// FileReplayer publishes the events of a recorded file in order, optionally
+// at the pace they were originally created.
+type FileReplayer struct {
+	source          eventSource
+	kafka           KafkaWriter
+	speed           float64
+	shiftTimestamps bool
+	now             func() time.Time
+}
+
+func NewFileReplayer(source eventSource, kafka KafkaWriter, conf config.ProducerReplay) *FileReplayer {
+	return &FileReplayer{
+		source:          source,
+		kafka:           kafka,
+		speed:           conf.Speed,
+		shiftTimestamps: conf.ShiftTimestamps,
+		now:             time.Now,
+	}
+}
+
+// Start publishes every event of the file and returns when the file is
+// exhausted. With a speed, an event is published when the time since its
+// first event, divided by the speed, has passed since the start; events older
+// than their predecessor are published right away. Shifted timestamps are
+// the moments the events are due.
+func (r *FileReplayer) Start(ctx context.Context) error {
+	if r.speed < 0 {
+		return fmt.Errorf("replay speed must not be negative")
+	}
+
+	var start, first time.Time
+	var published int
+	for {
+		event, err := r.source.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read event: %w", err)
+		}
+
+		if published == 0 {
+			start, first = r.now(), event.CreatedAt
+		}
+		offset := event.CreatedAt.Sub(first)
+		if r.speed > 0 {
+			offset = time.Duration(float64(offset) / r.speed)
+		}
+		due := start.Add(offset)
+
+		if r.speed > 0 {
+			if wait := due.Sub(r.now()); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					log.Printf("Replay stopped after %d events", published)
+					return nil
+				case <-timer.C:
+				}
+			}
+		}
+		if r.shiftTimestamps {
+			event.CreatedAt = due
+		}
+
+		if err = r.kafka.Publish(ctx, *event); err != nil {
+			return fmt.Errorf("producer failed to publish message: %w", err)
+		}
+		published++
+	}
+
+	log.Printf("Replay finished, %d events published", published)
+	return nil
+}
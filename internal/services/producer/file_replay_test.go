@@ -0,0 +1,100 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/producer/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFileReplayer_Start(t *testing.T) {
+	recorded := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newEvents := func() []entity.TransactionEvent {
+		return []entity.TransactionEvent{
+			{EventID: "e1", CreatedAt: recorded},
+			{EventID: "e2", CreatedAt: recorded.Add(100 * time.Millisecond)},
+			{EventID: "e3", CreatedAt: recorded.Add(200 * time.Millisecond)},
+		}
+	}
+	expectSource := func(source *mocks.MockeventSource, events []entity.TransactionEvent) {
+		calls := make([]any, 0, len(events)+1)
+		for i := range events {
+			calls = append(calls, source.EXPECT().Read().Return(&events[i], nil))
+		}
+		calls = append(calls, source.EXPECT().Read().Return(nil, io.EOF))
+		gomock.InOrder(calls...)
+	}
+
+	t.Run("publishes in order at the original pace times the speed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		source := mocks.NewMockeventSource(ctrl)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		expectSource(source, newEvents())
+
+		var published []string
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event entity.TransactionEvent) error {
+				published = append(published, event.EventID)
+				assert.True(t, event.CreatedAt.Before(recorded.Add(time.Second)), "timestamps are kept")
+				return nil
+			}).Times(3)
+
+		replayer := NewFileReplayer(source, mockKafka, config.ProducerReplay{Speed: 2})
+		started := time.Now()
+		require.NoError(t, replayer.Start(context.Background()))
+
+		assert.Equal(t, []string{"e1", "e2", "e3"}, published)
+		assert.GreaterOrEqual(t, time.Since(started), 100*time.Millisecond)
+		assert.Less(t, time.Since(started), 200*time.Millisecond)
+	})
+
+	t.Run("shifts timestamps to the replay start", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		source := mocks.NewMockeventSource(ctrl)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		expectSource(source, newEvents())
+
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		var timestamps []time.Time
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event entity.TransactionEvent) error {
+				timestamps = append(timestamps, event.CreatedAt)
+				return nil
+			}).Times(3)
+
+		replayer := NewFileReplayer(source, mockKafka, config.ProducerReplay{ShiftTimestamps: true})
+		replayer.now = func() time.Time { return now }
+		require.NoError(t, replayer.Start(context.Background()))
+
+		assert.Equal(t, []time.Time{now, now.Add(100 * time.Millisecond), now.Add(200 * time.Millisecond)}, timestamps)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		source := mocks.NewMockeventSource(ctrl)
+		source.EXPECT().Read().Return(nil, errors.New("line 3: invalid user_id"))
+
+		err := NewFileReplayer(source, mocks.NewMockKafkaWriter(ctrl), config.ProducerReplay{}).Start(context.Background())
+		assert.ErrorContains(t, err, "line 3")
+	})
+
+	t.Run("publish error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		source := mocks.NewMockeventSource(ctrl)
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		events := newEvents()
+		source.EXPECT().Read().Return(&events[0], nil)
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("kafka down"))
+
+		err := NewFileReplayer(source, mockKafka, config.ProducerReplay{}).Start(context.Background())
+		assert.ErrorContains(t, err, "kafka down")
+	})
+}
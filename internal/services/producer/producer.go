@@ -3,17 +3,43 @@ package producer
 import (
 	"context"
 	"log"
+	"math"
+	"runtime"
 	"sync"
 	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/google/uuid"
 )
 
+const (
+	asyncWorkers = 4
+	// deliveryTimeout bounds the wait for pending deliveries at the end of
+	// a run.
+	deliveryTimeout = 30 * time.Second
+)
+
 type Producer struct {
 	conf     config.Producer
 	kafka    KafkaWriter
 	usersMap map[int]uuid.UUID
+	rounds   roundGenerator
+	profile  *loadProfile
+	faults   *faultInjector
+	async    asyncKafkaWriter
+	inflight sync.WaitGroup
+	stats    *runStats
+	// continueOnError keeps a constant rate run going after a publish error
+	continueOnError bool
+
+	// mu guards the state the control API changes while the producer runs
+	mu       sync.RWMutex
+	settings entity.ProducerSettings
+	current  *loadProfile
+	paused   bool
+	rate     float64
+	pool     *workerPool
 }
 
 func NewProducer(conf config.Producer, kafka KafkaWriter) *Producer {
@@ -21,54 +47,131 @@ func NewProducer(conf config.Producer, kafka KafkaWriter) *Producer {
 		conf:     conf,
 		kafka:    kafka,
 		usersMap: make(map[int]uuid.UUID),
+		stats:    newRunStats(),
+		settings: entity.ProducerSettings{
+			CreationRPS:   conf.CreationRPS,
+			DistinctUsers: conf.DistinctUsers,
+			AmountFrom:    conf.AmountFrom,
+			AmountTo:      conf.AmountTo,
+		},
 	}
 }
 
+// SetScenario makes every job publish a round of the scenario instead of a
+// random event.
+func (p *Producer) SetScenario(rounds roundGenerator) {
+	p.rounds = rounds
+}
+
+// SetAsyncWriter makes the workers queue the events on the writer instead of
+// waiting for every delivery, so a few workers keep up with any rate.
+// Delivery errors are counted and do not stop the run.
+func (p *Producer) SetAsyncWriter(writer asyncKafkaWriter) {
+	p.async = writer
+}
+
+// ContinueOnError keeps the run going when an event fails to publish, for
+// writers that only fail when an event is lost for good, like the spool.
+func (p *Producer) ContinueOnError() {
+	p.continueOnError = true
+}
+
+// SetLoadProfile replaces the constant CreationRPS with the rate of the
+// profile. Publish errors no longer stop the run, they are counted in the
+// report.
+func (p *Producer) SetLoadProfile(conf config.LoadProfile) error {
+	profile, err := newLoadProfile(conf)
+	if err != nil {
+		return err
+	}
+	p.profile = profile
+	return nil
+}
+
+// Start publishes until the context is done or the profile duration has
+// passed, then logs the run report.
 func (p *Producer) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	p.preGenerateUsers(p.conf.DistinctUsers)
+	p.mu.Lock()
+	profile := p.profile
+	if profile == nil {
+		profile = constantProfile(p.settings.CreationRPS)
+	}
+	if profile.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, profile.duration)
+		defer cancel()
+	}
 
-	workersCount := getWorkersCount(p.conf.CreationRPS)
-	jobs := make(chan struct{}, p.conf.CreationRPS*2)
-	var wg sync.WaitGroup
-	wg.Add(workersCount)
-	for w := 1; w <= workersCount; w++ {
-		go p.Worker(ctx, w, jobs, &wg, cancel)
+	p.preGenerateUsers(p.settings.DistinctUsers)
+	p.stats.restart()
+
+	workersCount := p.workersCount(profile.peakRPS())
+	pool := &workerPool{
+		ctx:    ctx,
+		cancel: cancel,
+		// up to a second of jobs, few async workers drain a queue quickly
+		jobs: make(chan struct{}, max(workersCount*2, int(math.Ceil(profile.peakRPS())), p.conf.InitialBatchSize)),
 	}
+	p.current, p.pool = profile, pool
+	p.resizeWorkers(workersCount)
+	p.mu.Unlock()
 
-	if p.conf.InitialBatchSize > 0 {
-		for i := 0; i < p.conf.InitialBatchSize; i++ {
-			jobs <- struct{}{}
-		}
+	for i := 0; i < p.conf.InitialBatchSize; i++ {
+		pool.jobs <- struct{}{}
+		p.stats.schedule()
 	}
-	ticker := time.NewTicker(1 * time.Second)
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				for i := 0; i < p.conf.CreationRPS; i++ {
-					jobs <- struct{}{}
-				}
-			case <-ctx.Done():
-				log.Printf("Shutting down producer goroutine\n")
-				ticker.Stop()
-				return
-			}
-		}
-	}()
+	go p.pace(ctx, pool.jobs)
+
+	pool.wg.Wait()
+	p.mu.Lock()
+	p.pool = nil
+	p.mu.Unlock()
+	p.awaitDeliveries()
 
-	wg.Wait()
+	log.Printf("Producer run finished: %s", p.stats.report())
 	return nil
 }
 
+// Report returns the report of the current or last run.
+func (p *Producer) Report() RunReport {
+	return p.stats.report()
+}
+
+// preGenerateUsers creates the users the generated events belong to. Must
+// be called with p.mu held while the producer runs.
 func (p *Producer) preGenerateUsers(distinctUsers int) {
 	for i := 0; i < distinctUsers; i++ {
 		p.usersMap[i] = uuid.New()
 	}
 }
 
+// workersCount is the number of workers publishing at the rate. A worker
+// waits for every delivery, so a synchronous writer needs one per event per
+// second, while queueing on an async writer takes a few.
+func (p *Producer) workersCount(rps float64) int {
+	if p.async != nil {
+		return max(asyncWorkers, runtime.GOMAXPROCS(0))
+	}
+	return getWorkersCount(int(math.Ceil(rps)))
+}
+
+// awaitDeliveries waits for the reports of the events queued on the async
+// writer, so they are part of the run report.
+func (p *Producer) awaitDeliveries() {
+	done := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(deliveryTimeout):
+		log.Printf("Gave up waiting for deliveries after %s", deliveryTimeout)
+	}
+}
+
 func getWorkersCount(creationRPS int) int {
 	if creationRPS > 10 {
 		return creationRPS
@@ -2,12 +2,17 @@ package producer
 
 import (
 	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
 	"github.com/bsko/casino-transaction-system/internal/services/producer/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -77,4 +82,68 @@ func TestProducer_Start(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, conf.DistinctUsers, len(producer.usersMap))
 	})
+	t.Run("publish errors do not stop the run with continue on error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		conf := config.Producer{
+			InitialBatchSize: 3,
+			DistinctUsers:    1,
+			AmountFrom:       10,
+			AmountTo:         100,
+		}
+
+		producer := NewProducer(conf, mockKafka)
+		producer.ContinueOnError()
+
+		mockKafka.EXPECT().
+			Publish(gomock.Any(), gomock.Any()).
+			Return(errors.New("spool is full")).
+			Times(3)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		require.NoError(t, producer.Start(ctx))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		assert.Equal(t, int64(3), producer.Report().Errors)
+	})
+}
+
+func TestProducer_AsyncWriter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAsync := mocks.NewMockasyncKafkaWriter(ctrl)
+	var queued atomic.Int64
+	mockAsync.EXPECT().
+		PublishAsync(gomock.Any(), gomock.Any(), entity.Fault{}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ entity.TransactionEvent, _ entity.Fault, delivered func(error)) error {
+			// every tenth delivery fails, reported after the linger
+			var err error
+			if queued.Add(1)%10 == 0 {
+				err = errors.New("leader not available")
+			}
+			time.AfterFunc(20*time.Millisecond, func() { delivered(err) })
+			return nil
+		}).
+		AnyTimes()
+
+	producer := NewProducer(config.Producer{DistinctUsers: 2, AmountFrom: 10, AmountTo: 100}, mocks.NewMockKafkaWriter(ctrl))
+	producer.SetAsyncWriter(mockAsync)
+	require.NoError(t, producer.SetLoadProfile(config.LoadProfile{Type: ProfileSoak, RPS: 2000, DurationSec: 1}))
+
+	go func() {
+		require.Eventually(t, func() bool { return producer.Status().Running }, time.Second, time.Millisecond)
+		assert.Equal(t, max(asyncWorkers, runtime.GOMAXPROCS(0)), producer.Status().Workers)
+	}()
+	require.NoError(t, producer.Start(context.Background()))
+
+	// deliveries of the last events are awaited before the report
+	report := producer.Report()
+	assert.Equal(t, queued.Load(), report.Published+report.Errors)
+	assert.InDelta(t, 2000, queued.Load(), 300)
+	assert.Equal(t, queued.Load()/10, report.Errors)
 }
@@ -0,0 +1,176 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+)
+
+const (
+	ProfileConstant = "constant"
+	ProfileSoak     = "soak"
+	ProfileRamp     = "ramp"
+	ProfileStep     = "step"
+	ProfileSpike    = "spike"
+	ProfileSine     = "sine"
+
+	defaultSinePeriod = 24 * time.Hour
+	// paceInterval is how often the pacer releases the jobs that became due.
+	paceInterval = 10 * time.Millisecond
+)
+
+// loadProfile is the job rate as a function of the time since the start.
+type loadProfile struct {
+	kind          string
+	rps           float64
+	targetRPS     float64
+	duration      time.Duration
+	step          time.Duration
+	stepRPS       float64
+	spikeAt       time.Duration
+	spikeDuration time.Duration
+	period        time.Duration
+}
+
+func newLoadProfile(conf config.LoadProfile) (*loadProfile, error) {
+	p := &loadProfile{
+		kind:          conf.Type,
+		rps:           conf.RPS,
+		targetRPS:     conf.TargetRPS,
+		duration:      time.Duration(conf.DurationSec) * time.Second,
+		step:          time.Duration(conf.StepSec) * time.Second,
+		stepRPS:       conf.StepRPS,
+		spikeAt:       time.Duration(conf.SpikeAtSec) * time.Second,
+		spikeDuration: time.Duration(conf.SpikeDurationSec) * time.Second,
+		period:        time.Duration(conf.PeriodSec) * time.Second,
+	}
+	if p.kind == "" {
+		p.kind = ProfileConstant
+	}
+	if p.rps < 0 || p.targetRPS < 0 || p.duration < 0 {
+		return nil, fmt.Errorf("profile rates and duration must not be negative")
+	}
+
+	switch p.kind {
+	case ProfileConstant:
+	case ProfileSoak:
+		if p.duration == 0 {
+			return nil, fmt.Errorf("soak profile requires durationSec")
+		}
+	case ProfileRamp:
+		if p.duration == 0 {
+			return nil, fmt.Errorf("ramp profile requires durationSec")
+		}
+	case ProfileStep:
+		if p.step <= 0 || p.stepRPS <= 0 {
+			return nil, fmt.Errorf("step profile requires positive stepSec and stepRps")
+		}
+		if p.targetRPS == 0 && p.duration == 0 {
+			return nil, fmt.Errorf("step profile requires targetRps or durationSec")
+		}
+	case ProfileSpike:
+		if p.targetRPS == 0 || p.spikeDuration <= 0 {
+			return nil, fmt.Errorf("spike profile requires targetRps and spikeDurationSec")
+		}
+	case ProfileSine:
+		if p.targetRPS < p.rps {
+			return nil, fmt.Errorf("sine profile requires targetRps at or above rps")
+		}
+		if p.period == 0 {
+			p.period = defaultSinePeriod
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile type %q, must be one of constant, soak, ramp, step, spike, sine", conf.Type)
+	}
+	return p, nil
+}
+
+// constantProfile is the rate of a producer without a profile.
+func constantProfile(rps int) *loadProfile {
+	return &loadProfile{kind: ProfileConstant, rps: float64(rps)}
+}
+
+// rate returns the jobs per second at the given time since the start.
+func (p *loadProfile) rate(elapsed time.Duration) float64 {
+	switch p.kind {
+	case ProfileRamp:
+		progress := min(1, elapsed.Seconds()/p.duration.Seconds())
+		return p.rps + (p.targetRPS-p.rps)*progress
+	case ProfileStep:
+		rate := p.rps + p.stepRPS*math.Floor(elapsed.Seconds()/p.step.Seconds())
+		if p.targetRPS > 0 {
+			rate = min(rate, p.targetRPS)
+		}
+		return rate
+	case ProfileSpike:
+		if elapsed >= p.spikeAt && elapsed < p.spikeAt+p.spikeDuration {
+			return p.targetRPS
+		}
+		return p.rps
+	case ProfileSine:
+		// starts at the trough, peaks half a period later
+		phase := 2 * math.Pi * elapsed.Seconds() / p.period.Seconds()
+		return p.rps + (p.targetRPS-p.rps)*(1-math.Cos(phase))/2
+	default:
+		return p.rps
+	}
+}
+
+// peakRPS is the highest rate of the profile, used to size the workers.
+func (p *loadProfile) peakRPS() float64 {
+	switch p.kind {
+	case ProfileStep:
+		if p.targetRPS > 0 {
+			return max(p.rps, p.targetRPS)
+		}
+		return p.rate(p.duration)
+	case ProfileRamp, ProfileSpike, ProfileSine:
+		return max(p.rps, p.targetRPS)
+	default:
+		return p.rps
+	}
+}
+
+// pace releases jobs at the rate of the current profile until the context
+// is done. Jobs that become due while every worker is busy and the queue is
+// full are dropped and counted, so the rate does not burst to catch up. No
+// jobs become due while the producer is paused.
+func (p *Producer) pace(ctx context.Context, jobs chan<- struct{}) {
+	ticker := time.NewTicker(paceInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	last := start
+	var due float64
+	for {
+		select {
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.rate = 0
+			p.mu.Unlock()
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			rate := p.current.rate(now.Sub(start))
+			if p.paused {
+				rate = 0
+			}
+			p.rate = rate
+			p.mu.Unlock()
+
+			due += rate * now.Sub(last).Seconds()
+			last = now
+			for ; due >= 1; due-- {
+				select {
+				case jobs <- struct{}{}:
+					p.stats.schedule()
+				default:
+					p.stats.drop()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package producer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/services/producer/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoadProfile_Rate(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     config.LoadProfile
+		elapsed  time.Duration
+		expected float64
+	}{
+		{"constant", config.LoadProfile{RPS: 50}, time.Hour, 50},
+		{"soak", config.LoadProfile{Type: ProfileSoak, RPS: 20, DurationSec: 7200}, 90 * time.Minute, 20},
+		{"ramp start", config.LoadProfile{Type: ProfileRamp, RPS: 10, TargetRPS: 110, DurationSec: 100}, 0, 10},
+		{"ramp middle", config.LoadProfile{Type: ProfileRamp, RPS: 10, TargetRPS: 110, DurationSec: 100}, 50 * time.Second, 60},
+		{"ramp end", config.LoadProfile{Type: ProfileRamp, RPS: 10, TargetRPS: 110, DurationSec: 100}, 200 * time.Second, 110},
+		{"step", config.LoadProfile{Type: ProfileStep, RPS: 10, StepRPS: 5, StepSec: 60, TargetRPS: 100}, 125 * time.Second, 20},
+		{"step capped", config.LoadProfile{Type: ProfileStep, RPS: 10, StepRPS: 5, StepSec: 60, TargetRPS: 25}, time.Hour, 25},
+		{"before spike", config.LoadProfile{Type: ProfileSpike, RPS: 10, TargetRPS: 500, SpikeAtSec: 60, SpikeDurationSec: 10}, 59 * time.Second, 10},
+		{"spike", config.LoadProfile{Type: ProfileSpike, RPS: 10, TargetRPS: 500, SpikeAtSec: 60, SpikeDurationSec: 10}, 65 * time.Second, 500},
+		{"after spike", config.LoadProfile{Type: ProfileSpike, RPS: 10, TargetRPS: 500, SpikeAtSec: 60, SpikeDurationSec: 10}, 70 * time.Second, 10},
+		{"sine trough", config.LoadProfile{Type: ProfileSine, RPS: 10, TargetRPS: 90}, 0, 10},
+		{"sine peak", config.LoadProfile{Type: ProfileSine, RPS: 10, TargetRPS: 90}, 12 * time.Hour, 90},
+		{"sine middle", config.LoadProfile{Type: ProfileSine, RPS: 10, TargetRPS: 90, PeriodSec: 3600}, 15 * time.Minute, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := newLoadProfile(tt.conf)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, profile.rate(tt.elapsed), 0.001)
+		})
+	}
+}
+
+func TestNewLoadProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.LoadProfile
+		err  string
+	}{
+		{"unknown type", config.LoadProfile{Type: "zigzag"}, "unknown profile type"},
+		{"soak without duration", config.LoadProfile{Type: ProfileSoak, RPS: 10}, "durationSec"},
+		{"ramp without duration", config.LoadProfile{Type: ProfileRamp, TargetRPS: 10}, "durationSec"},
+		{"step without step", config.LoadProfile{Type: ProfileStep, TargetRPS: 10}, "stepSec"},
+		{"unbounded step", config.LoadProfile{Type: ProfileStep, StepSec: 1, StepRPS: 1}, "targetRps or durationSec"},
+		{"spike without duration", config.LoadProfile{Type: ProfileSpike, TargetRPS: 10}, "spikeDurationSec"},
+		{"inverted sine", config.LoadProfile{Type: ProfileSine, RPS: 10, TargetRPS: 5}, "targetRps"},
+		{"negative rate", config.LoadProfile{RPS: -1}, "negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newLoadProfile(tt.conf)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestProducer_LoadProfile(t *testing.T) {
+	t.Run("runs for the profile duration and reports", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		mockKafka.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		producer := NewProducer(config.Producer{DistinctUsers: 2, AmountFrom: 10, AmountTo: 100}, mockKafka)
+		require.NoError(t, producer.SetLoadProfile(config.LoadProfile{Type: ProfileSoak, RPS: 200, DurationSec: 1}))
+
+		done := make(chan error, 1)
+		go func() { done <- producer.Start(context.Background()) }()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("producer did not stop after the profile duration")
+		}
+
+		report := producer.Report()
+		assert.InDelta(t, 200, report.Published, 30)
+		assert.Equal(t, report.Scheduled, report.Published)
+		assert.Zero(t, report.Errors)
+		assert.InDelta(t, 200, report.AchievedRate, 30)
+	})
+}
+
+func TestRunStats_Report(t *testing.T) {
+	stats := newRunStats()
+	for i := 1; i <= 100; i++ {
+		stats.schedule()
+		stats.observe(time.Duration(i)*time.Millisecond, nil)
+	}
+	stats.observe(time.Second, assert.AnError)
+	stats.drop()
+
+	report := stats.report()
+	assert.Equal(t, int64(100), report.Scheduled)
+	assert.Equal(t, int64(1), report.Dropped)
+	assert.Equal(t, int64(100), report.Published)
+	assert.Equal(t, int64(1), report.Errors)
+	assert.Equal(t, 50*time.Millisecond, report.P50)
+	assert.Equal(t, 90*time.Millisecond, report.P90)
+	assert.Equal(t, 99*time.Millisecond, report.P99)
+	assert.Equal(t, 100*time.Millisecond, report.Max)
+}
@@ -0,0 +1,368 @@
+package producer
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultScenarioPlayers = 100
+	maxConcurrentSessions  = 10
+	// winDelay separates the win of a round from its bet.
+	winDelay = 50 * time.Millisecond
+)
+
+// ScenarioConfig is a scenario as declared in the scenario file.
+type ScenarioConfig struct {
+	// Seed makes runs reproducible, a random seed is used when zero.
+	Seed               int64             `yaml:"seed"`
+	Players            int               `yaml:"players"`
+	ConcurrentSessions int               `yaml:"concurrent_sessions"`
+	Games              []GameConfig      `yaml:"games"`
+	Archetypes         []ArchetypeConfig `yaml:"archetypes"`
+}
+
+// GameConfig is a game: a round is won with WinProbability and the expected
+// payout is RTP times the bet.
+type GameConfig struct {
+	Name           string  `yaml:"name"`
+	RTP            float64 `yaml:"rtp"`
+	WinProbability float64 `yaml:"win_probability"`
+}
+
+// ArchetypeConfig describes how a kind of player plays. Players of the
+// archetype are picked in proportion to Weight. A session lasts a number of
+// rounds in SessionRounds and ends early when the balance no longer covers
+// the minimum bet, or reaches StopWin times the session start balance. Bets
+// are BalanceFraction of the balance when set, clamped to Bet, and uniform in
+// Bet otherwise. A player who cannot cover the minimum bet deposits Deposit
+// at the start of the next session.
+type ArchetypeConfig struct {
+	Name            string      `yaml:"name"`
+	Weight          float64     `yaml:"weight"`
+	Deposit         float64     `yaml:"deposit"`
+	SessionRounds   RangeConfig `yaml:"session_rounds"`
+	Bet             RangeConfig `yaml:"bet"`
+	BalanceFraction float64     `yaml:"balance_fraction"`
+	StopWin         float64     `yaml:"stop_win"`
+	Games           []string    `yaml:"games"`
+}
+
+type RangeConfig struct {
+	Min float64 `yaml:"min"`
+	Max float64 `yaml:"max"`
+}
+
+// Scenario is a validated scenario file.
+type Scenario struct {
+	seed               int64
+	players            int
+	concurrentSessions int
+	archetypes         []*archetype
+	totalWeight        float64
+}
+
+type archetype struct {
+	name            string
+	weight          float64
+	deposit         entity.Money
+	minRounds       int
+	maxRounds       int
+	minBet          entity.Money
+	maxBet          entity.Money
+	balanceFraction float64
+	stopWin         float64
+	games           []*game
+}
+
+type game struct {
+	name           string
+	winProbability float64
+	// meanMultiplier is the mean payout of a won round per unit bet.
+	meanMultiplier float64
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+func ParseScenario(data []byte) (*Scenario, error) {
+	var conf ScenarioConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file: %w", err)
+	}
+	return NewScenario(conf)
+}
+
+func NewScenario(conf ScenarioConfig) (*Scenario, error) {
+	if len(conf.Games) == 0 {
+		return nil, fmt.Errorf("scenario has no games")
+	}
+	if len(conf.Archetypes) == 0 {
+		return nil, fmt.Errorf("scenario has no archetypes")
+	}
+	if conf.Players < 0 || conf.ConcurrentSessions < 0 {
+		return nil, fmt.Errorf("players and concurrent_sessions must not be negative")
+	}
+
+	games := make(map[string]*game, len(conf.Games))
+	allGames := make([]*game, 0, len(conf.Games))
+	for _, gameConf := range conf.Games {
+		if gameConf.Name == "" {
+			return nil, fmt.Errorf("game name is required")
+		}
+		if _, ok := games[gameConf.Name]; ok {
+			return nil, fmt.Errorf("duplicate game name %q", gameConf.Name)
+		}
+		if gameConf.WinProbability <= 0 || gameConf.WinProbability > 1 {
+			return nil, fmt.Errorf("game %q: win_probability must be in (0, 1]", gameConf.Name)
+		}
+		if gameConf.RTP <= 0 {
+			return nil, fmt.Errorf("game %q: rtp must be positive", gameConf.Name)
+		}
+		g := &game{
+			name:           gameConf.Name,
+			winProbability: gameConf.WinProbability,
+			meanMultiplier: gameConf.RTP / gameConf.WinProbability,
+		}
+		games[g.name] = g
+		allGames = append(allGames, g)
+	}
+
+	scenario := &Scenario{
+		seed:               conf.Seed,
+		players:            conf.Players,
+		concurrentSessions: conf.ConcurrentSessions,
+		archetypes:         make([]*archetype, 0, len(conf.Archetypes)),
+	}
+	if scenario.players == 0 {
+		scenario.players = defaultScenarioPlayers
+	}
+	if scenario.concurrentSessions == 0 {
+		scenario.concurrentSessions = min(scenario.players, maxConcurrentSessions)
+	}
+	if scenario.concurrentSessions > scenario.players {
+		return nil, fmt.Errorf("concurrent_sessions exceeds the number of players")
+	}
+
+	names := make(map[string]bool, len(conf.Archetypes))
+	for _, archetypeConf := range conf.Archetypes {
+		a, err := newArchetype(archetypeConf, games, allGames)
+		if err != nil {
+			return nil, err
+		}
+		if names[a.name] {
+			return nil, fmt.Errorf("duplicate archetype name %q", a.name)
+		}
+		names[a.name] = true
+		scenario.archetypes = append(scenario.archetypes, a)
+		scenario.totalWeight += a.weight
+	}
+	return scenario, nil
+}
+
+func newArchetype(conf ArchetypeConfig, games map[string]*game, allGames []*game) (*archetype, error) {
+	if conf.Name == "" {
+		return nil, fmt.Errorf("archetype name is required")
+	}
+	if conf.Weight <= 0 {
+		return nil, fmt.Errorf("archetype %q: weight must be positive", conf.Name)
+	}
+	if conf.Bet.Min <= 0 || conf.Bet.Max < conf.Bet.Min {
+		return nil, fmt.Errorf("archetype %q: bet needs a positive min not above max", conf.Name)
+	}
+	if conf.Deposit < conf.Bet.Min {
+		return nil, fmt.Errorf("archetype %q: deposit must cover the minimum bet", conf.Name)
+	}
+	if conf.SessionRounds.Min < 1 || conf.SessionRounds.Max < conf.SessionRounds.Min {
+		return nil, fmt.Errorf("archetype %q: session_rounds needs a min of at least 1 not above max", conf.Name)
+	}
+	if conf.BalanceFraction < 0 || conf.BalanceFraction > 1 {
+		return nil, fmt.Errorf("archetype %q: balance_fraction must be in [0, 1]", conf.Name)
+	}
+	if conf.StopWin != 0 && conf.StopWin <= 1 {
+		return nil, fmt.Errorf("archetype %q: stop_win must be above 1", conf.Name)
+	}
+
+	a := &archetype{
+		name:            conf.Name,
+		weight:          conf.Weight,
+		deposit:         entity.ToMoney(conf.Deposit),
+		minRounds:       int(conf.SessionRounds.Min),
+		maxRounds:       int(conf.SessionRounds.Max),
+		minBet:          entity.ToMoney(conf.Bet.Min),
+		maxBet:          entity.ToMoney(conf.Bet.Max),
+		balanceFraction: conf.BalanceFraction,
+		stopWin:         conf.StopWin,
+		games:           allGames,
+	}
+	if len(conf.Games) > 0 {
+		a.games = make([]*game, 0, len(conf.Games))
+		for _, name := range conf.Games {
+			g, ok := games[name]
+			if !ok {
+				return nil, fmt.Errorf("archetype %q: unknown game %q", conf.Name, name)
+			}
+			a.games = append(a.games, g)
+		}
+	}
+	return a, nil
+}
+
+// Seed returns the seed of the scenario file, zero when it has none.
+func (s *Scenario) Seed() int64 {
+	return s.seed
+}
+
+// Engine plays a scenario: players of the scenario archetypes play sessions
+// of rounds, a round is a bet followed by a win of the same player when the
+// round is won. The sequence of rounds, user ids and event ids depends only
+// on the scenario and the seed.
+type Engine struct {
+	rng      *rand.Rand
+	tenantID string
+	now      func() time.Time
+	idle     []*scenarioPlayer
+	sessions []*session
+	mu       sync.Mutex
+}
+
+type scenarioPlayer struct {
+	userID    entity.UserID
+	archetype *archetype
+	balance   entity.Money
+}
+
+type session struct {
+	player     *scenarioPlayer
+	roundsLeft int
+	stopAt     entity.Money
+}
+
+func NewEngine(scenario *Scenario, seed int64, tenantID string) *Engine {
+	e := &Engine{
+		rng:      rand.New(rand.NewSource(seed)),
+		tenantID: tenantID,
+		now:      time.Now,
+		idle:     make([]*scenarioPlayer, 0, scenario.players),
+	}
+	for i := 0; i < scenario.players; i++ {
+		e.idle = append(e.idle, &scenarioPlayer{
+			userID:    *entity.NewUserID(e.newUUID()),
+			archetype: scenario.pickArchetype(e.rng),
+		})
+	}
+	for i := 0; i < scenario.concurrentSessions; i++ {
+		e.startSession()
+	}
+	return e
+}
+
+// NextRound plays a round of a random running session and returns its
+// events, the bet first.
+func (e *Engine) NextRound() []entity.TransactionEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	index := e.rng.Intn(len(e.sessions))
+	s := e.sessions[index]
+	player := s.player
+	a := player.archetype
+	g := a.games[e.rng.Intn(len(a.games))]
+	now := e.now()
+
+	bet := a.betSize(player.balance, e.rng)
+	player.balance -= bet
+	events := []entity.TransactionEvent{e.newEvent(player, entity.TransactionTypeBet, bet, now)}
+
+	if e.rng.Float64() < g.winProbability {
+		// exponential payouts keep the mean at the game RTP with a long tail
+		payout := entity.Money(float64(bet)*g.meanMultiplier*e.rng.ExpFloat64() + 0.5)
+		if payout > 0 {
+			player.balance += payout
+			events = append(events, e.newEvent(player, entity.TransactionTypeWin, payout, now.Add(winDelay)))
+		}
+	}
+
+	s.roundsLeft--
+	if s.roundsLeft <= 0 || player.balance < a.minBet || (s.stopAt > 0 && player.balance >= s.stopAt) {
+		e.sessions[index] = e.sessions[len(e.sessions)-1]
+		e.sessions = e.sessions[:len(e.sessions)-1]
+		e.idle = append(e.idle, player)
+		e.startSession()
+	}
+	return events
+}
+
+// startSession starts a session of a random idle player, who deposits when
+// the balance does not cover the minimum bet.
+func (e *Engine) startSession() {
+	index := e.rng.Intn(len(e.idle))
+	player := e.idle[index]
+	e.idle[index] = e.idle[len(e.idle)-1]
+	e.idle = e.idle[:len(e.idle)-1]
+
+	a := player.archetype
+	if player.balance < a.minBet {
+		player.balance += a.deposit
+	}
+	s := &session{
+		player:     player,
+		roundsLeft: a.minRounds + e.rng.Intn(a.maxRounds-a.minRounds+1),
+	}
+	if a.stopWin > 0 {
+		s.stopAt = entity.Money(float64(player.balance) * a.stopWin)
+	}
+	e.sessions = append(e.sessions, s)
+}
+
+func (e *Engine) newEvent(player *scenarioPlayer, transactionType entity.TransactionType, amount entity.Money, createdAt time.Time) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		EventID:         e.newUUID().String(),
+		TenantID:        e.tenantID,
+		UserID:          player.userID,
+		TransactionType: transactionType,
+		Amount:          amount,
+		CreatedAt:       createdAt,
+	}
+}
+
+func (e *Engine) newUUID() uuid.UUID {
+	id, _ := uuid.NewRandomFromReader(e.rng)
+	return id
+}
+
+func (s *Scenario) pickArchetype(rng *rand.Rand) *archetype {
+	value := rng.Float64() * s.totalWeight
+	for _, a := range s.archetypes {
+		if value < a.weight {
+			return a
+		}
+		value -= a.weight
+	}
+	return s.archetypes[len(s.archetypes)-1]
+}
+
+// betSize picks a bet the balance covers, the caller makes sure it covers the
+// minimum bet.
+func (a *archetype) betSize(balance entity.Money, rng *rand.Rand) entity.Money {
+	var bet entity.Money
+	if a.balanceFraction > 0 {
+		bet = entity.Money(float64(balance) * a.balanceFraction)
+		bet = max(a.minBet, min(a.maxBet, bet))
+	} else {
+		bet = a.minBet + entity.Money(rng.Int63n(int64(a.maxBet-a.minBet)+1))
+	}
+	return min(bet, balance)
+}
@@ -0,0 +1,134 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `
+seed: 7
+players: 50
+concurrent_sessions: 5
+games:
+  - name: slots
+    rtp: 0.95
+    win_probability: 0.3
+archetypes:
+  - name: casual
+    weight: 70
+    deposit: 50
+    session_rounds: {min: 10, max: 40}
+    bet: {min: 0.5, max: 2}
+  - name: high_roller
+    weight: 5
+    deposit: 5000
+    session_rounds: {min: 20, max: 100}
+    bet: {min: 50, max: 500}
+    balance_fraction: 0.05
+  - name: bonus_hunter
+    weight: 25
+    deposit: 100
+    session_rounds: {min: 5, max: 200}
+    bet: {min: 1, max: 5}
+    stop_win: 2
+    games: [slots]
+`
+
+func newTestEngine(t *testing.T, seed int64) *Engine {
+	scenario, err := ParseScenario([]byte(testScenario))
+	require.NoError(t, err)
+	engine := NewEngine(scenario, seed, "brand-a")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return engine
+}
+
+func TestEngine_NextRound(t *testing.T) {
+	t.Run("same seed plays the same rounds", func(t *testing.T) {
+		first, second := newTestEngine(t, 7), newTestEngine(t, 7)
+		for i := 0; i < 500; i++ {
+			assert.Equal(t, first.NextRound(), second.NextRound())
+		}
+	})
+
+	t.Run("different seeds play different rounds", func(t *testing.T) {
+		assert.NotEqual(t, newTestEngine(t, 7).NextRound(), newTestEngine(t, 8).NextRound())
+	})
+
+	t.Run("wins follow bets and balances stay positive", func(t *testing.T) {
+		engine := newTestEngine(t, 7)
+		var bets, wins entity.Money
+		for i := 0; i < 100000; i++ {
+			round := engine.NextRound()
+			require.NotEmpty(t, round)
+			require.LessOrEqual(t, len(round), 2)
+
+			bet := round[0]
+			assert.Equal(t, entity.TransactionTypeBet, bet.TransactionType)
+			assert.Positive(t, int64(bet.Amount))
+			assert.Equal(t, "brand-a", bet.TenantID)
+			bets += bet.Amount
+			if len(round) == 2 {
+				win := round[1]
+				assert.Equal(t, entity.TransactionTypeWin, win.TransactionType)
+				assert.Equal(t, bet.UserID, win.UserID)
+				assert.True(t, win.CreatedAt.After(bet.CreatedAt))
+				assert.NotEqual(t, bet.EventID, win.EventID)
+				wins += win.Amount
+			}
+		}
+
+		for _, player := range engine.idle {
+			assert.GreaterOrEqual(t, int64(player.balance), int64(0))
+		}
+		for _, s := range engine.sessions {
+			assert.GreaterOrEqual(t, int64(s.player.balance), int64(0))
+		}
+		assert.InDelta(t, 0.95, wins.ToFloat()/bets.ToFloat(), 0.05)
+	})
+}
+
+func TestParseScenario(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		err      string
+	}{
+		{"no games", `archetypes: [{name: a, weight: 1, deposit: 10, session_rounds: {min: 1, max: 1}, bet: {min: 1, max: 1}}]`, "no games"},
+		{"no archetypes", `games: [{name: slots, rtp: 0.9, win_probability: 0.5}]`, "no archetypes"},
+		{"win probability above one", `
+games: [{name: slots, rtp: 0.9, win_probability: 1.5}]
+archetypes: [{name: a, weight: 1, deposit: 10, session_rounds: {min: 1, max: 1}, bet: {min: 1, max: 1}}]`, "win_probability"},
+		{"unknown game", `
+games: [{name: slots, rtp: 0.9, win_probability: 0.5}]
+archetypes: [{name: a, weight: 1, deposit: 10, session_rounds: {min: 1, max: 1}, bet: {min: 1, max: 1}, games: [poker]}]`, "unknown game"},
+		{"deposit below minimum bet", `
+games: [{name: slots, rtp: 0.9, win_probability: 0.5}]
+archetypes: [{name: a, weight: 1, deposit: 1, session_rounds: {min: 1, max: 1}, bet: {min: 5, max: 10}}]`, "deposit"},
+		{"more sessions than players", `
+players: 2
+concurrent_sessions: 3
+games: [{name: slots, rtp: 0.9, win_probability: 0.5}]
+archetypes: [{name: a, weight: 1, deposit: 10, session_rounds: {min: 1, max: 1}, bet: {min: 1, max: 1}}]`, "concurrent_sessions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tt.scenario))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		scenario, err := ParseScenario([]byte(`
+games: [{name: slots, rtp: 0.9, win_probability: 0.5}]
+archetypes: [{name: a, weight: 1, deposit: 10, session_rounds: {min: 1, max: 1}, bet: {min: 1, max: 1}}]`))
+		require.NoError(t, err)
+		assert.Equal(t, defaultScenarioPlayers, scenario.players)
+		assert.Equal(t, maxConcurrentSessions, scenario.concurrentSessions)
+		assert.Zero(t, scenario.Seed())
+	})
+}
@@ -0,0 +1,207 @@
+package producer
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples bounds the memory of long runs, percentiles are computed
+// from a uniform sample of the publish latencies.
+const latencySamples = 100000
+
+// rateWindow is the number of whole seconds the current rate is averaged
+// over.
+const rateWindow = 5
+
+// RunReport summarises a producer run. Scheduled jobs were handed to a
+// worker, dropped ones became due while every worker was busy. Published and
+// Errors count events, a scenario round publishes one or two.
+type RunReport struct {
+	Duration     time.Duration
+	Scheduled    int64
+	Dropped      int64
+	Published    int64
+	Errors       int64
+	TargetRate   float64
+	AchievedRate float64
+	P50          time.Duration
+	P90          time.Duration
+	P99          time.Duration
+	Max          time.Duration
+	// Faults counts the injected faults by kind.
+	Faults map[string]int64
+}
+
+func (r RunReport) String() string {
+	return fmt.Sprintf("duration %s, target %.1f jobs/s, achieved %.1f events/s, %d jobs scheduled, %d dropped, "+
+		"%d events published, %d errors, publish latency p50 %s p90 %s p99 %s max %s",
+		r.Duration.Round(time.Millisecond), r.TargetRate, r.AchievedRate, r.Scheduled, r.Dropped,
+		r.Published, r.Errors, r.P50, r.P90, r.P99, r.Max) + r.faultsString()
+}
+
+func (r RunReport) faultsString() string {
+	if len(r.Faults) == 0 {
+		return ""
+	}
+	kinds := make([]string, 0, len(r.Faults))
+	for kind := range r.Faults {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	result := ", faults injected:"
+	for i, kind := range kinds {
+		if i > 0 {
+			result += ","
+		}
+		result += fmt.Sprintf(" %s %d", kind, r.Faults[kind])
+	}
+	return result
+}
+
+// runStats collects the counters and publish latencies of a run.
+type runStats struct {
+	start     time.Time
+	scheduled int64
+	dropped   int64
+	published int64
+	errors    int64
+	observed  int64
+	faults    map[string]int64
+	max       time.Duration
+	samples   []time.Duration
+	rng       *rand.Rand
+	// recent counts the events published in each of the last seconds,
+	// seconds holds the unix second of every bucket
+	recent  [rateWindow + 1]int64
+	seconds [rateWindow + 1]int64
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+func newRunStats() *runStats {
+	return &runStats{
+		start:   time.Now(),
+		samples: make([]time.Duration, 0, 1024),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:     time.Now,
+	}
+}
+
+// restart clears the stats at the start of a run.
+func (s *runStats) restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.start = time.Now()
+	s.scheduled, s.dropped, s.published, s.errors, s.observed = 0, 0, 0, 0, 0
+	s.faults = nil
+	s.max = 0
+	s.samples = s.samples[:0]
+	s.recent, s.seconds = [rateWindow + 1]int64{}, [rateWindow + 1]int64{}
+}
+
+func (s *runStats) schedule() {
+	s.mu.Lock()
+	s.scheduled++
+	s.mu.Unlock()
+}
+
+func (s *runStats) drop() {
+	s.mu.Lock()
+	s.dropped++
+	s.mu.Unlock()
+}
+
+// inject counts an injected fault.
+func (s *runStats) inject(kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.faults == nil {
+		s.faults = make(map[string]int64)
+	}
+	s.faults[kind]++
+}
+
+// observe records a publish attempt, keeping a reservoir sample of the
+// latencies.
+func (s *runStats) observe(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.errors++
+		return
+	}
+	s.published++
+	s.observed++
+	second := s.now().Unix()
+	bucket := second % int64(len(s.recent))
+	if s.seconds[bucket] != second {
+		s.seconds[bucket], s.recent[bucket] = second, 0
+	}
+	s.recent[bucket]++
+	s.max = max(s.max, latency)
+	if len(s.samples) < latencySamples {
+		s.samples = append(s.samples, latency)
+	} else if i := s.rng.Int63n(s.observed); i < latencySamples {
+		s.samples[i] = latency
+	}
+}
+
+// currentRate returns the events published per second over the last
+// rateWindow whole seconds, the running second is not complete yet.
+func (s *runStats) currentRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().Unix()
+	var published int64
+	for i, second := range s.seconds {
+		if second < now && second >= now-rateWindow {
+			published += s.recent[i]
+		}
+	}
+	return float64(published) / rateWindow
+}
+
+func (s *runStats) report() RunReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := RunReport{
+		Duration:  time.Since(s.start),
+		Scheduled: s.scheduled,
+		Dropped:   s.dropped,
+		Published: s.published,
+		Errors:    s.errors,
+		Max:       s.max,
+	}
+	if len(s.faults) > 0 {
+		report.Faults = make(map[string]int64, len(s.faults))
+		for kind, count := range s.faults {
+			report.Faults[kind] = count
+		}
+	}
+	if seconds := report.Duration.Seconds(); seconds > 0 {
+		report.TargetRate = float64(s.scheduled+s.dropped) / seconds
+		report.AchievedRate = float64(s.published) / seconds
+	}
+
+	sorted := append([]time.Duration(nil), s.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	report.P50 = percentile(sorted, 0.50)
+	report.P90 = percentile(sorted, 0.90)
+	report.P99 = percentile(sorted, 0.99)
+	return report
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted))*p+0.5) - 1
+	return sorted[max(0, min(index, len(sorted)-1))]
+}
@@ -9,4 +9,19 @@ import (
 
 type KafkaWriter interface {
 	Publish(ctx context.Context, event entity.TransactionEvent) error
+	PublishFault(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error
+}
+
+// asyncKafkaWriter queues events and reports their delivery to the
+// callback, which is not called when queueing fails.
+type asyncKafkaWriter interface {
+	PublishAsync(ctx context.Context, event entity.TransactionEvent, fault entity.Fault, delivered func(err error)) error
+}
+
+type roundGenerator interface {
+	NextRound() []entity.TransactionEvent
+}
+
+type eventSource interface {
+	Read() (*entity.TransactionEvent, error)
 }
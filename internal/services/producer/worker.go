@@ -4,39 +4,109 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"log"
 	"math"
 	"math/big"
 	"sync"
 	"time"
 
 	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
 )
 
-func (p *Producer) Worker(ctx context.Context, id int, jobs <-chan struct{}, wg *sync.WaitGroup, cancel context.CancelFunc) {
+// Worker publishes a message per job until the context is done or stop is
+// closed. A job taken before stop is closed is published with ctx, so
+// stopping a worker never interrupts a publish.
+func (p *Producer) Worker(ctx context.Context, id int, jobs <-chan struct{}, stop <-chan struct{}, wg *sync.WaitGroup, cancel context.CancelFunc) {
 	defer wg.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			fmt.Printf("finishing by context finished event: %d\n", id)
 			return
+		case <-stop:
+			return
 		case _ = <-jobs:
 			err := p.generateSingleMessage(ctx)
 			if err != nil {
 				fmt.Printf("failed to generate single message: %s\n", err)
-				cancel()
+				if p.profile == nil && !p.continueOnError {
+					cancel()
+				}
 			}
 		}
 	}
 }
 
 func (p *Producer) generateSingleMessage(ctx context.Context) error {
-	err := p.kafka.Publish(ctx, *p.generateData())
+	if p.rounds != nil {
+		return p.publishRound(ctx)
+	}
+	err := p.publish(ctx, *p.generateData())
 	if err != nil {
 		return fmt.Errorf("producer failed to publish message: %w", err)
 	}
 	return nil
 }
 
+// publishRound publishes the events of a scenario round in order, so the win
+// of a round is never published before its bet.
+func (p *Producer) publishRound(ctx context.Context) error {
+	for _, event := range p.rounds.NextRound() {
+		if err := p.publish(ctx, event); err != nil {
+			return fmt.Errorf("producer failed to publish message: %w", err)
+		}
+	}
+	return nil
+}
+
+// publish publishes the event, or a faulty message when the fault injector
+// picks a fault for it.
+func (p *Producer) publish(ctx context.Context, event entity.TransactionEvent) error {
+	if p.faults != nil {
+		if kind := p.faults.pick(); kind != "" {
+			return p.publishFault(ctx, event, kind)
+		}
+	}
+	return p.publishEvent(ctx, event)
+}
+
+// publishEvent publishes the event without a fault.
+func (p *Producer) publishEvent(ctx context.Context, event entity.TransactionEvent) error {
+	return p.send(ctx, event, entity.Fault{})
+}
+
+// send publishes the event, with the fault when it has a kind, and records
+// its latency in the run stats. With an async writer it returns once the
+// event is queued and the delivery is recorded when it is reported.
+func (p *Producer) send(ctx context.Context, event entity.TransactionEvent, fault entity.Fault) error {
+	start := time.Now()
+	if p.async != nil {
+		p.inflight.Add(1)
+		err := p.async.PublishAsync(ctx, event, fault, func(err error) {
+			defer p.inflight.Done()
+			p.stats.observe(time.Since(start), err)
+			if err != nil {
+				log.Printf("Failed to deliver event %s: %v", event.EventID, err)
+			}
+		})
+		if err != nil {
+			p.inflight.Done()
+			p.stats.observe(time.Since(start), err)
+		}
+		return err
+	}
+
+	var err error
+	if fault.Kind == "" {
+		err = p.kafka.Publish(ctx, event)
+	} else {
+		err = p.kafka.PublishFault(ctx, event, fault)
+	}
+	p.stats.observe(time.Since(start), err)
+	return err
+}
+
 func (p *Producer) generateData() *entity.TransactionEvent {
 	randomInt, _ := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
 	simpleInt := randomInt.Int64()
@@ -44,11 +114,16 @@ func (p *Producer) generateData() *entity.TransactionEvent {
 	if simpleInt%2 == 0 {
 		transactionType = entity.TransactionTypeWin
 	}
-	userID := p.usersMap[int(simpleInt%int64(p.conf.DistinctUsers))]
+	p.mu.RLock()
+	settings := p.settings
+	userID := p.usersMap[int(simpleInt%int64(settings.DistinctUsers))]
+	p.mu.RUnlock()
 	return &entity.TransactionEvent{
+		EventID:         uuid.NewString(),
+		TenantID:        p.conf.TenantID,
 		UserID:          *entity.NewUserID(userID),
 		TransactionType: transactionType,
-		Amount:          p.calculateAmount(simpleInt, p.conf.AmountFrom, p.conf.AmountTo),
+		Amount:          p.calculateAmount(simpleInt, settings.AmountFrom, settings.AmountTo),
 		CreatedAt:       time.Now(),
 	}
 }
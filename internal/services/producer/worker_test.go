@@ -45,7 +45,7 @@ func TestProducer_Worker(t *testing.T) {
 		jobs <- struct{}{}
 		jobs <- struct{}{}
 
-		go producer.Worker(ctx, 1, jobs, &wg, cancel)
+		go producer.Worker(ctx, 1, jobs, nil, &wg, cancel)
 
 		time.Sleep(50 * time.Millisecond)
 		cancel()
@@ -91,7 +91,7 @@ func TestProducer_Worker(t *testing.T) {
 
 		jobs <- struct{}{}
 
-		go producer.Worker(ctx, 1, jobs, &wg, cancel)
+		go producer.Worker(ctx, 1, jobs, nil, &wg, cancel)
 
 		time.Sleep(50 * time.Millisecond)
 		cancel()
@@ -167,6 +167,29 @@ func TestProducer_generateSingleMessage(t *testing.T) {
 		assert.Contains(t, err.Error(), "producer failed to publish message")
 		assert.Contains(t, err.Error(), "kafka publish error")
 	})
+
+	t.Run("scenario round is published in order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockKafka := mocks.NewMockKafkaWriter(ctrl)
+		mockRounds := mocks.NewMockroundGenerator(ctrl)
+
+		producer := NewProducer(config.Producer{}, mockKafka)
+		producer.SetScenario(mockRounds)
+
+		ctx := context.Background()
+		bet := entity.TransactionEvent{EventID: "bet", TransactionType: entity.TransactionTypeBet}
+		win := entity.TransactionEvent{EventID: "win", TransactionType: entity.TransactionTypeWin}
+
+		mockRounds.EXPECT().NextRound().Return([]entity.TransactionEvent{bet, win})
+		gomock.InOrder(
+			mockKafka.EXPECT().Publish(ctx, bet).Return(nil),
+			mockKafka.EXPECT().Publish(ctx, win).Return(nil),
+		)
+
+		require.NoError(t, producer.generateSingleMessage(ctx))
+	})
 }
 
 func TestProducer_generateData(t *testing.T) {
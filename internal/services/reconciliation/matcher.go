@@ -0,0 +1,243 @@
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// matchResult is the outcome of comparing settlement records with stored
+// events: the number of records that agree with a stored event and the
+// discrepancies, missing and mismatched first in file order, then extra in
+// time order.
+type matchResult struct {
+	matched int
+	items   []entity.ReconciliationItem
+}
+
+// match pairs records with stored events in two passes. Records carrying an
+// event id are paired with the stored event of the same id and compared
+// field by field. The remaining records are paired with an unpaired stored
+// event of the same user, type and amount, closest in time within the window.
+// Records left over are missing, stored events left over are extra.
+func match(records []entity.SettlementRecord, events []entity.TransactionEvent, window time.Duration) matchResult {
+	var result matchResult
+
+	paired := make([]bool, len(events))
+	byEventID := make(map[string]int, len(events))
+	for i, event := range events {
+		if event.EventID != "" {
+			byEventID[event.EventID] = i
+		}
+	}
+
+	discrepancies := make(map[int]entity.ReconciliationItem, len(records))
+	var unpaired []int
+	for i, record := range records {
+		if record.EventID == "" {
+			unpaired = append(unpaired, i)
+			continue
+		}
+		position, ok := byEventID[record.EventID]
+		if !ok || paired[position] {
+			unpaired = append(unpaired, i)
+			continue
+		}
+		paired[position] = true
+		if reason := compare(record, events[position], window); reason != "" {
+			discrepancies[i] = mismatchedItem(record, events[position], reason)
+			continue
+		}
+		result.matched++
+	}
+
+	candidates := make(map[candidateKey][]int)
+	for i, event := range events {
+		if paired[i] {
+			continue
+		}
+		key := candidateKey{userID: event.UserID, transactionType: event.TransactionType, amount: event.Amount}
+		candidates[key] = append(candidates[key], i)
+	}
+
+	for _, i := range unpaired {
+		record := records[i]
+		key := candidateKey{userID: record.UserID, transactionType: record.TransactionType, amount: record.Amount}
+		best := -1
+		var bestDistance time.Duration
+		for _, position := range candidates[key] {
+			event := events[position]
+			if paired[position] {
+				continue
+			}
+			// a stored event known under another id is a different transaction
+			if record.EventID != "" && event.EventID != "" && record.EventID != event.EventID {
+				continue
+			}
+			distance := absDuration(record.CreatedAt.Sub(event.CreatedAt))
+			if distance > window {
+				continue
+			}
+			if best < 0 || distance < bestDistance {
+				best, bestDistance = position, distance
+			}
+		}
+		if best < 0 {
+			discrepancies[i] = missingItem(record)
+			continue
+		}
+		paired[best] = true
+		result.matched++
+	}
+
+	for i := range records {
+		if item, ok := discrepancies[i]; ok {
+			result.items = append(result.items, item)
+		}
+	}
+
+	var extra []entity.ReconciliationItem
+	for i, event := range events {
+		if !paired[i] {
+			extra = append(extra, extraItem(event))
+		}
+	}
+	sort.SliceStable(extra, func(i, j int) bool {
+		return extra[i].StoredTime.Before(*extra[j].StoredTime)
+	})
+	result.items = append(result.items, extra...)
+
+	return result
+}
+
+// storedEvents collects the stored events of the period page by page. Only
+// the events a record can be paired with, by event id or by user, type and
+// amount, are kept for matching; the others are extra as soon as they are
+// read, so a period holding far more events than the file is not kept in
+// memory.
+type storedEvents struct {
+	eventIDs   map[string]struct{}
+	keys       map[candidateKey]struct{}
+	skipExtra  bool
+	count      int
+	candidates []entity.TransactionEvent
+	extra      []entity.ReconciliationItem
+}
+
+func newStoredEvents(records []entity.SettlementRecord, skipExtra bool) *storedEvents {
+	s := &storedEvents{
+		eventIDs:  make(map[string]struct{}, len(records)),
+		keys:      make(map[candidateKey]struct{}, len(records)),
+		skipExtra: skipExtra,
+	}
+	for _, record := range records {
+		if record.EventID != "" {
+			s.eventIDs[record.EventID] = struct{}{}
+		}
+		s.keys[candidateKey{userID: record.UserID, transactionType: record.TransactionType, amount: record.Amount}] = struct{}{}
+	}
+	return s
+}
+
+func (s *storedEvents) add(events []entity.TransactionEvent) {
+	s.count += len(events)
+	for _, event := range events {
+		_, byEventID := s.eventIDs[event.EventID]
+		_, byKey := s.keys[candidateKey{userID: event.UserID, transactionType: event.TransactionType, amount: event.Amount}]
+		switch {
+		case byEventID || byKey:
+			s.candidates = append(s.candidates, event)
+		case !s.skipExtra:
+			s.extra = append(s.extra, extraItem(event))
+		}
+	}
+}
+
+// match matches the records with the kept events and adds the events that
+// were extra when read, keeping the extra items in time order.
+func (s *storedEvents) match(records []entity.SettlementRecord, window time.Duration) matchResult {
+	result := match(records, s.candidates, window)
+	if len(s.extra) == 0 {
+		return result
+	}
+
+	start := len(result.items)
+	for start > 0 && result.items[start-1].Status == entity.ReconciliationStatusExtra {
+		start--
+	}
+	result.items = append(result.items, s.extra...)
+	extra := result.items[start:]
+	sort.SliceStable(extra, func(i, j int) bool {
+		return extra[i].StoredTime.Before(*extra[j].StoredTime)
+	})
+	return result
+}
+
+type candidateKey struct {
+	userID          entity.UserID
+	transactionType entity.TransactionType
+	amount          entity.Money
+}
+
+// compare returns why a record and the stored event with the same event id
+// disagree, or an empty string when they agree.
+func compare(record entity.SettlementRecord, event entity.TransactionEvent, window time.Duration) string {
+	var reasons []string
+	if record.UserID != event.UserID {
+		reasons = append(reasons, fmt.Sprintf("user_id differs, stored %s", event.UserID.UUID))
+	}
+	if record.TransactionType != event.TransactionType {
+		reasons = append(reasons, fmt.Sprintf("transaction_type differs, stored %s", event.TransactionType))
+	}
+	if record.Amount != event.Amount {
+		reasons = append(reasons, fmt.Sprintf("amount differs, stored %s", event.Amount))
+	}
+	if distance := absDuration(record.CreatedAt.Sub(event.CreatedAt)); distance > window {
+		reasons = append(reasons, fmt.Sprintf("timestamp differs by %s", distance))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func missingItem(record entity.SettlementRecord) entity.ReconciliationItem {
+	amount, createdAt := record.Amount, record.CreatedAt
+	return entity.ReconciliationItem{
+		Status:          entity.ReconciliationStatusMissing,
+		Line:            record.Line,
+		EventID:         record.EventID,
+		UserID:          record.UserID,
+		TransactionType: record.TransactionType,
+		ProviderAmount:  &amount,
+		ProviderTime:    &createdAt,
+	}
+}
+
+func extraItem(event entity.TransactionEvent) entity.ReconciliationItem {
+	amount, createdAt := event.Amount, event.CreatedAt
+	return entity.ReconciliationItem{
+		Status:          entity.ReconciliationStatusExtra,
+		EventID:         event.EventID,
+		UserID:          event.UserID,
+		TransactionType: event.TransactionType,
+		StoredAmount:    &amount,
+		StoredTime:      &createdAt,
+	}
+}
+
+func mismatchedItem(record entity.SettlementRecord, event entity.TransactionEvent, reason string) entity.ReconciliationItem {
+	item := missingItem(record)
+	item.Status = entity.ReconciliationStatusMismatched
+	item.StoredAmount = &event.Amount
+	item.StoredTime = &event.CreatedAt
+	item.Reason = reason
+	return item
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,115 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	window := time.Minute
+
+	record := func(line int, eventID string, transactionType entity.TransactionType, amount entity.Money, at time.Time) entity.SettlementRecord {
+		return entity.SettlementRecord{Line: line, EventID: eventID, UserID: userID, TransactionType: transactionType, Amount: amount, CreatedAt: at}
+	}
+	event := func(eventID string, transactionType entity.TransactionType, amount entity.Money, at time.Time) entity.TransactionEvent {
+		return entity.TransactionEvent{EventID: eventID, UserID: userID, TransactionType: transactionType, Amount: amount, CreatedAt: at}
+	}
+
+	t.Run("records matching by event id or by details are not reported", func(t *testing.T) {
+		result := match(
+			[]entity.SettlementRecord{
+				record(2, "r-1", entity.TransactionTypeBet, 100, now),
+				record(3, "", entity.TransactionTypeWin, 250, now.Add(30*time.Second)),
+			},
+			[]entity.TransactionEvent{
+				event("r-1", entity.TransactionTypeBet, 100, now.Add(10*time.Second)),
+				event("", entity.TransactionTypeWin, 250, now),
+			},
+			window,
+		)
+
+		assert.Equal(t, 2, result.matched)
+		assert.Empty(t, result.items)
+	})
+
+	t.Run("same event id with different details is mismatched", func(t *testing.T) {
+		result := match(
+			[]entity.SettlementRecord{record(2, "r-1", entity.TransactionTypeBet, 150, now.Add(2*time.Minute))},
+			[]entity.TransactionEvent{event("r-1", entity.TransactionTypeBet, 100, now)},
+			window,
+		)
+
+		assert.Zero(t, result.matched)
+		require.Len(t, result.items, 1)
+		item := result.items[0]
+		assert.Equal(t, entity.ReconciliationStatusMismatched, item.Status)
+		assert.Equal(t, 2, item.Line)
+		assert.Equal(t, entity.Money(150), *item.ProviderAmount)
+		assert.Equal(t, entity.Money(100), *item.StoredAmount)
+		assert.Equal(t, "amount differs, stored $1.00; timestamp differs by 2m0s", item.Reason)
+	})
+
+	t.Run("unmatched records are missing and unmatched events are extra", func(t *testing.T) {
+		result := match(
+			[]entity.SettlementRecord{
+				record(2, "", entity.TransactionTypeBet, 100, now),
+				record(3, "r-9", entity.TransactionTypeWin, 500, now),
+			},
+			[]entity.TransactionEvent{
+				event("", entity.TransactionTypeBet, 100, now.Add(2*time.Minute)),
+				event("r-8", entity.TransactionTypeWin, 500, now),
+			},
+			window,
+		)
+
+		assert.Zero(t, result.matched)
+		require.Len(t, result.items, 4)
+		assert.Equal(t, entity.ReconciliationStatusMissing, result.items[0].Status)
+		assert.Equal(t, 2, result.items[0].Line)
+		assert.Equal(t, entity.ReconciliationStatusMissing, result.items[1].Status)
+		assert.Equal(t, "r-9", result.items[1].EventID)
+		assert.Equal(t, entity.ReconciliationStatusExtra, result.items[2].Status)
+		assert.Equal(t, "r-8", result.items[2].EventID)
+		assert.Equal(t, entity.ReconciliationStatusExtra, result.items[3].Status)
+		assert.Nil(t, result.items[3].ProviderAmount)
+	})
+
+	t.Run("detail match picks the closest event in time", func(t *testing.T) {
+		result := match(
+			[]entity.SettlementRecord{record(2, "", entity.TransactionTypeBet, 100, now)},
+			[]entity.TransactionEvent{
+				event("", entity.TransactionTypeBet, 100, now.Add(-40*time.Second)),
+				event("", entity.TransactionTypeBet, 100, now.Add(5*time.Second)),
+			},
+			window,
+		)
+
+		assert.Equal(t, 1, result.matched)
+		require.Len(t, result.items, 1)
+		assert.Equal(t, entity.ReconciliationStatusExtra, result.items[0].Status)
+		assert.Equal(t, now.Add(-40*time.Second), *result.items[0].StoredTime)
+	})
+
+	t.Run("duplicate event id in the file is paired once", func(t *testing.T) {
+		result := match(
+			[]entity.SettlementRecord{
+				record(2, "r-1", entity.TransactionTypeBet, 100, now),
+				record(3, "r-1", entity.TransactionTypeBet, 100, now),
+			},
+			[]entity.TransactionEvent{event("r-1", entity.TransactionTypeBet, 100, now)},
+			window,
+		)
+
+		assert.Equal(t, 1, result.matched)
+		require.Len(t, result.items, 1)
+		assert.Equal(t, entity.ReconciliationStatusMissing, result.items[0].Status)
+		assert.Equal(t, 3, result.items[0].Line)
+	})
+}
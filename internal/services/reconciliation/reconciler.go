@@ -0,0 +1,110 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+// eventsPageSize is the number of stored events read at a time.
+const eventsPageSize = 1000
+
+// Request describes one settlement file to reconcile. When From and To are
+// zero the period is derived from the earliest and latest record, widened by
+// the time window on both sides.
+type Request struct {
+	Provider   string
+	FileName   string
+	Records    []entity.SettlementRecord
+	TimeWindow time.Duration
+	From       time.Time
+	To         time.Time
+	// SkipExtra disables reporting stored events the file does not mention,
+	// for periods in which other providers' events are stored too.
+	SkipExtra bool
+}
+
+type Reconciler struct {
+	repository reconciliationRepository
+}
+
+func NewReconciler(repository reconciliationRepository) *Reconciler {
+	return &Reconciler{
+		repository: repository,
+	}
+}
+
+// Reconcile compares the records with the events stored in the period and
+// stores the run together with the discrepancies found.
+func (r *Reconciler) Reconcile(ctx context.Context, request Request) (*entity.ReconciliationRun, error) {
+	from, to := request.From, request.To
+	if from.IsZero() || to.IsZero() {
+		if len(request.Records) == 0 {
+			return nil, fmt.Errorf("%w: period is required for an empty settlement file", entity.ErrInvalidArgument)
+		}
+		from, to = recordsPeriod(request.Records, request.TimeWindow)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: period start must be before its end", entity.ErrInvalidArgument)
+	}
+
+	stored := newStoredEvents(request.Records, request.SkipExtra)
+	var after *entity.EventCursor
+	for {
+		events, err := r.repository.ListEventsBetween(ctx, from, to, after, eventsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		stored.add(events)
+		if len(events) < eventsPageSize {
+			break
+		}
+		after = entity.CursorOf(events[len(events)-1])
+	}
+
+	result := stored.match(request.Records, request.TimeWindow)
+
+	run := &entity.ReconciliationRun{
+		Provider:        request.Provider,
+		FileName:        request.FileName,
+		PeriodFrom:      from,
+		PeriodTo:        to,
+		ProviderRecords: len(request.Records),
+		StoredRecords:   stored.count,
+		Matched:         result.matched,
+	}
+	for _, item := range result.items {
+		switch item.Status {
+		case entity.ReconciliationStatusMissing:
+			run.Missing++
+		case entity.ReconciliationStatusMismatched:
+			run.Mismatched++
+		case entity.ReconciliationStatusExtra:
+			if request.SkipExtra {
+				continue
+			}
+			run.Extra++
+		}
+		run.Items = append(run.Items, item)
+	}
+
+	if err := r.repository.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func recordsPeriod(records []entity.SettlementRecord, window time.Duration) (time.Time, time.Time) {
+	from, to := records[0].CreatedAt, records[0].CreatedAt
+	for _, record := range records[1:] {
+		if record.CreatedAt.Before(from) {
+			from = record.CreatedAt
+		}
+		if record.CreatedAt.After(to) {
+			to = record.CreatedAt
+		}
+	}
+	return from.Add(-window), to.Add(window)
+}
@@ -0,0 +1,210 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/reconciliation/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	userID := entity.UserID{UUID: uuid.New()}
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	records := []entity.SettlementRecord{
+		{Line: 2, EventID: "r-1", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: 100, CreatedAt: now},
+		{Line: 3, EventID: "r-2", UserID: userID, TransactionType: entity.TransactionTypeWin, Amount: 300, CreatedAt: now.Add(time.Hour)},
+	}
+	events := []entity.TransactionEvent{
+		{EventID: "r-1", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: 100, CreatedAt: now},
+		{EventID: "r-3", UserID: userID, TransactionType: entity.TransactionTypeBet, Amount: 50, CreatedAt: now.Add(time.Minute)},
+	}
+
+	t.Run("period is derived from the records and the run is stored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockreconciliationRepository(ctrl)
+		reconciler := NewReconciler(mockRepo)
+
+		mockRepo.EXPECT().
+			ListEventsBetween(gomock.Any(), now.Add(-time.Minute), now.Add(time.Hour+time.Minute), nil, eventsPageSize).
+			Return(events, nil)
+		mockRepo.EXPECT().
+			CreateRun(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, run *entity.ReconciliationRun) error {
+				run.ID = 7
+				return nil
+			})
+
+		run, err := reconciler.Reconcile(context.Background(), Request{
+			Provider:   "acme",
+			FileName:   "acme-2025-01-02.csv",
+			Records:    records,
+			TimeWindow: time.Minute,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), run.ID)
+		assert.Equal(t, 2, run.ProviderRecords)
+		assert.Equal(t, 2, run.StoredRecords)
+		assert.Equal(t, 1, run.Matched)
+		assert.Equal(t, 1, run.Missing)
+		assert.Equal(t, 1, run.Extra)
+		assert.Zero(t, run.Mismatched)
+		require.Len(t, run.Items, 2)
+	})
+
+	t.Run("extra events can be skipped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockreconciliationRepository(ctrl)
+		reconciler := NewReconciler(mockRepo)
+
+		from, to := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+		mockRepo.EXPECT().ListEventsBetween(gomock.Any(), from, to, nil, eventsPageSize).Return(events, nil)
+		mockRepo.EXPECT().CreateRun(gomock.Any(), gomock.Any()).Return(nil)
+
+		run, err := reconciler.Reconcile(context.Background(), Request{
+			Records:    records,
+			TimeWindow: time.Minute,
+			From:       from,
+			To:         to,
+			SkipExtra:  true,
+		})
+
+		require.NoError(t, err)
+		assert.Zero(t, run.Extra)
+		require.Len(t, run.Items, 1)
+		assert.Equal(t, entity.ReconciliationStatusMissing, run.Items[0].Status)
+	})
+
+	t.Run("stored events are read page by page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockreconciliationRepository(ctrl)
+		reconciler := NewReconciler(mockRepo)
+
+		page := make([]entity.TransactionEvent, eventsPageSize)
+		for i := range page {
+			page[i] = entity.TransactionEvent{
+				ID:              int64(i + 1),
+				UserID:          entity.UserID{UUID: uuid.New()},
+				TransactionType: entity.TransactionTypeBet,
+				Amount:          10,
+				CreatedAt:       now.Add(-time.Hour),
+			}
+		}
+		last := page[len(page)-1]
+		gomock.InOrder(
+			mockRepo.EXPECT().ListEventsBetween(gomock.Any(), gomock.Any(), gomock.Any(), nil, eventsPageSize).Return(page, nil),
+			mockRepo.EXPECT().
+				ListEventsBetween(gomock.Any(), gomock.Any(), gomock.Any(), &entity.EventCursor{CreatedAt: last.CreatedAt, ID: last.ID}, eventsPageSize).
+				Return(events, nil),
+		)
+		mockRepo.EXPECT().CreateRun(gomock.Any(), gomock.Any()).Return(nil)
+
+		run, err := reconciler.Reconcile(context.Background(), Request{
+			Records:    records,
+			TimeWindow: time.Minute,
+			From:       now.Add(-24 * time.Hour),
+			To:         now.Add(24 * time.Hour),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, eventsPageSize+2, run.StoredRecords)
+		assert.Equal(t, 1, run.Matched)
+		assert.Equal(t, 1, run.Missing)
+		assert.Equal(t, eventsPageSize+1, run.Extra)
+		require.Len(t, run.Items, eventsPageSize+2)
+		assert.Equal(t, entity.ReconciliationStatusMissing, run.Items[0].Status)
+		// extra events are in time order
+		assert.Equal(t, "r-3", run.Items[len(run.Items)-1].EventID)
+	})
+
+	t.Run("empty file without period is rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		reconciler := NewReconciler(mocks.NewMockreconciliationRepository(ctrl))
+
+		_, err := reconciler.Reconcile(context.Background(), Request{TimeWindow: time.Minute})
+
+		assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockreconciliationRepository(ctrl)
+		reconciler := NewReconciler(mockRepo)
+
+		expectedErr := errors.New("db error")
+		mockRepo.EXPECT().ListEventsBetween(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, expectedErr)
+
+		_, err := reconciler.Reconcile(context.Background(), Request{Records: records, TimeWindow: time.Minute})
+
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func TestReports(t *testing.T) {
+	amount := entity.Money(1250)
+	at := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	run := &entity.ReconciliationRun{
+		ID:       3,
+		Provider: "acme",
+		Missing:  1,
+		Items: []entity.ReconciliationItem{{
+			Status:          entity.ReconciliationStatusMissing,
+			Line:            2,
+			EventID:         "r-1",
+			UserID:          entity.UserID{UUID: uuid.MustParse("6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10")},
+			TransactionType: entity.TransactionTypeBet,
+			ProviderAmount:  &amount,
+			ProviderTime:    &at,
+		}},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteJSONReport(&buf, run))
+
+		var report map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+		assert.Equal(t, float64(3), report["run_id"])
+		assert.Equal(t, float64(1), report["missing"])
+		items := report["items"].([]any)
+		require.Len(t, items, 1)
+		item := items[0].(map[string]any)
+		assert.Equal(t, "missing", item["status"])
+		assert.Equal(t, 12.5, item["provider_amount"])
+		assert.NotContains(t, item, "stored_amount")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCSVReport(&buf, run))
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, csvReportHeader, rows[0])
+		assert.Equal(t, []string{
+			"missing", "2", "r-1", "6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10", "bet",
+			"12.50", "", "2025-01-02T10:00:00Z", "", "",
+		}, rows[1])
+	})
+}
@@ -0,0 +1,143 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type reportDTO struct {
+	RunID           int64           `json:"run_id"`
+	Provider        string          `json:"provider"`
+	FileName        string          `json:"file_name"`
+	PeriodFrom      time.Time       `json:"period_from"`
+	PeriodTo        time.Time       `json:"period_to"`
+	ProviderRecords int             `json:"provider_records"`
+	StoredRecords   int             `json:"stored_records"`
+	Matched         int             `json:"matched"`
+	Missing         int             `json:"missing"`
+	Extra           int             `json:"extra"`
+	Mismatched      int             `json:"mismatched"`
+	Items           []reportItemDTO `json:"items"`
+}
+
+type reportItemDTO struct {
+	Status          string     `json:"status"`
+	Line            int        `json:"line,omitempty"`
+	EventID         string     `json:"event_id,omitempty"`
+	UserID          string     `json:"user_id"`
+	TransactionType string     `json:"transaction_type"`
+	ProviderAmount  *float64   `json:"provider_amount,omitempty"`
+	StoredAmount    *float64   `json:"stored_amount,omitempty"`
+	ProviderTime    *time.Time `json:"provider_time,omitempty"`
+	StoredTime      *time.Time `json:"stored_time,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+}
+
+var csvReportHeader = []string{
+	"status", "line", "event_id", "user_id", "transaction_type",
+	"provider_amount", "stored_amount", "provider_time", "stored_time", "reason",
+}
+
+// WriteJSONReport writes the run summary and all its items as one JSON document.
+func WriteJSONReport(w io.Writer, run *entity.ReconciliationRun) error {
+	report := reportDTO{
+		RunID:           run.ID,
+		Provider:        run.Provider,
+		FileName:        run.FileName,
+		PeriodFrom:      run.PeriodFrom,
+		PeriodTo:        run.PeriodTo,
+		ProviderRecords: run.ProviderRecords,
+		StoredRecords:   run.StoredRecords,
+		Matched:         run.Matched,
+		Missing:         run.Missing,
+		Extra:           run.Extra,
+		Mismatched:      run.Mismatched,
+		Items:           make([]reportItemDTO, 0, len(run.Items)),
+	}
+	for _, item := range run.Items {
+		report.Items = append(report.Items, reportItemDTO{
+			Status:          string(item.Status),
+			Line:            item.Line,
+			EventID:         item.EventID,
+			UserID:          item.UserID.UUID.String(),
+			TransactionType: string(item.TransactionType),
+			ProviderAmount:  moneyToFloat(item.ProviderAmount),
+			StoredAmount:    moneyToFloat(item.StoredAmount),
+			ProviderTime:    item.ProviderTime,
+			StoredTime:      item.StoredTime,
+			Reason:          item.Reason,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write json report: %w", err)
+	}
+	return nil
+}
+
+// WriteCSVReport writes one row per item; empty cells stand for values that
+// only exist on the other side.
+func WriteCSVReport(w io.Writer, run *entity.ReconciliationRun) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvReportHeader); err != nil {
+		return fmt.Errorf("failed to write csv report: %w", err)
+	}
+
+	for _, item := range run.Items {
+		line := ""
+		if item.Line > 0 {
+			line = strconv.Itoa(item.Line)
+		}
+		row := []string{
+			string(item.Status),
+			line,
+			item.EventID,
+			item.UserID.UUID.String(),
+			string(item.TransactionType),
+			formatMoney(item.ProviderAmount),
+			formatMoney(item.StoredAmount),
+			formatTime(item.ProviderTime),
+			formatTime(item.StoredTime),
+			item.Reason,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv report: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv report: %w", err)
+	}
+	return nil
+}
+
+func moneyToFloat(m *entity.Money) *float64 {
+	if m == nil {
+		return nil
+	}
+	value := m.ToFloat()
+	return &value
+}
+
+func formatMoney(m *entity.Money) string {
+	if m == nil {
+		return ""
+	}
+	return strconv.FormatFloat(m.ToFloat(), 'f', 2, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
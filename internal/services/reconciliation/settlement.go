@@ -0,0 +1,209 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTimeWindow = 5 * time.Minute
+)
+
+var defaultColumns = config.SettlementColumns{
+	EventID:         "event_id",
+	UserID:          "user_id",
+	TransactionType: "transaction_type",
+	Amount:          "amount",
+	Timestamp:       "timestamp",
+}
+
+// SettlementReader parses provider settlement files according to the
+// provider's SettlementFormat.
+type SettlementReader struct {
+	format config.SettlementFormat
+	types  map[string]entity.TransactionType
+}
+
+func NewSettlementReader(format config.SettlementFormat) (*SettlementReader, error) {
+	if format.Delimiter == "" {
+		format.Delimiter = ","
+	}
+	if len([]rune(format.Delimiter)) != 1 {
+		return nil, fmt.Errorf("delimiter must be a single character, got %q", format.Delimiter)
+	}
+	if format.TimestampFormat == "" {
+		format.TimestampFormat = time.RFC3339
+	}
+	format.Columns = withDefaultColumns(format.Columns)
+
+	types := map[string]entity.TransactionType{
+		string(entity.TransactionTypeBet):     entity.TransactionTypeBet,
+		string(entity.TransactionTypeWin):     entity.TransactionTypeWin,
+		string(entity.TransactionTypeDeposit): entity.TransactionTypeDeposit,
+	}
+	for providerType, transactionType := range format.TransactionTypes {
+		mapped := entity.TransactionType(strings.ToLower(transactionType))
+		if !mapped.IsValid() {
+			return nil, fmt.Errorf("transaction type %q must map to bet, win or deposit, got %q", providerType, transactionType)
+		}
+		types[strings.ToLower(providerType)] = mapped
+	}
+
+	return &SettlementReader{
+		format: format,
+		types:  types,
+	}, nil
+}
+
+// TimeWindow is the largest difference between the provider's and the stored
+// timestamp of a transaction that still counts as a match.
+func (s *SettlementReader) TimeWindow() time.Duration {
+	if s.format.TimeWindowSec <= 0 {
+		return defaultTimeWindow
+	}
+	return time.Duration(s.format.TimeWindowSec) * time.Second
+}
+
+// Read parses the whole file. The first line must be a header naming the
+// mapped columns; the event id column is optional.
+func (s *SettlementReader) Read(r io.Reader) ([]entity.SettlementRecord, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = []rune(s.format.Delimiter)[0]
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("settlement file is empty")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	positions, err := s.columnPositions(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []entity.SettlementRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read settlement file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		record, err := s.parseRow(row, positions)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		record.Line = line
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+type columnPositions struct {
+	eventID, userID, transactionType, amount, timestamp int
+}
+
+func (s *SettlementReader) columnPositions(header []string) (*columnPositions, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	lookup := func(name string, required bool) (int, error) {
+		position, ok := index[strings.ToLower(name)]
+		if !ok {
+			if required {
+				return 0, fmt.Errorf("column %q not found in header", name)
+			}
+			return -1, nil
+		}
+		return position, nil
+	}
+
+	columns := s.format.Columns
+	positions := &columnPositions{}
+	var err error
+	if positions.eventID, err = lookup(columns.EventID, false); err != nil {
+		return nil, err
+	}
+	if positions.userID, err = lookup(columns.UserID, true); err != nil {
+		return nil, err
+	}
+	if positions.transactionType, err = lookup(columns.TransactionType, true); err != nil {
+		return nil, err
+	}
+	if positions.amount, err = lookup(columns.Amount, true); err != nil {
+		return nil, err
+	}
+	if positions.timestamp, err = lookup(columns.Timestamp, true); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+func (s *SettlementReader) parseRow(row []string, positions *columnPositions) (*entity.SettlementRecord, error) {
+	var record entity.SettlementRecord
+
+	if positions.eventID >= 0 {
+		record.EventID = strings.TrimSpace(row[positions.eventID])
+	}
+
+	userID, err := uuid.Parse(strings.TrimSpace(row[positions.userID]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id %q: %w", row[positions.userID], err)
+	}
+	record.UserID = entity.UserID{UUID: userID}
+
+	transactionType, ok := s.types[strings.ToLower(strings.TrimSpace(row[positions.transactionType]))]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type %q", row[positions.transactionType])
+	}
+	record.TransactionType = transactionType
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(row[positions.amount]), 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid amount %q", row[positions.amount])
+	}
+	record.Amount = entity.ToMoney(amount)
+
+	createdAt, err := time.Parse(s.format.TimestampFormat, strings.TrimSpace(row[positions.timestamp]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", row[positions.timestamp], err)
+	}
+	record.CreatedAt = createdAt
+
+	return &record, nil
+}
+
+func withDefaultColumns(columns config.SettlementColumns) config.SettlementColumns {
+	if columns.EventID == "" {
+		columns.EventID = defaultColumns.EventID
+	}
+	if columns.UserID == "" {
+		columns.UserID = defaultColumns.UserID
+	}
+	if columns.TransactionType == "" {
+		columns.TransactionType = defaultColumns.TransactionType
+	}
+	if columns.Amount == "" {
+		columns.Amount = defaultColumns.Amount
+	}
+	if columns.Timestamp == "" {
+		columns.Timestamp = defaultColumns.Timestamp
+	}
+	return columns
+}
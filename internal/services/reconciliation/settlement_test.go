@@ -0,0 +1,97 @@
+package reconciliation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettlementReader_Read(t *testing.T) {
+	t.Run("maps provider columns and transaction types", func(t *testing.T) {
+		reader, err := NewSettlementReader(config.SettlementFormat{
+			Delimiter:       ";",
+			TimestampFormat: "2006-01-02 15:04:05",
+			Columns: config.SettlementColumns{
+				EventID:         "Round",
+				UserID:          "Player",
+				TransactionType: "Kind",
+				Amount:          "Value",
+				Timestamp:       "Time",
+			},
+			TransactionTypes: map[string]string{"stake": "bet", "payout": "win"},
+		})
+		require.NoError(t, err)
+
+		records, err := reader.Read(strings.NewReader(
+			"Time;Player;Kind;Value;Round\n" +
+				"2025-01-02 10:00:00;6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10;STAKE;12.50;r-1\n" +
+				"2025-01-02 10:00:05;6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10;payout;30;r-1w\n"))
+
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, 2, records[0].Line)
+		assert.Equal(t, "r-1", records[0].EventID)
+		assert.Equal(t, "6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10", records[0].UserID.UUID.String())
+		assert.Equal(t, entity.TransactionTypeBet, records[0].TransactionType)
+		assert.Equal(t, entity.Money(1250), records[0].Amount)
+		assert.Equal(t, time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC), records[0].CreatedAt)
+		assert.Equal(t, entity.TransactionTypeWin, records[1].TransactionType)
+		assert.Equal(t, entity.Money(3000), records[1].Amount)
+	})
+
+	t.Run("event id column is optional", func(t *testing.T) {
+		reader, err := NewSettlementReader(config.SettlementFormat{})
+		require.NoError(t, err)
+
+		records, err := reader.Read(strings.NewReader(
+			"user_id,transaction_type,amount,timestamp\n" +
+				"6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10,bet,1,2025-01-02T10:00:00Z\n"))
+
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Empty(t, records[0].EventID)
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		reader, err := NewSettlementReader(config.SettlementFormat{})
+		require.NoError(t, err)
+
+		_, err = reader.Read(strings.NewReader("user_id,amount,timestamp\n"))
+
+		assert.ErrorContains(t, err, `column "transaction_type" not found`)
+	})
+
+	t.Run("invalid row reports its line", func(t *testing.T) {
+		reader, err := NewSettlementReader(config.SettlementFormat{})
+		require.NoError(t, err)
+
+		_, err = reader.Read(strings.NewReader(
+			"user_id,transaction_type,amount,timestamp\n" +
+				"6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10,bet,1,2025-01-02T10:00:00Z\n" +
+				"6f1c2b8e-7a3d-4b7e-9a41-0c5d1c3e2f10,chargeback,1,2025-01-02T10:00:00Z\n"))
+
+		assert.ErrorContains(t, err, "line 3")
+		assert.ErrorContains(t, err, `unknown transaction type "chargeback"`)
+	})
+
+	t.Run("transaction types must map to a known type", func(t *testing.T) {
+		_, err := NewSettlementReader(config.SettlementFormat{TransactionTypes: map[string]string{"cashback": "credit"}})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSettlementReader_TimeWindow(t *testing.T) {
+	reader, err := NewSettlementReader(config.SettlementFormat{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultTimeWindow, reader.TimeWindow())
+
+	reader, err = NewSettlementReader(config.SettlementFormat{TimeWindowSec: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, reader.TimeWindow())
+}
@@ -0,0 +1,14 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type reconciliationRepository interface {
+	ListEventsBetween(ctx context.Context, from, to time.Time, after *entity.EventCursor, limit int) ([]entity.TransactionEvent, error)
+	CreateRun(ctx context.Context, run *entity.ReconciliationRun) error
+}
@@ -0,0 +1,128 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	generatedSecretBytes = 32
+)
+
+type AdminProcessor struct {
+	repository subscriptionRepository
+	dispatcher subscriptionsCache
+}
+
+func NewAdminProcessor(repository subscriptionRepository, dispatcher subscriptionsCache) *AdminProcessor {
+	return &AdminProcessor{
+		repository: repository,
+		dispatcher: dispatcher,
+	}
+}
+
+// CreateSubscription validates the subscription and stores it. A random secret
+// is generated when none is provided.
+func (s *AdminProcessor) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+	if subscription.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+	if err := validateSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repository.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return created, nil
+}
+
+func (s *AdminProcessor) UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+	if subscription.Secret == "" {
+		existing, err := s.repository.GetSubscription(ctx, subscription.ID)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = existing.Secret
+	}
+	if err := validateSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repository.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return updated, nil
+}
+
+func (s *AdminProcessor) DeleteSubscription(ctx context.Context, id int64) error {
+	if err := s.repository.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *AdminProcessor) GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error) {
+	return s.repository.GetSubscription(ctx, id)
+}
+
+func (s *AdminProcessor) ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	return s.repository.ListSubscriptions(ctx)
+}
+
+func (s *AdminProcessor) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]entity.WebhookDelivery, error) {
+	if _, err := s.repository.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repository.ListDeliveries(ctx, subscriptionID, limit, offset)
+}
+
+func (s *AdminProcessor) ListAttempts(ctx context.Context, deliveryID int64) ([]entity.WebhookDeliveryAttempt, error) {
+	return s.repository.ListAttempts(ctx, deliveryID)
+}
+
+func (s *AdminProcessor) invalidate() {
+	if s.dispatcher != nil {
+		s.dispatcher.Invalidate()
+	}
+}
+
+func validateSubscription(subscription entity.WebhookSubscription) error {
+	if strings.TrimSpace(subscription.Name) == "" {
+		return fmt.Errorf("%w: name is required", entity.ErrInvalidArgument)
+	}
+
+	if _, err := ParseExpression(subscription.FilterExpression); err != nil {
+		return fmt.Errorf("%w: invalid filter expression: %v", entity.ErrInvalidArgument, err)
+	}
+
+	target, err := url.Parse(subscription.TargetURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: target_url must be an absolute http(s) URL", entity.ErrInvalidArgument)
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, generatedSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,183 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	defaultSubscriptionsCacheTTL = 10 * time.Second
+)
+
+type Payload struct {
+	SubscriptionID int64              `json:"subscription_id"`
+	Subscription   string             `json:"subscription"`
+	First          bool               `json:"first"`
+	Transaction    TransactionPayload `json:"transaction"`
+}
+
+type TransactionPayload struct {
+	TenantID        string    `json:"tenant_id,omitempty"`
+	UserID          string    `json:"user_id"`
+	TransactionType string    `json:"transaction_type"`
+	Amount          float64   `json:"amount"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+type compiledSubscription struct {
+	subscription entity.WebhookSubscription
+	expression   *Expression
+}
+
+// Dispatcher matches the transactions of a batch against active subscriptions
+// and derives a delivery for every match, which is enqueued in the transaction
+// that stores the batch. Sending is done asynchronously by Sender.
+type Dispatcher struct {
+	repository     dispatchRepository
+	history        transactionHistory
+	cacheTTL       time.Duration
+	subscriptions  []compiledSubscription
+	cacheExpiresAt time.Time
+	mu             sync.Mutex
+}
+
+func NewDispatcher(repository dispatchRepository, history transactionHistory, cacheTTL time.Duration) *Dispatcher {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultSubscriptionsCacheTTL
+	}
+	return &Dispatcher{
+		repository: repository,
+		history:    history,
+		cacheTTL:   cacheTTL,
+	}
+}
+
+// Derive returns the deliveries of the batch. It runs before the batch is
+// stored: a transaction is the first of its type for the user when none is
+// stored yet and it is the earliest of the batch, ties broken by batch order.
+func (d *Dispatcher) Derive(ctx context.Context, events []entity.TransactionEvent) ([]entity.WebhookDelivery, error) {
+	subscriptions, err := d.activeSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	var firsts map[int]bool
+	for _, compiled := range subscriptions {
+		if compiled.expression.Uses(FieldFirst) {
+			if firsts, err = d.firstTransactions(ctx, events); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	var deliveries []entity.WebhookDelivery
+	for i, event := range events {
+		facts := EventFacts{Event: event, First: firsts[i]}
+
+		for _, compiled := range subscriptions {
+			if !compiled.expression.Evaluate(facts) {
+				continue
+			}
+
+			payload, err := json.Marshal(newPayload(compiled.subscription, facts))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+			deliveries = append(deliveries, entity.WebhookDelivery{
+				SubscriptionID: compiled.subscription.ID,
+				Payload:        payload,
+				Status:         entity.WebhookDeliveryStatusPending,
+			})
+		}
+	}
+	return deliveries, nil
+}
+
+// firstTransactions returns the indexes of the batch events that are the
+// first transaction of their type for their user.
+func (d *Dispatcher) firstTransactions(ctx context.Context, events []entity.TransactionEvent) (map[int]bool, error) {
+	type userType struct {
+		userID          entity.UserID
+		transactionType entity.TransactionType
+	}
+
+	earliest := make(map[userType]int)
+	for i, event := range events {
+		key := userType{userID: event.UserID, transactionType: event.TransactionType}
+		if j, ok := earliest[key]; !ok || event.CreatedAt.Before(events[j].CreatedAt) {
+			earliest[key] = i
+		}
+	}
+
+	firsts := make(map[int]bool, len(earliest))
+	for key, i := range earliest {
+		exists, err := d.history.HasTransaction(ctx, key.userID, key.transactionType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check transaction history: %w", err)
+		}
+		firsts[i] = !exists
+	}
+	return firsts, nil
+}
+
+// Invalidate drops cached subscriptions so changes made through the admin API
+// are picked up on the next batch.
+func (d *Dispatcher) Invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.cacheExpiresAt = time.Time{}
+}
+
+func (d *Dispatcher) activeSubscriptions(ctx context.Context) ([]compiledSubscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Now().Before(d.cacheExpiresAt) {
+		return d.subscriptions, nil
+	}
+
+	subscriptions, err := d.repository.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	compiled := make([]compiledSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		expression, err := ParseExpression(subscription.FilterExpression)
+		if err != nil {
+			log.Printf("Skipping webhook subscription %d with invalid expression: %v", subscription.ID, err)
+			continue
+		}
+		compiled = append(compiled, compiledSubscription{subscription: subscription, expression: expression})
+	}
+
+	d.subscriptions = compiled
+	d.cacheExpiresAt = time.Now().Add(d.cacheTTL)
+	return compiled, nil
+}
+
+func newPayload(subscription entity.WebhookSubscription, facts EventFacts) Payload {
+	return Payload{
+		SubscriptionID: subscription.ID,
+		Subscription:   subscription.Name,
+		First:          facts.First,
+		Transaction: TransactionPayload{
+			TenantID:        facts.Event.TenantID,
+			UserID:          facts.Event.UserID.UUID.String(),
+			TransactionType: string(facts.Event.TransactionType),
+			Amount:          facts.Event.Amount.ToFloat(),
+			Timestamp:       facts.Event.CreatedAt,
+		},
+	}
+}
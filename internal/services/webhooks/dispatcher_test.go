@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/webhooks/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDispatcher_Derive(t *testing.T) {
+	t.Run("derives deliveries for matching subscriptions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdispatchRepository(ctrl)
+		mockHistory := mocks.NewMocktransactionHistory(ctrl)
+		dispatcher := NewDispatcher(mockRepo, mockHistory, time.Minute)
+
+		userID := uuid.New()
+		bigWin := entity.TransactionEvent{
+			UserID:          *entity.NewUserID(userID),
+			TransactionType: entity.TransactionTypeWin,
+			Amount:          entity.ToMoney(5000.0),
+			CreatedAt:       time.Now(),
+		}
+		firstDeposit := entity.TransactionEvent{
+			UserID:          *entity.NewUserID(userID),
+			TransactionType: entity.TransactionTypeDeposit,
+			Amount:          entity.ToMoney(10.0),
+			CreatedAt:       time.Now(),
+		}
+
+		mockRepo.EXPECT().
+			ListActiveSubscriptions(gomock.Any()).
+			Return([]entity.WebhookSubscription{
+				{ID: 1, Name: "big-wins", FilterExpression: `type == "win" && amount >= 1000`},
+				{ID: 2, Name: "first-deposits", FilterExpression: `type == "deposit" && first == true`},
+				{ID: 3, Name: "broken", FilterExpression: `nonsense`},
+			}, nil).
+			Times(1)
+
+		mockHistory.EXPECT().
+			HasTransaction(gomock.Any(), bigWin.UserID, entity.TransactionTypeWin).
+			Return(true, nil)
+		mockHistory.EXPECT().
+			HasTransaction(gomock.Any(), firstDeposit.UserID, entity.TransactionTypeDeposit).
+			Return(false, nil)
+
+		deliveries, err := dispatcher.Derive(context.Background(), []entity.TransactionEvent{bigWin, firstDeposit})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, int64(1), deliveries[0].SubscriptionID)
+		assert.Equal(t, int64(2), deliveries[1].SubscriptionID)
+		assert.Equal(t, entity.WebhookDeliveryStatusPending, deliveries[1].Status)
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(deliveries[1].Payload, &payload))
+		assert.Equal(t, "first-deposits", payload.Subscription)
+		assert.True(t, payload.First)
+		assert.Equal(t, userID.String(), payload.Transaction.UserID)
+		assert.Equal(t, "deposit", payload.Transaction.TransactionType)
+		assert.Equal(t, 10.0, payload.Transaction.Amount)
+
+		// subscriptions are cached, the second batch does not hit the repository
+		deliveries, err = dispatcher.Derive(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("only the earliest transaction of the batch is first", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdispatchRepository(ctrl)
+		mockHistory := mocks.NewMocktransactionHistory(ctrl)
+		dispatcher := NewDispatcher(mockRepo, mockHistory, time.Minute)
+
+		userID := *entity.NewUserID(uuid.New())
+		now := time.Now()
+		deposit := func(amount float64, at time.Time) entity.TransactionEvent {
+			return entity.TransactionEvent{UserID: userID, TransactionType: entity.TransactionTypeDeposit, Amount: entity.ToMoney(amount), CreatedAt: at}
+		}
+		// the second and third deposit share a timestamp, the second one is
+		// the earliest as the first is later
+		events := []entity.TransactionEvent{deposit(1, now.Add(time.Second)), deposit(2, now), deposit(3, now)}
+
+		mockRepo.EXPECT().
+			ListActiveSubscriptions(gomock.Any()).
+			Return([]entity.WebhookSubscription{
+				{ID: 1, Name: "first-deposits", FilterExpression: `type == "deposit" && first == true`},
+			}, nil)
+		mockHistory.EXPECT().
+			HasTransaction(gomock.Any(), userID, entity.TransactionTypeDeposit).
+			Return(false, nil).
+			Times(1)
+
+		deliveries, err := dispatcher.Derive(context.Background(), events)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+		assert.Equal(t, 2.0, payload.Transaction.Amount)
+	})
+
+	t.Run("history error is returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdispatchRepository(ctrl)
+		mockHistory := mocks.NewMocktransactionHistory(ctrl)
+		dispatcher := NewDispatcher(mockRepo, mockHistory, time.Minute)
+
+		expectedErr := errors.New("db error")
+		mockRepo.EXPECT().
+			ListActiveSubscriptions(gomock.Any()).
+			Return([]entity.WebhookSubscription{
+				{ID: 1, Name: "first-deposits", FilterExpression: `type == "deposit" && first == true`},
+			}, nil)
+		mockHistory.EXPECT().
+			HasTransaction(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(false, expectedErr)
+
+		_, err := dispatcher.Derive(context.Background(), []entity.TransactionEvent{
+			{UserID: *entity.NewUserID(uuid.New()), TransactionType: entity.TransactionTypeDeposit, Amount: entity.ToMoney(1.0), CreatedAt: time.Now()},
+		})
+		assert.ErrorIs(t, err, expectedErr)
+	})
+
+	t.Run("invalidate reloads subscriptions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockdispatchRepository(ctrl)
+		mockHistory := mocks.NewMocktransactionHistory(ctrl)
+		dispatcher := NewDispatcher(mockRepo, mockHistory, time.Minute)
+
+		mockRepo.EXPECT().
+			ListActiveSubscriptions(gomock.Any()).
+			Return(nil, nil).
+			Times(2)
+
+		_, err := dispatcher.Derive(context.Background(), nil)
+		require.NoError(t, err)
+		dispatcher.Invalidate()
+		_, err = dispatcher.Derive(context.Background(), nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestAdminProcessor_CreateSubscription(t *testing.T) {
+	t.Run("generates secret and invalidates cache", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMocksubscriptionRepository(ctrl)
+		mockCache := mocks.NewMocksubscriptionsCache(ctrl)
+		processor := NewAdminProcessor(mockRepo, mockCache)
+
+		mockRepo.EXPECT().
+			CreateSubscription(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+				assert.Len(t, subscription.Secret, 2*generatedSecretBytes)
+				subscription.ID = 7
+				return &subscription, nil
+			})
+		mockCache.EXPECT().Invalidate().Times(1)
+
+		created, err := processor.CreateSubscription(context.Background(), entity.WebhookSubscription{
+			Name:             "crm",
+			FilterExpression: `type == "win" && amount >= 1000`,
+			TargetURL:        "https://crm.example.com/hooks",
+			Active:           true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), created.ID)
+	})
+
+	t.Run("rejects invalid subscriptions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		processor := NewAdminProcessor(mocks.NewMocksubscriptionRepository(ctrl), nil)
+
+		invalid := []entity.WebhookSubscription{
+			{Name: "", FilterExpression: `amount > 1`, TargetURL: "https://example.com"},
+			{Name: "bad-expression", FilterExpression: `amount >`, TargetURL: "https://example.com"},
+			{Name: "bad-url", FilterExpression: `amount > 1`, TargetURL: "ftp://example.com"},
+		}
+		for _, subscription := range invalid {
+			_, err := processor.CreateSubscription(context.Background(), subscription)
+			assert.ErrorIs(t, err, entity.ErrInvalidArgument)
+		}
+	})
+}
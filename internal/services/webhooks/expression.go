@@ -0,0 +1,344 @@
+package webhooks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	FieldType   = "type"
+	FieldAmount = "amount"
+	FieldUserID = "user_id"
+	FieldFirst  = "first"
+)
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+)
+
+var fieldKinds = map[string]valueKind{
+	FieldType:   kindString,
+	FieldAmount: kindNumber,
+	FieldUserID: kindString,
+	FieldFirst:  kindBool,
+}
+
+// EventFacts is what an expression is evaluated against. First tells whether
+// the event is the first one of its type for the user.
+type EventFacts struct {
+	Event entity.TransactionEvent
+	First bool
+}
+
+// Expression is a parsed filter such as `type == "win" && amount >= 1000`.
+// Supported fields are type, amount (in dollars), user_id and first,
+// combined with &&, || and parentheses.
+type Expression struct {
+	source string
+	root   node
+	fields map[string]bool
+}
+
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &parser{tokens: tokens, fields: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected token %q", p.peek().text)
+	}
+
+	return &Expression{source: source, root: root, fields: p.fields}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Uses reports whether the expression references the given field.
+func (e *Expression) Uses(field string) bool {
+	return e.fields[field]
+}
+
+func (e *Expression) Evaluate(facts EventFacts) bool {
+	return e.root.eval(facts)
+}
+
+type node interface {
+	eval(facts EventFacts) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(facts EventFacts) bool { return n.left.eval(facts) && n.right.eval(facts) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(facts EventFacts) bool { return n.left.eval(facts) || n.right.eval(facts) }
+
+type comparisonNode struct {
+	field    string
+	operator string
+	str      string
+	number   float64
+	boolean  bool
+}
+
+func (n comparisonNode) eval(facts EventFacts) bool {
+	switch n.field {
+	case FieldType:
+		return compareStrings(string(facts.Event.TransactionType), n.operator, n.str)
+	case FieldUserID:
+		return compareStrings(facts.Event.UserID.UUID.String(), n.operator, strings.ToLower(n.str))
+	case FieldFirst:
+		if n.operator == "==" {
+			return facts.First == n.boolean
+		}
+		return facts.First != n.boolean
+	case FieldAmount:
+		return compareNumbers(int64(facts.Event.Amount), n.operator, int64(entity.ToMoney(n.number)))
+	}
+	return false
+}
+
+func compareStrings(left, operator, right string) bool {
+	if operator == "==" {
+		return left == right
+	}
+	return left != right
+}
+
+func compareNumbers(left int64, operator string, right int64) bool {
+	switch operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLogical
+	tokenParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{kind: tokenParen, text: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[i+1 : end])})
+			i = end + 1
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenLogical, text: string([]rune{r, r})})
+			i += 2
+		case strings.ContainsRune("=!<>", r):
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokenOperator, text: string([]rune{r, '='})})
+				i += 2
+				continue
+			}
+			if r == '=' || r == '!' {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r)})
+			i++
+		case unicode.IsDigit(r) || r == '.' || r == '-':
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]bool
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() (token, error) {
+	if p.done() {
+		return token{}, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() && p.peek().kind == tokenLogical && p.peek().text == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() && p.peek().kind == tokenLogical && p.peek().text == "&&" {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.kind == tokenParen && t.text == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, err := p.next()
+		if err != nil || closing.kind != tokenParen || closing.text != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+
+	if t.kind != tokenIdent {
+		return nil, fmt.Errorf("expected field name, got %q", t.text)
+	}
+	kind, ok := fieldKinds[t.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", t.text)
+	}
+	p.fields[t.text] = true
+
+	operator, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if operator.kind != tokenOperator {
+		return nil, fmt.Errorf("expected comparison operator after %q, got %q", t.text, operator.text)
+	}
+	if kind != kindNumber && operator.text != "==" && operator.text != "!=" {
+		return nil, fmt.Errorf("operator %q is not supported for field %q", operator.text, t.text)
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := comparisonNode{field: t.text, operator: operator.text}
+	switch kind {
+	case kindString:
+		if value.kind != tokenString {
+			return nil, fmt.Errorf("field %q expects a quoted string", t.text)
+		}
+		comparison.str = value.text
+	case kindNumber:
+		if value.kind != tokenNumber {
+			return nil, fmt.Errorf("field %q expects a number", t.text)
+		}
+		comparison.number, err = strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %w", value.text, err)
+		}
+	case kindBool:
+		if value.kind != tokenIdent || (value.text != "true" && value.text != "false") {
+			return nil, fmt.Errorf("field %q expects true or false", t.text)
+		}
+		comparison.boolean = value.text == "true"
+	}
+
+	return comparison, nil
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression(t *testing.T) {
+	userID := uuid.New()
+	win := EventFacts{Event: entity.TransactionEvent{
+		UserID:          *entity.NewUserID(userID),
+		TransactionType: entity.TransactionTypeWin,
+		Amount:          entity.ToMoney(1500.0),
+		CreatedAt:       time.Now(),
+	}}
+	firstBet := EventFacts{First: true, Event: entity.TransactionEvent{
+		UserID:          *entity.NewUserID(userID),
+		TransactionType: entity.TransactionTypeBet,
+		Amount:          entity.ToMoney(20.0),
+		CreatedAt:       time.Now(),
+	}}
+
+	tests := []struct {
+		name       string
+		expression string
+		facts      EventFacts
+		expected   bool
+	}{
+		{"win above threshold", `type == "win" && amount >= 1000`, win, true},
+		{"win below threshold", `type == "win" && amount > 1500`, win, false},
+		{"first bet", `type == "bet" && first == true`, firstBet, true},
+		{"not first", `first == false`, firstBet, false},
+		{"or with parentheses", `(type == "bet" && amount < 10) || amount == 1500`, win, true},
+		{"user id match", `user_id == "` + userID.String() + `"`, win, true},
+		{"user id mismatch", `user_id != "` + userID.String() + `"`, win, false},
+		{"decimal amount", `amount <= 20.00`, firstBet, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expression.Evaluate(tt.facts))
+		})
+	}
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	invalid := []string{
+		``,
+		`balance > 10`,
+		`type > "win"`,
+		`amount >= "ten"`,
+		`first == 1`,
+		`type == "win" &&`,
+		`(type == "win"`,
+		`type = "win"`,
+		`type == "win`,
+		`amount >= 10 amount <= 20`,
+	}
+
+	for _, source := range invalid {
+		t.Run(source, func(t *testing.T) {
+			_, err := ParseExpression(source)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExpression_Uses(t *testing.T) {
+	expression, err := ParseExpression(`type == "bet" && first == true`)
+	require.NoError(t, err)
+
+	assert.True(t, expression.Uses(FieldFirst))
+	assert.True(t, expression.Uses(FieldType))
+	assert.False(t, expression.Uses(FieldAmount))
+}
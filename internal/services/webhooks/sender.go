@@ -0,0 +1,201 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	defaultPollInterval   = time.Second
+	defaultBatchSize      = 50
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 5 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultRequestTimeout = 10 * time.Second
+
+	maxStoredErrorLength = 1024
+)
+
+// Sender polls the delivery outbox and posts due deliveries to their targets,
+// retrying failures with exponential backoff until MaxAttempts is reached.
+type Sender struct {
+	repository     deliveryRepository
+	client         httpClient
+	pollInterval   time.Duration
+	batchSize      int
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	requestTimeout time.Duration
+}
+
+func NewSender(repository deliveryRepository, client httpClient, conf config.Webhooks) *Sender {
+	return &Sender{
+		repository:     repository,
+		client:         client,
+		pollInterval:   millisecondsOrDefault(conf.PollIntervalMs, defaultPollInterval),
+		batchSize:      intOrDefault(conf.BatchSize, defaultBatchSize),
+		maxAttempts:    intOrDefault(conf.MaxAttempts, defaultMaxAttempts),
+		initialBackoff: millisecondsOrDefault(conf.InitialBackoffMs, defaultInitialBackoff),
+		maxBackoff:     millisecondsOrDefault(conf.MaxBackoffMs, defaultMaxBackoff),
+		requestTimeout: millisecondsOrDefault(conf.RequestTimeoutMs, defaultRequestTimeout),
+	}
+}
+
+func (s *Sender) Start(ctx context.Context) error {
+	log.Println("Starting webhook sender")
+	defer func() { log.Println("Stopping webhook sender") }()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to process webhook deliveries: %v", err)
+			}
+		}
+	}
+}
+
+// ProcessDue sends one batch of due deliveries and returns how many were
+// attempted.
+func (s *Sender) ProcessDue(ctx context.Context) (int, error) {
+	// the lease keeps other consumer instances away while requests are in flight
+	lease := s.requestTimeout*time.Duration(s.batchSize) + s.pollInterval
+	dispatches, err := s.repository.ClaimDueDeliveries(ctx, s.batchSize, lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	for _, dispatch := range dispatches {
+		delivery, attempt := s.deliver(ctx, dispatch)
+		if err = s.repository.RecordAttempt(ctx, delivery, attempt); err != nil {
+			return 0, fmt.Errorf("failed to record attempt for delivery %d: %w", delivery.ID, err)
+		}
+	}
+
+	return len(dispatches), nil
+}
+
+func (s *Sender) deliver(ctx context.Context, dispatch entity.WebhookDispatch) (entity.WebhookDelivery, entity.WebhookDeliveryAttempt) {
+	delivery := dispatch.Delivery
+	startedAt := time.Now()
+
+	statusCode, err := s.send(ctx, dispatch, startedAt)
+
+	attempt := entity.WebhookDeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		StatusCode:  statusCode,
+		Duration:    time.Since(startedAt),
+		AttemptedAt: startedAt,
+	}
+	delivery.Attempts++
+
+	if err == nil {
+		delivery.Status = entity.WebhookDeliveryStatusDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &startedAt
+		return delivery, attempt
+	}
+
+	attempt.Error = truncate(err.Error(), maxStoredErrorLength)
+	delivery.LastError = attempt.Error
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = entity.WebhookDeliveryStatusFailed
+	} else {
+		delivery.NextAttemptAt = startedAt.Add(s.Backoff(delivery.Attempts))
+	}
+	return delivery, attempt
+}
+
+func (s *Sender) send(ctx context.Context, dispatch entity.WebhookDispatch, now time.Time) (int, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodPost, dispatch.TargetURL, bytes.NewReader(dispatch.Delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(dispatch.Delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(dispatch.Secret, timestamp, dispatch.Delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts.
+func (s *Sender) Backoff(attempts int) time.Duration {
+	delay := s.initialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign computes the hex encoded HMAC-SHA256 of "<timestamp>.<body>", which
+// receivers compare with the X-Webhook-Signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func millisecondsOrDefault(ms int, def time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func intOrDefault(value, def int) int {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
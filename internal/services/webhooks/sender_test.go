@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/config"
+	"github.com/bsko/casino-transaction-system/internal/entity"
+	"github.com/bsko/casino-transaction-system/internal/services/webhooks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSender_ProcessDue(t *testing.T) {
+	payload := json.RawMessage(`{"subscription_id":1,"transaction":{"amount":1500}}`)
+
+	t.Run("delivers signed payload", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		const secret = "top-secret"
+		received := make(chan *http.Request, 1)
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, "sha256="+Sign(secret, timestamp, body), r.Header.Get(SignatureHeader))
+			assert.JSONEq(t, string(payload), string(body))
+			received <- r
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		mockRepo := mocks.NewMockdeliveryRepository(ctrl)
+		sender := NewSender(mockRepo, receiver.Client(), config.Webhooks{})
+
+		mockRepo.EXPECT().
+			ClaimDueDeliveries(gomock.Any(), defaultBatchSize, gomock.Any()).
+			Return([]entity.WebhookDispatch{{
+				Delivery:  entity.WebhookDelivery{ID: 42, Payload: payload, Status: entity.WebhookDeliveryStatusPending},
+				TargetURL: receiver.URL,
+				Secret:    secret,
+			}}, nil)
+		mockRepo.EXPECT().
+			RecordAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, delivery entity.WebhookDelivery, attempt entity.WebhookDeliveryAttempt) error {
+				assert.Equal(t, entity.WebhookDeliveryStatusDelivered, delivery.Status)
+				assert.Equal(t, 1, delivery.Attempts)
+				assert.NotNil(t, delivery.DeliveredAt)
+				assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+				assert.Empty(t, attempt.Error)
+				return nil
+			})
+
+		processed, err := sender.ProcessDue(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+		req := <-received
+		assert.Equal(t, "42", req.Header.Get(DeliveryHeader))
+	})
+
+	t.Run("failed delivery is rescheduled with backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		mockRepo := mocks.NewMockdeliveryRepository(ctrl)
+		sender := NewSender(mockRepo, receiver.Client(), config.Webhooks{MaxAttempts: 3, InitialBackoffMs: 1000})
+
+		mockRepo.EXPECT().
+			ClaimDueDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]entity.WebhookDispatch{{
+				Delivery:  entity.WebhookDelivery{ID: 1, Payload: payload, Attempts: 1, Status: entity.WebhookDeliveryStatusPending},
+				TargetURL: receiver.URL,
+			}}, nil)
+		mockRepo.EXPECT().
+			RecordAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, delivery entity.WebhookDelivery, attempt entity.WebhookDeliveryAttempt) error {
+				assert.Equal(t, entity.WebhookDeliveryStatusPending, delivery.Status)
+				assert.Equal(t, 2, delivery.Attempts)
+				assert.WithinDuration(t, attempt.AttemptedAt.Add(2*time.Second), delivery.NextAttemptAt, time.Millisecond)
+				assert.Equal(t, http.StatusServiceUnavailable, attempt.StatusCode)
+				assert.Contains(t, attempt.Error, "503")
+				return nil
+			})
+
+		_, err := sender.ProcessDue(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("delivery fails permanently after max attempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		mockRepo := mocks.NewMockdeliveryRepository(ctrl)
+		sender := NewSender(mockRepo, receiver.Client(), config.Webhooks{MaxAttempts: 3})
+
+		mockRepo.EXPECT().
+			ClaimDueDeliveries(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return([]entity.WebhookDispatch{{
+				Delivery:  entity.WebhookDelivery{ID: 1, Payload: payload, Attempts: 2, Status: entity.WebhookDeliveryStatusPending},
+				TargetURL: receiver.URL,
+			}}, nil)
+		mockRepo.EXPECT().
+			RecordAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, delivery entity.WebhookDelivery, _ entity.WebhookDeliveryAttempt) error {
+				assert.Equal(t, entity.WebhookDeliveryStatusFailed, delivery.Status)
+				assert.Equal(t, 3, delivery.Attempts)
+				return nil
+			})
+
+		_, err := sender.ProcessDue(context.Background())
+		require.NoError(t, err)
+	})
+}
+
+func TestSender_Backoff(t *testing.T) {
+	sender := NewSender(nil, nil, config.Webhooks{InitialBackoffMs: 1000, MaxBackoffMs: 10000})
+
+	assert.Equal(t, time.Second, sender.Backoff(1))
+	assert.Equal(t, 2*time.Second, sender.Backoff(2))
+	assert.Equal(t, 8*time.Second, sender.Backoff(4))
+	assert.Equal(t, 10*time.Second, sender.Backoff(5))
+	assert.Equal(t, 10*time.Second, sender.Backoff(30))
+}
@@ -0,0 +1,41 @@
+//go:generate go run go.uber.org/mock/mockgen@latest -source=types.go -destination=mocks/mocks.go -package=mocks
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bsko/casino-transaction-system/internal/entity"
+)
+
+type subscriptionRepository interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription entity.WebhookSubscription) (*entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetSubscription(ctx context.Context, id int64) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]entity.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID int64) ([]entity.WebhookDeliveryAttempt, error)
+}
+
+type dispatchRepository interface {
+	ListActiveSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+}
+
+type transactionHistory interface {
+	HasTransaction(ctx context.Context, userID entity.UserID, transactionType entity.TransactionType) (bool, error)
+}
+
+type deliveryRepository interface {
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDispatch, error)
+	RecordAttempt(ctx context.Context, delivery entity.WebhookDelivery, attempt entity.WebhookDeliveryAttempt) error
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type subscriptionsCache interface {
+	Invalidate()
+}
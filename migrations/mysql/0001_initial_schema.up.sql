@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS transaction_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL DEFAULT '',
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    user_id CHAR(36) NOT NULL,
+    transaction_type VARCHAR(10) NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    row_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ingested_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_transaction_events_user_id_created_at (user_id, created_at DESC),
+    INDEX idx_transaction_events_user_id_type_created_at (user_id, transaction_type, created_at DESC),
+    INDEX idx_transaction_events_event_id (event_id),
+    INDEX idx_transaction_events_user_id_id (user_id, id),
+    INDEX idx_transaction_events_ingested_at (ingested_at, id),
+    INDEX idx_transaction_events_tenant_id_created_at (tenant_id, created_at DESC),
+    INDEX idx_transaction_events_tenant_id_user_id_created_at (tenant_id, user_id, created_at DESC)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS audit_chain_heads (
+    user_id CHAR(36) PRIMARY KEY,
+    last_hash VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    erased_at DATETIME(6)
+);
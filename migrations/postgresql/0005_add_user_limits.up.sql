@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS user_limits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    limit_type VARCHAR(16) NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, limit_type, period)
+);
+
+CREATE TABLE IF NOT EXISTS limit_breaches (
+    id BIGSERIAL PRIMARY KEY,
+    limit_id BIGINT NOT NULL REFERENCES user_limits(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    limit_type VARCHAR(16) NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL,
+    consumed BIGINT NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_limit_breaches_user_id
+ON limit_breaches(user_id, detected_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_limit_breaches_limit_id
+ON limit_breaches(limit_id, detected_at DESC);
@@ -0,0 +1,38 @@
+ALTER TABLE transaction_events ADD COLUMN IF NOT EXISTS event_id VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_event_id
+ON transaction_events(event_id) WHERE event_id <> '';
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(255) NOT NULL,
+    file_name VARCHAR(1024) NOT NULL,
+    period_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    provider_records INT NOT NULL,
+    stored_records INT NOT NULL,
+    matched INT NOT NULL,
+    missing INT NOT NULL,
+    extra INT NOT NULL,
+    mismatched INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_provider ON reconciliation_runs(provider, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL,
+    line INT NOT NULL DEFAULT 0,
+    event_id VARCHAR(255) NOT NULL DEFAULT '',
+    user_id UUID NOT NULL,
+    transaction_type VARCHAR(10) NOT NULL,
+    provider_amount BIGINT,
+    stored_amount BIGINT,
+    provider_time TIMESTAMP WITH TIME ZONE,
+    stored_time TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_items_run_id ON reconciliation_items(run_id);
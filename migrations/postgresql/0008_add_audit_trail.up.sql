@@ -0,0 +1,24 @@
+ALTER TABLE transaction_events ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE transaction_events ADD COLUMN IF NOT EXISTS row_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE transaction_events ADD COLUMN IF NOT EXISTS ingested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_user_id_id ON transaction_events(user_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_ingested_at ON transaction_events(ingested_at, id);
+
+CREATE TABLE IF NOT EXISTS audit_chain_heads (
+    user_id UUID PRIMARY KEY,
+    last_hash VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_digests (
+    day DATE PRIMARY KEY,
+    row_count BIGINT NOT NULL,
+    prev_digest VARCHAR(64) NOT NULL,
+    digest VARCHAR(64) NOT NULL,
+    signature VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS erased_users (
+    user_hash VARCHAR(64) PRIMARY KEY,
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    erased_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    vault_destroyed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS pseudonym_vault (
+    user_hash VARCHAR(64) PRIMARY KEY REFERENCES erased_users(user_hash),
+    user_id UUID NOT NULL,
+    pseudonym UUID NOT NULL UNIQUE,
+    pseudonym_key VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_chain_heads ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_items_user_id ON reconciliation_items(user_id);
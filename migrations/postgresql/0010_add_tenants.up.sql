@@ -0,0 +1,7 @@
+ALTER TABLE transaction_events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(255) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_tenant_id_created_at
+ON transaction_events(tenant_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_tenant_id_user_id_created_at
+ON transaction_events(tenant_id, user_id, created_at DESC);